@@ -0,0 +1,54 @@
+package inngestgo
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// AckModeAuto acknowledges the function's event automatically.
+	AckModeAuto = "auto"
+	// AckModeManual requires the function to acknowledge its event by calling
+	// step.Ack or reject it by calling step.Nack.
+	AckModeManual = "manual"
+)
+
+// AckConfig configures how a function acknowledges its event, for event
+// sources which use an ack/nack protocol.  Acknowledgement is enforced by the
+// SDK and isn't synced to Inngest, which doesn't support it:  acks are
+// recorded as regular steps, and rejected events are redelivered by retrying
+// the run.  Nacks aren't recorded, so the retry runs the function again.
+//
+// Most functions don't need this: in AckModeAuto, the default, events are
+// acknowledged automatically and failed runs are retried as usual, which is
+// appropriate for all standard use cases.
+type AckConfig struct {
+	// Mode is either AckModeAuto or AckModeManual.
+	Mode string
+	// AckTimeout is how long the function has to call step.Ack or step.Nack
+	// in AckModeManual.  It's measured from the start of each request to the
+	// function within an attempt, so retries get a fresh timeout.  Once it
+	// passes, the function's context is cancelled and the run fails with a
+	// retryable error, so that the event is redelivered.
+	AckTimeout time.Duration
+}
+
+// Validate returns an error if the ack config is not well formed.
+func (a AckConfig) Validate() error {
+	switch a.Mode {
+	case AckModeAuto:
+		return nil
+	case AckModeManual:
+		if a.AckTimeout <= 0 {
+			return fmt.Errorf("AckTimeout must be greater than 0 in manual mode")
+		}
+		return nil
+	default:
+		return fmt.Errorf("Mode must be one of %q, %q", AckModeAuto, AckModeManual)
+	}
+}
+
+// manual returns whether the function must acknowledge its event.
+func (a *AckConfig) manual() bool {
+	return a != nil && a.Mode == AckModeManual
+}
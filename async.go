@@ -0,0 +1,115 @@
+package inngestgo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/khulnasoft-lab/inngestgo/errors"
+	"github.com/khulnasoft-lab/inngestgo/internal/sdkrequest"
+)
+
+// AsyncQueue enqueues invoke requests for asynchronous execution.  See
+// HandlerOpts.Queue.
+type AsyncQueue interface {
+	// Enqueue stores the payload until it's processed by an AsyncWorker.
+	Enqueue(ctx context.Context, payload []byte) error
+}
+
+// asyncPayload is the payload enqueued for each invoke request.
+type asyncPayload struct {
+	FnID    string          `json:"fn_id"`
+	StepID  *string         `json:"step_id,omitempty"`
+	Request json.RawMessage `json:"request"`
+}
+
+// enqueue adds a validated invoke request to the handler's queue.
+func (h *handler) enqueue(ctx context.Context, w http.ResponseWriter, fnID string, stepID *string, body []byte) error {
+	if err := h.enqueuePayload(ctx, fnID, stepID, body); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusAccepted)
+	return nil
+}
+
+// enqueuePayload adds the payload for an invoke request to the handler's queue.
+func (h *handler) enqueuePayload(ctx context.Context, fnID string, stepID *string, body []byte) error {
+	payload, err := json.Marshal(asyncPayload{
+		FnID:    fnID,
+		StepID:  stepID,
+		Request: body,
+	})
+	if err != nil {
+		return fmt.Errorf("error marshalling async payload: %w", err)
+	}
+	if err := h.Queue.Enqueue(ctx, payload); err != nil {
+		return fmt.Errorf("error enqueueing request: %w", err)
+	}
+	return nil
+}
+
+// AsyncWorker executes invoke requests enqueued by a handler with
+// HandlerOpts.Queue set.
+//
+// Async mode is fire-and-forget.  Inngest receives a 202 Accepted response with
+// no body for each enqueued request, which it treats as the run completing
+// successfully without output, before the function has executed.  The result
+// of Process is never reported to Inngest:  failed executions still show as
+// completed runs, Inngest doesn't retry them, and failure handlers aren't
+// triggered.  Any retries and failure reporting must be handled by the queue
+// and the caller of Process, using the error it returns.
+//
+// As step results can't be reported back to Inngest, async mode only supports
+// functions without steps.  Process fails with a non-retryable error for
+// functions which use steps.
+//
+// Requests are authenticated before they're enqueued, so payloads must only be
+// read from a trusted queue.
+type AsyncWorker struct {
+	h *handler
+}
+
+// NewAsyncWorker returns an AsyncWorker which executes functions registered with
+// the given handler.
+func NewAsyncWorker(h Handler) *AsyncWorker {
+	// Only handlers created via NewHandler can execute functions;  Process
+	// returns an error for any other implementation.
+	hnd, _ := h.(*handler)
+	return &AsyncWorker{h: hnd}
+}
+
+// Process executes the function for an enqueued payload.
+func (a *AsyncWorker) Process(ctx context.Context, payload []byte) error {
+	if a.h == nil {
+		return fmt.Errorf("async worker requires a handler created via NewHandler")
+	}
+	if a.h.Queue == nil {
+		return fmt.Errorf("async worker requires a handler with HandlerOpts.Queue set")
+	}
+
+	p := asyncPayload{}
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return fmt.Errorf("error unmarshalling async payload: %w", err)
+	}
+	request := &sdkrequest.Request{}
+	if err := json.Unmarshal(p.Request, request); err != nil {
+		return fmt.Errorf("error decoding function request: %w", err)
+	}
+
+	fn := a.h.invokableFunction(p.FnID)
+	if fn == nil {
+		return fmt.Errorf("%w: %s", errFunctionMissing, p.FnID)
+	}
+
+	_, ops, err := a.h.invokeWithHooks(ctx, fn, request, p.StepID)
+	if err != nil {
+		return err
+	}
+	if len(ops) == 0 {
+		return nil
+	}
+	// Step results can't be sent to Inngest, so functions with steps can't
+	// make progress.
+	return errors.NoRetryError(fmt.Errorf("function '%s' uses steps, which can't be executed asynchronously", p.FnID))
+}
@@ -0,0 +1,99 @@
+package inngestgo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	sdkerrors "github.com/khulnasoft-lab/inngestgo/errors"
+	"github.com/khulnasoft-lab/inngestgo/step"
+	"github.com/stretchr/testify/require"
+)
+
+type sliceQueue struct {
+	payloads [][]byte
+}
+
+func (q *sliceQueue) Enqueue(ctx context.Context, payload []byte) error {
+	q.payloads = append(q.payloads, payload)
+	return nil
+}
+
+func TestAsyncWorker(t *testing.T) {
+	queue := &sliceQueue{}
+	h := NewHandler("async-app", HandlerOpts{Dev: BoolPtr(true), Queue: queue})
+
+	var calls []string
+	h.Register(
+		CreateFunction(
+			FunctionOpts{ID: "ok"},
+			EventTrigger("my-event", nil),
+			func(ctx context.Context, input Input[any]) (any, error) {
+				calls = append(calls, "ok")
+				return nil, nil
+			},
+		),
+		CreateFunction(
+			FunctionOpts{ID: "fails"},
+			EventTrigger("my-event", nil),
+			func(ctx context.Context, input Input[any]) (any, error) {
+				calls = append(calls, "fails")
+				return nil, fmt.Errorf("oh no")
+			},
+		),
+	)
+	server := httptest.NewServer(h)
+	defer server.Close()
+
+	body, _ := json.Marshal(createRequest(t, map[string]any{"name": "my-event"}))
+	for _, id := range []string{"ok", "fails"} {
+		resp, err := http.Post(server.URL+"?fnId=async-app-"+id, "application/json", bytes.NewReader(body))
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	}
+
+	// Nothing runs until the worker processes the queue.
+	require.Empty(t, calls)
+	require.Len(t, queue.payloads, 2)
+
+	w := NewAsyncWorker(h)
+	ctx := context.Background()
+	require.NoError(t, w.Process(ctx, queue.payloads[0]))
+	require.EqualError(t, w.Process(ctx, queue.payloads[1]), "oh no")
+	require.Equal(t, []string{"ok", "fails"}, calls)
+
+	require.Error(t, w.Process(ctx, []byte(`{"fn_id":"async-app-missing","request":{}}`)))
+}
+
+func TestAsyncWorkerSteps(t *testing.T) {
+	queue := &sliceQueue{}
+	h := NewHandler("async-app", HandlerOpts{Dev: BoolPtr(true), Queue: queue})
+
+	var calls int
+	h.Register(
+		CreateFunction(
+			FunctionOpts{ID: "steps"},
+			EventTrigger("my-event", nil),
+			func(ctx context.Context, input Input[any]) (any, error) {
+				calls++
+				return step.Run(ctx, "a", func(ctx context.Context) (string, error) { return "a", nil })
+			},
+		),
+	)
+
+	body, _ := json.Marshal(createRequest(t, map[string]any{"name": "my-event"}))
+	require.NoError(t, h.(*handler).enqueuePayload(context.Background(), "async-app-steps", nil, body))
+
+	// Step results can't be reported to Inngest, so functions with steps fail
+	// without being retried or enqueueing more work.
+	err := NewAsyncWorker(h).Process(context.Background(), queue.payloads[0])
+	require.True(t, sdkerrors.IsNoRetryError(err))
+	require.ErrorContains(t, err, "function 'async-app-steps' uses steps, which can't be executed asynchronously")
+	require.Equal(t, 1, calls)
+	require.Len(t, queue.payloads, 1)
+}
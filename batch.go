@@ -0,0 +1,202 @@
+package inngestgo
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultBatchSize          = 100
+	defaultBatchFlushInterval = time.Second
+	defaultBatchBufferSize    = 10_000
+	defaultBatchMaxRetries    = 3
+	batchRetryBackoff         = 100 * time.Millisecond
+)
+
+// BatchEventSenderOpts configures a BatchEventSender.
+type BatchEventSenderOpts struct {
+	// MaxBatchSize is the number of buffered events which triggers a flush.
+	// Defaults to 100.
+	MaxBatchSize int
+	// FlushInterval is how often buffered events are flushed.  Defaults to 1
+	// second.
+	FlushInterval time.Duration
+	// BufferSize is the maximum number of buffered events.  Events sent while
+	// the buffer is full are dropped.  Defaults to 10,000.
+	BufferSize int
+	// MaxRetries is the number of times a failed flush is retried before its
+	// events are dropped.  Defaults to 3, and a negative value disables
+	// retries.
+	MaxRetries int
+	// Logger logs failed flushes.  Defaults to slog.Default().
+	Logger *slog.Logger
+}
+
+// BatchSenderMetrics counts events handled by a BatchEventSender.
+type BatchSenderMetrics struct {
+	// Sent is the number of events sent successfully.
+	Sent atomic.Int64
+	// Dropped is the number of events dropped, either because the buffer was
+	// full, the sender was closed, or every attempt to send them failed.
+	Dropped atomic.Int64
+	// Retries is the number of retried flushes.
+	Retries atomic.Int64
+}
+
+// BatchEventSender sends events in batches for high-throughput event ingestion.
+// Events are buffered by Send and flushed when MaxBatchSize events are buffered,
+// every FlushInterval, and on Close.
+type BatchEventSender struct {
+	// Metrics counts sent, dropped and retried events.
+	Metrics BatchSenderMetrics
+
+	client Client
+	opts   BatchEventSenderOpts
+
+	// l guards buf and closed.
+	l      sync.Mutex
+	buf    []any
+	closed bool
+
+	// flushL ensures that batches are sent in order.
+	flushL sync.Mutex
+
+	trigger chan struct{}
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+// NewBatchEventSender returns a BatchEventSender which sends events using the
+// given client, starting a background goroutine which flushes events.  Close
+// must be called to flush remaining events and stop the goroutine.
+func NewBatchEventSender(c Client, opts BatchEventSenderOpts) *BatchEventSender {
+	if opts.MaxBatchSize <= 0 {
+		opts.MaxBatchSize = defaultBatchSize
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = defaultBatchFlushInterval
+	}
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = defaultBatchBufferSize
+	}
+	if opts.MaxRetries < 0 {
+		opts.MaxRetries = 0
+	} else if opts.MaxRetries == 0 {
+		opts.MaxRetries = defaultBatchMaxRetries
+	}
+	if opts.Logger == nil {
+		opts.Logger = slog.Default()
+	}
+
+	s := &BatchEventSender{
+		client:  c,
+		opts:    opts,
+		trigger: make(chan struct{}, 1),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// Send buffers the event, returning immediately.  The event is dropped if the
+// buffer is full or the sender is closed.
+func (s *BatchEventSender) Send(evt Event) {
+	s.l.Lock()
+	if s.closed || len(s.buf) >= s.opts.BufferSize {
+		s.l.Unlock()
+		s.Metrics.Dropped.Add(1)
+		return
+	}
+	s.buf = append(s.buf, evt)
+	full := len(s.buf) >= s.opts.MaxBatchSize
+	s.l.Unlock()
+
+	if full {
+		select {
+		case s.trigger <- struct{}{}:
+		default:
+			// A flush is already pending.
+		}
+	}
+}
+
+// Flush sends all buffered events, returning an error if any batch couldn't be
+// sent after retrying.
+func (s *BatchEventSender) Flush() error {
+	s.flushL.Lock()
+	defer s.flushL.Unlock()
+
+	s.l.Lock()
+	events := s.buf
+	s.buf = nil
+	s.l.Unlock()
+
+	var err error
+	for len(events) > 0 {
+		n := min(len(events), s.opts.MaxBatchSize)
+		if serr := s.send(events[:n]); serr != nil {
+			err = serr
+		}
+		events = events[n:]
+	}
+	return err
+}
+
+// Close flushes buffered events and stops the background goroutine.  Events
+// sent after Close are dropped.
+func (s *BatchEventSender) Close() error {
+	s.l.Lock()
+	if s.closed {
+		s.l.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.l.Unlock()
+
+	close(s.done)
+	<-s.stopped
+	return s.Flush()
+}
+
+func (s *BatchEventSender) run() {
+	defer close(s.stopped)
+
+	t := time.NewTicker(s.opts.FlushInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-t.C:
+		case <-s.trigger:
+		}
+		// Errors are logged and counted when sending.
+		_ = s.Flush()
+	}
+}
+
+// send sends a batch of events, retrying with backoff on failure.
+func (s *BatchEventSender) send(events []any) error {
+	var err error
+	for attempt := 0; attempt <= s.opts.MaxRetries; attempt++ {
+		if attempt > 0 {
+			s.Metrics.Retries.Add(1)
+			time.Sleep(batchRetryBackoff * time.Duration(attempt))
+		}
+		if _, err = s.client.SendMany(context.Background(), events); err == nil {
+			s.Metrics.Sent.Add(int64(len(events)))
+			return nil
+		}
+		s.opts.Logger.Warn("error sending event batch", "error", err, "attempt", attempt, "events", len(events))
+	}
+
+	s.Metrics.Dropped.Add(int64(len(events)))
+	s.opts.Logger.Error("dropping event batch after retries", "error", err, "events", len(events))
+	return fmt.Errorf("error sending %d events: %w", len(events), err)
+}
@@ -0,0 +1,118 @@
+package inngestgo
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// batchClient records batches sent via SendMany, failing the first failures
+// calls.
+type batchClient struct {
+	Client
+
+	l        sync.Mutex
+	batches  [][]any
+	failures int
+}
+
+func (c *batchClient) SendMany(ctx context.Context, evts []any) ([]string, error) {
+	c.l.Lock()
+	defer c.l.Unlock()
+	if c.failures > 0 {
+		c.failures--
+		return nil, fmt.Errorf("unavailable")
+	}
+	c.batches = append(c.batches, evts)
+	return nil, nil
+}
+
+func (c *batchClient) sent() [][]any {
+	c.l.Lock()
+	defer c.l.Unlock()
+	return c.batches
+}
+
+func TestBatchEventSender(t *testing.T) {
+	evt := func(i int) Event {
+		return Event{Name: "my-event", Data: map[string]any{"i": i}}
+	}
+
+	t.Run("flushes when the batch is full", func(t *testing.T) {
+		c := &batchClient{}
+		s := NewBatchEventSender(c, BatchEventSenderOpts{MaxBatchSize: 2, FlushInterval: time.Hour})
+		defer s.Close()
+
+		for i := 0; i < 4; i++ {
+			s.Send(evt(i))
+		}
+		require.Eventually(t, func() bool {
+			return s.Metrics.Sent.Load() == 4
+		}, time.Second, 5*time.Millisecond)
+		for _, batch := range c.sent() {
+			require.LessOrEqual(t, len(batch), 2)
+		}
+	})
+
+	t.Run("flushes on the interval", func(t *testing.T) {
+		c := &batchClient{}
+		s := NewBatchEventSender(c, BatchEventSenderOpts{FlushInterval: 10 * time.Millisecond})
+		defer s.Close()
+
+		s.Send(evt(1))
+		require.Eventually(t, func() bool {
+			return len(c.sent()) == 1
+		}, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("close flushes and drops later events", func(t *testing.T) {
+		c := &batchClient{}
+		s := NewBatchEventSender(c, BatchEventSenderOpts{FlushInterval: time.Hour})
+
+		s.Send(evt(1))
+		s.Send(evt(2))
+		require.NoError(t, s.Close())
+		require.Equal(t, [][]any{{evt(1), evt(2)}}, c.sent())
+
+		s.Send(evt(3))
+		require.EqualValues(t, 1, s.Metrics.Dropped.Load())
+	})
+
+	t.Run("drops events when the buffer is full", func(t *testing.T) {
+		c := &batchClient{}
+		s := NewBatchEventSender(c, BatchEventSenderOpts{BufferSize: 2, FlushInterval: time.Hour})
+
+		for i := 0; i < 3; i++ {
+			s.Send(evt(i))
+		}
+		require.NoError(t, s.Close())
+		require.EqualValues(t, 2, s.Metrics.Sent.Load())
+		require.EqualValues(t, 1, s.Metrics.Dropped.Load())
+	})
+
+	t.Run("retries failed flushes", func(t *testing.T) {
+		c := &batchClient{failures: 2}
+		s := NewBatchEventSender(c, BatchEventSenderOpts{FlushInterval: time.Hour})
+
+		s.Send(evt(1))
+		require.NoError(t, s.Flush())
+		require.EqualValues(t, 2, s.Metrics.Retries.Load())
+		require.EqualValues(t, 1, s.Metrics.Sent.Load())
+		require.NoError(t, s.Close())
+	})
+
+	t.Run("drops events after retries", func(t *testing.T) {
+		c := &batchClient{failures: 2}
+		s := NewBatchEventSender(c, BatchEventSenderOpts{FlushInterval: time.Hour, MaxRetries: 1})
+
+		s.Send(evt(1))
+		require.ErrorContains(t, s.Flush(), "unavailable")
+		require.EqualValues(t, 1, s.Metrics.Retries.Load())
+		require.EqualValues(t, 1, s.Metrics.Dropped.Load())
+		require.NoError(t, s.Close())
+	})
+}
@@ -0,0 +1,53 @@
+package inngestgo
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// BodyParser returns the effective body of an incoming request, eg. by
+// decompressing or decrypting the request's body.
+type BodyParser func(r *http.Request) (io.Reader, error)
+
+// DefaultBodyParser decodes gzip-encoded request bodies, based off of the
+// request's Content-Encoding header.  Other bodies are returned as-is.
+func DefaultBodyParser(r *http.Request) (io.Reader, error) {
+	switch enc := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding"))); enc {
+	case "", "identity":
+		return r.Body, nil
+	case "gzip":
+		return gzip.NewReader(r.Body)
+	default:
+		return nil, fmt.Errorf("unsupported content encoding '%s'", enc)
+	}
+}
+
+// readBody reads at most max bytes of the request's effective body, as
+// returned by HandlerOpts.BodyParser.  Errors from the parser, or from
+// reading the parsed body, wrap errBadRequest.
+func (h *handler) readBody(w http.ResponseWriter, r *http.Request, max int64) ([]byte, error) {
+	parse := h.BodyParser
+	if parse == nil {
+		parse = DefaultBodyParser
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, max)
+	body, err := parse(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: error parsing request body: %s", errBadRequest, err)
+	}
+
+	// Limit the parsed body as well, as decompressed bodies may be far larger
+	// than the request.
+	byt, err := io.ReadAll(io.LimitReader(body, max+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: error reading request body: %s", errBadRequest, err)
+	}
+	if int64(len(byt)) > max {
+		return nil, fmt.Errorf("%w: request body is larger than %d bytes", errBadRequest, max)
+	}
+	return byt, nil
+}
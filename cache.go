@@ -0,0 +1,42 @@
+package inngestgo
+
+import (
+	"context"
+
+	"github.com/inngest/inngest/pkg/execution/state"
+	"github.com/khulnasoft-lab/inngestgo/internal/sdkrequest"
+)
+
+// StepResultCache stores step results by step ID, allowing step state to be
+// injected when unit testing functions with many steps:
+//
+//	cache := inngestgo.NewStepResultCache()
+//	_ = cache.Set("fetch-user", User{ID: "u1"})
+//
+//	req := inngestgo.NewRequestFromCache(cache)
+//	req.Event = json.RawMessage(`{"name": "user/created", "data": {}}`)
+//	out, ops, err := inngestgo.InvokeRequest(ctx, fn, req)
+type StepResultCache = sdkrequest.StepResultCache
+
+// NewStepResultCache returns an empty, concurrency-safe StepResultCache.
+func NewStepResultCache() StepResultCache {
+	return sdkrequest.NewStepResultCache()
+}
+
+// Request represents a request from Inngest to execute a function, containing
+// the triggering event and the function's step state.
+type Request = sdkrequest.Request
+
+// NewRequestFromCache returns a request which reads step state from the given
+// cache, for executing functions via InvokeRequest.
+func NewRequestFromCache(cache StepResultCache) *Request {
+	return sdkrequest.NewRequestFromCache(cache)
+}
+
+// InvokeRequest executes the function once using the given request, as the
+// handler does for each request from Inngest.  Steps with state in the request
+// are memoized.  Once a step without state runs, execution stops and the
+// step's op is returned, otherwise the function's output is returned.
+func InvokeRequest(ctx context.Context, fn ServableFunction, req *Request) (any, []state.GeneratorOpcode, error) {
+	return invoke(ctx, fn, req, nil, nil)
+}
@@ -0,0 +1,56 @@
+package inngestgo_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/inngest/inngest/pkg/enums"
+	"github.com/khulnasoft-lab/inngestgo"
+	"github.com/khulnasoft-lab/inngestgo/step"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInvokeRequestFromCache(t *testing.T) {
+	fn := inngestgo.CreateFunction(
+		inngestgo.FunctionOpts{Name: "greet"},
+		inngestgo.EventTrigger("user/created", nil),
+		func(ctx context.Context, input inngestgo.Input[map[string]any]) (any, error) {
+			name, err := step.Run(ctx, "fetch-name", func(ctx context.Context) (string, error) {
+				return "", nil
+			})
+			if err != nil {
+				return nil, err
+			}
+			return step.Run(ctx, "greet", func(ctx context.Context) (string, error) {
+				return "hello " + name, nil
+			})
+		},
+	)
+
+	cache := inngestgo.NewStepResultCache()
+	require.NoError(t, cache.Set("fetch-name", "alice"))
+
+	newRequest := func() *inngestgo.Request {
+		req := inngestgo.NewRequestFromCache(cache)
+		req.Event = json.RawMessage(`{"name": "user/created", "data": {}}`)
+		return req
+	}
+
+	t.Run("runs the first step without state", func(t *testing.T) {
+		_, ops, err := inngestgo.InvokeRequest(context.Background(), fn, newRequest())
+		require.NoError(t, err)
+		require.Len(t, ops, 1)
+		require.Equal(t, enums.OpcodeStepRun, ops[0].Op)
+		require.Equal(t, "greet", ops[0].Name)
+		require.JSONEq(t, `"hello alice"`, string(ops[0].Data))
+	})
+
+	t.Run("returns the output once every step is cached", func(t *testing.T) {
+		require.NoError(t, cache.Set("greet", "hi alice"))
+		out, ops, err := inngestgo.InvokeRequest(context.Background(), fn, newRequest())
+		require.NoError(t, err)
+		require.Empty(t, ops)
+		require.Equal(t, "hi alice", out)
+	})
+}
@@ -0,0 +1,103 @@
+package inngestgo
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/inngest/inngest/pkg/enums"
+	"github.com/inngest/inngest/pkg/execution/state"
+	"github.com/khulnasoft-lab/inngestgo/internal/sdkrequest"
+)
+
+// CheckpointBackend stores the step state of function runs.
+type CheckpointBackend interface {
+	// Save stores the state of the given steps of the run, keyed by hashed
+	// step ID.  State previously saved for other steps of the run must be
+	// kept, as requests for a run's parallel steps save concurrently.
+	Save(ctx context.Context, runID string, data map[string]any) error
+	// Load returns the step state saved for the given run, keyed by hashed
+	// step ID, or nil if there's no saved state.
+	Load(ctx context.Context, runID string) (map[string]any, error)
+}
+
+// CheckpointConfig persists the step state of function runs to a backend,
+// so that steps aren't re-executed if the process restarts before a step's
+// result is sent to Inngest.  When a step with saved state is next run, its
+// saved result is reported to Inngest instead of re-executing the step, and
+// the function continues once Inngest re-invokes it with the step's state.
+type CheckpointConfig struct {
+	// Backend stores the step state of runs.
+	Backend CheckpointBackend
+}
+
+// checkpointedStep is the state of a step saved within a checkpoint.
+type checkpointedStep struct {
+	Data json.RawMessage `json:"data"`
+	Name string          `json:"name,omitempty"`
+}
+
+// checkpointRestorer returns a StepRestorer which returns step results saved
+// within the function's checkpoint backend, or nil if checkpointing is
+// disabled.  The run's saved state is loaded when the first step without state
+// from Inngest is about to run.  Errors are logged, as the steps are
+// re-executed instead.
+func (h *handler) checkpointRestorer(ctx context.Context, fn ServableFunction, request *sdkrequest.Request) sdkrequest.StepRestorer {
+	c := fn.Config().Checkpointing
+	if c == nil || c.Backend == nil {
+		return nil
+	}
+	l := h.Logger.With("fn", fn.Slug(h.appName), "run_id", request.CallCtx.RunID)
+
+	load := sync.OnceValue(func() map[string]any {
+		saved, err := c.Backend.Load(ctx, request.CallCtx.RunID)
+		if err != nil {
+			l.Error("error loading checkpoint", "error", err)
+			return nil
+		}
+		return saved
+	})
+
+	return func(hashedID string) (json.RawMessage, bool) {
+		val, ok := load()[hashedID]
+		if !ok {
+			return nil, false
+		}
+		// Backends may return decoded JSON, so re-encode the step's state.
+		step := checkpointedStep{}
+		byt, err := json.Marshal(val)
+		if err == nil {
+			err = json.Unmarshal(byt, &step)
+		}
+		if err != nil || step.Data == nil {
+			l.Error("error restoring checkpoint", "step_id", hashedID, "error", err)
+			return nil, false
+		}
+		return step.Data, true
+	}
+}
+
+// saveCheckpoint saves the results of any steps which ran within the
+// function's checkpoint backend.  Errors are logged, as step results are still
+// sent to Inngest.
+func (h *handler) saveCheckpoint(ctx context.Context, fn ServableFunction, request *sdkrequest.Request, ops []state.GeneratorOpcode) {
+	c := fn.Config().Checkpointing
+	if c == nil || c.Backend == nil {
+		return
+	}
+
+	data := map[string]any{}
+	for _, op := range ops {
+		if op.Op != enums.OpcodeStepRun || len(op.Data) == 0 {
+			continue
+		}
+		data[op.ID] = checkpointedStep{Data: op.Data, Name: op.Name}
+	}
+	if len(data) == 0 {
+		return
+	}
+
+	if err := c.Backend.Save(context.WithoutCancel(ctx), request.CallCtx.RunID, data); err != nil {
+		h.Logger.Error("error saving checkpoint", "fn", fn.Slug(h.appName), "run_id", request.CallCtx.RunID, "error", err)
+	}
+}
@@ -0,0 +1,124 @@
+// Package checkpoint provides backends for inngestgo.CheckpointConfig.
+package checkpoint
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/khulnasoft-lab/inngestgo"
+)
+
+const (
+	// DefaultKeyPrefix prefixes the Redis keys of saved step state.
+	DefaultKeyPrefix = "inngest:checkpoint:"
+	// DefaultTTL is how long saved step state is kept after the most recent
+	// step of a run.
+	DefaultTTL = 7 * 24 * time.Hour
+)
+
+// RedisClient is the subset of Redis commands used by the Redis backend.  This
+// is satisfied by a small adapter around any Redis client, eg. for go-redis:
+//
+//	type client struct{ *redis.Client }
+//
+//	func (c client) HSet(ctx context.Context, key string, fields map[string][]byte, ttl time.Duration) error {
+//		vals := make(map[string]any, len(fields))
+//		for k, v := range fields {
+//			vals[k] = v
+//		}
+//		_, err := c.Client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+//			p.HSet(ctx, key, vals)
+//			p.Expire(ctx, key, ttl)
+//			return nil
+//		})
+//		return err
+//	}
+//
+//	func (c client) HGetAll(ctx context.Context, key string) (map[string][]byte, error) {
+//		vals, err := c.Client.HGetAll(ctx, key).Result()
+//		fields := make(map[string][]byte, len(vals))
+//		for k, v := range vals {
+//			fields[k] = []byte(v)
+//		}
+//		return fields, err
+//	}
+type RedisClient interface {
+	// HSet sets the given fields of the hash stored at key, keeping its other
+	// fields, and expires the key after the TTL.
+	HSet(ctx context.Context, key string, fields map[string][]byte, ttl time.Duration) error
+	// HGetAll returns every field of the hash stored at key, or no fields if
+	// the key doesn't exist.
+	HGetAll(ctx context.Context, key string) (map[string][]byte, error)
+}
+
+// RedisOpt configures the Redis backend.
+type RedisOpt func(*redisBackend)
+
+// WithKeyPrefix sets the prefix of the Redis keys of saved step state, which
+// defaults to DefaultKeyPrefix.
+func WithKeyPrefix(prefix string) RedisOpt {
+	return func(r *redisBackend) {
+		r.prefix = prefix
+	}
+}
+
+// WithTTL sets how long saved step state is kept after the most recent step
+// of a run, which defaults to DefaultTTL.
+func WithTTL(ttl time.Duration) RedisOpt {
+	return func(r *redisBackend) {
+		r.ttl = ttl
+	}
+}
+
+// RedisCheckpointBackend returns a CheckpointBackend which saves the step state
+// of each run within a Redis hash, with a JSON-encoded field per step.  Each
+// save only sets the saved steps' fields, so concurrent saves for a run's
+// parallel steps don't overwrite each other.
+func RedisCheckpointBackend(client RedisClient, opts ...RedisOpt) inngestgo.CheckpointBackend {
+	r := &redisBackend{
+		client: client,
+		prefix: DefaultKeyPrefix,
+		ttl:    DefaultTTL,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+type redisBackend struct {
+	client RedisClient
+	prefix string
+	ttl    time.Duration
+}
+
+func (r *redisBackend) Save(ctx context.Context, runID string, data map[string]any) error {
+	fields := make(map[string][]byte, len(data))
+	for id, val := range data {
+		byt, err := json.Marshal(val)
+		if err != nil {
+			return fmt.Errorf("error marshalling checkpoint for step '%s': %w", id, err)
+		}
+		fields[id] = byt
+	}
+	return r.client.HSet(ctx, r.prefix+runID, fields, r.ttl)
+}
+
+func (r *redisBackend) Load(ctx context.Context, runID string) (map[string]any, error) {
+	fields, err := r.client.HGetAll(ctx, r.prefix+runID)
+	if err != nil || len(fields) == 0 {
+		return nil, err
+	}
+	data := make(map[string]any, len(fields))
+	for id, byt := range fields {
+		if !json.Valid(byt) {
+			return nil, fmt.Errorf("error unmarshalling checkpoint for step '%s': invalid JSON", id)
+		}
+		// Keep each step's JSON as-is, so that its result is restored
+		// exactly.
+		data[id] = json.RawMessage(byt)
+	}
+	return data, nil
+}
@@ -0,0 +1,58 @@
+package checkpoint
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeRedis struct {
+	hashes map[string]map[string][]byte
+	ttls   map[string]time.Duration
+}
+
+func (f *fakeRedis) HSet(ctx context.Context, key string, fields map[string][]byte, ttl time.Duration) error {
+	if f.hashes[key] == nil {
+		f.hashes[key] = map[string][]byte{}
+	}
+	for k, v := range fields {
+		f.hashes[key][k] = v
+	}
+	f.ttls[key] = ttl
+	return nil
+}
+
+func (f *fakeRedis) HGetAll(ctx context.Context, key string) (map[string][]byte, error) {
+	return f.hashes[key], nil
+}
+
+func TestRedisCheckpointBackend(t *testing.T) {
+	ctx := context.Background()
+	client := &fakeRedis{hashes: map[string]map[string][]byte{}, ttls: map[string]time.Duration{}}
+	backend := RedisCheckpointBackend(client, WithKeyPrefix("test:"), WithTTL(time.Hour))
+
+	data, err := backend.Load(ctx, "run-1")
+	require.NoError(t, err)
+	require.Nil(t, data)
+
+	require.NoError(t, backend.Save(ctx, "run-1", map[string]any{"a": map[string]any{"data": 1}}))
+	require.Equal(t, time.Hour, client.ttls["test:run-1"])
+	require.JSONEq(t, `{"data":1}`, string(client.hashes["test:run-1"]["a"]))
+
+	// Saving other steps keeps the state of earlier steps.
+	require.NoError(t, backend.Save(ctx, "run-1", map[string]any{"b": json.RawMessage(`{"data":12345678901234567890}`)}))
+
+	data, err = backend.Load(ctx, "run-1")
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{
+		"a": json.RawMessage(`{"data":1}`),
+		"b": json.RawMessage(`{"data":12345678901234567890}`),
+	}, data)
+
+	client.hashes["test:run-2"] = map[string][]byte{"a": []byte("{")}
+	_, err = backend.Load(ctx, "run-2")
+	require.ErrorContains(t, err, "error unmarshalling checkpoint for step 'a'")
+}
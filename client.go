@@ -3,10 +3,12 @@ package inngestgo
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"os"
+	"time"
 )
 
 var (
@@ -28,7 +30,8 @@ var (
 )
 
 const (
-	defaultEndpoint = "https://inn.gs"
+	defaultEndpoint     = "https://inn.gs"
+	defaultPollInterval = time.Second
 )
 
 // Send uses the DefaultClient to send the given event.
@@ -50,9 +53,62 @@ func SendMany(ctx context.Context, e []any) ([]string, error) {
 // Client represents a client used to send events to Inngest.
 type Client interface {
 	// Send sends the specific event to the ingest API.
+	//
+	// Within a function which sets FunctionOpts.EventNamespace, the event's
+	// name is prefixed with "<namespace>/".  Use WithoutEventNamespace to send
+	// events outside of the namespace.
 	Send(ctx context.Context, evt any) (string, error)
-	// Send sends a batch of events to the ingest API.
+	// Send sends a batch of events to the ingest API.  Events are scoped to
+	// the function's namespace as with Send.
 	SendMany(ctx context.Context, evt []any) ([]string, error)
+	// TriggerFunction runs the function with the given ID directly using the
+	// given event, bypassing event matching, and returns the run ID.  Use
+	// GetRun to poll for the run's completion.  This returns
+	// ErrFunctionNotFound if the function doesn't exist.
+	//
+	// Experimental: this calls POST /v1/functions/{functionID}/invoke, which
+	// isn't yet part of Inngest's REST API, so it requires server support.
+	TriggerFunction(ctx context.Context, functionID string, evt Event) (string, error)
+	// GetRun returns the function run with the given ID.
+	GetRun(ctx context.Context, runID string) (*Run, error)
+	// GetEventRuns returns all function runs triggered by the given event ID.
+	GetEventRuns(ctx context.Context, eventID string) ([]Run, error)
+	// GetFunctionRun returns the function run with the given ID, including a
+	// trace of every step executed within the run.
+	//
+	// Experimental: this calls GET /v1/runs/{runID}/trace, which isn't yet
+	// part of Inngest's REST API, so it requires server support.
+	GetFunctionRun(ctx context.Context, runID string) (*FunctionRun, error)
+	// Subscribe calls fn with every event with the given name received by
+	// Inngest, until the returned UnsubscribeFunc is called or ctx is
+	// cancelled.  This is intended for integration tests.
+	//
+	// Experimental: this calls GET /v1/events/stream, which isn't yet part of
+	// Inngest's REST API, so it requires server support.
+	Subscribe(ctx context.Context, eventName string, fn func(Event)) (UnsubscribeFunc, error)
+	// SubscribeWithFilter is like Subscribe, only calling fn with events
+	// matching the given filter.
+	//
+	// Experimental: see Subscribe.
+	SubscribeWithFilter(ctx context.Context, eventName string, filter EventStreamFilter, fn func(Event)) (UnsubscribeFunc, error)
+	// ListRuns returns a page of function runs matching the given filter.  Use
+	// RunPages to iterate through every page.
+	//
+	// Experimental: this calls GET /v1/runs, which isn't yet part of Inngest's
+	// REST API, so it requires server support.
+	ListRuns(ctx context.Context, filter RunFilter) ([]*RunSummary, error)
+	// SearchRuns returns function runs matching the given query, eg. to find
+	// every failed run for a given user:
+	//
+	//	since := time.Now().Add(-24 * time.Hour)
+	//	runs, err := client.SearchRuns(ctx, inngestgo.RunSearchQuery{
+	//		Expression: "output.error != null",
+	//		Since:      &since,
+	//	}.WithEventField("userId", "123"))
+	//
+	// Experimental: this calls POST /v1/runs/search, which isn't yet part of
+	// Inngest's REST API, so it requires server support.
+	SearchRuns(ctx context.Context, query RunSearchQuery) ([]*RunSummary, error)
 }
 
 type ClientOpts struct {
@@ -68,6 +124,28 @@ type ClientOpts struct {
 	// os.Getenv("INNGEST_ENV").  This only deploys to branches if the
 	// signing key is a branch signing key.
 	Env *string
+	// SigningKey is the signing key used to authenticate with the REST API when
+	// fetching runs.  This defaults to the `INNGEST_SIGNING_KEY` environment
+	// variable if nil.
+	SigningKey *string
+	// SigningKeyFallback is used if authentication with SigningKey fails.  This
+	// defaults to the `INNGEST_SIGNING_KEY_FALLBACK` environment variable if nil.
+	SigningKeyFallback *string
+	// APIBaseURL is the URL of the REST API used to fetch runs.  This defaults
+	// to https://api.inngest.com if nil.
+	APIBaseURL *string
+	// PollInterval is how often InvokeSync polls for a run's status.  This
+	// defaults to one second.
+	PollInterval time.Duration
+	// OutboundRequestInterceptor is called with every request made by the
+	// client, such as sending events, before the request is sent.  The
+	// returned request is sent in its place, eg. to add proxy authentication
+	// headers.  If the interceptor returns nil, the request is sent unmodified.
+	OutboundRequestInterceptor func(*http.Request) *http.Request
+	// TLSConfig configures TLS for the client's requests, eg. for mutual TLS
+	// via MutualTLSConfig.  This is ignored if HTTPClient uses a transport
+	// other than *http.Transport.
+	TLSConfig *tls.Config
 }
 
 // NewClient returns a concrete client initialized with the given ingest key,
@@ -80,6 +158,21 @@ func NewClient(opts ClientOpts) Client {
 	if c.ClientOpts.HTTPClient == nil {
 		c.ClientOpts.HTTPClient = http.DefaultClient
 	}
+	if opts.TLSConfig != nil || opts.OutboundRequestInterceptor != nil {
+		hc := *c.ClientOpts.HTTPClient
+		next := hc.Transport
+		if next == nil {
+			next = http.DefaultTransport
+		}
+		if opts.TLSConfig != nil {
+			next = withTLSConfig(next, opts.TLSConfig)
+		}
+		if opts.OutboundRequestInterceptor != nil {
+			next = interceptingTransport{next: next, intercept: opts.OutboundRequestInterceptor}
+		}
+		hc.Transport = next
+		c.ClientOpts.HTTPClient = &hc
+	}
 
 	return c
 }
@@ -97,6 +190,40 @@ func (a apiClient) GetEnv() string {
 	return *a.Env
 }
 
+func (a apiClient) GetSigningKey() string {
+	if a.SigningKey == nil {
+		return os.Getenv("INNGEST_SIGNING_KEY")
+	}
+	return *a.SigningKey
+}
+
+func (a apiClient) GetSigningKeyFallback() string {
+	if a.SigningKeyFallback == nil {
+		return os.Getenv("INNGEST_SIGNING_KEY_FALLBACK")
+	}
+	return *a.SigningKeyFallback
+}
+
+func (a apiClient) GetAPIBaseURL() string {
+	if a.APIBaseURL != nil {
+		return *a.APIBaseURL
+	}
+	if base := os.Getenv("INNGEST_API_BASE_URL"); base != "" {
+		return base
+	}
+	if IsDev() {
+		return DevServerURL()
+	}
+	return defaultAPIOrigin
+}
+
+func (a apiClient) GetPollInterval() time.Duration {
+	if a.PollInterval <= 0 {
+		return defaultPollInterval
+	}
+	return a.PollInterval
+}
+
 func (a apiClient) GetEventKey() string {
 	if a.EventKey != nil {
 		return *a.EventKey
@@ -127,6 +254,15 @@ func (a apiClient) Send(ctx context.Context, e any) (string, error) {
 }
 
 func (a apiClient) SendMany(ctx context.Context, e []any) ([]string, error) {
+	if ns := EventNamespaceFromContext(ctx); ns != "" && !isUnscoped(ctx) {
+		// Events sent within a namespaced function are scoped to its namespace.
+		scoped := make([]any, len(e))
+		for i, evt := range e {
+			scoped[i] = namespaceEvent(ns, evt)
+		}
+		e = scoped
+	}
+
 	for _, e := range e {
 		if v, ok := e.(validatable); ok {
 			if err := v.Validate(); err != nil {
@@ -1,9 +1,17 @@
 package inngestgo
 
 import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestGetEventKey(t *testing.T) {
@@ -42,3 +50,224 @@ func TestGetEventKey(t *testing.T) {
 		assert.Equal(t, "NO_EVENT_KEY_SET", c.GetEventKey())
 	})
 }
+
+func TestInvokeSync(t *testing.T) {
+	var polls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/e/key":
+			_, _ = w.Write([]byte(`{"ids":["evt-1"],"status":200}`))
+		case "/v1/events/evt-1/runs":
+			_, _ = w.Write([]byte(`{"data":[
+				{"run_id":"run-other","function_id":"other-fn","status":"Running"},
+				{"run_id":"run-1","function_id":"my-fn","status":"Running"}
+			]}`))
+		case "/v1/runs/run-1":
+			if atomic.AddInt32(&polls, 1) < 3 {
+				_, _ = w.Write([]byte(`{"data":{"run_id":"run-1","function_id":"my-fn","status":"Running"}}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"data":{"run_id":"run-1","function_id":"my-fn","status":"Completed","output":{"ok":true}}}`))
+		case "/v1/runs/run-failed":
+			_, _ = w.Write([]byte(`{"data":{"run_id":"run-failed","status":"Failed","output":{"error":"oh no"}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	c := NewClient(ClientOpts{
+		EventKey:     StrPtr("key"),
+		EventURL:     StrPtr(server.URL),
+		APIBaseURL:   StrPtr(server.URL),
+		SigningKey:   StrPtr(""),
+		PollInterval: 5 * time.Millisecond,
+	})
+	ctx := context.Background()
+
+	t.Run("returns the run output", func(t *testing.T) {
+		out, err := InvokeSync[map[string]bool](ctx, c, "my-fn", Event{Name: "my-event", Data: map[string]any{"id": 1}}, time.Second)
+		require.NoError(t, err)
+		require.Equal(t, map[string]bool{"ok": true}, out)
+		require.EqualValues(t, 3, atomic.LoadInt32(&polls))
+	})
+
+	t.Run("times out", func(t *testing.T) {
+		_, err := InvokeSync[any](ctx, c, "missing-fn", Event{Name: "my-event", Data: map[string]any{"id": 1}}, 20*time.Millisecond)
+		require.ErrorIs(t, err, ErrInvocationTimeout)
+	})
+
+	t.Run("returns failures", func(t *testing.T) {
+		_, err := runOutput[any](&Run{Status: RunStatusFailed, Output: json.RawMessage(`{"error":"oh no"}`)})
+		require.ErrorIs(t, err, ErrInvocationFailed)
+		require.ErrorContains(t, err, "oh no")
+	})
+}
+
+func TestSendNamespacedEvents(t *testing.T) {
+	var names []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var evts []map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&evts))
+		for _, evt := range evts {
+			names = append(names, evt["name"].(string))
+		}
+		_, _ = w.Write([]byte(`{"ids":["evt-1","evt-2","evt-3"],"status":200}`))
+	}))
+	defer server.Close()
+
+	c := NewClient(ClientOpts{EventKey: StrPtr("key"), EventURL: StrPtr(server.URL)})
+	ctx := withEventNamespace(context.Background(), StrPtr("acme"))
+
+	data := map[string]any{"id": 1}
+	evt := Event{Name: "report/requested", Data: data}
+	_, err := c.SendMany(ctx, []any{
+		evt,
+		&Event{Name: "acme/report/sent", Data: data},
+		GenericEvent[map[string]any, any]{Name: "report/failed", Data: data},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"acme/report/requested", "acme/report/sent", "acme/report/failed"}, names)
+	require.Equal(t, "report/requested", evt.Name)
+	require.Equal(t, "acme", EventNamespaceFromContext(ctx))
+
+	names = nil
+	_, err = c.Send(WithoutEventNamespace(ctx), evt)
+	require.NoError(t, err)
+	require.Equal(t, []string{"report/requested"}, names)
+}
+
+func TestListRuns(t *testing.T) {
+	var queries []url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/v1/runs", r.URL.Path)
+		queries = append(queries, r.URL.Query())
+		switch r.URL.Query().Get("cursor") {
+		case "":
+			_, _ = w.Write([]byte(`{"data":[{"run_id":"run-1","status":"Completed"},{"run_id":"run-2","status":"Completed"}]}`))
+		case "run-2":
+			_, _ = w.Write([]byte(`{"data":[{"run_id":"run-3","status":"Completed"}]}`))
+		}
+	}))
+	defer server.Close()
+
+	c := NewClient(ClientOpts{APIBaseURL: StrPtr(server.URL), SigningKey: StrPtr("")})
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	filter := RunFilter{
+		FunctionID: StrPtr("my-fn"),
+		Status:     StrPtr("Completed"),
+		Since:      &since,
+		Limit:      2,
+	}
+
+	pages := RunPages(c, filter)
+	var ids []string
+	for pages.Next(context.Background()) {
+		for _, run := range pages.Page() {
+			ids = append(ids, run.ID)
+		}
+	}
+	require.NoError(t, pages.Err())
+	require.Equal(t, []string{"run-1", "run-2", "run-3"}, ids)
+
+	require.Len(t, queries, 2)
+	require.Equal(t, url.Values{
+		"function_id": {"my-fn"},
+		"status":      {"Completed"},
+		"since":       {"2024-01-01T00:00:00Z"},
+		"limit":       {"2"},
+	}, queries[0])
+	require.Equal(t, "run-2", queries[1].Get("cursor"))
+}
+
+func TestSearchRuns(t *testing.T) {
+	var body map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "/v1/runs/search", r.URL.Path)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		_, _ = w.Write([]byte(`{"data":[{"run_id":"run-1","function_id":"my-fn","status":"Failed"}]}`))
+	}))
+	defer server.Close()
+
+	c := NewClient(ClientOpts{APIBaseURL: StrPtr(server.URL), SigningKey: StrPtr("")})
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	runs, err := c.SearchRuns(context.Background(), RunSearchQuery{
+		FunctionID: StrPtr("my-fn"),
+		Since:      &since,
+	}.WithEventField("userId", "123"))
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	require.Equal(t, RunStatusFailed, runs[0].Status)
+	require.Equal(t, map[string]any{
+		"expression":  `event.data.userId == "123"`,
+		"function_id": "my-fn",
+		"since":       "2024-01-01T00:00:00Z",
+	}, body)
+
+	q := RunSearchQuery{Expression: "output.ok == false"}.
+		WithEventField("event.data.plan", "pro").
+		WithEventField("region", `eu "west"`)
+	require.Equal(t, `((output.ok == false) && event.data.plan == "pro") && event.data.region == "eu \"west\""`, q.Expression)
+}
+
+func TestGetFunctionRun(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/v1/runs/run-1/trace", r.URL.Path)
+		_, _ = w.Write([]byte(`{"data":{
+			"run_id": "run-1",
+			"function_id": "my-fn",
+			"status": "Failed",
+			"run_started_at": "2024-01-01T00:00:00Z",
+			"ended_at": "2024-01-01T00:00:05Z",
+			"steps": [
+				{"id": "a", "name": "a", "status": "Completed", "output": {"ok": true}, "started_at": "2024-01-01T00:00:00Z", "ended_at": "2024-01-01T00:00:01Z"},
+				{"id": "b", "name": "b", "status": "Failed", "error": "oh no", "started_at": "2024-01-01T00:00:01Z", "ended_at": "2024-01-01T00:00:05Z"}
+			]
+		}}`))
+	}))
+	defer server.Close()
+
+	c := NewClient(ClientOpts{APIBaseURL: StrPtr(server.URL), SigningKey: StrPtr("")})
+	run, err := c.GetFunctionRun(context.Background(), "run-1")
+	require.NoError(t, err)
+	require.Equal(t, "run-1", run.ID)
+	require.Equal(t, RunStatusFailed, run.Status)
+	require.Equal(t, 5*time.Second, run.Duration())
+
+	require.Len(t, run.Steps, 2)
+	require.Equal(t, "oh no", *run.Steps[1].Error)
+
+	succeeded := run.SucceededSteps()
+	require.Len(t, succeeded, 1)
+	require.Equal(t, "a", succeeded[0].ID)
+	require.JSONEq(t, `{"ok":true}`, string(succeeded[0].Output))
+}
+
+func TestTriggerFunction(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/functions/missing/invoke" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "/v1/functions/app-report/invoke", r.URL.Path)
+
+		evt := Event{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&evt))
+		require.Equal(t, "report/requested", evt.Name)
+		require.Equal(t, "u1", evt.Data["userId"])
+		_, _ = w.Write([]byte(`{"data":{"run_id":"run-1"}}`))
+	}))
+	defer server.Close()
+
+	c := NewClient(ClientOpts{APIBaseURL: StrPtr(server.URL), SigningKey: StrPtr("")})
+	evt := Event{Name: "report/requested", Data: map[string]any{"userId": "u1"}}
+
+	runID, err := c.TriggerFunction(context.Background(), "app-report", evt)
+	require.NoError(t, err)
+	require.Equal(t, "run-1", runID)
+
+	_, err = c.TriggerFunction(context.Background(), "missing", evt)
+	require.ErrorIs(t, err, ErrFunctionNotFound)
+}
@@ -0,0 +1,252 @@
+package inngestgo
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/khulnasoft-lab/inngestgo/errors"
+	"github.com/khulnasoft-lab/inngestgo/step"
+)
+
+// CloudEventSpecVersion is the CloudEvents spec version used by NewCloudEvent.
+const CloudEventSpecVersion = "1.0"
+
+// CloudEvent is an event in the structured CloudEvents JSON format.  Functions
+// which set FunctionOpts.CloudEvent can be triggered by CloudEvents, and
+// functions can emit CloudEvents using EmitCloudEvent.
+type CloudEvent[T any] struct {
+	// ID identifies the event.  If empty, a random ID is generated when the
+	// event is emitted.
+	ID string `json:"id"`
+	// Source identifies the context in which the event happened, eg.
+	// "/billing/invoices".
+	Source string `json:"source"`
+	// Type is the type of the event, eg. "com.example.invoice.paid".
+	Type string `json:"type"`
+	// SpecVersion is the CloudEvents spec version.  Defaults to
+	// CloudEventSpecVersion.
+	SpecVersion string `json:"specversion"`
+	// Time is when the event happened.  If zero, this is set to the time the
+	// event is emitted.
+	Time time.Time `json:"time"`
+	// DataContentType is the content type of Data.  Defaults to
+	// "application/json".
+	DataContentType string `json:"datacontenttype,omitempty"`
+	// Data is the event's payload.
+	Data T `json:"data"`
+}
+
+// NewCloudEvent returns a CloudEvent with the given source, type and data.
+func NewCloudEvent[T any](source, eventType string, data T) CloudEvent[T] {
+	return CloudEvent[T]{
+		Source:          source,
+		Type:            eventType,
+		SpecVersion:     CloudEventSpecVersion,
+		DataContentType: "application/json",
+		Data:            data,
+	}
+}
+
+// CloudEventEmitter delivers a CloudEvent, serialized in the structured
+// application/cloudevents+json format, to another system.  See
+// HandlerOpts.CloudEventEmitter.
+type CloudEventEmitter func(ctx context.Context, body []byte) error
+
+type cloudEventEmitterCtxKeyType struct{}
+
+var cloudEventEmitterCtxKey = cloudEventEmitterCtxKeyType{}
+
+func withCloudEventEmitter(ctx context.Context, e CloudEventEmitter) context.Context {
+	return context.WithValue(ctx, cloudEventEmitterCtxKey, e)
+}
+
+func cloudEventEmitterFromContext(ctx context.Context) CloudEventEmitter {
+	e, _ := ctx.Value(cloudEventEmitterCtxKey).(CloudEventEmitter)
+	return e
+}
+
+// EmitCloudEvent emits the given CloudEvent as a step, serializing the event in
+// the application/cloudevents+json format and delivering it using the handler's
+// HandlerOpts.CloudEventEmitter.  Like any other step, the event is emitted once
+// and memoized when the function is replayed.  If delivery fails, the step is
+// retried.
+func EmitCloudEvent[T any](ctx context.Context, id string, event CloudEvent[T]) error {
+	emit := cloudEventEmitterFromContext(ctx)
+
+	_, err := step.Run(ctx, id, func(ctx context.Context) (json.RawMessage, error) {
+		if emit == nil {
+			return nil, errors.NoRetryError(fmt.Errorf("no CloudEvent emitter set; use HandlerOpts.CloudEventEmitter"))
+		}
+		if event.Source == "" || event.Type == "" {
+			return nil, errors.NoRetryError(fmt.Errorf("CloudEvent source and type must be present"))
+		}
+		if event.ID == "" {
+			event.ID = uuid.NewString()
+		}
+		if event.SpecVersion == "" {
+			event.SpecVersion = CloudEventSpecVersion
+		}
+		if event.Time.IsZero() {
+			event.Time = time.Now()
+		}
+
+		byt, err := json.Marshal(event)
+		if err != nil {
+			return nil, errors.NoRetryError(fmt.Errorf("error marshalling CloudEvent: %w", err))
+		}
+		if err := emit(ctx, byt); err != nil {
+			return nil, fmt.Errorf("error emitting CloudEvent: %w", err)
+		}
+		return byt, nil
+	})
+	return err
+}
+
+// CloudEventConfig enables CloudEvents support for a function.  When set, any
+// triggering event in the CloudEvents JSON format is mapped to an Inngest event
+// before being passed to the function:
+//
+//   - type becomes the event name
+//   - id becomes the event ID
+//   - time becomes the event timestamp
+//   - data becomes the event data.  Binary data, sent as data_base64, is
+//     decoded and used as the event data if it's JSON, and is otherwise
+//     available as bytes within the "data" field.
+//   - source, subject and all other attributes are available within "metadata"
+//
+// This means that Input[T] is populated as usual, and functions don't need to
+// change their signature to accept CloudEvents.
+type CloudEventConfig struct {
+	// Strict rejects triggering events which are not CloudEvents.  By default,
+	// native Inngest events are passed to the function unchanged.
+	Strict bool
+}
+
+// cloudEventAttributes are the CloudEvents context attributes which are mapped
+// directly to Inngest event fields.
+var cloudEventAttributes = map[string]struct{}{
+	"specversion": {},
+	"id":          {},
+	"type":        {},
+	"time":        {},
+	"data":        {},
+	"data_base64": {},
+}
+
+// fromCloudEvent maps a CloudEvent to an Inngest event.  If the given event is
+// not a CloudEvent, this returns the original event and false.
+func fromCloudEvent(byt json.RawMessage) (json.RawMessage, bool, error) {
+	attrs := map[string]json.RawMessage{}
+	if err := json.Unmarshal(byt, &attrs); err != nil {
+		return nil, false, fmt.Errorf("error unmarshalling event: %w", err)
+	}
+	if _, ok := attrs["specversion"]; !ok {
+		return byt, false, nil
+	}
+
+	var ce struct {
+		CloudEvent[json.RawMessage]
+		// DataBase64 is the event's binary data, which is mutually exclusive
+		// with Data.
+		DataBase64 *string `json:"data_base64"`
+	}
+	if err := json.Unmarshal(byt, &ce); err != nil {
+		return nil, true, fmt.Errorf("error unmarshalling cloud event: %w", err)
+	}
+	if ce.Type == "" {
+		return nil, true, fmt.Errorf("cloud event type must be present")
+	}
+
+	data := ce.Data
+	if ce.DataBase64 != nil {
+		if len(data) > 0 {
+			return nil, true, fmt.Errorf("cloud event data and data_base64 can't both be present")
+		}
+		decoded, err := decodeCloudEventData(*ce.DataBase64)
+		if err != nil {
+			return nil, true, err
+		}
+		data = decoded
+	}
+
+	// Inngest event data is always an object, so wrap any other data.
+	if len(data) == 0 || data[0] != '{' {
+		if len(data) == 0 {
+			data = json.RawMessage("null")
+		}
+		data, _ = json.Marshal(map[string]json.RawMessage{"data": data})
+	}
+
+	metadata := map[string]json.RawMessage{}
+	for k, v := range attrs {
+		if _, ok := cloudEventAttributes[k]; ok {
+			continue
+		}
+		metadata[k] = v
+	}
+	metadata["specversion"] = attrs["specversion"]
+
+	evt := map[string]any{
+		"name":     ce.Type,
+		"data":     data,
+		"metadata": metadata,
+	}
+	if ce.ID != "" {
+		evt["id"] = ce.ID
+	}
+	if !ce.Time.IsZero() {
+		evt["ts"] = ce.Time.UnixMilli()
+	}
+
+	out, err := json.Marshal(evt)
+	if err != nil {
+		return nil, true, fmt.Errorf("error marshalling cloud event: %w", err)
+	}
+	return out, true, nil
+}
+
+// decodeCloudEventData decodes a CloudEvent's data_base64 attribute.  JSON data
+// is returned as is, and any other data is encoded as a JSON string which
+// unmarshals into []byte.
+func decodeCloudEventData(b64 string) (json.RawMessage, error) {
+	byt, err := base64.StdEncoding.DecodeString(b64)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding cloud event data_base64: %w", err)
+	}
+	if json.Valid(byt) {
+		return byt, nil
+	}
+	return json.Marshal(byt)
+}
+
+// fromCloudEvents maps the request's event and event batch from CloudEvents to
+// Inngest events.
+func (c CloudEventConfig) fromCloudEvents(evt json.RawMessage, evts []json.RawMessage) (json.RawMessage, []json.RawMessage, error) {
+	convert := func(byt json.RawMessage) (json.RawMessage, error) {
+		out, ok, err := fromCloudEvent(byt)
+		if err != nil {
+			return nil, err
+		}
+		if !ok && c.Strict {
+			return nil, fmt.Errorf("event is not a cloud event")
+		}
+		return out, nil
+	}
+
+	evt, err := convert(evt)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	converted := make([]json.RawMessage, len(evts))
+	for i, e := range evts {
+		if converted[i], err = convert(e); err != nil {
+			return nil, nil, err
+		}
+	}
+	return evt, converted, nil
+}
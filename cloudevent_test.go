@@ -0,0 +1,75 @@
+package inngestgo
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/inngest/inngest/pkg/enums"
+	sdkerrors "github.com/khulnasoft-lab/inngestgo/errors"
+	"github.com/khulnasoft-lab/inngestgo/internal/sdkrequest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmitCloudEvent(t *testing.T) {
+	type invoice struct {
+		ID string `json:"id"`
+	}
+
+	var emitted [][]byte
+	h := NewHandler("test-cloudevents", HandlerOpts{
+		Dev: BoolPtr(true),
+		CloudEventEmitter: func(ctx context.Context, body []byte) error {
+			emitted = append(emitted, body)
+			return nil
+		},
+	}).(*handler)
+
+	fn := CreateFunction(
+		FunctionOpts{Name: "paid"},
+		EventTrigger("invoice/paid", nil),
+		func(ctx context.Context, input Input[any]) (any, error) {
+			evt := NewCloudEvent("/billing", "com.example.invoice.paid", invoice{ID: "inv_1"})
+			return nil, EmitCloudEvent(ctx, "emit-paid", evt)
+		},
+	)
+
+	req := createRequest(t, map[string]any{"name": "invoice/paid"})
+	_, ops, err := h.invokeWithHooks(context.Background(), fn, req, nil)
+	require.NoError(t, err)
+	require.Len(t, emitted, 1)
+
+	ce := map[string]any{}
+	require.NoError(t, json.Unmarshal(emitted[0], &ce))
+	require.Equal(t, "1.0", ce["specversion"])
+	require.Equal(t, "/billing", ce["source"])
+	require.Equal(t, "com.example.invoice.paid", ce["type"])
+	require.Equal(t, "application/json", ce["datacontenttype"])
+	require.Equal(t, map[string]any{"id": "inv_1"}, ce["data"])
+	require.NotEmpty(t, ce["id"])
+	require.NotEmpty(t, ce["time"])
+
+	// The step's output is the serialized event.
+	require.Len(t, ops, 1)
+	require.Equal(t, enums.OpcodeStepRun, ops[0].Op)
+	require.JSONEq(t, string(emitted[0]), string(ops[0].Data))
+
+	t.Run("memoized on replay", func(t *testing.T) {
+		hashed := sdkrequest.UnhashedOp{Op: enums.OpcodeStep, ID: "emit-paid"}.MustHash()
+		req := createRequest(t, map[string]any{"name": "invoice/paid"})
+		req.Steps = map[string]json.RawMessage{hashed: ops[0].Data}
+		_, ops, err := h.invokeWithHooks(context.Background(), fn, req, nil)
+		require.NoError(t, err)
+		require.Empty(t, ops)
+		require.Len(t, emitted, 1)
+	})
+
+	t.Run("requires an emitter", func(t *testing.T) {
+		h := NewHandler("test-cloudevents", HandlerOpts{Dev: BoolPtr(true)}).(*handler)
+		_, ops, err := h.invokeWithHooks(context.Background(), fn, createRequest(t, map[string]any{"name": "invoice/paid"}), nil)
+		require.True(t, sdkerrors.IsNoRetryError(err))
+		require.Len(t, ops, 1)
+		require.Equal(t, enums.OpcodeStepError, ops[0].Op)
+		require.Contains(t, ops[0].Error.Message, "no CloudEvent emitter set")
+	})
+}
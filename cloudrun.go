@@ -0,0 +1,119 @@
+package inngestgo
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	envKeyCloudRunService  = "K_SERVICE"
+	envKeyCloudRunRevision = "K_REVISION"
+)
+
+// cloudRunMetadataURL is the base URL of the metadata server available to
+// Cloud Run services.
+var cloudRunMetadataURL = "http://metadata.google.internal/computeMetadata/v1"
+
+// IsGoogleCloudRun returns whether the process is running on Google Cloud Run,
+// by checking the presence of the K_SERVICE and K_REVISION environment
+// variables which Cloud Run sets for every service.
+func IsGoogleCloudRun() bool {
+	return os.Getenv(envKeyCloudRunService) != "" && os.Getenv(envKeyCloudRunRevision) != ""
+}
+
+// cloudRunServiceURL returns the deterministic URL of the Cloud Run service
+// serving the handler at the given path.  Cloud Run doesn't expose the
+// service's URL, so it's constructed from K_SERVICE and the project number
+// and region reported by the metadata server as
+// https://<service>-<project number>.<region>.run.app.
+func cloudRunServiceURL(ctx context.Context, servePath string) (*url.URL, error) {
+	service := os.Getenv(envKeyCloudRunService)
+	if service == "" {
+		return nil, fmt.Errorf("%s must be set", envKeyCloudRunService)
+	}
+	project, err := cloudRunMetadata(ctx, "project/numeric-project-id")
+	if err != nil {
+		return nil, fmt.Errorf("error reading project number: %w", err)
+	}
+	// The region is reported as "projects/<project number>/regions/<region>".
+	region, err := cloudRunMetadata(ctx, "instance/region")
+	if err != nil {
+		return nil, fmt.Errorf("error reading region: %w", err)
+	}
+	return &url.URL{
+		Scheme: "https",
+		Host:   fmt.Sprintf("%s-%s.%s.run.app", service, project, path.Base(region)),
+		Path:   servePath,
+	}, nil
+}
+
+// cloudRunMetadata returns the value at the given path within the metadata
+// server.
+func cloudRunMetadata(ctx context.Context, key string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, cloudRunMetadataURL+"/"+key, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	byt, err := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("metadata server responded with status %d", resp.StatusCode)
+	}
+	val := strings.TrimSpace(string(byt))
+	if val == "" {
+		return "", fmt.Errorf("metadata server returned an empty value for '%s'", key)
+	}
+	return val, nil
+}
+
+// applyCloudRun sets the handler's AppVersion from the Cloud Run environment,
+// unless it's already set, and returns whether the handler's URL must be
+// detected via detectCloudRunURL.
+func (h *HandlerOpts) applyCloudRun() bool {
+	if !IsGoogleCloudRun() {
+		return false
+	}
+	if h.AppVersion == nil {
+		h.AppVersion = StrPtr(os.Getenv(envKeyCloudRunRevision))
+	}
+	return h.URL == nil
+}
+
+// detectCloudRunURL sets the handler's URL to the Cloud Run service's URL,
+// if it must be detected.  This is called before syncing, which fails if the
+// URL can't be detected rather than syncing an incorrect URL.
+func (h *handler) detectCloudRunURL(ctx context.Context) error {
+	if !h.detectURL {
+		return nil
+	}
+	h.l.Lock()
+	defer h.l.Unlock()
+	if h.URL != nil {
+		return nil
+	}
+	u, err := cloudRunServiceURL(ctx, h.GetServePath())
+	if err != nil {
+		return fmt.Errorf("error detecting Cloud Run service URL: %w", err)
+	}
+	h.URL = u
+	return nil
+}
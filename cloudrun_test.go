@@ -0,0 +1,102 @@
+package inngestgo
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCloudRunAutoDetect(t *testing.T) {
+	metadata := map[string]string{
+		"/project/numeric-project-id": "123456789",
+		"/instance/region":            "projects/123456789/regions/us-central1",
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		val, ok := metadata[r.URL.Path]
+		if !ok || r.Header.Get("Metadata-Flavor") != "Google" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(val))
+	}))
+	defer server.Close()
+
+	defaultURL := cloudRunMetadataURL
+	cloudRunMetadataURL = server.URL
+	defer func() { cloudRunMetadataURL = defaultURL }()
+
+	cloudRun := func(t *testing.T) {
+		t.Setenv("K_SERVICE", "billing")
+		t.Setenv("K_REVISION", "billing-00042-abc")
+	}
+
+	t.Run("detects cloud run", func(t *testing.T) {
+		t.Setenv("K_SERVICE", "")
+		t.Setenv("K_REVISION", "")
+		require.False(t, IsGoogleCloudRun())
+
+		cloudRun(t)
+		require.True(t, IsGoogleCloudRun())
+	})
+
+	t.Run("sets url and version", func(t *testing.T) {
+		cloudRun(t)
+		h := NewHandler("test-cloud-run", HandlerOpts{
+			CloudRunAutoDetect: true,
+			ServePath:          StrPtr("/api/inngest"),
+		}).(*handler)
+		require.Equal(t, "billing-00042-abc", h.GetAppVersion())
+
+		// The URL is detected when syncing.
+		require.Nil(t, h.URL)
+		require.NoError(t, h.detectCloudRunURL(context.Background()))
+		require.Equal(t, "https://billing-123456789.us-central1.run.app/api/inngest", h.URL.String())
+	})
+
+	t.Run("doesn't override options", func(t *testing.T) {
+		cloudRun(t)
+		appURL, _ := url.Parse("http://test.local")
+		h := NewHandler("test-cloud-run", HandlerOpts{
+			CloudRunAutoDetect: true,
+			URL:                appURL,
+			AppVersion:         StrPtr("v1.2.3"),
+		}).(*handler)
+		require.NoError(t, h.detectCloudRunURL(context.Background()))
+		require.Equal(t, appURL, h.URL)
+		require.Equal(t, "v1.2.3", h.GetAppVersion())
+	})
+
+	t.Run("set options", func(t *testing.T) {
+		cloudRun(t)
+		h := NewHandler("test-cloud-run", HandlerOpts{}).(*handler)
+		h.SetOptions(HandlerOpts{CloudRunAutoDetect: true})
+		require.Equal(t, "billing-00042-abc", h.GetAppVersion())
+		require.NoError(t, h.detectCloudRunURL(context.Background()))
+		require.Equal(t, "https://billing-123456789.us-central1.run.app", h.URL.String())
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		cloudRun(t)
+		h := NewHandler("test-cloud-run", HandlerOpts{}).(*handler)
+		require.NoError(t, h.detectCloudRunURL(context.Background()))
+		require.Nil(t, h.URL)
+		require.Empty(t, h.GetAppVersion())
+	})
+
+	t.Run("sync fails without metadata", func(t *testing.T) {
+		cloudRun(t)
+		delete(metadata, "/instance/region")
+		h := NewHandler("test-cloud-run", HandlerOpts{CloudRunAutoDetect: true}).(*handler)
+		require.ErrorContains(t, h.detectCloudRunURL(context.Background()), "error detecting Cloud Run service URL: error reading region")
+		require.Nil(t, h.URL)
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/", nil))
+		require.Equal(t, http.StatusInternalServerError, w.Code)
+		require.Contains(t, w.Body.String(), "error detecting Cloud Run service URL")
+	})
+}
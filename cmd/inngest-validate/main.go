@@ -0,0 +1,290 @@
+// Command inngest-validate checks Inngest function definitions before they're
+// deployed.  It scans the given packages for inngestgo.CreateFunction calls and
+// reports:
+//
+//   - functions with duplicate IDs
+//   - functions missing both an ID and a name
+//   - aliases which duplicate the function's own ID, or another function's ID
+//
+// Usage:
+//
+//	inngest-validate [-tags tag,list] [packages]
+//
+// Packages are directories, and may end in "/..." to include subdirectories.
+// Import paths aren't resolved, so packages within other modules can't be
+// checked, and subdirectories containing their own go.mod are skipped.  Files
+// are selected using the build constraints for the current GOOS and GOARCH and
+// the given tags, as with go build.  Packages aren't type checked, so code
+// which must be generated before building is only checked if it's present.
+// Problems are printed in the same format as go vet, and the command exits with
+// status 1 if any are found.
+//
+// Only IDs, names and aliases defined as string literals are checked.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gosimple/slug"
+)
+
+func main() {
+	tags := flag.String("tags", "", "comma-separated list of build tags")
+	flag.Parse()
+
+	patterns := flag.Args()
+	if len(patterns) == 0 {
+		patterns = []string{"."}
+	}
+
+	bctx := build.Default
+	if *tags != "" {
+		bctx.BuildTags = strings.Split(*tags, ",")
+	}
+
+	var files []string
+	for _, pattern := range patterns {
+		found, err := goFiles(bctx, pattern)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "inngest-validate: %s\n", err)
+			os.Exit(2)
+		}
+		files = append(files, found...)
+	}
+
+	fset := token.NewFileSet()
+	var fns []function
+	for _, file := range files {
+		f, err := parser.ParseFile(fset, file, nil, 0)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "inngest-validate: %s\n", err)
+			os.Exit(2)
+		}
+		fns = append(fns, findFunctions(fset, f)...)
+	}
+
+	problems := validate(fns)
+	for _, p := range problems {
+		fmt.Fprintln(os.Stderr, p)
+	}
+	if len(problems) > 0 {
+		os.Exit(1)
+	}
+}
+
+// goFiles returns the non-test Go files which match the build context for the
+// given package pattern.
+func goFiles(bctx build.Context, pattern string) ([]string, error) {
+	dir, recursive := strings.CutSuffix(pattern, "/...")
+	if pattern == "..." {
+		dir, recursive = ".", true
+	}
+
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != dir {
+			name := d.Name()
+			if !recursive || name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") {
+				return filepath.SkipDir
+			}
+			if _, err := os.Stat(filepath.Join(path, "go.mod")); err == nil {
+				// Nested modules are separate from the pattern's module.
+				return filepath.SkipDir
+			}
+		}
+
+		pkg, err := bctx.ImportDir(path, 0)
+		var noGo *build.NoGoError
+		if errors.As(err, &noGo) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		for _, name := range pkg.GoFiles {
+			files = append(files, filepath.Join(path, name))
+		}
+		return nil
+	})
+	return files, err
+}
+
+// function is a function definition found via a CreateFunction call.
+type function struct {
+	pos token.Position
+	// id is the function's ID, or the slug of its name if no ID is set.  This
+	// is empty if the ID is not a string literal.
+	id string
+	// missingID is true if neither the ID nor name is set.
+	missingID bool
+	aliases   []alias
+}
+
+type alias struct {
+	pos token.Position
+	id  string
+}
+
+// findFunctions returns all functions created via CreateFunction calls with a
+// FunctionOpts literal.
+func findFunctions(fset *token.FileSet, f *ast.File) []function {
+	var fns []function
+	ast.Inspect(f, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || len(call.Args) == 0 || !isCreateFunction(call.Fun) {
+			return true
+		}
+		opts, ok := call.Args[0].(*ast.CompositeLit)
+		if !ok {
+			return true
+		}
+
+		fn := function{pos: fset.Position(call.Pos())}
+		var (
+			id, name               string
+			idDynamic, nameDynamic bool
+		)
+		for _, elt := range opts.Elts {
+			kv, ok := elt.(*ast.KeyValueExpr)
+			if !ok {
+				continue
+			}
+			key, ok := kv.Key.(*ast.Ident)
+			if !ok {
+				continue
+			}
+			switch key.Name {
+			case "ID":
+				id, ok = stringLit(kv.Value)
+				idDynamic = !ok
+			case "Name":
+				name, ok = stringLit(kv.Value)
+				nameDynamic = !ok
+			case "Aliases":
+				lit, ok := kv.Value.(*ast.CompositeLit)
+				if !ok {
+					continue
+				}
+				for _, a := range lit.Elts {
+					if s, ok := stringLit(a); ok {
+						fn.aliases = append(fn.aliases, alias{pos: fset.Position(a.Pos()), id: s})
+					}
+				}
+			}
+		}
+
+		switch {
+		case id != "":
+			fn.id = id
+		case idDynamic:
+		case name != "":
+			fn.id = slug.Make(name)
+		case nameDynamic:
+		default:
+			fn.missingID = true
+		}
+		fns = append(fns, fn)
+		return true
+	})
+	return fns
+}
+
+// isCreateFunction returns whether the expression refers to CreateFunction,
+// either qualified by a package name or dot-imported.
+func isCreateFunction(expr ast.Expr) bool {
+	// Skip explicit type parameters, eg. CreateFunction[T](...).
+	if idx, ok := expr.(*ast.IndexExpr); ok {
+		expr = idx.X
+	}
+	switch e := expr.(type) {
+	case *ast.SelectorExpr:
+		return e.Sel.Name == "CreateFunction"
+	case *ast.Ident:
+		return e.Name == "CreateFunction"
+	}
+	return false
+}
+
+func stringLit(expr ast.Expr) (string, bool) {
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	s, err := strconv.Unquote(lit.Value)
+	return s, err == nil
+}
+
+// validate returns all problems with the given functions, sorted by position.
+func validate(fns []function) []string {
+	type problem struct {
+		pos token.Position
+		msg string
+	}
+	var problems []problem
+
+	ids := map[string]function{}
+	for _, fn := range fns {
+		if fn.missingID {
+			problems = append(problems, problem{fn.pos, "function has no ID or Name"})
+			continue
+		}
+		if fn.id == "" {
+			continue
+		}
+		if first, ok := ids[fn.id]; ok {
+			problems = append(problems, problem{fn.pos, fmt.Sprintf("duplicate function ID %q, first defined at %s", fn.id, first.pos)})
+			continue
+		}
+		ids[fn.id] = fn
+	}
+
+	for _, fn := range fns {
+		seen := map[string]bool{}
+		for _, a := range fn.aliases {
+			switch other, ok := ids[a.id]; {
+			case seen[a.id]:
+				problems = append(problems, problem{a.pos, fmt.Sprintf("duplicate alias %q", a.id)})
+			case a.id == fn.id:
+				problems = append(problems, problem{a.pos, fmt.Sprintf("alias %q is unused as it matches the function's ID", a.id)})
+			case ok:
+				problems = append(problems, problem{a.pos, fmt.Sprintf("alias %q conflicts with the function defined at %s", a.id, other.pos)})
+			}
+			seen[a.id] = true
+		}
+	}
+
+	sort.SliceStable(problems, func(i, j int) bool {
+		a, b := problems[i].pos, problems[j].pos
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+
+	out := make([]string, len(problems))
+	for i, p := range problems {
+		out[i] = fmt.Sprintf("%s: %s", p.pos, p.msg)
+	}
+	return out
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"go/build"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	src := `package fns
+
+import "github.com/khulnasoft-lab/inngestgo"
+
+var (
+	a = inngestgo.CreateFunction(inngestgo.FunctionOpts{ID: "charge", Aliases: []string{"charge", "pay"}}, nil, nil)
+	b = inngestgo.CreateFunction(inngestgo.FunctionOpts{Name: "Pay"}, nil, nil)
+	c = inngestgo.CreateFunction(inngestgo.FunctionOpts{Name: "Charge"}, nil, nil)
+	d = inngestgo.CreateFunction(inngestgo.FunctionOpts{}, nil, nil)
+	e = inngestgo.CreateFunction(inngestgo.FunctionOpts{ID: dynamicID}, nil, nil)
+	f = inngestgo.CreateFunction(inngestgo.FunctionOpts{ID: dynamicID}, nil, nil)
+)
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "fns.go", src, 0)
+	require.NoError(t, err)
+
+	fns := findFunctions(fset, f)
+	require.Len(t, fns, 6)
+	require.Equal(t, []string{
+		`fns.go:6:86: alias "charge" is unused as it matches the function's ID`,
+		`fns.go:6:96: alias "pay" conflicts with the function defined at fns.go:7:6`,
+		`fns.go:8:6: duplicate function ID "charge", first defined at fns.go:6:6`,
+		`fns.go:9:6: function has no ID or Name`,
+	}, validate(fns))
+}
+
+func TestGoFiles(t *testing.T) {
+	dir := t.TempDir()
+	write := func(path, src string) {
+		path = filepath.Join(dir, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(src), 0o644))
+	}
+	write("fns.go", "package fns\n")
+	write("fns_test.go", "package fns\n")
+	write("ignored.go", "//go:build ignore\n\npackage fns\n")
+	write("tagged.go", "//go:build integration\n\npackage fns\n")
+	write("sub/sub.go", "package sub\n")
+	write("nested/go.mod", "module nested\n")
+	write("nested/nested.go", "package nested\n")
+
+	files, err := goFiles(build.Default, dir+"/...")
+	require.NoError(t, err)
+	require.Equal(t, []string{filepath.Join(dir, "fns.go"), filepath.Join(dir, "sub/sub.go")}, files)
+
+	bctx := build.Default
+	bctx.BuildTags = []string{"integration"}
+	files, err = goFiles(bctx, dir)
+	require.NoError(t, err)
+	require.Equal(t, []string{filepath.Join(dir, "fns.go"), filepath.Join(dir, "tagged.go")}, files)
+}
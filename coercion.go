@@ -0,0 +1,155 @@
+package inngestgo
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// CoercionConfig coerces loosely typed JSON within events into the types of
+// the function's event fields, eg. when events are sent with "true" instead
+// of true.
+type CoercionConfig struct {
+	// CoerceStrings parses strings into bool, integer, and float fields, eg.
+	// "true" into a bool and "123" into an int.
+	CoerceStrings bool
+	// CoerceNumbers converts floats with no fractional part into integer
+	// fields, eg. 3.0 into an int.  Other floats still fail to unmarshal.
+	CoerceNumbers bool
+}
+
+// unmarshalInput unmarshals the event JSON into v, coercing values into the
+// types of v's fields as configured.
+func unmarshalInput(c *CoercionConfig, byt []byte, v any) error {
+	if c == nil || (!c.CoerceStrings && !c.CoerceNumbers) {
+		return json.Unmarshal(byt, v)
+	}
+
+	// Decode numbers as json.Number so that they're re-encoded as-is.
+	dec := json.NewDecoder(bytes.NewReader(byt))
+	dec.UseNumber()
+	var raw any
+	if err := dec.Decode(&raw); err != nil {
+		return err
+	}
+
+	byt, err := json.Marshal(c.coerce(raw, reflect.TypeOf(v)))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(byt, v)
+}
+
+// coerce returns the decoded JSON value coerced into the given type, where
+// possible.  Values which can't be coerced are returned as-is, so that
+// unmarshalling reports the error.
+func (c CoercionConfig) coerce(val any, t reflect.Type) any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if ptr := reflect.PointerTo(t); ptr.Implements(jsonUnmarshalerType) || ptr.Implements(textUnmarshalerType) {
+		// Types such as time.Time handle their own JSON.
+		return val
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if m, ok := val.(map[string]any); ok {
+			fields := jsonFields(t)
+			for k, v := range m {
+				// Match keys case-insensitively, as encoding/json does.
+				if ft, ok := fields[strings.ToLower(k)]; ok {
+					m[k] = c.coerce(v, ft)
+				}
+			}
+		}
+	case reflect.Map:
+		if m, ok := val.(map[string]any); ok {
+			for k, v := range m {
+				m[k] = c.coerce(v, t.Elem())
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if s, ok := val.([]any); ok {
+			for i, v := range s {
+				s[i] = c.coerce(v, t.Elem())
+			}
+		}
+	case reflect.Bool:
+		if s, ok := val.(string); ok && c.CoerceStrings {
+			if b, err := strconv.ParseBool(s); err == nil {
+				return b
+			}
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		switch v := val.(type) {
+		case string:
+			if !c.CoerceStrings {
+				break
+			}
+			if _, err := strconv.ParseInt(v, 10, 64); err == nil {
+				return json.Number(v)
+			}
+			if _, err := strconv.ParseUint(v, 10, 64); err == nil {
+				return json.Number(v)
+			}
+		case json.Number:
+			if !c.CoerceNumbers {
+				break
+			}
+			if f, err := v.Float64(); err == nil && f == math.Trunc(f) && !math.IsInf(f, 0) {
+				return json.Number(strconv.FormatFloat(f, 'f', -1, 64))
+			}
+		}
+	case reflect.Float32, reflect.Float64:
+		if s, ok := val.(string); ok && c.CoerceStrings {
+			if _, err := strconv.ParseFloat(s, 64); err == nil {
+				return json.Number(s)
+			}
+		}
+	}
+	return val
+}
+
+// jsonFields returns the types of the struct's fields, keyed by the lowercased
+// JSON name of each field.  Fields of embedded structs are included.
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" || (!f.IsExported() && !f.Anonymous) {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		ft := f.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			for k, v := range jsonFields(ft) {
+				if _, ok := fields[k]; !ok {
+					fields[k] = v
+				}
+			}
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+		fields[strings.ToLower(name)] = f.Type
+	}
+	return fields
+}
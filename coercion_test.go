@@ -0,0 +1,103 @@
+package inngestgo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type orderData struct {
+	Paid     bool      `json:"paid"`
+	Quantity int       `json:"quantity"`
+	Total    float64   `json:"total"`
+	Items    []uint    `json:"items"`
+	Shipping *shipping `json:"shipping"`
+	At       time.Time `json:"at"`
+}
+
+type shipping struct {
+	Express bool
+}
+
+func TestUnmarshalInput(t *testing.T) {
+	input := []byte(`{
+		"paid": "true",
+		"quantity": "3",
+		"total": 10,
+		"items": [1.0, "2"],
+		"shipping": {"express": "false"},
+		"at": "2024-01-01T00:00:00Z"
+	}`)
+
+	t.Run("coerces strings and numbers", func(t *testing.T) {
+		out := orderData{}
+		err := unmarshalInput(&CoercionConfig{CoerceStrings: true, CoerceNumbers: true}, input, &out)
+		require.NoError(t, err)
+		require.Equal(t, orderData{
+			Paid:     true,
+			Quantity: 3,
+			Total:    10,
+			Items:    []uint{1, 2},
+			Shipping: &shipping{Express: false},
+			At:       time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		}, out)
+	})
+
+	t.Run("string to bool", func(t *testing.T) {
+		out := orderData{}
+		require.NoError(t, unmarshalInput(&CoercionConfig{CoerceStrings: true}, []byte(`{"paid":"1"}`), &out))
+		require.True(t, out.Paid)
+	})
+
+	t.Run("string to int", func(t *testing.T) {
+		out := orderData{}
+		require.NoError(t, unmarshalInput(&CoercionConfig{CoerceStrings: true}, []byte(`{"quantity":"-12"}`), &out))
+		require.Equal(t, -12, out.Quantity)
+
+		err := unmarshalInput(&CoercionConfig{CoerceStrings: true}, []byte(`{"quantity":"twelve"}`), &out)
+		require.Error(t, err)
+	})
+
+	t.Run("float to int", func(t *testing.T) {
+		out := orderData{}
+		require.NoError(t, unmarshalInput(&CoercionConfig{CoerceNumbers: true}, []byte(`{"quantity":4.0}`), &out))
+		require.Equal(t, 4, out.Quantity)
+
+		err := unmarshalInput(&CoercionConfig{CoerceNumbers: true}, []byte(`{"quantity":4.5}`), &out)
+		require.Error(t, err)
+		err = unmarshalInput(&CoercionConfig{CoerceStrings: true}, []byte(`{"quantity":4.0}`), &out)
+		require.Error(t, err)
+	})
+
+	t.Run("int to float", func(t *testing.T) {
+		out := orderData{}
+		require.NoError(t, unmarshalInput(&CoercionConfig{CoerceNumbers: true}, []byte(`{"total":7}`), &out))
+		require.Equal(t, float64(7), out.Total)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		out := orderData{}
+		require.Error(t, unmarshalInput(nil, input, &out))
+		require.Error(t, unmarshalInput(&CoercionConfig{}, input, &out))
+	})
+}
+
+func TestInputCoercion(t *testing.T) {
+	var data orderData
+	fn := CreateFunction(
+		FunctionOpts{Name: "order", InputCoercion: &CoercionConfig{CoerceStrings: true}},
+		EventTrigger("order/created", nil),
+		func(ctx context.Context, input Input[GenericEvent[orderData, any]]) (any, error) {
+			data = input.Event.Data
+			return nil, nil
+		},
+	)
+
+	evt := map[string]any{"name": "order/created", "data": map[string]any{"paid": "true", "quantity": "2"}}
+	_, _, err := invoke(context.Background(), fn, createRequest(t, evt), nil, nil)
+	require.NoError(t, err)
+	require.True(t, data.Paid)
+	require.Equal(t, 2, data.Quantity)
+}
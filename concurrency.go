@@ -0,0 +1,56 @@
+package inngestgo
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// concurrencyRetryAfter is the Retry-After header sent when the handler is at
+// HandlerOpts.MaxConcurrentFunctions.
+const concurrencyRetryAfter = "1"
+
+var errConcurrencyLimit = fmt.Errorf("handler is at its concurrency limit")
+
+// concurrency limits the number of functions executing at once.
+type concurrency struct {
+	// sem is a semaphore with a slot per concurrent function, or nil if the
+	// number of functions is unlimited.
+	sem    chan struct{}
+	active atomic.Int64
+}
+
+func newConcurrency(limit *int) *concurrency {
+	c := &concurrency{}
+	if limit != nil && *limit > 0 {
+		c.sem = make(chan struct{}, *limit)
+	}
+	return c
+}
+
+// acquire reserves a slot for a function, returning errConcurrencyLimit if all
+// slots are taken.  The returned function releases the slot.
+func (c *concurrency) acquire() (func(), error) {
+	if c.sem != nil {
+		select {
+		case c.sem <- struct{}{}:
+		default:
+			return nil, errConcurrencyLimit
+		}
+	}
+
+	c.active.Add(1)
+	return func() {
+		c.active.Add(-1)
+		if c.sem != nil {
+			<-c.sem
+		}
+	}, nil
+}
+
+// CurrentConcurrency returns the number of functions currently executing.
+func (h *handler) CurrentConcurrency() int {
+	if h.concurrency == nil {
+		return 0
+	}
+	return int(h.concurrency.active.Load())
+}
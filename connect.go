@@ -8,6 +8,7 @@ import (
 	"github.com/khulnasoft-lab/inngestgo/connect"
 	"github.com/khulnasoft-lab/inngestgo/internal/sdkrequest"
 	"net/url"
+	"slices"
 )
 
 const (
@@ -44,12 +45,15 @@ func (h *handler) Connect(ctx context.Context, opts ConnectOpts) (connect.Worker
 		return nil, fmt.Errorf("missing required Instance ID")
 	}
 
-	fns, err := createFunctionConfigs(h.appName, h.funcs, connectPlaceholder, true)
+	fns, err := createFunctionConfigs(h.appName, h.funcs, connectPlaceholder, true, h.GetEnv())
 	if err != nil {
 		return nil, fmt.Errorf("error creating function configs: %w", err)
 	}
 
-	signingKey := h.GetSigningKey()
+	signingKey, err := h.signingKey(ctx)
+	if err != nil {
+		return nil, err
+	}
 	if signingKey == "" {
 		return nil, fmt.Errorf("signing key is required")
 	}
@@ -93,7 +97,7 @@ func (h *handler) getServableFunctionBySlug(slug string) ServableFunction {
 	h.l.RLock()
 	var fn ServableFunction
 	for _, f := range h.funcs {
-		if f.Slug(h.appName) == slug {
+		if slices.Contains(functionSlugs(f, h.appName), slug) {
 			fn = f
 			break
 		}
@@ -117,7 +121,7 @@ func (h *handler) InvokeFunction(ctx context.Context, slug string, stepId *strin
 	}
 
 	// Invoke function, always complete regardless of
-	resp, ops, err := invoke(context.Background(), fn, &request, stepId)
+	resp, ops, err := h.invokeWithHooks(context.Background(), fn, &request, stepId)
 
 	return resp, ops, err
 }
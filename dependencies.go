@@ -0,0 +1,51 @@
+package inngestgo
+
+import (
+	"fmt"
+	"strings"
+)
+
+// dependencySlug returns the slug of the function with the given DependsOn ID,
+// and whether the function belongs to another app.  Functions within other
+// apps are referenced as "<app-id>/<function-id>".
+func dependencySlug(appName, id string) (string, bool) {
+	if app, fnID, ok := strings.Cut(id, "/"); ok {
+		return app + "-" + fnID, true
+	}
+	if appName == "" {
+		return id, false
+	}
+	return appName + "-" + id, false
+}
+
+// validateDependencies checks that the function's dependencies within the same
+// app are registered.
+func validateDependencies(appName string, fn ServableFunction, slugs map[string]struct{}) error {
+	for _, id := range fn.Config().DependsOn {
+		if slug, external := dependencySlug(appName, id); !external {
+			if _, ok := slugs[slug]; !ok {
+				return fmt.Errorf("dependency '%s' is not registered", id)
+			}
+		}
+	}
+	return nil
+}
+
+// BuildDependencyGraph returns the registered functions' dependencies declared
+// via FunctionOpts.DependsOn, as an adjacency list keyed by function slug.
+// Functions without dependencies are included with no edges.
+func (h *handler) BuildDependencyGraph() map[string][]string {
+	h.l.RLock()
+	defer h.l.RUnlock()
+
+	graph := make(map[string][]string, len(h.funcs))
+	for _, fn := range h.funcs {
+		deps := make([]string, 0, len(fn.Config().DependsOn))
+		for _, id := range fn.Config().DependsOn {
+			slug, _ := dependencySlug(h.appName, id)
+			deps = append(deps, slug)
+		}
+		graph[fn.Slug(h.appName)] = deps
+	}
+	return graph
+}
@@ -0,0 +1,49 @@
+package inngestgo
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDependsOn(t *testing.T) {
+	appURL, _ := url.Parse("http://test.local")
+	noop := func(ctx context.Context, input Input[any]) (any, error) { return nil, nil }
+	charge := CreateFunction(FunctionOpts{ID: "charge"}, EventTrigger("order/created", nil), noop)
+	notify := CreateFunction(FunctionOpts{ID: "notify"}, EventTrigger("order/charged", nil), noop)
+	checkout := CreateFunction(
+		FunctionOpts{ID: "checkout", DependsOn: []string{"charge", "notify", "emails/send-receipt"}},
+		EventTrigger("checkout/completed", nil),
+		noop,
+	)
+
+	t.Run("manifest", func(t *testing.T) {
+		fns, err := createFunctionConfigs("shop", []ServableFunction{charge, notify, checkout}, *appURL, false, "")
+		require.NoError(t, err)
+
+		byt, err := json.Marshal(fns[2])
+		require.NoError(t, err)
+		out := map[string]any{}
+		require.NoError(t, json.Unmarshal(byt, &out))
+		// Dependencies are advisory, so they're never synced.
+		require.NotContains(t, out, "dependsOn")
+	})
+
+	t.Run("unregistered dependency", func(t *testing.T) {
+		_, err := createFunctionConfigs("shop", []ServableFunction{charge, checkout}, *appURL, false, "")
+		require.EqualError(t, err, "invalid dependencies for function 'shop-checkout': dependency 'notify' is not registered")
+	})
+
+	t.Run("dependency graph", func(t *testing.T) {
+		h := NewHandler("shop", HandlerOpts{})
+		h.Register(charge, notify, checkout)
+		require.Equal(t, map[string][]string{
+			"shop-charge":   {},
+			"shop-notify":   {},
+			"shop-checkout": {"shop-charge", "shop-notify", "emails-send-receipt"},
+		}, h.BuildDependencyGraph())
+	})
+}
@@ -0,0 +1,209 @@
+package inngestgo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/inngest/inngest/pkg/sdk"
+)
+
+const (
+	defaultDevProxyPollInterval = 5 * time.Second
+)
+
+// LocalDevProxy keeps a handler registered with a locally running Inngest dev
+// server.  This removes the need to manually run `npx inngest-cli dev -u <your-app>`
+// to point the dev server at your app:
+//
+//	h := inngestgo.NewHandler("my-app", inngestgo.HandlerOpts{URL: appURL})
+//	proxy := inngestgo.NewLocalDevProxy(h, "")
+//	if err := proxy.Start(ctx); err != nil {
+//		// handle error
+//	}
+//	defer proxy.Stop()
+//
+// The handler's URL option must be set so that the dev server knows where to
+// call your functions.  Stop doesn't unregister the app:  the app stays
+// registered with the dev server until the dev server fails to reach it.
+type LocalDevProxy struct {
+	// PollInterval is how often the dev server's health is checked.  If the dev
+	// server restarts, the app is re-registered on the next successful check.
+	// Defaults to 5 seconds.
+	PollInterval time.Duration
+
+	h            *handler
+	devServerURL string
+	registered   atomic.Bool
+
+	l      sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewLocalDevProxy returns a LocalDevProxy which registers the given handler with
+// the dev server running at devServerURL.  If devServerURL is empty this defaults
+// to DevServerURL().
+func NewLocalDevProxy(h Handler, devServerURL string) *LocalDevProxy {
+	if devServerURL == "" {
+		devServerURL = DevServerURL()
+	}
+	// Only handlers created via NewHandler can be registered;  Start returns an
+	// error for any other implementation.
+	hnd, _ := h.(*handler)
+	return &LocalDevProxy{
+		PollInterval: defaultDevProxyPollInterval,
+		h:            hnd,
+		devServerURL: devServerURL,
+	}
+}
+
+// Start registers the app with the dev server, then polls the dev server's
+// health in the background until Stop is called or ctx is cancelled.
+func (p *LocalDevProxy) Start(ctx context.Context) error {
+	p.l.Lock()
+	defer p.l.Unlock()
+
+	if p.cancel != nil {
+		return fmt.Errorf("dev proxy already started")
+	}
+	if err := p.register(ctx); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	p.cancel = cancel
+	p.done = make(chan struct{})
+	go p.poll(ctx)
+	return nil
+}
+
+// Stop stops polling the dev server.  This doesn't unregister the app, as the
+// dev server doesn't expose an API for removing apps:  the app stays registered,
+// and the dev server keeps calling it, until the dev server fails to reach it.
+func (p *LocalDevProxy) Stop() error {
+	p.l.Lock()
+	defer p.l.Unlock()
+
+	if p.cancel == nil {
+		return nil
+	}
+	p.cancel()
+	<-p.done
+	p.cancel = nil
+	p.registered.Store(false)
+	return nil
+}
+
+// Registered returns whether the proxy has registered the app with the dev
+// server and is keeping it registered.  This returns false after Stop, even
+// though the dev server still lists the app.
+func (p *LocalDevProxy) Registered() bool {
+	return p.registered.Load()
+}
+
+func (p *LocalDevProxy) poll(ctx context.Context) {
+	defer close(p.done)
+
+	interval := p.PollInterval
+	if interval <= 0 {
+		interval = defaultDevProxyPollInterval
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+
+		if !p.healthy(ctx) {
+			p.registered.Store(false)
+			continue
+		}
+		if p.registered.Load() {
+			continue
+		}
+		// The dev server has come back up, so re-register the app.
+		if err := p.register(ctx); err != nil {
+			p.h.Logger.Warn("error re-registering with dev server", "error", err)
+		}
+	}
+}
+
+func (p *LocalDevProxy) healthy(ctx context.Context) bool {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.devServerURL+"/dev", nil)
+	if err != nil {
+		return false
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return false
+	}
+	_ = resp.Body.Close()
+	return resp.StatusCode == http.StatusOK
+}
+
+func (p *LocalDevProxy) register(ctx context.Context) error {
+	h := p.h
+	if h == nil {
+		return fmt.Errorf("dev proxy requires a handler created via NewHandler")
+	}
+	if h.URL == nil {
+		return fmt.Errorf("handler URL must be set to register with the dev server")
+	}
+
+	h.l.RLock()
+	fns, err := createFunctionConfigs(h.appName, h.funcs, *h.URL, false, h.GetEnv())
+	h.l.RUnlock()
+	if err != nil {
+		return fmt.Errorf("error creating function configs: %w", err)
+	}
+
+	byt, err := json.Marshal(sdk.RegisterRequest{
+		URL:        h.URL.String(),
+		V:          "1",
+		DeployType: sdk.DeployTypePing,
+		SDK:        HeaderValueSDK,
+		AppName:    h.appName,
+		Headers: sdk.Headers{
+			Env:      h.GetEnv(),
+			Platform: platform(),
+		},
+		Capabilities: capabilities,
+		AppVersion:   h.GetAppVersion(),
+		Functions:    fns,
+	})
+	if err != nil {
+		return fmt.Errorf("error marshalling function config: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.devServerURL+"/fn/register", bytes.NewReader(byt))
+	if err != nil {
+		return fmt.Errorf("error creating new request: %w", err)
+	}
+	SetBasicRequestHeaders(req)
+	req.Header.Set(HeaderKeySDK, sdkHeaderValue(h.GetAppVersion()))
+
+	resp, err := h.httpClient().Do(req)
+	if err != nil {
+		return fmt.Errorf("error registering with dev server: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode > 299 {
+		byt, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("error registering with dev server: status %d: %s", resp.StatusCode, byt)
+	}
+
+	p.registered.Store(true)
+	return nil
+}
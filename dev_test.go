@@ -0,0 +1,78 @@
+package inngestgo
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/inngest/inngest/pkg/sdk"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalDevProxy(t *testing.T) {
+	r := require.New(t)
+
+	var (
+		registrations int32
+		healthy       atomic.Bool
+	)
+	healthy.Store(true)
+
+	devServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/dev":
+			if !healthy.Load() {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		case "/fn/register":
+			body := sdk.RegisterRequest{}
+			r.NoError(json.NewDecoder(req.Body).Decode(&body))
+			r.Equal("dev-proxy-app", body.AppName)
+			r.Len(body.Functions, 1)
+			atomic.AddInt32(&registrations, 1)
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer devServer.Close()
+
+	appURL, _ := url.Parse("http://localhost:3000/api/inngest")
+	h := NewHandler("dev-proxy-app", HandlerOpts{URL: appURL})
+	h.Register(CreateFunction(
+		FunctionOpts{Name: "my-fn"},
+		EventTrigger("my-event", nil),
+		func(ctx context.Context, input Input[any]) (any, error) {
+			return nil, nil
+		},
+	))
+
+	proxy := NewLocalDevProxy(h, devServer.URL)
+	proxy.PollInterval = 10 * time.Millisecond
+
+	r.NoError(proxy.Start(context.Background()))
+	r.True(proxy.Registered())
+	r.EqualValues(1, atomic.LoadInt32(&registrations))
+
+	t.Run("it re-registers when the dev server comes back", func(t *testing.T) {
+		healthy.Store(false)
+		require.Eventually(t, func() bool { return !proxy.Registered() }, time.Second, 5*time.Millisecond)
+
+		healthy.Store(true)
+		require.Eventually(t, proxy.Registered, time.Second, 5*time.Millisecond)
+		require.EqualValues(t, 2, atomic.LoadInt32(&registrations))
+	})
+
+	r.NoError(proxy.Stop())
+	r.False(proxy.Registered())
+
+	t.Run("it requires a handler URL", func(t *testing.T) {
+		proxy := NewLocalDevProxy(NewHandler("dev-proxy-app", HandlerOpts{}), devServer.URL)
+		require.Error(t, proxy.Start(context.Background()))
+	})
+}
@@ -0,0 +1,73 @@
+package inngestgo
+
+import (
+	"fmt"
+	"time"
+)
+
+// DLQEventName is the name of the event which DLQ handlers are triggered by,
+// once Inngest supports DLQs.  See DLQConfig.
+const DLQEventName = "inngest/function.dead_lettered"
+
+// DLQConfig routes the events of runs which fail after exhausting their retries
+// to a dead letter queue handler, so that they can be reprocessed manually.
+//
+// The Inngest server doesn't support DLQs yet, so the config is validated but
+// isn't synced, and DLQEventName events aren't sent.  Until then, handlers can
+// be triggered by the "inngest/function.failed" event which Inngest sends for
+// every failed run.
+type DLQConfig struct {
+	// FunctionID is the ID of the DLQ handler function, which must be
+	// registered within the same app.  The handler is called with a
+	// DLQEventName event, whose data is a DLQInput.
+	FunctionID string
+	// MaxAge discards failed events which are older than the given duration
+	// rather than sending them to the DLQ handler.  If nil, every failed
+	// event is sent to the handler.
+	MaxAge *time.Duration
+}
+
+// Validate returns an error if the DLQ config is not well formed.
+func (d DLQConfig) Validate() error {
+	if d.FunctionID == "" {
+		return fmt.Errorf("FunctionID must be set")
+	}
+	if d.MaxAge != nil && *d.MaxAge <= 0 {
+		return fmt.Errorf("MaxAge must be greater than 0")
+	}
+	return nil
+}
+
+// DLQInput is the data of DLQEventName events received by DLQ handlers.  T is
+// the data type of the failed function's event:
+//
+//	inngestgo.CreateFunction(
+//		inngestgo.FunctionOpts{ID: "payments-dlq"},
+//		inngestgo.EventTrigger(inngestgo.DLQEventName, nil),
+//		func(ctx context.Context, input inngestgo.Input[inngestgo.GenericEvent[inngestgo.DLQInput[PaymentData], any]]) (any, error) {
+//			failed := input.Event.Data
+//			return nil, requeue(ctx, failed.OriginalEvent, failed.Error)
+//		},
+//	)
+type DLQInput[T any] struct {
+	// OriginalEvent is the event which triggered the failed run.
+	OriginalEvent GenericEvent[T, any] `json:"original_event"`
+	// Error is the error message of the run's final attempt.
+	Error string `json:"error"`
+	// Attempts is the number of times the run was attempted.
+	Attempts int `json:"attempts"`
+	// FailedAt is when the run's final attempt failed.
+	FailedAt time.Time `json:"failed_at"`
+}
+
+// validateDLQ checks that the function's DLQ handler is registered.
+func validateDLQ(appName, fnSlug string, c DLQConfig, slugs map[string]struct{}) error {
+	slug := appName + "-" + c.FunctionID
+	if slug == fnSlug {
+		return fmt.Errorf("function can't be its own DLQ handler")
+	}
+	if _, ok := slugs[slug]; !ok {
+		return fmt.Errorf("DLQ function '%s' is not registered", c.FunctionID)
+	}
+	return nil
+}
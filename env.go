@@ -8,6 +8,8 @@ import (
 
 const (
 	envKeyAllowInBandSync = "INNGEST_ALLOW_IN_BAND_SYNC"
+	envKeyLocality        = "INNGEST_LOCALITY"
+	envKeyIPAllowList     = "INNGEST_IP_ALLOW_LIST"
 )
 
 // IsDev returns whether to use the dev server, by checking the presence of the INNGEST_DEV
@@ -34,6 +36,15 @@ func DevServerURL() string {
 	return devServerOrigin
 }
 
+// LocalityFromEnv returns the locality set within the INNGEST_LOCALITY
+// environment variable, or nil if it's unset.  See FunctionOpts.Locality.
+func LocalityFromEnv() *string {
+	if locality := os.Getenv(envKeyLocality); locality != "" {
+		return &locality
+	}
+	return nil
+}
+
 func isTrue(val string) bool {
 	val = strings.ToLower(val)
 	if val == "true" || val == "1" {
@@ -14,12 +14,21 @@ type StepError struct {
 	// the resulting value when step errors occur with an additional
 	// response type.
 	Data json.RawMessage `json:"data,omitempty"`
+	// Cause is the registered error that the step failed with, if the error
+	// was registered via RegisterError.  This is resolved from Name when the
+	// step is replayed.
+	Cause error `json:"-"`
 }
 
 func (e StepError) Error() string {
 	return e.Message
 }
 
+// Unwrap returns the step's registered error, if any.
+func (e StepError) Unwrap() error {
+	return e.Cause
+}
+
 func (e StepError) Is(err error) bool {
 	switch err.(type) {
 	case *StepError, StepError:
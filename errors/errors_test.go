@@ -38,3 +38,26 @@ func TestGetRetryAtTime(t *testing.T) {
 		require.EqualValues(t, expected, *GetRetryAtTime(wrapped))
 	})
 }
+
+func TestErrorRegistry(t *testing.T) {
+	errA := fmt.Errorf("a")
+	errB := fmt.Errorf("b")
+
+	r := NewErrorRegistry()
+	r.Register(errA, "a")
+	r.Register(errB, "b")
+
+	code, ok := r.Code(fmt.Errorf("wrapped: %w", errB))
+	require.True(t, ok)
+	require.Equal(t, "b", code)
+
+	_, ok = r.Code(fmt.Errorf("other"))
+	require.False(t, ok)
+
+	err, ok := r.Lookup("a")
+	require.True(t, ok)
+	require.Equal(t, errA, err)
+
+	_, ok = r.Lookup("c")
+	require.False(t, ok)
+}
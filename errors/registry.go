@@ -0,0 +1,72 @@
+package errors
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrRegistry is the global registry of errors which survive step
+// serialization.  Populate it at startup via RegisterError.
+var ErrRegistry = NewErrorRegistry()
+
+// RegisterError registers the given error with a unique code in ErrRegistry.
+// When a step fails with an error matching err, the code is stored with the
+// step's error.  When the step is replayed, the returned StepError wraps err,
+// so that callers can use errors.Is:
+//
+//	func init() {
+//		errors.RegisterError(sql.ErrNoRows, "sql.ErrNoRows")
+//	}
+//
+//	_, err := step.Run(ctx, "load-user", loadUser)
+//	if errors.Is(err, sql.ErrNoRows) {
+//		// ...
+//	}
+func RegisterError(err error, code string) {
+	ErrRegistry.Register(err, code)
+}
+
+// ErrorRegistry maps errors to unique codes, allowing errors to be
+// reconstructed after serialization.
+type ErrorRegistry struct {
+	l      sync.RWMutex
+	codes  []string
+	byCode map[string]error
+}
+
+// NewErrorRegistry returns an empty ErrorRegistry.
+func NewErrorRegistry() *ErrorRegistry {
+	return &ErrorRegistry{byCode: map[string]error{}}
+}
+
+// Register registers the given error with the given code, replacing any error
+// previously registered with the code.
+func (r *ErrorRegistry) Register(err error, code string) {
+	r.l.Lock()
+	defer r.l.Unlock()
+	if _, ok := r.byCode[code]; !ok {
+		r.codes = append(r.codes, code)
+	}
+	r.byCode[code] = err
+}
+
+// Code returns the code for the first registered error matching err using
+// errors.Is, in order of registration.
+func (r *ErrorRegistry) Code(err error) (string, bool) {
+	r.l.RLock()
+	defer r.l.RUnlock()
+	for _, code := range r.codes {
+		if errors.Is(err, r.byCode[code]) {
+			return code, true
+		}
+	}
+	return "", false
+}
+
+// Lookup returns the error registered with the given code.
+func (r *ErrorRegistry) Lookup(code string) (error, bool) {
+	r.l.RLock()
+	defer r.l.RUnlock()
+	err, ok := r.byCode[code]
+	return err, ok
+}
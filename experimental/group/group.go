@@ -2,6 +2,7 @@ package group
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/khulnasoft-lab/inngestgo/step"
 )
@@ -11,23 +12,77 @@ type Result struct {
 	Value any
 }
 
+// ParallelOpt configures a parallel block.
+type ParallelOpt func(*parallelOpts)
+
+type parallelOpts struct {
+	concurrency int
+}
+
+// WithConcurrency caps how many steps within the parallel block are planned at
+// once, overriding FunctionOpts.MaxParallelSteps.  This panics if n is less
+// than 1.
+func WithConcurrency(n int) ParallelOpt {
+	if n < 1 {
+		panic(fmt.Sprintf("group: WithConcurrency requires n > 0, got %d", n))
+	}
+	return func(o *parallelOpts) {
+		o.concurrency = n
+	}
+}
+
 func Parallel(
 	ctx context.Context,
 	fns ...func(ctx context.Context,
 	) (any, error)) []Result {
+	return ParallelWithOpts(ctx, nil, fns...)
+}
+
+// ParallelWithOpts runs the given functions in parallel like Parallel, using the
+// given options.
+//
+// If the number of parallel steps is capped via WithConcurrency,
+// step.SetLocalConcurrency or FunctionOpts.MaxParallelSteps, in order of
+// precedence, only that many incomplete steps are planned.
+// The remaining steps are queued and planned as earlier steps complete.  Steps
+// that are still in flight are reported again when a later request plans the
+// block, as Inngest doesn't send the set of planned steps with a request.
+func ParallelWithOpts(
+	ctx context.Context,
+	opts []ParallelOpt,
+	fns ...func(ctx context.Context,
+	) (any, error)) []Result {
+	o := parallelOpts{}
+	if n, ok := step.MaxParallelSteps(ctx); ok {
+		o.concurrency = n
+	}
+	if n, ok := step.LocalConcurrency(ctx); ok {
+		o.concurrency = n
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	ctx = context.WithValue(ctx, step.ParallelKey, true)
 
 	results := []Result{}
 	isPlanned := false
+	inFlight := 0
 	ch := make(chan struct{}, 1)
 	var unexpectedPanic any
 	for _, fn := range fns {
+		if o.concurrency > 0 && inFlight >= o.concurrency {
+			// Queue the remaining steps until in-flight steps complete.
+			break
+		}
+
 		fn := fn
 		go func(fn func(ctx context.Context) (any, error)) {
 			defer func() {
 				if r := recover(); r != nil {
 					if _, ok := r.(step.ControlHijack); ok {
 						isPlanned = true
+						inFlight++
 					} else {
 						unexpectedPanic = r
 					}
@@ -0,0 +1,97 @@
+package group
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/inngest/inngest/pkg/enums"
+	"github.com/khulnasoft-lab/inngestgo/internal/sdkrequest"
+	"github.com/khulnasoft-lab/inngestgo/step"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParallelConcurrency(t *testing.T) {
+	fns := make([]func(ctx context.Context) (any, error), 4)
+	for i := range fns {
+		id := fmt.Sprintf("step-%d", i)
+		fns[i] = func(ctx context.Context) (any, error) {
+			return step.Run(ctx, id, func(ctx context.Context) (string, error) {
+				return id, nil
+			})
+		}
+	}
+
+	// plan runs the parallel block with the given completed steps, returning
+	// the names of planned steps.
+	plan := func(t *testing.T, ctx context.Context, completed []string, opts ...ParallelOpt) []string {
+		t.Helper()
+		req := &sdkrequest.Request{Steps: map[string]json.RawMessage{}}
+		for _, id := range completed {
+			op := sdkrequest.UnhashedOp{Op: enums.OpcodeStep, ID: id}
+			req.Steps[op.MustHash()] = json.RawMessage(fmt.Sprintf(`{"data":%q}`, id))
+		}
+		ctx, cancel := context.WithCancel(ctx)
+		mgr := sdkrequest.NewManager(cancel, req)
+		ctx = sdkrequest.SetManager(ctx, mgr)
+
+		func() {
+			defer func() {
+				require.Equal(t, step.ControlHijack{}, recover())
+			}()
+			ParallelWithOpts(ctx, opts, fns...)
+		}()
+
+		names := []string{}
+		for _, op := range mgr.Ops() {
+			require.Equal(t, enums.OpcodeStepPlanned, op.Op)
+			names = append(names, op.Name)
+		}
+		return names
+	}
+
+	t.Run("without a limit", func(t *testing.T) {
+		require.Equal(t, []string{"step-0", "step-1", "step-2", "step-3"}, plan(t, context.Background(), nil))
+	})
+
+	t.Run("WithConcurrency queues excess steps", func(t *testing.T) {
+		ctx := context.Background()
+		require.Equal(t, []string{"step-0", "step-1"}, plan(t, ctx, nil, WithConcurrency(2)))
+		require.Equal(t, []string{"step-1", "step-2"}, plan(t, ctx, []string{"step-0"}, WithConcurrency(2)))
+	})
+
+	t.Run("WithConcurrency requires a positive limit", func(t *testing.T) {
+		require.PanicsWithValue(t, "group: WithConcurrency requires n > 0, got 0", func() { WithConcurrency(0) })
+		require.Panics(t, func() { WithConcurrency(-1) })
+	})
+
+	t.Run("function-level limit", func(t *testing.T) {
+		ctx := step.WithMaxParallelSteps(context.Background(), 1)
+		require.Equal(t, []string{"step-0"}, plan(t, ctx, nil))
+		// WithConcurrency overrides the function-level limit.
+		require.Equal(t, []string{"step-0", "step-1", "step-2"}, plan(t, ctx, nil, WithConcurrency(3)))
+	})
+
+	t.Run("local limit", func(t *testing.T) {
+		ctx := step.SetLocalConcurrency(step.WithMaxParallelSteps(context.Background(), 1), 2)
+		require.Equal(t, []string{"step-0", "step-1"}, plan(t, ctx, nil))
+		// WithConcurrency overrides the local limit.
+		require.Equal(t, []string{"step-0", "step-1", "step-2"}, plan(t, ctx, nil, WithConcurrency(3)))
+		// Removing the local limit restores the function-level limit.
+		require.Equal(t, []string{"step-0"}, plan(t, step.SetLocalConcurrency(ctx, 0), nil))
+	})
+
+	t.Run("completed steps return results", func(t *testing.T) {
+		all := []string{"step-0", "step-1", "step-2", "step-3"}
+		req := &sdkrequest.Request{Steps: map[string]json.RawMessage{}}
+		for _, id := range all {
+			op := sdkrequest.UnhashedOp{Op: enums.OpcodeStep, ID: id}
+			req.Steps[op.MustHash()] = json.RawMessage(fmt.Sprintf(`{"data":%q}`, id))
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		ctx = sdkrequest.SetManager(ctx, sdkrequest.NewManager(cancel, req))
+		results := ParallelWithOpts(ctx, []ParallelOpt{WithConcurrency(1)}, fns...)
+		require.Len(t, results, 4)
+	})
+}
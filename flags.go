@@ -0,0 +1,35 @@
+package inngestgo
+
+import "context"
+
+type featureFlagsCtxKeyType struct{}
+
+var featureFlagsCtxKey = featureFlagsCtxKeyType{}
+
+// WithFeatureFlag declares feature flags used by the function.  See
+// FunctionOpts.FeatureFlags.
+func WithFeatureFlag(flags ...string) FunctionOption {
+	return func(f *FunctionOpts) {
+		f.FeatureFlags = append(f.FeatureFlags, flags...)
+	}
+}
+
+// FeatureFlagEnabled returns whether the given feature flag is enabled for the
+// current function run, as given by the run's request.  The Inngest server doesn't
+// resolve flag state yet, so flags are disabled unless the request sets them.  This
+// always returns false for flags which are not declared within
+// FunctionOpts.FeatureFlags, or when called outside of a function.
+func FeatureFlagEnabled(ctx context.Context, flag string) bool {
+	flags, _ := ctx.Value(featureFlagsCtxKey).(map[string]bool)
+	return flags[flag]
+}
+
+// withFeatureFlags stores the state of the function's declared feature flags
+// within ctx.
+func withFeatureFlags(ctx context.Context, declared []string, state map[string]bool) context.Context {
+	flags := make(map[string]bool, len(declared))
+	for _, f := range declared {
+		flags[f] = state[f]
+	}
+	return context.WithValue(ctx, featureFlagsCtxKey, flags)
+}
@@ -2,11 +2,20 @@ package inngestgo
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/rand/v2"
+	"net/mail"
 	"reflect"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/gosimple/slug"
 	"github.com/inngest/inngest/pkg/inngest"
+	"github.com/khulnasoft-lab/inngestgo/jsonschema"
 )
 
 // Ptr converts the given type to a pointer.  Nil pointers are sometimes
@@ -29,12 +38,126 @@ type FunctionOpts struct {
 
 	Priority    *inngest.Priority
 	Concurrency []inngest.Concurrency
+	// ConcurrencyKey is an expression used to limit the function to a single
+	// concurrent run per entity, eg. "event.data.userId".  This is shorthand
+	// for a Concurrency limit of 1 with the given key, and can't be used
+	// alongside Concurrency.
+	ConcurrencyKey *string
+	// Idempotency is an expression used to skip duplicate events, eg.
+	// "event.data.orderId".  This is shorthand for EventDeduplication using
+	// DeduplicateSkip, and can't be used alongside EventDeduplication.
 	Idempotency *string
-	Retries     *int
-	Cancel      []inngest.Cancel
-	Debounce    *Debounce
-	// Timeouts represents timeouts for a function.
+	// SideEffectMode controls how the function's non-idempotent side effects,
+	// such as sending emails, are protected from re-execution.  This defaults
+	// to SideEffectMemoized.
+	SideEffectMode SideEffectMode
+	// EventDeduplication configures how runs are handled when duplicate
+	// events, with the same key, are received.
+	EventDeduplication *DeduplicationConfig
+	// Retries is the number of times the function is retried.  See Retry for
+	// how this interacts with other retry options.
+	//
+	// Deprecated: Use Retry.
+	Retries *int
+	// Retry configures the number of retries and the delay between each
+	// retry.  The function's retry options are resolved into a single config
+	// with the following precedence:
+	//
+	//   - Retry, which can't be used alongside Retries, DisableAutoRetry or
+	//     Resilience.
+	//   - Resilience.MaxAttempts and Resilience.BackoffPolicy.
+	//   - DisableAutoRetry, which can't be used alongside Retries.
+	//   - Retries.
+	//
+	// MaxRetryDelay caps the delay between retries of whichever applies.
+	//
+	// Only the number of retries is synced.  The Inngest server doesn't
+	// support configuring retry delays, so when a backoff policy or retry
+	// delay is set the SDK computes each delay itself and sends the retry
+	// time to Inngest, as with RetryAtError.
+	Retry *RetryConfig
+	// MaxRetryDelay caps the delay between retries.  This is shorthand for
+	// Retry.MaxRetryDelay, and can be used alongside the other retry options.
+	// See Retry.
+	MaxRetryDelay *time.Duration
+	// DynamicRetry decides whether and when to retry each failed attempt
+	// based off of the attempt's error.  Static retry config still limits the
+	// number of retries:  the policy is only called for attempts which would
+	// otherwise be retried.
+	DynamicRetry *DynamicRetryConfig
+	Cancel       []inngest.Cancel
+	Debounce     *Debounce
+	// Cooldown prevents the function from running again within the given
+	// period of a run starting.  Unlike Debounce, which delays runs until
+	// events stop being received, the first event runs the function
+	// immediately and any events received during the cooldown are ignored.
+	// This is useful for alerts and notifications which should fire at most
+	// once per period.  If set, this must be greater than 0.
+	//
+	// The Inngest server doesn't support cooldowns directly, so a cooldown is
+	// synced as a RateLimit of one run per period, and can't be used with
+	// RateLimit.
+	Cooldown *time.Duration
+	// AutoCancel cancels in-progress runs of this function when a new event
+	// with the same key is received, eg. to only generate the latest report
+	// for each user.  See AutoCancelOnSameEvent.
+	AutoCancel *AutoCancelConfig
+	// MaxParallelSteps caps how many steps within group.Parallel are planned
+	// at once.  Once the limit is reached, the remaining steps are queued and
+	// planned as earlier steps complete.  If set, this must be at least 1.
+	MaxParallelSteps *int
+	// WarmPool is the number of instances of the function to keep warm,
+	// reducing cold starts in serverless environments.  If set, this must be
+	// at least 1.  The Inngest server doesn't support warm pools yet, so this
+	// is validated but isn't synced.
+	WarmPool *int
+	// InitFunc initializes the function, eg. by creating clients.  It's called
+	// once per instance when the function is registered with a handler, and
+	// while it fails the function is unhealthy (see Handler.FunctionHealth).
+	InitFunc func(ctx context.Context) error
+	// HealthCheck checks whether the function's dependencies are ready.  It's
+	// called by Handler.HealthCheck, and while it fails the handler rejects
+	// invoke requests for the function with a 503.
+	HealthCheck func(ctx context.Context) error
+	// Hooks are called as steps within this function are executed.
+	Hooks HookConfig
+	// OnRetry is called when an attempt fails and the function will be
+	// retried, before the response is sent to Inngest.  attempt is the number
+	// of the upcoming retry, starting at 1, and lastErr is the error from the
+	// failed attempt.  This is called synchronously, so it must not block.
+	// Panics within OnRetry are recovered and logged.
+	OnRetry func(ctx context.Context, attempt int, lastErr error)
+	// OnSuccess is called with the function's JSON-encoded output when a run
+	// completes successfully, before the response is sent to Inngest.  Unlike
+	// Hooks, this is called once per run rather than for each step.  The
+	// context is cancelled after 5 seconds, after which the response is sent
+	// without waiting for OnSuccess to return.
+	OnSuccess func(ctx context.Context, result json.RawMessage)
+	// RetryOnPanic converts panics within step.Run into step errors, so that
+	// the step is retried like any other failing step instead of failing the
+	// entire request.  The panic's value is included in the error message.
+	RetryOnPanic bool
+	// DisableAutoRetry disables retries, so that the function runs exactly once.
+	// This is a clearer alternative to setting Retries to zero.  See Retry.
+	DisableAutoRetry bool
+	// Timeouts represents timeouts for a function.  Resilience.Timeout takes
+	// precedence over Timeouts.Finish when set.
 	Timeouts *Timeouts
+	// RunTimeout is the maximum duration of the entire function run, across
+	// all of its steps, after which Inngest cancels the run.  This is enforced
+	// by Inngest, and replaces Timeouts.Finish.
+	RunTimeout *time.Duration
+	// StepTimeout is the maximum duration of each step.Run function.  The
+	// SDK cancels the step's context once exceeded and fails the step with
+	// step.ErrStepTimeout, so that the step is retried.  Steps must respect
+	// their context's cancellation to be interrupted.  This is enforced by the
+	// SDK, and isn't synced.
+	StepTimeout *time.Duration
+	// Resilience configures retries, timeouts, backoff and circuit breaking as a
+	// single policy.  Its timeout overrides Timeouts.Finish, and its attempts
+	// and backoff policy are resolved as described by Retry.  Its circuit
+	// breaker threshold isn't synced;  see ResilienceConfig.
+	Resilience *ResilienceConfig
 	// Throttle represents a soft rate limit for gating function starts.  Any function runs
 	// over the throttle period will be enqueued in the backlog to run at the next available
 	// time.
@@ -45,13 +168,466 @@ type FunctionOpts struct {
 	RateLimit *RateLimit
 	// BatchEvents represents batching
 	BatchEvents *inngest.EventBatchConfig
+	// EventBuffering buffers related events, grouped by key, before running
+	// the function with every buffered event in Input.Events.  Each key's
+	// events are buffered independently.  This is shorthand for BatchEvents
+	// with a key, and is synced as the function's batch config, so it can't be
+	// used with BatchEvents.
+	EventBuffering *BufferingConfig
+	// CloudEvent allows the function to be triggered by events in the CloudEvents
+	// format, in addition to native Inngest events.
+	CloudEvent *CloudEventConfig
+	// SLA represents the function's SLA requirements.  The Inngest server
+	// doesn't support SLAs yet, so the SLA is validated but isn't synced and
+	// doesn't trigger alerts.
+	SLA *SLAConfig
+	// CompletionEmail configures emails sent when the function's runs
+	// complete, eg. for rarely run functions such as data exports.  The Inngest
+	// server doesn't send completion emails yet, so this is validated but isn't
+	// synced.
+	CompletionEmail *EmailConfig
+	// DLQ routes the events of runs which fail after exhausting their retries
+	// to a registered dead letter queue handler function.  This isn't synced,
+	// as the Inngest server doesn't support DLQs yet;  see DLQConfig.
+	DLQ *DLQConfig
+	// TrackProgress enables step.Progress, sending progress updates as events
+	// via HandlerOpts.ProgressClient as they're reported.  If false, calls to
+	// step.Progress are no-ops.
+	TrackProgress bool
+	// FeatureFlags lists the feature flags used within the function, which can
+	// be checked using FeatureFlagEnabled.  The Inngest server doesn't support
+	// feature flags yet, so these aren't synced and each flag's state is only
+	// set if a run's request includes it.
+	FeatureFlags []string
+	// RunLogLevel is the minimum level of SDK logs written while executing
+	// this function.  This can only restrict HandlerOpts.Logger:  messages
+	// below the logger's own level are never written.  The level is available
+	// within the function via LogLevelFromContext.
+	RunLogLevel *slog.Level
+	// EventNamespace scopes the function's event triggers to the given
+	// namespace, eg. a tenant ID.  Each event trigger's name is prefixed with
+	// "<namespace>/" when syncing, so the function only processes events within
+	// the namespace.  Events sent via Client.Send within the function are also
+	// prefixed, unless sent using WithoutEventNamespace.  See
+	// ScopedEventTrigger.
+	EventNamespace *string
+	// Region is the Inngest region which the function's execution should be
+	// pinned to, which must be one of ValidRegions.  The Inngest server doesn't
+	// support pinning functions to regions yet, so this is validated but isn't
+	// synced, and functions run in any region.
+	Region *string
+	// Locality is the locality which the function's execution should be
+	// restricted to, eg. "eu" for data residency, which must be one of
+	// ValidLocalities.  Use LocalityFromEnv to set this from the
+	// INNGEST_LOCALITY environment variable.
+	//
+	// The Inngest server doesn't support localities yet, so this is validated
+	// but isn't synced:  it doesn't restrict where the function runs, and
+	// can't be relied upon for data residency.
+	Locality *string
+	// Timezone is the IANA timezone, eg. "America/New_York", in which the
+	// function's cron triggers are scheduled.  If empty, crons use UTC.  Use
+	// CronTriggerInZone to override the timezone of single triggers.  The
+	// timezone is applied to each cron expression when syncing, using a
+	// "TZ=" prefix.
+	Timezone string
+	// Aliases lists IDs previously used by this function.  When renaming a
+	// function, add its old ID here so that in-flight runs using the old ID
+	// continue to work:  the handler routes requests for each alias to the
+	// function.  Aliases must not match another function's ID.  Aliases aren't
+	// synced, as the Inngest server doesn't support them.
+	Aliases []string
+	// DependsOn lists the IDs of functions which this function typically
+	// calls via step.Invoke, for Handler.BuildDependencyGraph.  This is
+	// advisory only and isn't synced.  Functions within other apps are referenced as
+	// "<app-id>/<function-id>", and functions within the same app must be
+	// registered with the handler.
+	DependsOn []string
+	// HTTP overrides the handler's HTTP behaviour when executing this
+	// function.
+	HTTP *FunctionHTTPConfig
+	// Observability configures tracing for this function.  If nil, a span is
+	// created for every execution using the global OpenTelemetry tracer
+	// provider.
+	Observability *ObservabilityConfig
+	// SecretEnv lists environment variables containing secrets used by the
+	// function, whose values are replaced with "[REDACTED]" within the
+	// handler's logs for the function's executions, eg. within errors.
+	SecretEnv []string
+	// DataMasking masks fields within step results before they're stored in
+	// Inngest's state store, eg. to prevent PII from being persisted.  Masked
+	// values are returned when steps are replayed.  Event data and the inputs
+	// of steps, such as invoked functions' events, aren't masked.
+	DataMasking []MaskRule
+	// Checkpointing saves the run's step state to a backend after each step,
+	// and restores each step's state when the step is next run.  This prevents
+	// steps from re-executing if the process restarts before step results are
+	// sent to Inngest.
+	Checkpointing *CheckpointConfig
+	// GracefulDegradation completes failing steps with fallback results, eg.
+	// cached data, instead of retrying them when dependencies are down.
+	GracefulDegradation *DegradationConfig
+	// EventAck configures how the function acknowledges its event, for event
+	// sources which use an ack/nack protocol.  Most functions don't need
+	// this, as events are acknowledged automatically.
+	EventAck *AckConfig
+	// SpanAttributes are added to the function's root span, eg. to tag spans
+	// with the owning team.
+	SpanAttributes map[string]string
+	// SpanAttributesFromEvent lists paths of fields within the triggering
+	// event which are added to the function's root span.  Each attribute is
+	// named after the field within the event's data, eg. "data.userId" is
+	// added as "event.userId".  Missing fields are ignored.
+	SpanAttributesFromEvent []string
+	// Schema validates the function's typed event before the function is
+	// called.
+	Schema *SchemaConfig
+	// OutputSchema validates the function's JSON-encoded output once the run
+	// completes.  Validation failures are logged as warnings, unless
+	// StrictOutputValidation is set.
+	OutputSchema *jsonschema.Schema
+	// StrictOutputValidation fails the run's final execution with an error
+	// when its output doesn't match OutputSchema.
+	StrictOutputValidation bool
+	// InputCoercion coerces loosely typed event data, such as "123" or 3.0,
+	// into the types of the function's event fields.  If nil, event data must
+	// match the field types exactly.
+	InputCoercion *CoercionConfig
+	// InputTransformer transforms each raw event before the function's Input is
+	// created, eg. to adapt legacy event schemas without changing the function's
+	// event type.  If this returns an error, the function fails without
+	// retrying.
+	InputTransformer func(ctx context.Context, raw json.RawMessage) (json.RawMessage, error)
+	// OutputTransformer transforms the function's output and error once the
+	// function finishes, before the response is returned to Inngest, eg. to
+	// redact sensitive fields or normalize error messages.  This isn't called
+	// while the function has steps to run.
+	OutputTransformer func(ctx context.Context, output any, err error) (any, error)
+	// Environment adds per-environment conditions to the function's event
+	// triggers.  Keys are Inngest environment names, matched against
+	// HandlerOpts.Env, and values are expressions combined with each event
+	// trigger's own expression using &&.  For example, the following only
+	// triggers on staging events when synced to the "staging" environment:
+	//
+	//	Environment: map[string]string{"staging": "event.data.env == 'staging'"}
+	Environment map[string]string
+	// ResourceLimits sets resource limits for the function, which are enforced
+	// by the SDK and aren't synced.
+	ResourceLimits *ResourceConfig
+}
+
+// ValidRegions returns the Inngest regions which FunctionOpts.Region accepts.
+func ValidRegions() []string {
+	return []string{"us-east-1", "eu-west-1"}
+}
+
+// ValidLocalities returns the localities which FunctionOpts.Locality accepts.
+func ValidLocalities() []string {
+	return []string{"us", "eu", "apac"}
+}
+
+// FunctionOption modifies FunctionOpts, and can be passed to CreateFunction as
+// shorthand for common configuration.
+type FunctionOption func(*FunctionOpts)
+
+// WithSLA sets the function's SLA, with runs expected to take at most max and
+// the given p99 latency.  See FunctionOpts.SLA.
+func WithSLA(max, p99 time.Duration) FunctionOption {
+	return func(f *FunctionOpts) {
+		f.SLA = &SLAConfig{
+			MaxDuration: max,
+			P99Latency:  p99,
+		}
+	}
+}
+
+// DeduplicationStrategy determines how duplicate events are handled.
+type DeduplicationStrategy string
+
+const (
+	// DeduplicateSkip ignores duplicate events, so that only the first event
+	// runs the function.  This is synced as the function's idempotency key.
+	DeduplicateSkip DeduplicationStrategy = "skip"
+)
+
+// DeduplicationConfig configures how a function handles duplicate events, eg.
+// events redelivered by a webhook provider.
+type DeduplicationConfig struct {
+	// Strategy determines how duplicate events are handled.  The Inngest
+	// server only supports skipping duplicates, so DeduplicateSkip is the only
+	// strategy.
+	Strategy DeduplicationStrategy
+	// Key is the expression used to identify duplicate events, eg.
+	// "event.data.orderId".  Events with the same key are duplicates.
+	Key string
+}
+
+// Validate returns an error if the deduplication config is not well formed.
+func (d DeduplicationConfig) Validate() error {
+	switch d.Strategy {
+	case DeduplicateSkip:
+	default:
+		return fmt.Errorf("unknown strategy '%s'", d.Strategy)
+	}
+	if d.Key == "" {
+		return fmt.Errorf("key must not be empty")
+	}
+	return nil
+}
+
+// deduplication returns the function's deduplication config, including
+// Idempotency as a DeduplicateSkip strategy.
+func (f FunctionOpts) deduplication() *DeduplicationConfig {
+	if f.EventDeduplication != nil {
+		return f.EventDeduplication
+	}
+	if f.Idempotency != nil {
+		return &DeduplicationConfig{Strategy: DeduplicateSkip, Key: *f.Idempotency}
+	}
+	if f.SideEffectMode == SideEffectOnce {
+		return &DeduplicationConfig{Strategy: DeduplicateSkip, Key: "event.id"}
+	}
+	return nil
+}
+
+// concurrency returns the function's concurrency limits, including the limit
+// created by ConcurrencyKey.
+func (f FunctionOpts) concurrency() []inngest.Concurrency {
+	if f.ConcurrencyKey != nil {
+		return []inngest.Concurrency{{Limit: 1, Key: f.ConcurrencyKey}}
+	}
+	return f.Concurrency
+}
+
+// WithPerEntityConcurrency limits the function to a single concurrent run per
+// value of keyExpr, eg. "event.data.userId".  See FunctionOpts.ConcurrencyKey.
+func WithPerEntityConcurrency(keyExpr string) FunctionOption {
+	return func(f *FunctionOpts) {
+		f.ConcurrencyKey = &keyExpr
+	}
+}
+
+// WithCooldown sets the function's cooldown, ignoring events received within d
+// of a run starting.  See FunctionOpts.Cooldown.
+func WithCooldown(d time.Duration) FunctionOption {
+	return func(f *FunctionOpts) {
+		f.Cooldown = &d
+	}
+}
+
+// Validate returns an error if the function's options are invalid.
+func (f FunctionOpts) Validate() error {
+	if f.DisableAutoRetry && f.Retries != nil {
+		return fmt.Errorf("DisableAutoRetry and Retries cannot both be set")
+	}
+	if f.Retry != nil && (f.Retries != nil || f.DisableAutoRetry || f.Resilience != nil) {
+		return fmt.Errorf("Retry can't be used with Retries, DisableAutoRetry or Resilience")
+	}
+	if f.MaxRetryDelay != nil && f.Retry != nil && f.Retry.MaxRetryDelay != nil {
+		return fmt.Errorf("MaxRetryDelay and Retry.MaxRetryDelay cannot both be set")
+	}
+	if r := f.retryConfig(); r != nil {
+		if err := r.Validate(); err != nil {
+			return fmt.Errorf("invalid retry config: %w", err)
+		}
+	}
+	switch f.SideEffectMode {
+	case SideEffectMemoized, SideEffectOnce:
+	default:
+		return fmt.Errorf("unsupported side effect mode '%s'", f.SideEffectMode)
+	}
+	if f.ConcurrencyKey != nil {
+		if len(f.Concurrency) > 0 {
+			return fmt.Errorf("ConcurrencyKey and Concurrency cannot both be set")
+		}
+		if strings.TrimSpace(*f.ConcurrencyKey) == "" {
+			return fmt.Errorf("ConcurrencyKey must not be empty")
+		}
+	}
+	if f.EventDeduplication != nil {
+		if f.Idempotency != nil {
+			return fmt.Errorf("Idempotency and EventDeduplication cannot both be set")
+		}
+		if err := f.EventDeduplication.Validate(); err != nil {
+			return fmt.Errorf("invalid EventDeduplication: %w", err)
+		}
+	}
+	if f.Cooldown != nil {
+		if *f.Cooldown <= 0 {
+			return fmt.Errorf("Cooldown must be greater than 0")
+		}
+		if f.RateLimit != nil {
+			return fmt.Errorf("Cooldown can't be used with RateLimit")
+		}
+	}
+	if f.WarmPool != nil && *f.WarmPool < 1 {
+		return fmt.Errorf("WarmPool must be at least 1")
+	}
+	if f.MaxParallelSteps != nil && *f.MaxParallelSteps < 1 {
+		return fmt.Errorf("MaxParallelSteps must be at least 1")
+	}
+	for env, expr := range f.Environment {
+		if strings.TrimSpace(expr) == "" {
+			return fmt.Errorf("expression for environment '%s' must not be empty", env)
+		}
+	}
+	if f.Checkpointing != nil && f.Checkpointing.Backend == nil {
+		return fmt.Errorf("Checkpointing.Backend must be set")
+	}
+	if f.DLQ != nil {
+		if err := f.DLQ.Validate(); err != nil {
+			return fmt.Errorf("invalid DLQ: %w", err)
+		}
+	}
+	if f.StrictOutputValidation && f.OutputSchema == nil {
+		return fmt.Errorf("StrictOutputValidation requires OutputSchema")
+	}
+	if f.RunTimeout != nil {
+		if *f.RunTimeout <= 0 {
+			return fmt.Errorf("RunTimeout must be positive")
+		}
+		if f.Timeouts != nil && f.Timeouts.Finish != nil {
+			return fmt.Errorf("RunTimeout can't be used with Timeouts.Finish")
+		}
+		if f.Resilience != nil && f.Resilience.Timeout > 0 {
+			return fmt.Errorf("RunTimeout can't be used with Resilience.Timeout")
+		}
+	}
+	if f.StepTimeout != nil && *f.StepTimeout <= 0 {
+		return fmt.Errorf("StepTimeout must be positive")
+	}
+	if f.EventAck != nil {
+		if err := f.EventAck.Validate(); err != nil {
+			return fmt.Errorf("invalid EventAck: %w", err)
+		}
+	}
+	if f.GracefulDegradation != nil && f.GracefulDegradation.FallbackProvider == nil {
+		return fmt.Errorf("GracefulDegradation.FallbackProvider must be set")
+	}
+	if f.DynamicRetry != nil && f.DynamicRetry.Policy == nil {
+		return fmt.Errorf("DynamicRetry.Policy must be set")
+	}
+	for _, rule := range f.DataMasking {
+		if err := rule.Validate(); err != nil {
+			return fmt.Errorf("invalid data masking rule: %w", err)
+		}
+	}
+	if f.EventNamespace != nil && (*f.EventNamespace == "" || strings.ContainsAny(*f.EventNamespace, "/*")) {
+		return fmt.Errorf("EventNamespace must be non-empty and must not contain '/' or '*'")
+	}
+	if f.Timezone != "" {
+		if _, err := time.LoadLocation(f.Timezone); err != nil {
+			return fmt.Errorf("invalid Timezone '%s': %w", f.Timezone, err)
+		}
+	}
+	if f.Locality != nil && !slices.Contains(ValidLocalities(), *f.Locality) {
+		return fmt.Errorf("locality '%s' must be one of: %s", *f.Locality, strings.Join(ValidLocalities(), ", "))
+	}
+	if f.Region != nil && !slices.Contains(ValidRegions(), *f.Region) {
+		return fmt.Errorf("region '%s' must be one of: %s", *f.Region, strings.Join(ValidRegions(), ", "))
+	}
+	if f.EventBuffering != nil {
+		if f.BatchEvents != nil {
+			return fmt.Errorf("EventBuffering can't be used with BatchEvents")
+		}
+		if err := f.EventBuffering.Validate(); err != nil {
+			return fmt.Errorf("invalid EventBuffering: %w", err)
+		}
+	}
+	if f.Resilience != nil {
+		if err := f.Resilience.Validate(); err != nil {
+			return fmt.Errorf("invalid resilience config: %w", err)
+		}
+	}
+	if f.AutoCancel != nil {
+		if err := f.AutoCancel.Validate(); err != nil {
+			return fmt.Errorf("invalid AutoCancel: %w", err)
+		}
+	}
+	if f.SLA != nil {
+		if err := f.SLA.Validate(); err != nil {
+			return fmt.Errorf("invalid SLA: %w", err)
+		}
+	}
+	if f.CompletionEmail != nil {
+		if err := f.CompletionEmail.Validate(); err != nil {
+			return fmt.Errorf("invalid CompletionEmail: %w", err)
+		}
+	}
+	if f.HTTP != nil {
+		if err := f.HTTP.Validate(); err != nil {
+			return fmt.Errorf("invalid HTTP config: %w", err)
+		}
+	}
+	if f.ResourceLimits != nil {
+		if err := f.ResourceLimits.Validate(); err != nil {
+			return fmt.Errorf("invalid resource limits: %w", err)
+		}
+	}
+	if f.Observability != nil {
+		if err := f.Observability.Validate(); err != nil {
+			return fmt.Errorf("invalid observability config: %w", err)
+		}
+	}
+	return nil
+}
+
+// ObservabilityConfig configures distributed tracing for a function.
+type ObservabilityConfig struct {
+	// SampleRate is the fraction of runs, between 0.0 (never trace) and 1.0
+	// (always trace), for which spans are created.  Sampling is decided per
+	// run ID, so every step of a run is either traced or not.  If nil, every
+	// run is traced.
+	SampleRate *float64
+	// TraceAttributes are added to all spans created for the function.
+	TraceAttributes map[string]string
+}
+
+// Validate returns an error if SampleRate is not between 0.0 and 1.0.
+func (c ObservabilityConfig) Validate() error {
+	if c.SampleRate != nil && (*c.SampleRate < 0 || *c.SampleRate > 1) {
+		return fmt.Errorf("SampleRate must be between 0.0 and 1.0")
+	}
+	return nil
+}
+
+// FunctionHTTPConfig configures the handler's HTTP behaviour when executing a
+// specific function, overriding the handler-level defaults.
+type FunctionHTTPConfig struct {
+	// ReadTimeout is the maximum duration for reading the request body.
+	ReadTimeout *time.Duration
+	// WriteTimeout is the maximum duration for executing the function and
+	// writing its response.  Once exceeded, the connection is closed and
+	// Inngest retries the request.
+	WriteTimeout *time.Duration
+	// MaxBodySize is the max body size to read for incoming invoke requests.
+	// This overrides HandlerOpts.MaxBodySize.
+	MaxBodySize *int64
+}
+
+// Validate returns an error if any of the config's values are not positive.
+func (c FunctionHTTPConfig) Validate() error {
+	if c.ReadTimeout != nil && *c.ReadTimeout <= 0 {
+		return fmt.Errorf("ReadTimeout must be positive")
+	}
+	if c.WriteTimeout != nil && *c.WriteTimeout <= 0 {
+		return fmt.Errorf("WriteTimeout must be positive")
+	}
+	if c.MaxBodySize != nil && *c.MaxBodySize <= 0 {
+		return fmt.Errorf("MaxBodySize must be positive")
+	}
+	return nil
 }
 
 // GetRateLimit returns the inngest.RateLimit for function configuration.  The
 // SDK's RateLimit type is incompatible with the inngest.RateLimit type signature
-// for ease of definition.
+// for ease of definition.  A Cooldown is returned as a limit of one run per
+// cooldown period.
 func (f FunctionOpts) GetRateLimit() *inngest.RateLimit {
 	if f.RateLimit == nil {
+		if f.Cooldown != nil {
+			return RateLimit{Limit: 1, Period: *f.Cooldown}.Convert()
+		}
 		return nil
 	}
 	return f.RateLimit.Convert()
@@ -59,6 +635,17 @@ func (f FunctionOpts) GetRateLimit() *inngest.RateLimit {
 
 // GetTimeouts returns the inngest.Timeouts in a compatible type signature.
 func (f FunctionOpts) GetTimeouts() *inngest.Timeouts {
+	finish := f.RunTimeout
+	if f.Resilience != nil && f.Resilience.Timeout > 0 {
+		finish = &f.Resilience.Timeout
+	}
+	if finish != nil {
+		t := Timeouts{Finish: finish}
+		if f.Timeouts != nil {
+			t.Start = f.Timeouts.Start
+		}
+		return t.Convert()
+	}
 	if f.Timeouts == nil {
 		return nil
 	}
@@ -101,6 +688,37 @@ type Throttle struct {
 	Key *string `json:"key,omitempty"`
 }
 
+// BufferingConfig represents event buffering, used to run a function once
+// for a cluster of related events.
+type BufferingConfig struct {
+	// MaxSize is the maximum number of events buffered for each key.  The
+	// function runs as soon as a key's buffer is full.  This must be at least
+	// 2.
+	MaxSize int `json:"maxSize"`
+	// Timeout is how long to buffer events for each key, starting from the
+	// key's first event.  The function runs with the buffered events when the
+	// timeout fires, even if the buffer isn't full.  This must be at least 1
+	// second.
+	Timeout time.Duration `json:"timeout"`
+	// Key is the expression used to group related events, eg.
+	// "event.data.orderId".  Events are buffered separately for each value.
+	Key string `json:"key"`
+}
+
+// Validate returns an error if the buffering config is not well formed.
+func (b BufferingConfig) Validate() error {
+	if b.Key == "" {
+		return fmt.Errorf("key must not be empty")
+	}
+	if b.MaxSize < 2 {
+		return fmt.Errorf("max size must be at least 2")
+	}
+	if b.Timeout < time.Second {
+		return fmt.Errorf("timeout must be at least 1s")
+	}
+	return nil
+}
+
 type RateLimit struct {
 	// Limit is how often the function can be called within the specified period
 	Limit uint `json:"limit"`
@@ -139,6 +757,8 @@ type Timeouts struct {
 	//
 	// Note that if the final request to a function begins before this timeout, and completes
 	// after this timeout, the function will succeed.
+	//
+	// Deprecated: Use FunctionOpts.Resilience.Timeout.
 	Finish *time.Duration `json:"finish,omitempty"`
 }
 
@@ -158,6 +778,462 @@ func (t Timeouts) Convert() *inngest.Timeouts {
 	}
 }
 
+const (
+	// WildcardEvent is the event name which matches every event, used to create
+	// catch-all functions via EventTrigger(WildcardEvent, nil).
+	WildcardEvent = "*"
+)
+
+// SLAConfig represents SLA requirements for a function.  These don't affect
+// execution, and aren't synced to Inngest.  See FunctionOpts.SLA.
+type SLAConfig struct {
+	// MaxDuration is the maximum acceptable duration of a function run.
+	MaxDuration time.Duration
+	// P99Latency is the expected p99 latency for function runs.  This must be
+	// less than MaxDuration.
+	P99Latency time.Duration
+}
+
+// Validate returns an error if the SLA is not well formed.
+func (s SLAConfig) Validate() error {
+	if s.MaxDuration <= 0 {
+		return fmt.Errorf("SLA max duration must be greater than 0")
+	}
+	if s.P99Latency < 0 || s.P99Latency >= s.MaxDuration {
+		return fmt.Errorf("SLA p99 latency must be less than the max duration")
+	}
+	return nil
+}
+
+// emailPlaceholders are the placeholders supported within
+// EmailConfig.SubjectTemplate.
+var emailPlaceholders = []string{"function_id", "run_id", "status"}
+
+// EmailConfig configures emails sent when a function run completes.  See
+// FunctionOpts.CompletionEmail.
+type EmailConfig struct {
+	// To lists the addresses to email, eg. "Ops <ops@example.com>".
+	To []string
+	// OnSuccess emails when a run completes successfully.
+	OnSuccess bool
+	// OnFailure emails when a run fails.
+	OnFailure bool
+	// SubjectTemplate is the subject of the email.  This may contain the
+	// placeholders {function_id}, {run_id}, and {status}, eg.
+	// "Export {run_id}: {status}".
+	SubjectTemplate string
+}
+
+// Validate returns an error if the email config is not well formed.
+func (e EmailConfig) Validate() error {
+	if len(e.To) == 0 {
+		return fmt.Errorf("at least one recipient is required")
+	}
+	for _, to := range e.To {
+		if _, err := mail.ParseAddress(to); err != nil {
+			return fmt.Errorf("invalid email address '%s': %w", to, err)
+		}
+	}
+	if !e.OnSuccess && !e.OnFailure {
+		return fmt.Errorf("at least one of OnSuccess or OnFailure must be set")
+	}
+
+	rest := e.SubjectTemplate
+	for {
+		start := strings.IndexAny(rest, "{}")
+		if start == -1 {
+			return nil
+		}
+		if rest[start] == '}' {
+			return fmt.Errorf("subject template has an unmatched '}'")
+		}
+		end := strings.IndexAny(rest[start+1:], "{}")
+		if end == -1 || rest[start+1+end] == '{' {
+			return fmt.Errorf("subject template has an unmatched '{'")
+		}
+		name := rest[start+1 : start+1+end]
+		if !slices.Contains(emailPlaceholders, name) {
+			return fmt.Errorf("unknown subject template placeholder '{%s}'", name)
+		}
+		rest = rest[start+1+end+1:]
+	}
+}
+
+// SideEffectMode controls how a function's non-idempotent side effects are
+// protected from re-execution.
+type SideEffectMode string
+
+const (
+	// SideEffectMemoized relies on wrapping side effects in steps, eg. using
+	// step.SideEffect, so that each side effect's result is memoized and the
+	// side effect isn't repeated when the function is re-executed.
+	SideEffectMemoized SideEffectMode = ""
+	// SideEffectOnce additionally saves the function's output as a step once
+	// the function finishes, and skips calling the function when the run is
+	// re-executed after the output is saved, eg. when the final response is
+	// lost.  Code outside of steps still runs each time the function is
+	// called before it finishes.  Runs are also deduplicated by event ID,
+	// unless the function sets its own Idempotency or EventDeduplication, so
+	// that an event which is sent more than once only runs the function once.
+	SideEffectOnce SideEffectMode = "once"
+)
+
+// sideEffectOnceStepID is the ID of the step which saves the output of
+// functions using SideEffectOnce.  This isn't a valid user step ID, so it
+// can't clash with the function's own steps.
+const sideEffectOnceStepID = "inngest/side-effect-once"
+
+// BackoffPolicy is the policy used to delay retries.
+type BackoffPolicy string
+
+const (
+	// BackoffDefault uses Inngest's default exponential backoff with jitter,
+	// or exponential backoff computed by the SDK when MinRetryDelay or
+	// MaxRetryDelay are set.
+	BackoffDefault BackoffPolicy = ""
+	// BackoffExponential doubles the delay between each retry.
+	BackoffExponential BackoffPolicy = "exponential"
+	// BackoffLinear increases the delay between each retry linearly.
+	BackoffLinear BackoffPolicy = "linear"
+	// BackoffConstant uses the same delay between each retry.
+	BackoffConstant BackoffPolicy = "constant"
+	// BackoffNoRetry disables retries, so that the function runs exactly once.
+	BackoffNoRetry BackoffPolicy = "none"
+)
+
+// RetryConfig configures how a function is retried.
+type RetryConfig struct {
+	// Attempts is the number of times the function is retried.
+	Attempts int
+	// Backoff is the policy used to delay retries.  BackoffNoRetry can't be
+	// used:  set Attempts to zero instead.
+	Backoff BackoffPolicy
+	// MinRetryDelay is the minimum delay between retries.
+	MinRetryDelay *time.Duration
+	// MaxRetryDelay caps the delay between retries, eg. so that exponential
+	// backoff doesn't delay recovery for days.  This must be at least
+	// MinRetryDelay.
+	MaxRetryDelay *time.Duration
+	// JitterFactor adds randomness to retry delays, between 0.0 and 1.0, so
+	// that failing runs don't retry at the same time.  The SDK computes each
+	// delay from Backoff and MinRetryDelay as delay * (1 + rand * JitterFactor),
+	// capped at MaxRetryDelay or at a week without one, and sends the retry
+	// time to Inngest.
+	JitterFactor float64
+}
+
+// RetryConfigOption modifies a RetryConfig.
+type RetryConfigOption func(r *RetryConfig)
+
+// WithFullJitter sets the RetryConfig's JitterFactor to 1.0.
+func WithFullJitter() RetryConfigOption {
+	return func(r *RetryConfig) {
+		r.JitterFactor = 1
+	}
+}
+
+// WithEqualJitter sets the RetryConfig's JitterFactor to 0.5.
+func WithEqualJitter() RetryConfigOption {
+	return func(r *RetryConfig) {
+		r.JitterFactor = 0.5
+	}
+}
+
+// WithBoundedExponentialBackoff returns a RetryConfig which retries the given
+// number of times using exponential backoff, with delays between min and max.
+func WithBoundedExponentialBackoff(attempts int, min, max time.Duration, opts ...RetryConfigOption) *RetryConfig {
+	r := &RetryConfig{
+		Attempts:      attempts,
+		Backoff:       BackoffExponential,
+		MinRetryDelay: &min,
+		MaxRetryDelay: &max,
+	}
+	for _, o := range opts {
+		o(r)
+	}
+	return r
+}
+
+// Validate returns an error if the retry config is not well formed.
+func (r RetryConfig) Validate() error {
+	if r.Attempts < 0 {
+		return fmt.Errorf("Attempts must not be negative")
+	}
+	switch r.Backoff {
+	case BackoffDefault, BackoffExponential, BackoffLinear, BackoffConstant:
+	default:
+		return fmt.Errorf("unsupported backoff policy '%s'", r.Backoff)
+	}
+	if r.MinRetryDelay != nil && *r.MinRetryDelay <= 0 {
+		return fmt.Errorf("MinRetryDelay must be greater than 0")
+	}
+	if r.MaxRetryDelay != nil && *r.MaxRetryDelay <= 0 {
+		return fmt.Errorf("MaxRetryDelay must be greater than 0")
+	}
+	if r.MinRetryDelay != nil && r.MaxRetryDelay != nil && *r.MaxRetryDelay < *r.MinRetryDelay {
+		return fmt.Errorf("MaxRetryDelay must be at least MinRetryDelay")
+	}
+	if r.JitterFactor < 0 || r.JitterFactor > 1 {
+		return fmt.Errorf("JitterFactor must be between 0.0 and 1.0")
+	}
+	return nil
+}
+
+// retryBaseDelay is the delay before the first retry for configs without a
+// MinRetryDelay.
+const retryBaseDelay = 10 * time.Second
+
+// retryMaxDelay caps retry delays for configs without a MaxRetryDelay, so that
+// large attempts don't overflow time.Duration.
+const retryMaxDelay = 7 * 24 * time.Hour
+
+// jitterRand returns a random number within [0.0, 1.0).
+var jitterRand = rand.Float64
+
+// retryDelay returns the delay before retrying after the given zero-based
+// attempt, including the config's jitter.
+func (r RetryConfig) retryDelay(attempt int) time.Duration {
+	base := retryBaseDelay
+	if r.MinRetryDelay != nil {
+		base = *r.MinRetryDelay
+	}
+	delay := float64(base)
+	switch r.Backoff {
+	case BackoffLinear:
+		delay *= float64(attempt + 1)
+	case BackoffConstant:
+	default:
+		delay *= math.Pow(2, float64(attempt))
+	}
+	delay *= 1 + jitterRand()*r.JitterFactor
+
+	// Clamp the delay before converting it, as float64 delays may exceed the
+	// range of time.Duration.
+	limit := retryMaxDelay
+	if r.MaxRetryDelay != nil {
+		limit = *r.MaxRetryDelay
+	}
+	if math.IsNaN(delay) || delay > float64(limit) {
+		return limit
+	}
+	return time.Duration(delay)
+}
+
+// retryConfig returns the function's resolved retry delays, or nil if the
+// function uses Inngest's default backoff.  The number of retries is resolved
+// by retries.  See FunctionOpts.Retry for precedence.
+func (f FunctionOpts) retryConfig() *RetryConfig {
+	r := RetryConfig{}
+	switch {
+	case f.Retry != nil:
+		r = *f.Retry
+	case f.Resilience != nil && f.Resilience.BackoffPolicy != BackoffDefault && f.Resilience.BackoffPolicy != BackoffNoRetry:
+		r.Backoff = f.Resilience.BackoffPolicy
+	case f.MaxRetryDelay == nil:
+		return nil
+	}
+	if f.MaxRetryDelay != nil {
+		r.MaxRetryDelay = f.MaxRetryDelay
+	}
+	return &r
+}
+
+// DegradationConfig configures fallback results for steps which fail.  When a
+// step fails with a matching error, the step completes with the fallback
+// result instead of being retried, and a warning is logged.  If the
+// fallback provider also fails, the step fails with its original error and
+// is retried as usual.
+type DegradationConfig struct {
+	// FallbackProvider returns the fallback result for the step with the
+	// given ID, eg. the step's most recent cached result.
+	FallbackProvider func(ctx context.Context, stepID string) (any, error)
+	// FallbackOnErrors lists the names of errors which trigger the fallback.
+	// Each name is either the code of an error registered via
+	// errors.RegisterError or the Go type of an error, eg. "*net.OpError".  If
+	// empty, every step error triggers the fallback.
+	FallbackOnErrors []string
+}
+
+// DynamicRetryConfig selects the retry policy for each failed attempt of a
+// function, eg. to retry rate limit errors after a delay and to stop retrying
+// validation errors immediately.
+type DynamicRetryConfig struct {
+	// Policy is called with the error from the failed attempt and the
+	// attempt's zero-based number, returning whether and when to retry.
+	Policy func(ctx context.Context, err error, attempt int) RetryPolicy
+}
+
+// RetryPolicy is the decision returned by DynamicRetryConfig.Policy.
+type RetryPolicy struct {
+	// Retry is whether the function is retried.  If false, the run fails
+	// permanently.
+	Retry bool
+	// Wait is how long to wait before retrying.  If zero, the function's
+	// backoff is used.
+	Wait time.Duration
+}
+
+// defaultRetries is the number of times Inngest retries functions which don't
+// configure retries.
+const defaultRetries = 3
+
+// retries returns the number of times the function is retried, or nil to use
+// Inngest's default.  See FunctionOpts.Retry for precedence.
+func (f FunctionOpts) retries() *int {
+	if f.Retry != nil {
+		return &f.Retry.Attempts
+	}
+	if f.Resilience != nil {
+		return f.Resilience.retries()
+	}
+	if f.DisableAutoRetry {
+		return IntPtr(0)
+	}
+	return f.Retries
+}
+
+// ResilienceConfig configures how a function handles failures as a single
+// policy, replacing FunctionOpts.Retries, DisableAutoRetry and Timeouts.  Its
+// attempts and backoff policy are resolved into the function's retry config;
+// see FunctionOpts.Retry.
+type ResilienceConfig struct {
+	// MaxAttempts is the maximum number of attempts, including the first.  If
+	// zero, Inngest's default number of retries is used.
+	MaxAttempts int
+	// Timeout is the maximum duration of a function run, after which the
+	// run is cancelled.  If zero, runs don't time out.
+	Timeout time.Duration
+	// BackoffPolicy is the policy used to delay retries.
+	BackoffPolicy BackoffPolicy
+	// CircuitBreakerThreshold is the failure rate, between 0.0 and 1.0, above
+	// which new runs of the function shouldn't start until the failure rate
+	// recovers.  The Inngest server doesn't support circuit breaking yet, so
+	// this is validated but isn't synced.
+	CircuitBreakerThreshold *float64
+}
+
+// Validate returns an error if the config's settings are invalid or
+// contradictory.
+func (r ResilienceConfig) Validate() error {
+	if r.MaxAttempts < 0 {
+		return fmt.Errorf("MaxAttempts must not be negative")
+	}
+	if r.Timeout < 0 {
+		return fmt.Errorf("Timeout must not be negative")
+	}
+	switch r.BackoffPolicy {
+	case BackoffDefault, BackoffExponential, BackoffLinear, BackoffConstant:
+	case BackoffNoRetry:
+		if r.MaxAttempts > 1 {
+			return fmt.Errorf("MaxAttempts must be at most 1 with the no retry backoff policy")
+		}
+	default:
+		return fmt.Errorf("unknown backoff policy '%s'", r.BackoffPolicy)
+	}
+	if r.MaxAttempts == 1 && r.BackoffPolicy != BackoffDefault && r.BackoffPolicy != BackoffNoRetry {
+		return fmt.Errorf("a backoff policy can't be used with a single attempt")
+	}
+	if t := r.CircuitBreakerThreshold; t != nil && (*t <= 0 || *t > 1) {
+		return fmt.Errorf("CircuitBreakerThreshold must be greater than 0.0 and at most 1.0")
+	}
+	return nil
+}
+
+// retries returns the number of retries for the config, or nil to use the
+// default.
+func (r ResilienceConfig) retries() *int {
+	if r.BackoffPolicy == BackoffNoRetry {
+		return IntPtr(0)
+	}
+	if r.MaxAttempts > 0 {
+		return IntPtr(r.MaxAttempts - 1)
+	}
+	return nil
+}
+
+// AutoCancelConfig cancels in-progress runs of a function when a new event
+// is received for the same key.  This is shorthand for a Cancel using the same
+// event as the function's trigger.
+//
+// Note that this differs from Debounce:  debouncing delays runs until events
+// stop being received, whereas AutoCancel starts a new run for every event and
+// cancels previous runs which are still in progress.  Any side effects from
+// steps which already ran within cancelled runs are not undone.
+type AutoCancelConfig struct {
+	// Event is the event which cancels runs.  If empty, each of the
+	// function's event triggers cancels runs.
+	Event string
+	// Key is the expression used to group runs, eg. "event.data.userId".  A
+	// run is cancelled when the new event's key matches the key of the event
+	// which started the run.  It must reference the event, ie. start with
+	// "event.".
+	Key string
+}
+
+// AutoCancelOnSameEvent returns an AutoCancelConfig which cancels in-progress
+// runs whenever the function's trigger event is received with the same key,
+// eg. AutoCancelOnSameEvent("event.data.userId").
+func AutoCancelOnSameEvent(keyExpr string) *AutoCancelConfig {
+	return &AutoCancelConfig{Key: keyExpr}
+}
+
+// Validate returns an error if the key doesn't reference the event.
+func (a AutoCancelConfig) Validate() error {
+	if !strings.HasPrefix(a.Key, "event.") {
+		return fmt.Errorf("key must start with \"event.\"")
+	}
+	if a.Event != "" && strings.Contains(a.Event, "*") {
+		return fmt.Errorf("event must not contain wildcards")
+	}
+	return nil
+}
+
+// cancels returns the cancellations for the given function triggers.
+func (a AutoCancelConfig) cancels(triggers []inngest.Trigger) []inngest.Cancel {
+	// The key is compared against the key of the event which started the
+	// run, which is referenced using "async" in cancellation expressions.
+	expr := fmt.Sprintf("%s == async.%s", a.Key, strings.TrimPrefix(a.Key, "event."))
+
+	if a.Event != "" {
+		return []inngest.Cancel{{Event: a.Event, If: &expr}}
+	}
+
+	cancels := []inngest.Cancel{}
+	for _, t := range triggers {
+		if t.EventTrigger == nil || strings.Contains(t.Event, "*") {
+			continue
+		}
+		cancels = append(cancels, inngest.Cancel{Event: t.Event, If: &expr})
+	}
+	return cancels
+}
+
+// ResourceConfig represents soft resource limits for a function.
+type ResourceConfig struct {
+	// MaxMemoryMB is the maximum heap, in megabytes, that the process may
+	// use while the function executes.  While the function executes, the
+	// SDK samples the heap allocated by the whole process and fails the
+	// execution with ErrMemoryLimitExceeded when this is exceeded.  This is
+	// a process-wide limit, not a per-function one:  the heap includes
+	// memory allocated by concurrent executions and everything else running
+	// in the process, so an execution can fail due to another's allocations.
+	MaxMemoryMB int
+	// SampleIntervalMs is how often memory usage is sampled, in milliseconds.
+	// Defaults to 100ms.
+	SampleIntervalMs int
+}
+
+// Validate returns an error if any of the limits are negative.
+func (r ResourceConfig) Validate() error {
+	if r.MaxMemoryMB < 0 {
+		return fmt.Errorf("MaxMemoryMB must not be negative")
+	}
+	if r.SampleIntervalMs < 0 {
+		return fmt.Errorf("SampleIntervalMs must not be negative")
+	}
+	return nil
+}
+
 // CreateFunction creates a new function which can be registered within a handler.
 //
 // This function uses generics, allowing you to supply the event that triggers the function.
@@ -180,11 +1256,18 @@ func (t Timeouts) Convert() *inngest.Timeouts {
 //			// step.Run(ctx, "Do some logic", func(ctx context.Context) (string, error) { return "hi", nil })
 //		},
 //	)
+//
+// Any FunctionOption passed is applied to the given FunctionOpts in order.
 func CreateFunction[T any](
 	fc FunctionOpts,
 	trigger inngest.Trigger,
 	f SDKFunction[T],
+	opts ...FunctionOption,
 ) ServableFunction {
+	for _, o := range opts {
+		o(&fc)
+	}
+
 	// Validate that the input type is a concrete type, and not an interface.
 	//
 	// The only exception is `any`, when users don't care about the input event
@@ -203,6 +1286,14 @@ func CreateFunction[T any](
 	return sf
 }
 
+// EventTrigger returns a trigger which runs the function whenever the named event
+// is received.  The optional expression further filters which events run the function,
+// eg. "event.data.amount > 100".
+//
+// The event name may contain a single trailing wildcard.  WildcardEvent ("*") matches
+// every event, which is useful for catch-all functions such as audit logging.  A prefix
+// wildcard such as "payments/*" matches every event within the "payments/" domain.  The
+// expression is applied to wildcard triggers as usual.
 func EventTrigger(name string, expression *string) inngest.Trigger {
 	return inngest.Trigger{
 		EventTrigger: &inngest.EventTrigger{
@@ -212,6 +1303,19 @@ func EventTrigger(name string, expression *string) inngest.Trigger {
 	}
 }
 
+// validateEventName ensures that wildcards within an event trigger are either the
+// catch-all wildcard or a trailing prefix wildcard such as "payments/*".
+func validateEventName(name string) error {
+	n := strings.Count(name, "*")
+	if n == 0 || name == WildcardEvent {
+		return nil
+	}
+	if n > 1 || !strings.HasSuffix(name, "/*") {
+		return fmt.Errorf("invalid wildcard event trigger '%s': wildcards must be \"*\" or a prefix such as \"payments/*\"", name)
+	}
+	return nil
+}
+
 func CronTrigger(cron string) inngest.Trigger {
 	return inngest.Trigger{
 		CronTrigger: &inngest.CronTrigger{
@@ -220,6 +1324,38 @@ func CronTrigger(cron string) inngest.Trigger {
 	}
 }
 
+// CronTriggerInZone returns a cron trigger scheduled in the given IANA
+// timezone, eg. "America/New_York".  This overrides FunctionOpts.Timezone.
+func CronTriggerInZone(cron, timezone string) inngest.Trigger {
+	return CronTrigger("TZ=" + timezone + " " + cron)
+}
+
+// cronTimezone returns the timezone prefixed to the cron expression, if any.
+func cronTimezone(cron string) (string, bool) {
+	for _, prefix := range []string{"TZ=", "CRON_TZ="} {
+		if rest, ok := strings.CutPrefix(cron, prefix); ok {
+			tz, _, _ := strings.Cut(rest, " ")
+			return tz, true
+		}
+	}
+	return "", false
+}
+
+// zonedCron returns the cron expression scheduled in the given timezone,
+// unless the expression specifies its own timezone.
+func zonedCron(cron, timezone string) (string, error) {
+	if tz, ok := cronTimezone(cron); ok {
+		if _, err := time.LoadLocation(tz); err != nil {
+			return "", fmt.Errorf("invalid timezone for cron '%s': %w", cron, err)
+		}
+		return cron, nil
+	}
+	if timezone == "" {
+		return cron, nil
+	}
+	return "TZ=" + timezone + " " + cron, nil
+}
+
 // SDKFunction represents a user-defined function to be called based off of events or
 // on a schedule.
 //
@@ -299,6 +1435,18 @@ func (s servableFunc) Slug(appName string) string {
 	return appName + "-" + fnSlug
 }
 
+// functionSlugs returns the function's slug plus the slugs for any aliases.
+func functionSlugs(fn ServableFunction, appName string) []string {
+	slugs := []string{fn.Slug(appName)}
+	for _, alias := range fn.Config().Aliases {
+		if appName != "" {
+			alias = appName + "-" + alias
+		}
+		slugs = append(slugs, alias)
+	}
+	return slugs
+}
+
 func (s servableFunc) Name() string {
 	return s.fc.Name
 }
@@ -0,0 +1,95 @@
+package inngestgo
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// acceptsGzip returns whether the request accepts gzip-encoded responses.
+func acceptsGzip(r *http.Request) bool {
+	for _, enc := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(enc), ";")
+		if strings.EqualFold(strings.TrimSpace(name), "gzip") && strings.ReplaceAll(params, " ", "") != "q=0" {
+			return true
+		}
+	}
+	return false
+}
+
+// gzipResponseWriter compresses responses written to the underlying
+// http.ResponseWriter.  Close must be called once the response is written.
+type gzipResponseWriter struct {
+	http.ResponseWriter
+
+	gz *gzip.Writer
+	// raw is set once a pre-compressed body is written directly to the
+	// underlying writer, via writeSigned.
+	raw bool
+}
+
+func (g *gzipResponseWriter) WriteHeader(code int) {
+	g.start()
+	g.ResponseWriter.WriteHeader(code)
+}
+
+func (g *gzipResponseWriter) Write(b []byte) (int, error) {
+	g.start()
+	return g.gz.Write(b)
+}
+
+func (g *gzipResponseWriter) Flush() {
+	if g.gz != nil {
+		_ = g.gz.Flush()
+	}
+	if f, ok := g.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Close writes the remainder of the compressed response.
+func (g *gzipResponseWriter) Close() error {
+	if g.gz == nil {
+		return nil
+	}
+	return g.gz.Close()
+}
+
+func (g *gzipResponseWriter) start() {
+	if g.gz != nil || g.raw {
+		return
+	}
+	g.Header().Set("Content-Encoding", "gzip")
+	g.Header().Del("Content-Length")
+	g.gz = gzip.NewWriter(g.ResponseWriter)
+}
+
+// writeSigned writes the response body along with its signature, using the
+// given signing key.  If the response is gzip-encoded, the signature is
+// computed over the compressed body, as that's what the client receives.
+func writeSigned(w http.ResponseWriter, key string, status int, body []byte) error {
+	gw, ok := w.(*gzipResponseWriter)
+	if ok && gw.gz == nil {
+		buf := &bytes.Buffer{}
+		zw := gzip.NewWriter(buf)
+		_, _ = zw.Write(body)
+		if err := zw.Close(); err != nil {
+			return err
+		}
+		body = buf.Bytes()
+		gw.raw = true
+		w = gw.ResponseWriter
+		w.Header().Set("Content-Encoding", "gzip")
+	}
+
+	sig, err := signWithoutJCS(time.Now(), []byte(key), body)
+	if err != nil {
+		return err
+	}
+	w.Header().Set(HeaderKeySignature, sig)
+	w.WriteHeader(status)
+	_, err = w.Write(body)
+	return err
+}
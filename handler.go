@@ -3,6 +3,7 @@ package inngestgo
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -27,6 +28,7 @@ import (
 	"github.com/khulnasoft-lab/inngestgo/internal/sdkrequest"
 	"github.com/khulnasoft-lab/inngestgo/internal/types"
 	"github.com/khulnasoft-lab/inngestgo/step"
+	"github.com/khulnasoft-lab/inngestgo/tracing"
 )
 
 var (
@@ -43,6 +45,8 @@ var (
 	errBadRequest      = fmt.Errorf("bad request")
 	errFunctionMissing = fmt.Errorf("function not found")
 	errUnauthorized    = fmt.Errorf("unauthorized")
+	errShuttingDown    = fmt.Errorf("handler is shutting down")
+	errForbidden       = fmt.Errorf("forbidden")
 
 	// DefaultMaxBodySize is the default maximum size read within a single incoming
 	// invoke request (100MB).
@@ -83,6 +87,12 @@ type HandlerOpts struct {
 	// defaults to os.Getenv("INNGEST_SIGNING_KEY_FALLBACK").
 	SigningKeyFallback *string
 
+	// SigningKeyProvider provides the signing key when SigningKey is nil,
+	// eg. to source the key from a JWK set using NewSigningKeyFromJWK.  It's
+	// called with each request's context;  if it fails, the last key it
+	// returned is used, and requests fail until it returns a key.
+	SigningKeyProvider SigningKeyProvider
+
 	// APIOrigin is the specified host to be used to make API calls
 	APIBaseURL *string
 
@@ -112,28 +122,173 @@ type HandlerOpts struct {
 
 	// AppVersion supplies an application version identifier. This should change
 	// whenever code within one of your Inngest function or any dependency thereof changes.
+	// The version is included in syncs and the X-Inngest-SDK header.  Use
+	// AppVersionFromGitTag to source this from the Go module version.
 	AppVersion *string
 
 	// MaxBodySize is the max body size to read for incoming invoke requests
 	MaxBodySize int
 
+	// BodyParser returns the effective body of incoming requests before
+	// they're parsed, eg. to decrypt bodies.  Errors returned by the parser
+	// cause a 400 Bad Request response.  This defaults to DefaultBodyParser,
+	// which decodes gzip-encoded bodies.
+	BodyParser BodyParser
+
+	// GzipResponse compresses responses using gzip when the request accepts
+	// gzip-encoded responses, eg. to reduce the size of syncs for apps with
+	// many functions.  Signed responses are signed after compression.
+	GzipResponse bool
+
+	// ResponseSerializer serializes the app's manifest within in-band sync
+	// responses, eg. to use an alternate format.  Responses are signed after
+	// serialization.  This defaults to JSONSerializer.
+	ResponseSerializer ResponseSerializer
+
+	// GlobalSecretEnvVars lists environment variables containing secrets,
+	// whose values are replaced with "[REDACTED]" within the handler's logs.
+	// Use FunctionOpts.SecretEnv to redact secrets used by single functions.
+	GlobalSecretEnvVars []string
+
 	// URL that the function is served at.  If not supplied this is taken from
 	// the incoming request's data.
 	URL *url.URL
 
+	// CloudRunAutoDetect sets URL and AppVersion when running on Google Cloud
+	// Run, if they're unset.  The URL is the service's deterministic URL,
+	// https://<K_SERVICE>-<project number>.<region>.run.app followed by
+	// ServePath, where the project number and region are read from the
+	// metadata server when syncing.  Syncs fail if they can't be read.
+	// AppVersion is the service's revision, K_REVISION.
+	CloudRunAutoDetect bool
+
 	// UseStreaming enables streaming - continued writes to the HTTP writer.  This
 	// differs from true streaming in that we don't support server-sent events.
 	UseStreaming bool
 
+	// CloudEventEmitter delivers the CloudEvents emitted by functions via
+	// EmitCloudEvent, eg. by publishing them to a message broker.  If nil,
+	// EmitCloudEvent fails the step without retrying.
+	CloudEventEmitter CloudEventEmitter
+
+	// ProgressClient sends the progress reported via step.Progress, for
+	// functions which set FunctionOpts.TrackProgress, as ProgressEventName
+	// events.  If nil, a client is created which uses the handler's TLSConfig
+	// and OutboundRequestInterceptor.
+	ProgressClient Client
+
 	// AllowInBandSync allows in-band syncs to occur. If nil, in-band syncs are
 	// disallowed.
 	AllowInBandSync *bool
 
+	// CustomHeaders are added to every response from the handler, for example
+	// when serving functions behind an API gateway which requires specific
+	// headers.  Keys must not start with "X-Inngest-", and Content-Type can't be
+	// overridden.
+	CustomHeaders map[string]string
+
+	// RequestMetrics records the method, kind, status and duration of every
+	// request served by the handler, eg. LoggingMetricsCollector.  If nil, no
+	// metrics are recorded.
+	RequestMetrics RequestMetricsCollector
+
+	// IPAllowList restricts requests to the handler to the given IP ranges,
+	// eg. from IPAllowListFromEnv.  Requests from other IPs are rejected with
+	// a 403.  If empty, requests from any IP are allowed.
+	IPAllowList IPAllowList
+
+	// OnFunctionStart is called whenever a function run starts, once per run
+	// rather than for each retry.  It's called in a new goroutine, and so
+	// never blocks the function.
+	OnFunctionStart func(ctx context.Context, info FunctionStartInfo)
+
+	// OnFunctionEnd is called whenever a function run finishes, successfully
+	// or once its final attempt fails.  It's called in a new goroutine, and so
+	// never blocks the function.
+	OnFunctionEnd func(ctx context.Context, info FunctionEndInfo)
+
+	// StepIDHasher overrides how step IDs are hashed.  If nil, step IDs are
+	// hashed using SHA-1.  This is useful for producing readable step IDs in
+	// tests, eg. fmt.Sprintf("%s-%s", op.Op, op.ID).
+	StepIDHasher func(op UnhashedOp) string
+
+	// Queue enables fire-and-forget execution.  When set, invoke requests are
+	// validated then enqueued, and the handler responds with 202 Accepted
+	// immediately, which Inngest records as the run completing successfully.
+	// Enqueued work is executed via an AsyncWorker, and its result, including
+	// any failure, is never reported to Inngest.  This only supports
+	// functions without steps;  see AsyncWorker.
+	Queue AsyncQueue
+
+	// TLSConfig configures TLS for outbound requests to Inngest, such as syncs
+	// and progress events, and for the server created by ListenAndServe.  Use
+	// MutualTLSConfig to create a config for mutual TLS.  To send events using
+	// the same config, set ClientOpts.TLSConfig.
+	TLSConfig *tls.Config
+
+	// OutboundRequestInterceptor is called with every outbound request made by
+	// the handler, such as syncs, before the request is sent.  The returned
+	// request is sent in its place, eg. to add proxy authentication headers.
+	// If the interceptor returns nil, the request is sent unmodified.  To
+	// intercept requests made when sending events, set
+	// ClientOpts.OutboundRequestInterceptor.
+	OutboundRequestInterceptor func(*http.Request) *http.Request
+
+	// RequestValidator runs custom validation on invoke requests, after the
+	// request's signature is verified and before the function is executed.  If
+	// validation fails, the handler responds with a 403.
+	RequestValidator RequestValidator
+
+	// Tracing configures propagation of trace context from incoming requests
+	// into functions.  The extracted span context is available within
+	// functions via tracing.SpanContextFromContext.
+	Tracing *TracingConfig
+
+	// MaxConcurrentFunctions limits how many functions the handler executes at
+	// once.  When the limit is reached, invoke requests are rejected with a 503
+	// and a Retry-After header.  If nil, concurrency is unlimited.
+	MaxConcurrentFunctions *int
+
+	// ShutdownTimeout is how long Shutdown waits for in-flight executions to
+	// finish before force-cancelling them.  Defaults to 30 seconds.
+	//
+	// Executions are only cancelled by shutting down:  a client disconnecting
+	// from the request doesn't cancel the function's context.
+	ShutdownTimeout time.Duration
+
+	// StepEncryption encrypts step results before they're sent to Inngest, and
+	// decrypts them when steps are replayed.  If nil, step results are stored
+	// in plaintext.
+	StepEncryption *StepEncryptionConfig
+
 	Dev *bool
 }
 
-// GetSigningKey returns the signing key defined within HandlerOpts, or the default
-// defined within INNGEST_SIGNING_KEY.
+// UnhashedOp represents a step before its ID is hashed, and is passed to
+// HandlerOpts.StepIDHasher.
+type UnhashedOp = sdkrequest.UnhashedOp
+
+// StepEncryptionConfig configures AES-GCM encryption of step results.
+type StepEncryptionConfig struct {
+	// Key is the AES-256 key used to encrypt step results, and must be 32
+	// bytes.
+	Key []byte
+
+	// KeyID identifies the key.  It's stored in plaintext alongside encrypted
+	// step results so that state can be decrypted with the correct key after
+	// rotating keys.
+	KeyID string
+
+	// PreviousKeys are the keys used before rotating keys, keyed by their
+	// KeyID.  They're used to decrypt step results which were encrypted before
+	// the rotation, and are never used to encrypt new results.  Each key must
+	// be 32 bytes.
+	PreviousKeys map[string][]byte
+}
+
+// GetSigningKey returns the signing key defined within HandlerOpts, or the
+// default defined within INNGEST_SIGNING_KEY.  Keys from the SigningKeyProvider
+// are fetched by the handler for each request instead.
 //
 // This is the private key used to register functions and communicate with the private
 // API.
@@ -228,6 +383,15 @@ func (h HandlerOpts) GetRegisterURL() string {
 	return *h.RegisterURL
 }
 
+// GetAppVersion returns the app version defined within HandlerOpts, or an empty
+// string if unset.
+func (h HandlerOpts) GetAppVersion() string {
+	if h.AppVersion == nil {
+		return ""
+	}
+	return *h.AppVersion
+}
+
 func (h HandlerOpts) IsInBandSyncAllowed() bool {
 	if h.AllowInBandSync != nil {
 		return *h.AllowInBandSync
@@ -268,23 +432,51 @@ type Handler interface {
 
 	// Connect establishes an outbound connection to Inngest
 	Connect(ctx context.Context, opts ConnectOpts) (connect.WorkerConnection, error)
+
+	// Shutdown stops the handler from executing new functions, then waits for
+	// in-flight executions to finish.  See HandlerOpts.ShutdownTimeout.
+	Shutdown(ctx context.Context) error
+
+	// ListenAndServe serves the handler on the given TCP address, using
+	// HandlerOpts.TLSConfig for TLS when set.  This blocks until the server
+	// stops, and the server is stopped by Shutdown.
+	ListenAndServe(addr string) error
+
+	// CurrentConcurrency returns the number of functions currently executing.
+	// See HandlerOpts.MaxConcurrentFunctions.
+	CurrentConcurrency() int
+
+	// HealthCheck runs every function's FunctionOpts.HealthCheck.  Functions
+	// whose health check fails are marked as unhealthy, and their invoke
+	// requests are rejected with a 503 until a later health check passes.
+	HealthCheck(ctx context.Context) error
+
+	// FunctionHealth returns the error from the given function's InitFunc or
+	// last health check, or nil if the function is healthy.
+	FunctionHealth(functionID string) error
+
+	// BuildDependencyGraph returns the registered functions' dependencies
+	// declared via FunctionOpts.DependsOn, as an adjacency list keyed by
+	// function slug.
+	BuildDependencyGraph() map[string][]string
 }
 
-// NewHandler returns a new Handler for serving Inngest functions.
-func NewHandler(appName string, opts HandlerOpts) Handler {
-	if opts.Logger == nil {
-		opts.Logger = slog.Default()
-	}
+// HandlerOption modifies HandlerOpts, and can be passed to NewHandler as
+// shorthand for common configuration, eg. logger adapters.
+type HandlerOption func(*HandlerOpts)
 
-	if opts.MaxBodySize == 0 {
-		opts.MaxBodySize = DefaultMaxBodySize
+// NewHandler returns a new Handler for serving Inngest functions.
+func NewHandler(appName string, opts HandlerOpts, options ...HandlerOption) Handler {
+	for _, o := range options {
+		o(&opts)
 	}
 
-	return &handler{
-		HandlerOpts: opts,
-		appName:     appName,
-		funcs:       []ServableFunction{},
+	h := &handler{
+		appName: appName,
+		funcs:   []ServableFunction{},
 	}
+	h.setOptions(opts)
+	return h
 }
 
 type handler struct {
@@ -294,19 +486,87 @@ type handler struct {
 	funcs   []ServableFunction
 	// lock prevents reading the function maps while serving
 	l sync.RWMutex
+
+	// executions tracks in-flight executions so that they can be drained on
+	// shutdown.
+	executions executions
+
+	// concurrency limits the number of functions executing at once.
+	concurrency *concurrency
+
+	// outbound is the client used for outbound requests to Inngest.
+	outbound *http.Client
+
+	// detectURL is whether the handler's URL is detected from the Cloud Run
+	// environment when syncing.  See HandlerOpts.CloudRunAutoDetect.
+	detectURL bool
+
+	// health stores the results of function health checks.
+	health functionHealth
+
+	// inits stores the results of functions' InitFunc.
+	inits functionInits
+
+	// server is the server started by ListenAndServe, if any.
+	server *http.Server
+
+	// providedKey is the last key returned by the SigningKeyProvider, used if
+	// the provider fails.
+	providedKey  string
+	providedKeyL sync.Mutex
+}
+
+// signingKey returns the handler's signing key, fetching it from the
+// SigningKeyProvider if SigningKey is nil.  If the provider fails, the last
+// key it returned is used.
+func (h *handler) signingKey(ctx context.Context) (string, error) {
+	if h.SigningKey != nil || h.SigningKeyProvider == nil {
+		return h.GetSigningKey(), nil
+	}
+
+	key, err := h.SigningKeyProvider.SigningKey(ctx)
+	if err == nil && key == "" {
+		err = fmt.Errorf("provider returned an empty key")
+	}
+
+	h.providedKeyL.Lock()
+	defer h.providedKeyL.Unlock()
+	if err == nil {
+		h.providedKey = key
+		return key, nil
+	}
+	if h.providedKey == "" {
+		return "", fmt.Errorf("error getting signing key: %w", err)
+	}
+	h.Logger.Warn("error getting signing key; using the previous key", "error", err)
+	return h.providedKey, nil
 }
 
 func (h *handler) SetOptions(opts HandlerOpts) Handler {
-	h.HandlerOpts = opts
+	h.setOptions(opts)
+	return h
+}
+
+// setOptions applies defaults to the given options and sets them as the
+// handler's options.  This is shared by NewHandler and SetOptions.
+func (h *handler) setOptions(opts HandlerOpts) {
+	if opts.Logger == nil {
+		opts.Logger = slog.Default()
+	}
+	opts.Logger = loggerWithSecrets(opts.Logger, opts.GlobalSecretEnvVars)
 
 	if opts.MaxBodySize == 0 {
 		opts.MaxBodySize = DefaultMaxBodySize
 	}
-	if opts.Logger == nil {
-		opts.Logger = slog.Default()
+
+	if err := validateCustomHeaders(opts.CustomHeaders); err != nil {
+		opts.Logger.Error("ignoring invalid custom headers", "error", err)
 	}
 
-	return h
+	h.detectURL = opts.CloudRunAutoDetect && opts.applyCloudRun()
+	h.HandlerOpts = opts
+	h.concurrency = newConcurrency(opts.MaxConcurrentFunctions)
+	h.outbound = newOutboundClient(opts)
 }
 
 func (h *handler) SetAppName(name string) Handler {
@@ -315,6 +575,8 @@ func (h *handler) SetAppName(name string) Handler {
 }
 
 func (h *handler) Register(funcs ...ServableFunction) {
+	h.initFunctions(funcs)
+
 	h.l.Lock()
 	defer h.l.Unlock()
 
@@ -341,7 +603,41 @@ func (h *handler) Register(funcs ...ServableFunction) {
 
 func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	h.Logger.Debug("received http request", "method", r.Method)
+	if h.RequestMetrics != nil {
+		rec := &statusRecorder{ResponseWriter: w}
+		start := time.Now()
+		defer func() {
+			h.RequestMetrics.RecordRequest(r.Method, requestKind(r), rec.Status(), time.Since(start))
+		}()
+		w = rec
+	}
+	if len(h.IPAllowList) > 0 && !h.IPAllowList.allowsRequest(r) {
+		h.Logger.Warn("rejected request from disallowed IP", "remote_addr", r.RemoteAddr)
+		w.Header().Set("content-type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		_ = json.NewEncoder(w).Encode(sdkrequest.ErrorResponse{
+			Message: errForbidden.Error(),
+		})
+		return
+	}
+	if h.GzipResponse {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if acceptsGzip(r) {
+			gw := &gzipResponseWriter{ResponseWriter: w}
+			defer gw.Close()
+			w = gw
+		}
+	}
 	SetBasicResponseHeaders(w)
+	if v := h.GetAppVersion(); v != "" {
+		w.Header().Set(HeaderKeySDK, sdkHeaderValue(v))
+	}
+	for k, v := range h.CustomHeaders {
+		if isProtectedHeader(k) {
+			continue
+		}
+		w.Header().Set(k, v)
+	}
 
 	switch r.Method {
 	case http.MethodGet:
@@ -383,6 +679,13 @@ func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 				status = http.StatusBadRequest
 			} else if errors.Is(err, errUnauthorized) {
 				status = http.StatusUnauthorized
+			} else if errors.Is(err, errShuttingDown) || errors.Is(err, errFunctionUnhealthy) {
+				status = http.StatusServiceUnavailable
+			} else if errors.Is(err, errConcurrencyLimit) {
+				status = http.StatusServiceUnavailable
+				w.Header().Set("Retry-After", concurrencyRetryAfter)
+			} else if errors.Is(err, errForbidden) {
+				status = http.StatusForbidden
 			}
 			w.WriteHeader(status)
 			w.Header().Set("content-type", "application/json")
@@ -420,6 +723,11 @@ func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 // all functions and automatically allows all functions to immediately be triggered
 // by incoming events or schedules.
 func (h *handler) register(w http.ResponseWriter, r *http.Request) error {
+	if err := h.detectCloudRunURL(r.Context()); err != nil {
+		h.Logger.Error("sync error", "error", err)
+		return err
+	}
+
 	var syncKind string
 	var err error
 	if r.Header.Get(HeaderKeySyncKind) == SyncKindInBand && h.IsInBandSyncAllowed() {
@@ -451,7 +759,9 @@ func (i inBandSynchronizeRequest) Validate() error {
 	return nil
 }
 
-type inBandSynchronizeResponse struct {
+// AppManifest is the app's configuration, including its functions, returned
+// to Inngest by in-band syncs.  See HandlerOpts.ResponseSerializer.
+type AppManifest struct {
 	AppID       string            `json:"app_id"`
 	Env         *string           `json:"env"`
 	Framework   *string           `json:"framework"`
@@ -488,18 +798,22 @@ func (h *handler) inBandSync(
 	if max == 0 {
 		max = DefaultMaxBodySize
 	}
-	reqByt, err := io.ReadAll(http.MaxBytesReader(w, r.Body, int64(max)))
+	reqByt, err := h.readBody(w, r, int64(max))
 	if err != nil {
 		return publicerr.Error{
-			Err:    fmt.Errorf("error reading request body"),
-			Status: 500,
+			Err:    err,
+			Status: 400,
 		}
 	}
 
+	signingKey, err := h.signingKey(ctx)
+	if err != nil {
+		return err
+	}
 	valid, skey, err := ValidateRequestSignature(
 		ctx,
 		sig,
-		h.GetSigningKey(),
+		signingKey,
 		h.GetSigningKeyFallback(),
 		reqByt,
 		h.isDev(),
@@ -550,7 +864,7 @@ func (h *handler) inBandSync(
 		appURL = h.URL
 	}
 
-	fns, err := createFunctionConfigs(h.appName, h.funcs, *appURL, false)
+	fns, err := createFunctionConfigs(h.appName, h.funcs, *appURL, false, h.GetEnv())
 	if err != nil {
 		return fmt.Errorf("error creating function configs: %w", err)
 	}
@@ -561,7 +875,7 @@ func (h *handler) inBandSync(
 		env = &val
 	}
 
-	inspection, err := h.createSecureInspection()
+	inspection, err := h.createSecureInspection(signingKey)
 	if err != nil {
 		return fmt.Errorf("error creating inspection: %w", err)
 	}
@@ -570,7 +884,7 @@ func (h *handler) inBandSync(
 		return fmt.Errorf("error converting inspection to map: %w", err)
 	}
 
-	respBody := inBandSynchronizeResponse{
+	respBody := AppManifest{
 		AppID:       h.appName,
 		Env:         env,
 		Functions:   fns,
@@ -581,24 +895,20 @@ func (h *handler) inBandSync(
 		URL:         appURL.String(),
 	}
 
-	respByt, err := json.Marshal(respBody)
-	if err != nil {
-		return fmt.Errorf("error marshalling response: %w", err)
+	serializer := h.ResponseSerializer
+	if serializer == nil {
+		serializer = JSONSerializer{}
 	}
-
-	resSig, err := signWithoutJCS(time.Now(), []byte(skey), respByt)
+	contentType, respByt, err := serializer.Serialize(respBody)
 	if err != nil {
-		return fmt.Errorf("error signing response: %w", err)
+		return fmt.Errorf("error serializing response: %w", err)
 	}
-	w.Header().Add(HeaderKeySignature, resSig)
-	w.Header().Add(HeaderKeyContentType, "application/json")
-	w.Header().Add(HeaderKeySyncKind, SyncKindInBand)
 
-	err = json.NewEncoder(w).Encode(respBody)
-	if err != nil {
+	w.Header().Set(HeaderKeyContentType, contentType)
+	w.Header().Add(HeaderKeySyncKind, SyncKindInBand)
+	if err := writeSigned(w, skey, http.StatusOK, respByt); err != nil {
 		return fmt.Errorf("error writing response: %w", err)
 	}
-
 	return nil
 }
 
@@ -622,11 +932,6 @@ func (h *handler) outOfBandSync(w http.ResponseWriter, r *http.Request) error {
 
 	pathAndParams := r.URL.String()
 
-	appVersion := ""
-	if h.AppVersion != nil {
-		appVersion = *h.AppVersion
-	}
-
 	config := sdk.RegisterRequest{
 		URL:        fmt.Sprintf("%s://%s%s", scheme, host, pathAndParams),
 		V:          "1",
@@ -638,10 +943,10 @@ func (h *handler) outOfBandSync(w http.ResponseWriter, r *http.Request) error {
 			Platform: platform(),
 		},
 		Capabilities: capabilities,
-		AppVersion:   appVersion,
+		AppVersion:   h.GetAppVersion(),
 	}
 
-	fns, err := createFunctionConfigs(h.appName, h.funcs, *h.url(r), false)
+	fns, err := createFunctionConfigs(h.appName, h.funcs, *h.url(r), false, h.GetEnv())
 	if err != nil {
 		return fmt.Errorf("error creating function configs: %w", err)
 	}
@@ -686,13 +991,19 @@ func (h *handler) outOfBandSync(w http.ResponseWriter, r *http.Request) error {
 		}
 
 		SetBasicRequestHeaders(req)
+		req.Header.Set(HeaderKeySDK, sdkHeaderValue(h.GetAppVersion()))
 
 		return req, nil
 	}
 
+	signingKey, err := h.signingKey(r.Context())
+	if err != nil {
+		return err
+	}
 	resp, err := fetchWithAuthFallback(
+		h.httpClient(),
 		createRequest,
-		h.GetSigningKey(),
+		signingKey,
 		h.GetSigningKeyFallback(),
 	)
 	if err != nil {
@@ -731,6 +1042,7 @@ func createFunctionConfigs(
 	fns []ServableFunction,
 	appURL url.URL,
 	isConnect bool,
+	env string,
 ) ([]sdk.SDKFunction, error) {
 	if appName == "" {
 		return nil, fmt.Errorf("missing app name")
@@ -739,15 +1051,28 @@ func createFunctionConfigs(
 		return nil, fmt.Errorf("missing URL")
 	}
 
+	slugs := map[string]struct{}{}
+	for _, fn := range fns {
+		slugs[fn.Slug(appName)] = struct{}{}
+	}
+
 	fnConfigs := make([]sdk.SDKFunction, len(fns))
 	for i, fn := range fns {
 		c := fn.Config()
+		if err := c.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid options for function '%s': %w", fn.Slug(appName), err)
+		}
+		// Validate struct tags up front, so that an invalid tag is caught when
+		// the function is synced rather than failing each run.
+		if c.Schema != nil && c.Schema.ValidateBasicTags {
+			if err := validateTags(reflect.TypeOf(fn.ZeroEvent())); err != nil {
+				return nil, fmt.Errorf("invalid schema for function '%s': %w", fn.Slug(appName), err)
+			}
+		}
 
 		var retries *sdk.StepRetries
-		if c.Retries != nil {
-			retries = &sdk.StepRetries{
-				Attempts: *c.Retries,
-			}
+		if n := c.retries(); n != nil {
+			retries = &sdk.StepRetries{Attempts: *n}
 		}
 
 		// Modify URL to contain fn ID, step params
@@ -789,6 +1114,12 @@ func createFunctionConfigs(
 			}
 		}
 
+		// Skipping duplicates is equivalent to idempotency, the only
+		// deduplication strategy supported by the server.
+		if d := c.deduplication(); d != nil {
+			f.Idempotency = &d.Key
+		}
+
 		if c.BatchEvents != nil {
 			f.EventBatch = map[string]any{
 				"maxSize": c.BatchEvents.MaxSize,
@@ -797,35 +1128,108 @@ func createFunctionConfigs(
 			}
 		}
 
-		if len(c.Concurrency) > 0 {
+		// Buffering is equivalent to batching by key.
+		if c.EventBuffering != nil {
+			f.EventBatch = map[string]any{
+				"maxSize": c.EventBuffering.MaxSize,
+				"timeout": c.EventBuffering.Timeout.String(),
+				"key":     c.EventBuffering.Key,
+			}
+		}
+
+		if c.DLQ != nil {
+			if err := validateDLQ(appName, fn.Slug(appName), *c.DLQ, slugs); err != nil {
+				return nil, fmt.Errorf("invalid options for function '%s': %w", fn.Slug(appName), err)
+			}
+		}
+
+		// Aliases and dependencies are only used by the SDK, to route requests
+		// and to build dependency graphs, so they're validated but not synced.
+		for _, alias := range functionSlugs(fn, appName)[1:] {
+			if _, ok := slugs[alias]; ok {
+				return nil, fmt.Errorf("alias '%s' for function '%s' conflicts with another function's ID", alias, fn.Slug(appName))
+			}
+		}
+		if err := validateDependencies(appName, fn, slugs); err != nil {
+			return nil, fmt.Errorf("invalid dependencies for function '%s': %w", fn.Slug(appName), err)
+		}
+
+		if limits := c.concurrency(); len(limits) > 0 {
 			// Marshal as an array, as the sdk/handler unmarshals correctly.
-			f.Concurrency = &inngest.ConcurrencyLimits{Limits: c.Concurrency}
+			f.Concurrency = &inngest.ConcurrencyLimits{Limits: limits}
 		}
 
 		triggers := fn.Trigger().Triggers()
 		for _, trigger := range triggers {
 			if trigger.EventTrigger != nil {
+				event := trigger.Event
+				if c.EventNamespace != nil {
+					event = namespacedEvent(*c.EventNamespace, event)
+				}
+				if err := validateEventName(event); err != nil {
+					return nil, err
+				}
 				f.Triggers = append(f.Triggers, inngest.Trigger{
 					EventTrigger: &inngest.EventTrigger{
-						Event:      trigger.Event,
-						Expression: trigger.Expression,
+						Event:      event,
+						Expression: envExpression(trigger.Expression, c.Environment[env]),
 					},
 				})
 			} else {
+				cron, err := zonedCron(trigger.Cron, c.Timezone)
+				if err != nil {
+					return nil, err
+				}
 				f.Triggers = append(f.Triggers, inngest.Trigger{
 					CronTrigger: &inngest.CronTrigger{
-						Cron: trigger.Cron,
+						Cron: cron,
 					},
 				})
 			}
 		}
 
+		if c.AutoCancel != nil {
+			// Copy the function's cancellations so that they're not modified.
+			f.Cancel = append(append([]inngest.Cancel{}, c.Cancel...), c.AutoCancel.cancels(f.Triggers)...)
+		}
+
 		fnConfigs[i] = f
 	}
 
 	return fnConfigs, nil
 }
 
+// envExpression combines a trigger's expression with the function's expression
+// for the current environment, if any.
+func envExpression(expr *string, envExpr string) *string {
+	if envExpr == "" {
+		return expr
+	}
+	if expr == nil || *expr == "" {
+		return &envExpr
+	}
+	combined := fmt.Sprintf("(%s) && (%s)", *expr, envExpr)
+	return &combined
+}
+
+// invokableFunction returns the function for the given ID, as sent in the fnId
+// query parameter of invoke requests.
+func (h *handler) invokableFunction(fnID string) ServableFunction {
+	if fn := h.getServableFunctionBySlug(fnID); fn != nil {
+		return fn
+	}
+
+	// Support the old format, which only includes the function slug.
+	h.l.RLock()
+	defer h.l.RUnlock()
+	for _, f := range h.funcs {
+		if f.Slug("") == fnID {
+			return f
+		}
+	}
+	return nil
+}
+
 // invoke handles incoming POST calls to invoke a function, delegating to invoke() after validating
 // the request.
 func (h *handler) invoke(w http.ResponseWriter, r *http.Request) error {
@@ -838,20 +1242,31 @@ func (h *handler) invoke(w http.ResponseWriter, r *http.Request) error {
 		}
 	}
 
-	max := h.HandlerOpts.MaxBodySize
+	fnID := r.URL.Query().Get("fnId")
+	fn := h.invokableFunction(fnID)
+
+	max := int64(h.HandlerOpts.MaxBodySize)
 	if max == 0 {
-		max = DefaultMaxBodySize
+		max = int64(DefaultMaxBodySize)
 	}
-	byt, err := io.ReadAll(http.MaxBytesReader(w, r.Body, int64(max)))
+	if fn != nil && fn.Config().HTTP != nil {
+		max = h.applyFunctionHTTPConfig(w, *fn.Config().HTTP, max)
+	}
+	byt, err := h.readBody(w, r, max)
 	if err != nil {
 		h.Logger.Error("error decoding function request", "error", err)
-		return fmt.Errorf("%w: %s", errBadRequest, err)
+		return err
 	}
 
+	signingKey, err := h.signingKey(r.Context())
+	if err != nil {
+		h.Logger.Error("error getting signing key", "error", err)
+		return err
+	}
 	if valid, _, err := ValidateRequestSignature(
 		r.Context(),
 		sig,
-		h.GetSigningKey(),
+		signingKey,
 		h.GetSigningKeyFallback(),
 		byt,
 		h.isDev(),
@@ -860,8 +1275,6 @@ func (h *handler) invoke(w http.ResponseWriter, r *http.Request) error {
 		return errUnauthorized
 	}
 
-	fnID := r.URL.Query().Get("fnId")
-
 	request := &sdkrequest.Request{}
 	if err := json.Unmarshal(byt, request); err != nil {
 		h.Logger.Error("error decoding function request", "error", err)
@@ -876,45 +1289,64 @@ func (h *handler) invoke(w http.ResponseWriter, r *http.Request) error {
 		_ = 0 // no-op to avoid linter error
 	}
 
-	h.l.RLock()
-	var fn ServableFunction
-	for _, f := range h.funcs {
-		isOldFormat := f.Slug("") == fnID // Only include function slug
-		if f.Slug(h.appName) == fnID || isOldFormat {
-			fn = f
-			break
+	if fn == nil {
+		return fmt.Errorf("%w: %s", errFunctionMissing, fnID)
+	}
+
+	if err := h.FunctionHealth(fnID); err != nil {
+		return fmt.Errorf("%w: %s", errFunctionUnhealthy, err)
+	}
+
+	if h.RequestValidator != nil {
+		if err := h.RequestValidator.Validate(r, fnID); err != nil {
+			h.Logger.Error("invoke request failed validation", "error", err, "fn", fnID)
+			return fmt.Errorf("%w: %s", errForbidden, err)
 		}
 	}
-	h.l.RUnlock()
 
-	if fn == nil {
-		return fmt.Errorf("%w: %s", errFunctionMissing, fnID)
+	var stepID *string
+	if rawStepID := r.URL.Query().Get("stepId"); rawStepID != "" && rawStepID != "step" {
+		stepID = &rawStepID
+	}
+
+	if h.Queue != nil {
+		return h.enqueue(r.Context(), w, fnID, stepID, byt)
 	}
 
-	l := h.Logger.With("fn", fnID, "call_ctx", request.CallCtx)
+	l := loggerWithSecrets(loggerWithLevel(h.Logger, fn.Config().RunLogLevel), fn.Config().SecretEnv).
+		With("fn", fnID, "call_ctx", request.CallCtx)
 	l.Debug("calling function")
 
+	ctx := h.extractTraceContext(r)
 	stream, streamCancel := context.WithCancel(context.Background())
+	// The keepalive is written concurrently with the final response, so guard
+	// the writer.
+	var wl sync.Mutex
 	if h.UseStreaming {
 		w.WriteHeader(201)
 		go func() {
 			for {
+				wl.Lock()
 				if stream.Err() != nil {
+					wl.Unlock()
 					return
 				}
 				_, _ = w.Write([]byte(" "))
+				if f, ok := w.(http.Flusher); ok {
+					f.Flush()
+				}
+				wl.Unlock()
 				<-time.After(5 * time.Second)
 			}
 		}()
 	}
 
-	var stepID *string
-	if rawStepID := r.URL.Query().Get("stepId"); rawStepID != "" && rawStepID != "step" {
-		stepID = &rawStepID
+	if fn.Config().TrackProgress {
+		ctx = sdkrequest.WithProgressReporter(ctx, h.progressReporter(r.Context(), request.CallCtx))
 	}
 
 	// Invoke the function, then immediately stop the streaming buffer.
-	resp, ops, err := invoke(r.Context(), fn, request, stepID)
+	resp, ops, err := h.invokeWithHooks(ctx, fn, request, stepID)
 	streamCancel()
 
 	// NOTE: When triggering step errors, we should have an OpcodeStepError
@@ -944,6 +1376,9 @@ func (h *handler) invoke(w http.ResponseWriter, r *http.Request) error {
 	}
 
 	if h.UseStreaming {
+		// Stop the keepalive from writing after the response.
+		wl.Lock()
+		defer wl.Unlock()
 		if err != nil {
 			// TODO: Add retry-at.
 			return json.NewEncoder(w).Encode(StreamResponse{
@@ -990,6 +1425,27 @@ func (h *handler) invoke(w http.ResponseWriter, r *http.Request) error {
 	return json.NewEncoder(w).Encode(resp)
 }
 
+// applyFunctionHTTPConfig sets the response's read and write deadlines using
+// the function's HTTP config, returning the max body size to read.
+func (h *handler) applyFunctionHTTPConfig(w http.ResponseWriter, c FunctionHTTPConfig, max int64) int64 {
+	rc := http.NewResponseController(w)
+	now := time.Now()
+	if c.ReadTimeout != nil {
+		if err := rc.SetReadDeadline(now.Add(*c.ReadTimeout)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			h.Logger.Error("error setting read deadline", "error", err)
+		}
+	}
+	if c.WriteTimeout != nil {
+		if err := rc.SetWriteDeadline(now.Add(*c.WriteTimeout)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			h.Logger.Error("error setting write deadline", "error", err)
+		}
+	}
+	if c.MaxBodySize != nil {
+		max = *c.MaxBodySize
+	}
+	return max
+}
+
 type insecureInspection struct {
 	SchemaVersion string `json:"schema_version"`
 
@@ -1021,6 +1477,7 @@ type secureInspection struct {
 
 func (h *handler) createInsecureInspection(
 	authenticationSucceeded *bool,
+	signingKey string,
 ) (*insecureInspection, error) {
 	mode := "cloud"
 	if h.isDev() {
@@ -1031,14 +1488,14 @@ func (h *handler) createInsecureInspection(
 		AuthenticationSucceeded: authenticationSucceeded,
 		FunctionCount:           len(h.funcs),
 		HasEventKey:             os.Getenv("INNGEST_EVENT_KEY") != "",
-		HasSigningKey:           h.GetSigningKey() != "",
+		HasSigningKey:           signingKey != "",
 		HasSigningKeyFallback:   h.GetSigningKeyFallback() != "",
 		Mode:                    mode,
 		SchemaVersion:           "2024-05-24",
 	}, nil
 }
 
-func (h *handler) createSecureInspection() (*secureInspection, error) {
+func (h *handler) createSecureInspection(signingKey string) (*secureInspection, error) {
 	apiOrigin := defaultAPIOrigin
 	eventAPIOrigin := defaultEventAPIOrigin
 	if h.isDev() {
@@ -1053,8 +1510,8 @@ func (h *handler) createSecureInspection() (*secureInspection, error) {
 	}
 
 	var signingKeyHash *string
-	if h.GetSigningKey() != "" {
-		key, err := hashedSigningKey([]byte(h.GetSigningKey()))
+	if signingKey != "" {
+		key, err := hashedSigningKey([]byte(signingKey))
 		if err != nil {
 			return nil, fmt.Errorf("error hashing signing key: %w", err)
 		}
@@ -1089,7 +1546,7 @@ func (h *handler) createSecureInspection() (*secureInspection, error) {
 	}
 
 	authenticationSucceeded = true
-	insecureInspection, err := h.createInsecureInspection(&authenticationSucceeded)
+	insecureInspection, err := h.createInsecureInspection(&authenticationSucceeded, signingKey)
 	if err != nil {
 		return nil, fmt.Errorf("error creating inspection: %w", err)
 	}
@@ -1114,18 +1571,23 @@ func (h *handler) createSecureInspection() (*secureInspection, error) {
 func (h *handler) inspect(w http.ResponseWriter, r *http.Request) error {
 	defer r.Body.Close()
 
+	signingKey, err := h.signingKey(r.Context())
+	if err != nil {
+		return err
+	}
+
 	sig := r.Header.Get(HeaderKeySignature)
 	if sig != "" {
 		valid, _, _ := ValidateRequestSignature(
 			r.Context(),
 			sig,
-			h.GetSigningKey(),
+			signingKey,
 			h.GetSigningKeyFallback(),
 			[]byte{},
 			h.isDev(),
 		)
 		if valid {
-			inspection, err := h.createSecureInspection()
+			inspection, err := h.createSecureInspection(signingKey)
 			if err != nil {
 				return err
 			}
@@ -1141,7 +1603,7 @@ func (h *handler) inspect(w http.ResponseWriter, r *http.Request) error {
 		authenticationSucceeded = &val
 	}
 
-	inspection, err := h.createInsecureInspection(authenticationSucceeded)
+	inspection, err := h.createInsecureInspection(authenticationSucceeded, signingKey)
 	if err != nil {
 		return fmt.Errorf("error creating inspection: %w", err)
 	}
@@ -1178,7 +1640,7 @@ func (h *handler) trust(
 	if max == 0 {
 		max = DefaultMaxBodySize
 	}
-	byt, err := io.ReadAll(http.MaxBytesReader(w, r.Body, int64(max)))
+	byt, err := h.readBody(w, r, int64(max))
 	if err != nil {
 		h.Logger.Error("error decoding function request", "error", err)
 		return publicerr.Error{
@@ -1187,10 +1649,14 @@ func (h *handler) trust(
 		}
 	}
 
+	signingKey, err := h.signingKey(ctx)
+	if err != nil {
+		return err
+	}
 	valid, key, err := ValidateRequestSignature(
 		ctx,
 		r.Header.Get("X-Inngest-Signature"),
-		h.GetSigningKey(),
+		signingKey,
 		h.GetSigningKeyFallback(),
 		byt,
 		h.isDev(),
@@ -1213,21 +1679,9 @@ func (h *handler) trust(
 		return err
 	}
 
-	resSig, err := signWithoutJCS(time.Now(), []byte(key), byt)
-	if err != nil {
-		return publicerr.Error{
-			Message: fmt.Sprintf("error signing response: %s", err),
-			Status:  500,
-		}
-	}
-
-	w.Header().Add("X-Inngest-Signature", resSig)
-	w.WriteHeader(200)
-	_, err = w.Write(byt)
-	if err != nil {
+	if err := writeSigned(w, key, http.StatusOK, byt); err != nil {
 		h.Logger.Error("error writing trust probe response", "error", err)
 	}
-
 	return nil
 }
 
@@ -1239,6 +1693,39 @@ type StreamResponse struct {
 	Headers    map[string]string `json:"headers"`
 }
 
+// ProgressEventName is the name of the events sent when a step reports its
+// progress via step.Progress, for functions which set FunctionOpts.TrackProgress.
+// The event's data contains the step's hashed "id" and "name", its "percent"
+// and "message", and the "function_id" and "run_id" of the run.
+const ProgressEventName = "inngest/function.progress"
+
+// progressReporter returns a reporter which sends progress updates for the
+// given run as ProgressEventName events.  Progress is sent out of band, rather
+// than within the response, so that it's visible while the step runs.
+func (h *handler) progressReporter(ctx context.Context, callCtx sdkrequest.CallCtx) sdkrequest.ProgressReporter {
+	client := h.ProgressClient
+	if client == nil {
+		client = NewClient(ClientOpts{HTTPClient: h.httpClient()})
+	}
+	return func(p sdkrequest.Progress) error {
+		_, err := client.Send(ctx, Event{
+			Name: ProgressEventName,
+			Data: map[string]any{
+				"id":          p.StepID,
+				"name":        p.Name,
+				"percent":     p.Percent,
+				"message":     p.Message,
+				"function_id": callCtx.FunctionID,
+				"run_id":      callCtx.RunID,
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("error sending progress: %w", err)
+		}
+		return nil
+	}
+}
+
 // invoke calls a given servable function with the specified input event.  The input event must
 // be fully typed.
 func invoke(
@@ -1246,6 +1733,7 @@ func invoke(
 	sf ServableFunction,
 	input *sdkrequest.Request,
 	stepID *string,
+	enc *StepEncryptionConfig,
 ) (any, []state.GeneratorOpcode, error) {
 	if sf.Func() == nil {
 		// This should never happen, but as sf.Func returns a nillable type we
@@ -1253,17 +1741,116 @@ func invoke(
 		return nil, nil, fmt.Errorf("no function defined")
 	}
 
+	if c := sf.Config().CloudEvent; c != nil {
+		evt, evts, err := c.fromCloudEvents(input.Event, input.Events)
+		if err != nil {
+			return nil, nil, sdkerrors.NoRetryError(fmt.Errorf("error mapping cloud event for function: %w", err))
+		}
+		req := *input
+		req.Event, req.Events = evt, evts
+		input = &req
+	}
+
+	if t := sf.Config().InputTransformer; t != nil {
+		req, err := transformInput(ctx, t, input)
+		if err != nil {
+			return nil, nil, sdkerrors.NoRetryError(fmt.Errorf("error transforming input for function: %w", err))
+		}
+		input = req
+	}
+
 	// Create a new context.  This context is cancellable and stores the opcode that ran
 	// within a step.  This allows us to prevent any execution of future tools after a
 	// tool has run.
 	fCtx, cancel := context.WithCancel(context.Background())
+	// Cancelling ctx force-cancels the function, eg. when the handler shuts
+	// down.
+	stop := context.AfterFunc(ctx, cancel)
+	defer stop()
 	if stepID != nil {
 		fCtx = step.SetTargetStepID(fCtx, *stepID)
 	}
 
 	// This must be a pointer so that it can be mutated from within function tools.
-	mgr := sdkrequest.NewManager(cancel, input)
+	logger := sdkrequest.LoggerFromContext(ctx)
+	mgr := sdkrequest.NewManagerWithLogger(cancel, input, logger)
+	if enc != nil {
+		var err error
+		mgr, err = sdkrequest.NewEncryptedManager(cancel, input, sdkrequest.Encryption{
+			Key:          enc.Key,
+			KeyID:        enc.KeyID,
+			PreviousKeys: enc.PreviousKeys,
+		}, logger)
+		if err != nil {
+			cancel()
+			return nil, nil, err
+		}
+	}
+	if hasher := sdkrequest.StepIDHasherFromContext(ctx); hasher != nil {
+		mgr.SetStepIDHasher(hasher)
+	}
+	if restorer := sdkrequest.StepRestorerFromContext(ctx); restorer != nil {
+		mgr.SetStepRestorer(restorer)
+	}
+	if rules := sf.Config().DataMasking; len(rules) > 0 {
+		mgr.SetOutputMasker(func(data json.RawMessage) (json.RawMessage, error) {
+			return maskJSON(rules, data)
+		})
+	}
+
+	// Functions using SideEffectOnce aren't called again once their output is
+	// saved, so that re-executing the run doesn't repeat their side effects.
+	once := sf.Config().SideEffectMode == SideEffectOnce
+	if once {
+		if val, ok := mgr.Step(mgr.PeekOp(enums.OpcodeStepRun, sideEffectOnceStepID)); ok {
+			cancel()
+			if err := mgr.Err(); err != nil {
+				return nil, nil, err
+			}
+			return sideEffectOnceOutput(val), nil, nil
+		}
+	}
+
+	if report := sdkrequest.ProgressReporterFromContext(ctx); report != nil {
+		fCtx = sdkrequest.WithProgressReporter(fCtx, report)
+	}
+	if emit := cloudEventEmitterFromContext(ctx); emit != nil {
+		fCtx = withCloudEventEmitter(fCtx, emit)
+	}
+	if sc, ok := tracing.SpanContextFromContext(ctx); ok {
+		fCtx = tracing.ContextWithSpanContext(fCtx, sc)
+	}
 	fCtx = sdkrequest.SetManager(fCtx, mgr)
+	fCtx = withFeatureFlags(fCtx, sf.Config().FeatureFlags, input.CallCtx.FeatureFlags)
+	fCtx = withLogLevel(fCtx, sf.Config().RunLogLevel)
+	fCtx = withEventNamespace(fCtx, sf.Config().EventNamespace)
+	if n := sf.Config().MaxParallelSteps; n != nil {
+		fCtx = step.WithMaxParallelSteps(fCtx, *n)
+	}
+	if sf.Config().RetryOnPanic {
+		fCtx = step.WithRetryOnPanic(fCtx)
+	}
+	if d := sf.Config().StepTimeout; d != nil {
+		fCtx = step.WithStepTimeout(fCtx, *d)
+	}
+	if d := sf.Config().GracefulDegradation; d != nil {
+		fCtx = step.WithFallback(fCtx, step.Fallback{
+			Provider: d.FallbackProvider,
+			Errors:   d.FallbackOnErrors,
+		})
+	}
+	acked := func() (step.AckStatus, string) { return step.AckAcked, "" }
+	if ack := sf.Config().EventAck; ack.manual() {
+		// The function must acknowledge its event within AckTimeout of this
+		// request starting, so that retries aren't timed out immediately.
+		var stopAck func()
+		deadline := time.Now().Add(ack.AckTimeout)
+		fCtx, acked, stopAck = step.WithManualAck(fCtx, deadline)
+		defer stopAck()
+	}
+	if hooks := sf.Config().Hooks; hooks.OnStepStart != nil || hooks.OnStepEnd != nil {
+		fCtx = sdkrequest.WithStepHooks(fCtx, sdkrequest.StepHooks(hooks))
+	}
 
 	// Create a new Input type.  We don't know ahead of time the type signature as
 	// this is generic;  we instead grab the generic event element and instantiate
@@ -1275,9 +1862,20 @@ func invoke(
 	if sf.ZeroEvent() != nil {
 		eventType := reflect.TypeOf(sf.ZeroEvent())
 
+		if schema := sf.Config().Schema; schema != nil && schema.StrictAdditionalProperties {
+			// Batches include the triggering event within Events.
+			events := input.Events
+			if len(events) == 0 {
+				events = []json.RawMessage{input.Event}
+			}
+			if err := validateAdditionalProperties(eventType, events...); err != nil {
+				return nil, nil, err
+			}
+		}
+
 		// Create a new copy of the event.
 		evtPtr := reflect.New(eventType).Interface()
-		if err := json.Unmarshal(input.Event, evtPtr); err != nil {
+		if err := unmarshalInput(sf.Config().InputCoercion, input.Event, evtPtr); err != nil {
 			return nil, nil, fmt.Errorf("error unmarshalling event for function: %w", err)
 		}
 		evt := reflect.ValueOf(evtPtr).Elem()
@@ -1290,13 +1888,27 @@ func invoke(
 		for _, rawjson := range input.Events {
 			newEvent := reflect.New(eventType).Interface()
 
-			if err := json.Unmarshal(rawjson, &newEvent); err != nil {
+			if err := unmarshalInput(sf.Config().InputCoercion, rawjson, newEvent); err != nil {
 				return nil, nil, fmt.Errorf("non-zero event: error unmarshalling event in event list: %w", err)
 			}
 
 			evtList = reflect.Append(evtList, reflect.ValueOf(newEvent).Elem())
 		}
 		inputVal.FieldByName("Events").Set(evtList)
+
+		if schema := sf.Config().Schema; schema != nil {
+			// Batches include the triggering event within Events.
+			events := []reflect.Value{evt}
+			if evtList.Len() > 0 {
+				events = events[:0]
+				for i := 0; i < evtList.Len(); i++ {
+					events = append(events, evtList.Index(i))
+				}
+			}
+			if err := validateInput(*schema, events...); err != nil {
+				return nil, nil, err
+			}
+		}
 	} else {
 		// Use a raw map to hold the input.
 		val := map[string]any{}
@@ -1343,6 +1955,12 @@ func invoke(
 				if _, ok := r.(step.ControlHijack); ok {
 					return
 				}
+				// step.MustRun panics with the step's error, which fails the
+				// function as if the error was returned.
+				if p, ok := r.(step.MustRunPanic); ok {
+					panickErr = p.Cause
+					return
+				}
 				stack := string(debug.Stack())
 				panickErr = fmt.Errorf("function panicked: %v.  stack:\n%s", r, stack)
 			}
@@ -1373,5 +1991,53 @@ func invoke(
 		response = res[0].Interface()
 	}
 
-	return response, mgr.Ops(), err
+	ops := mgr.Ops()
+	if len(ops) == 0 {
+		// The run fails with a retryable error, so that the event is
+		// redelivered, if the function rejects its event or finishes without
+		// acknowledging it.
+		switch status, reason := acked(); {
+		case status == step.AckNacked:
+			err = fmt.Errorf("function rejected its event: %s", reason)
+		case status == step.AckTimedOut:
+			err = fmt.Errorf("function didn't acknowledge its event within %s", sf.Config().EventAck.AckTimeout)
+		case err == nil && status == step.AckPending:
+			err = fmt.Errorf("function finished without acknowledging its event")
+		}
+	}
+
+	// Only transform the output once the function has finished.
+	if t := sf.Config().OutputTransformer; t != nil && len(ops) == 0 {
+		response, err = t(ctx, response, err)
+	}
+
+	// Save the output of functions using SideEffectOnce as a step, which
+	// marks the function as done when the run is re-executed.
+	if once && len(ops) == 0 && err == nil {
+		byt, err := json.Marshal(response)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error marshalling function output: %w", err)
+		}
+		mgr.AppendOp(state.GeneratorOpcode{
+			ID:   mgr.PeekOp(enums.OpcodeStepRun, sideEffectOnceStepID).MustHash(),
+			Op:   enums.OpcodeStepRun,
+			Name: sideEffectOnceStepID,
+			Data: byt,
+		})
+		return nil, mgr.Ops(), mgr.Err()
+	}
+
+	return response, ops, err
+}
+
+// sideEffectOnceOutput returns the function output saved within the given
+// step state.
+func sideEffectOnceOutput(val json.RawMessage) json.RawMessage {
+	wrapped := struct {
+		Data json.RawMessage `json:"data"`
+	}{}
+	if err := json.Unmarshal(val, &wrapped); err == nil && len(wrapped.Data) > 0 {
+		return wrapped.Data
+	}
+	return val
 }
@@ -2,13 +2,19 @@ package inngestgo
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"reflect"
+	"slices"
+	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -19,7 +25,9 @@ import (
 	"github.com/inngest/inngest/pkg/inngest"
 	"github.com/inngest/inngest/pkg/sdk"
 	"github.com/inngest/inngest/pkg/syscode"
+	sdkerrors "github.com/khulnasoft-lab/inngestgo/errors"
 	"github.com/khulnasoft-lab/inngestgo/internal/sdkrequest"
+	"github.com/khulnasoft-lab/inngestgo/jsonschema"
 	"github.com/khulnasoft-lab/inngestgo/step"
 	"github.com/stretchr/testify/require"
 )
@@ -101,7 +109,7 @@ func TestInvoke(t *testing.T) {
 		Register(a)
 
 		t.Run("it invokes the function with correct types", func(t *testing.T) {
-			actual, op, err := invoke(ctx, a, createRequest(t, input), nil)
+			actual, op, err := invoke(ctx, a, createRequest(t, input), nil, nil)
 			require.NoError(t, err)
 			require.Nil(t, op)
 			require.Equal(t, resp, actual)
@@ -135,7 +143,7 @@ func TestInvoke(t *testing.T) {
 		Register(a)
 
 		t.Run("it invokes the function with correct types", func(t *testing.T) {
-			actual, op, err := invoke(ctx, a, createBatchRequest(t, input, 5), nil)
+			actual, op, err := invoke(ctx, a, createBatchRequest(t, input, 5), nil, nil)
 			require.NoError(t, err)
 			require.Nil(t, op)
 			require.Equal(t, resp, actual)
@@ -170,7 +178,7 @@ func TestInvoke(t *testing.T) {
 		ctx := context.Background()
 
 		t.Run("it invokes the function with correct types", func(t *testing.T) {
-			actual, op, err := invoke(ctx, a, createRequest(t, input), nil)
+			actual, op, err := invoke(ctx, a, createRequest(t, input), nil, nil)
 			require.NoError(t, err)
 			require.Nil(t, op)
 			require.Equal(t, resp, actual)
@@ -208,7 +216,7 @@ func TestInvoke(t *testing.T) {
 
 		ctx := context.Background()
 		t.Run("it invokes the function with correct types", func(t *testing.T) {
-			actual, op, err := invoke(ctx, a, createRequest(t, input), nil)
+			actual, op, err := invoke(ctx, a, createRequest(t, input), nil, nil)
 			require.NoError(t, err)
 			require.Nil(t, op)
 			require.Equal(t, resp, actual)
@@ -245,7 +253,7 @@ func TestInvoke(t *testing.T) {
 
 		ctx := context.Background()
 		t.Run("it invokes the function with correct types", func(t *testing.T) {
-			actual, op, err := invoke(ctx, a, createRequest(t, input), nil)
+			actual, op, err := invoke(ctx, a, createRequest(t, input), nil, nil)
 			require.NoError(t, err)
 			require.Nil(t, op)
 			require.Equal(t, resp, actual)
@@ -269,6 +277,266 @@ func TestInvoke(t *testing.T) {
 		})
 	})
 
+	t.Run("With a CloudEvent", func(t *testing.T) {
+		ctx := context.Background()
+		r := require.New(t)
+
+		type CloudEventA struct {
+			ID       string         `json:"id"`
+			Name     string         `json:"name"`
+			Data     map[string]any `json:"data"`
+			TS       int64          `json:"ts"`
+			Metadata map[string]any `json:"metadata"`
+		}
+
+		a := CreateFunction(
+			FunctionOpts{Name: "my func name", CloudEvent: &CloudEventConfig{}},
+			EventTrigger("test/event.a", nil),
+			func(ctx context.Context, event Input[CloudEventA]) (any, error) {
+				return event.Event, nil
+			},
+		)
+
+		ce := map[string]any{
+			"specversion": "1.0",
+			"id":          "evt-1",
+			"type":        "test/event.a",
+			"source":      "/payments",
+			"time":        "2024-01-02T03:04:05Z",
+			"data":        map[string]any{"foo": "potato"},
+		}
+
+		actual, op, err := invoke(ctx, a, createRequest(t, ce), nil, nil)
+		r.NoError(err)
+		r.Nil(op)
+		r.Equal(CloudEventA{
+			ID:   "evt-1",
+			Name: "test/event.a",
+			Data: map[string]any{"foo": "potato"},
+			TS:   time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC).UnixMilli(),
+			Metadata: map[string]any{
+				"source":      "/payments",
+				"specversion": "1.0",
+			},
+		}, actual)
+
+		t.Run("binary data", func(t *testing.T) {
+			binary := map[string]any{
+				"specversion": "1.0",
+				"type":        "test/event.a",
+				"source":      "/payments",
+				"data_base64": base64.StdEncoding.EncodeToString([]byte(`{"foo":"potato"}`)),
+			}
+			actual, _, err := invoke(ctx, a, createRequest(t, binary), nil, nil)
+			r.NoError(err)
+			r.Equal(map[string]any{"foo": "potato"}, actual.(CloudEventA).Data)
+
+			binary["data_base64"] = base64.StdEncoding.EncodeToString([]byte{0xde, 0xad})
+			actual, _, err = invoke(ctx, a, createRequest(t, binary), nil, nil)
+			r.NoError(err)
+			r.Equal(map[string]any{"data": "3q0="}, actual.(CloudEventA).Data)
+		})
+
+		t.Run("native events are unchanged", func(t *testing.T) {
+			actual, _, err := invoke(ctx, a, createRequest(t, map[string]any{
+				"name": "test/event.a",
+				"data": map[string]any{"foo": "potato"},
+			}), nil, nil)
+			r.NoError(err)
+			r.Equal(CloudEventA{
+				Name: "test/event.a",
+				Data: map[string]any{"foo": "potato"},
+			}, actual)
+		})
+
+		t.Run("strict mode rejects native events", func(t *testing.T) {
+			strict := CreateFunction(
+				FunctionOpts{Name: "my func name", CloudEvent: &CloudEventConfig{Strict: true}},
+				EventTrigger("test/event.a", nil),
+				func(ctx context.Context, event Input[CloudEventA]) (any, error) {
+					return nil, nil
+				},
+			)
+			_, _, err := invoke(ctx, strict, createRequest(t, map[string]any{
+				"name": "test/event.a",
+			}), nil, nil)
+			r.Error(err)
+			r.True(sdkerrors.IsNoRetryError(err))
+		})
+	})
+
+	t.Run("With feature flags", func(t *testing.T) {
+		ctx := context.Background()
+		r := require.New(t)
+
+		a := CreateFunction(
+			FunctionOpts{Name: "my func name"},
+			EventTrigger("test/event.a", nil),
+			func(ctx context.Context, event Input[any]) (any, error) {
+				return map[string]bool{
+					"new-pricing": FeatureFlagEnabled(ctx, "new-pricing"),
+					"new-emails":  FeatureFlagEnabled(ctx, "new-emails"),
+					"undeclared":  FeatureFlagEnabled(ctx, "undeclared"),
+				}, nil
+			},
+			WithFeatureFlag("new-pricing", "new-emails"),
+		)
+
+		req := createRequest(t, map[string]any{"name": "test/event.a"})
+		req.CallCtx.FeatureFlags = map[string]bool{
+			"new-pricing": true,
+			"undeclared":  true,
+		}
+		actual, _, err := invoke(ctx, a, req, nil, nil)
+		r.NoError(err)
+		r.Equal(map[string]bool{
+			"new-pricing": true,
+			"new-emails":  false,
+			"undeclared":  false,
+		}, actual)
+		r.False(FeatureFlagEnabled(ctx, "new-pricing"))
+	})
+
+	t.Run("With step encryption", func(t *testing.T) {
+		ctx := context.Background()
+		r := require.New(t)
+
+		enc := &StepEncryptionConfig{Key: bytes.Repeat([]byte("k"), 32), KeyID: "key-1"}
+		a := CreateFunction(
+			FunctionOpts{Name: "my func name"},
+			EventTrigger("test/event.a", nil),
+			func(ctx context.Context, event Input[any]) (any, error) {
+				return step.Run(ctx, "card", func(ctx context.Context) (string, error) {
+					return "4242424242424242", nil
+				})
+			},
+		)
+
+		_, ops, err := invoke(ctx, a, createRequest(t, map[string]any{"name": "test/event.a"}), nil, enc)
+		r.NoError(err)
+		r.Len(ops, 1)
+		r.NotContains(string(ops[0].Data), "4242424242424242")
+		r.Contains(string(ops[0].Data), `"key-1"`)
+
+		replay := func(enc *StepEncryptionConfig) (any, error) {
+			req := createRequest(t, map[string]any{"name": "test/event.a"})
+			req.Steps = map[string]json.RawMessage{
+				ops[0].ID: json.RawMessage(fmt.Sprintf(`{"data":%s}`, ops[0].Data)),
+			}
+			actual, _, err := invoke(ctx, a, req, nil, enc)
+			return actual, err
+		}
+
+		actual, err := replay(enc)
+		r.NoError(err)
+		r.Equal("4242424242424242", actual)
+
+		_, err = replay(&StepEncryptionConfig{Key: enc.Key, KeyID: "key-2"})
+		r.ErrorContains(err, "key-1")
+
+		// After rotating keys, old state is decrypted using the previous key.
+		rotated := &StepEncryptionConfig{
+			Key:          bytes.Repeat([]byte("n"), 32),
+			KeyID:        "key-2",
+			PreviousKeys: map[string][]byte{"key-1": enc.Key},
+		}
+		actual, err = replay(rotated)
+		r.NoError(err)
+		r.Equal("4242424242424242", actual)
+
+		// Previous keys must not be used under the wrong key ID.
+		_, err = replay(&StepEncryptionConfig{
+			Key:          rotated.Key,
+			KeyID:        "key-3",
+			PreviousKeys: map[string][]byte{"key-1": rotated.Key},
+		})
+		r.ErrorContains(err, "error decrypting step state")
+
+		_, err = replay(&StepEncryptionConfig{Key: rotated.Key, KeyID: "key-2", PreviousKeys: map[string][]byte{"key-1": []byte("short")}})
+		r.ErrorContains(err, "32 bytes")
+
+		_, err = replay(&StepEncryptionConfig{Key: []byte("short")})
+		r.ErrorContains(err, "32 bytes")
+	})
+
+	t.Run("With data masking", func(t *testing.T) {
+		ctx := context.Background()
+		r := require.New(t)
+
+		var email string
+		a := CreateFunction(
+			FunctionOpts{
+				Name: "my func name",
+				DataMasking: []MaskRule{
+					{Path: "user.email", MaskFunc: MaskingPresets.MaskEmail()},
+					{Path: "user.card"},
+				},
+			},
+			EventTrigger("test/event.a", nil),
+			func(ctx context.Context, event Input[map[string]any]) (any, error) {
+				// Event data isn't masked.
+				email = event.Event["data"].(map[string]any)["email"].(string)
+				return step.Run(ctx, "user", func(ctx context.Context) (map[string]any, error) {
+					return map[string]any{
+						"user": map[string]any{"email": email, "card": 4242424242424242},
+					}, nil
+				})
+			},
+		)
+
+		req := createRequest(t, map[string]any{
+			"name": "test/event.a",
+			"data": map[string]any{"email": "jane@example.com"},
+		})
+		_, ops, err := invoke(ctx, a, req, nil, nil)
+		r.NoError(err)
+		r.Equal("jane@example.com", email)
+		r.Len(ops, 1)
+		r.JSONEq(`{"user":{"email":"j***@example.com","card":"***"}}`, string(ops[0].Data))
+
+		// Results are masked before they're encrypted.
+		enc := &StepEncryptionConfig{Key: bytes.Repeat([]byte("k"), 32), KeyID: "key-1"}
+		_, ops, err = invoke(ctx, a, req, nil, enc)
+		r.NoError(err)
+		req.Steps = map[string]json.RawMessage{
+			ops[0].ID: json.RawMessage(fmt.Sprintf(`{"data":%s}`, ops[0].Data)),
+		}
+		actual, _, err := invoke(ctx, a, req, nil, enc)
+		r.NoError(err)
+		r.Equal(map[string]any{
+			"user": map[string]any{"email": "j***@example.com", "card": "***"},
+		}, actual)
+	})
+
+	t.Run("With graceful degradation", func(t *testing.T) {
+		ctx := context.Background()
+		r := require.New(t)
+
+		a := CreateFunction(
+			FunctionOpts{
+				Name: "my func name",
+				GracefulDegradation: &DegradationConfig{
+					FallbackProvider: func(ctx context.Context, stepID string) (any, error) {
+						return "cached", nil
+					},
+				},
+			},
+			EventTrigger("test/event.a", nil),
+			func(ctx context.Context, event Input[any]) (any, error) {
+				return step.Run(ctx, "prices", func(ctx context.Context) (string, error) {
+					return "", fmt.Errorf("unavailable")
+				})
+			},
+		)
+
+		_, ops, err := invoke(ctx, a, createRequest(t, map[string]any{"name": "test/event.a"}), nil, nil)
+		r.NoError(err)
+		r.Len(ops, 1)
+		r.Equal(enums.OpcodeStepRun, ops[0].Op)
+		r.JSONEq(`"cached"`, string(ops[0].Data))
+		r.EqualError(FunctionOpts{Name: "fn", GracefulDegradation: &DegradationConfig{}}.Validate(), "GracefulDegradation.FallbackProvider must be set")
+	})
+
 	t.Run("captures panic stack", func(t *testing.T) {
 		ctx := context.Background()
 		r := require.New(t)
@@ -286,6 +554,7 @@ func TestInvoke(t *testing.T) {
 			ctx, a,
 			createRequest(t, EventA{Name: "my-event"}),
 			nil,
+			nil,
 		)
 		r.Nil(actual)
 		r.Nil(op)
@@ -368,6 +637,17 @@ func TestServe(t *testing.T) {
 		defer resp.Body.Close()
 		require.Equal(t, 410, resp.StatusCode)
 	})
+
+	t.Run("It includes the app version in the SDK header", func(t *testing.T) {
+		h := NewHandler("Go app", HandlerOpts{AppVersion: StrPtr("v1.2.3")})
+		server := httptest.NewServer(h)
+		defer server.Close()
+
+		resp, err := http.Get(server.URL)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, fmt.Sprintf("go:v%s/app:v1.2.3", SDKVersion), resp.Header.Get(HeaderKeySDK))
+	})
 }
 
 func TestSteps(t *testing.T) {
@@ -788,12 +1068,12 @@ func TestInBandSync(t *testing.T) {
 		r.Equal(http.StatusOK, resp.StatusCode)
 		r.Equal(resp.Header.Get("x-inngest-sync-kind"), "in_band")
 
-		var respBody inBandSynchronizeResponse
+		var respBody AppManifest
 		err = json.NewDecoder(resp.Body).Decode(&respBody)
 		r.NoError(err)
 
 		r.Equal(
-			inBandSynchronizeResponse{
+			AppManifest{
 				AppID: appID,
 				Env:   toPtr("my-env"),
 				Functions: []sdk.SDKFunction{{
@@ -845,6 +1125,100 @@ func TestInBandSync(t *testing.T) {
 		)
 	})
 
+	t.Run("gzip response", func(t *testing.T) {
+		// The response signature is computed over the compressed body.
+		r := require.New(t)
+		gh := NewHandler(appID, HandlerOpts{
+			AllowInBandSync: toPtr(true),
+			GzipResponse:    true,
+		})
+		gh.Register(fn)
+		gserver := httptest.NewServer(gh)
+		defer gserver.Close()
+
+		call := func(acceptEncoding string) (*http.Response, []byte) {
+			sig, _ := Sign(context.Background(), time.Now(), []byte(testKey), reqBodyByt)
+			req, err := http.NewRequest(http.MethodPut, gserver.URL, bytes.NewReader(reqBodyByt))
+			r.NoError(err)
+			req.Header.Set("x-inngest-signature", sig)
+			req.Header.Set("x-inngest-sync-kind", "in_band")
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+			resp, err := http.DefaultClient.Do(req)
+			r.NoError(err)
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			r.NoError(err)
+			return resp, body
+		}
+
+		resp, body := call("br, gzip")
+		r.Equal(http.StatusOK, resp.StatusCode)
+		r.Equal("gzip", resp.Header.Get("Content-Encoding"))
+		valid, err := ValidateResponseSignature(context.Background(), resp.Header.Get("x-inngest-signature"), []byte(testKey), body)
+		r.NoError(err)
+		r.True(valid)
+
+		gr, err := gzip.NewReader(bytes.NewReader(body))
+		r.NoError(err)
+		var respBody AppManifest
+		r.NoError(json.NewDecoder(gr).Decode(&respBody))
+		r.Equal(appID, respBody.AppID)
+		r.Len(respBody.Functions, 1)
+
+		resp, body = call("identity")
+		r.Empty(resp.Header.Get("Content-Encoding"))
+		valid, err = ValidateResponseSignature(context.Background(), resp.Header.Get("x-inngest-signature"), []byte(testKey), body)
+		r.NoError(err)
+		r.True(valid)
+		r.NoError(json.Unmarshal(body, &respBody))
+
+		// Unsigned responses are compressed too.
+		req, err := http.NewRequest(http.MethodGet, gserver.URL, nil)
+		r.NoError(err)
+		req.Header.Set("Accept-Encoding", "gzip")
+		resp, err = http.DefaultClient.Do(req)
+		r.NoError(err)
+		defer resp.Body.Close()
+		r.Equal("gzip", resp.Header.Get("Content-Encoding"))
+		gr, err = gzip.NewReader(resp.Body)
+		r.NoError(err)
+		inspection := map[string]any{}
+		r.NoError(json.NewDecoder(gr).Decode(&inspection))
+		r.Equal(float64(1), inspection["function_count"])
+	})
+
+	t.Run("custom serializer", func(t *testing.T) {
+		// The response signature is computed over the serialized body.
+		r := require.New(t)
+		sh := NewHandler(appID, HandlerOpts{
+			AllowInBandSync:    toPtr(true),
+			ResponseSerializer: MessagePackSerializer{},
+		})
+		sh.Register(fn)
+		sserver := httptest.NewServer(sh)
+		defer sserver.Close()
+
+		sig, _ := Sign(context.Background(), time.Now(), []byte(testKey), reqBodyByt)
+		req, err := http.NewRequest(http.MethodPut, sserver.URL, bytes.NewReader(reqBodyByt))
+		r.NoError(err)
+		req.Header.Set("x-inngest-signature", sig)
+		req.Header.Set("x-inngest-sync-kind", "in_band")
+		resp, err := http.DefaultClient.Do(req)
+		r.NoError(err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		r.NoError(err)
+
+		r.Equal(http.StatusOK, resp.StatusCode)
+		r.Equal("application/msgpack", resp.Header.Get("Content-Type"))
+		valid, err := ValidateResponseSignature(context.Background(), resp.Header.Get("x-inngest-signature"), []byte(testKey), body)
+		r.NoError(err)
+		r.True(valid)
+		// The body is a map, with the "app_id" key sorted first.
+		r.Equal(byte(0x80), body[0]&0xf0)
+		r.Equal("\xa6app_id", string(body[1:8]))
+	})
+
 	t.Run("invalid signature", func(t *testing.T) {
 		// SDK responds with an error when receiving an in-band sync request
 		// with an invalid signature
@@ -953,66 +1327,2297 @@ func TestInBandSync(t *testing.T) {
 	})
 }
 
-func createRequest(t *testing.T, evt any) *sdkrequest.Request {
-	t.Helper()
-
-	byt, err := json.Marshal(evt)
-	require.NoError(t, err)
+func TestCustomHeaders(t *testing.T) {
+	setEnvVars(t)
+	ctx := context.Background()
 
-	return &sdkrequest.Request{
-		Event: byt,
-		CallCtx: sdkrequest.CallCtx{
-			FunctionID: "fn-id",
-			RunID:      "run-id",
+	h := NewHandler("test-custom-headers", HandlerOpts{
+		AllowInBandSync: toPtr(true),
+		CustomHeaders: map[string]string{
+			"X-API-Version":       "2024-01-01",
+			"X-Deployment-ID":     "deploy-1",
+			"Content-Type":        "text/plain",
+			"X-Inngest-Sync-Kind": "out_of_band",
 		},
+	})
+	server := httptest.NewServer(h)
+	defer server.Close()
+
+	do := func(t *testing.T, method, url string, body []byte, headers map[string]string) *http.Response {
+		sig, _ := Sign(ctx, time.Now(), []byte(testKey), body)
+		req, err := http.NewRequest(method, url, bytes.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set(HeaderKeySignature, sig)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = resp.Body.Close() })
+		return resp
+	}
+
+	requireHeaders := func(t *testing.T, resp *http.Response) {
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, "2024-01-01", resp.Header.Get("X-API-Version"))
+		require.Equal(t, "deploy-1", resp.Header.Get("X-Deployment-ID"))
+		require.NotContains(t, resp.Header.Values(HeaderKeyContentType), "text/plain")
 	}
+
+	t.Run("trust probe", func(t *testing.T) {
+		resp := do(t, http.MethodPost, server.URL+"?probe=trust", []byte{}, nil)
+		requireHeaders(t, resp)
+	})
+
+	t.Run("sync", func(t *testing.T) {
+		body, _ := json.Marshal(inBandSynchronizeRequest{URL: "http://test.local"})
+		resp := do(t, http.MethodPut, server.URL, body, map[string]string{
+			HeaderKeySyncKind: SyncKindInBand,
+		})
+		requireHeaders(t, resp)
+		require.Equal(t, SyncKindInBand, resp.Header.Get(HeaderKeySyncKind))
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		require.NoError(t, validateCustomHeaders(map[string]string{"X-API-Version": "1"}))
+		require.Error(t, validateCustomHeaders(map[string]string{"x-inngest-signature": "sig"}))
+		require.Error(t, validateCustomHeaders(map[string]string{"content-type": "text/plain"}))
+	})
 }
 
-func createBatchRequest(t *testing.T, evt any, num int) *sdkrequest.Request {
-	t.Helper()
+func TestFunctionConfigs(t *testing.T) {
+	appURL, _ := url.Parse("http://test.local")
+	noop := func(ctx context.Context, input Input[any]) (any, error) {
+		return nil, nil
+	}
 
-	events := make([]json.RawMessage, num)
-	for i := 0; i < num; i++ {
-		byt, err := json.Marshal(evt)
+	// manifest returns the JSON manifest for a single function.
+	manifest := func(t *testing.T, fn ServableFunction) map[string]any {
+		t.Helper()
+		fns, err := createFunctionConfigs("app", []ServableFunction{fn}, *appURL, false, "")
 		require.NoError(t, err)
-		events[i] = byt
-	}
+		require.Len(t, fns, 1)
 
-	return &sdkrequest.Request{
-		Event:  events[0],
-		Events: events,
-		CallCtx: sdkrequest.CallCtx{
-			FunctionID: "fn-id",
-			RunID:      "run-id",
-		},
+		byt, err := json.Marshal(fns[0])
+		require.NoError(t, err)
+		out := map[string]any{}
+		require.NoError(t, json.Unmarshal(byt, &out))
+		return out
 	}
-}
 
-func handlerPost(t *testing.T, url string, r *sdkrequest.Request) *http.Response {
-	t.Helper()
+	t.Run("only upstream fields", func(t *testing.T) {
+		// Options which the server doesn't support are enforced by the SDK or
+		// mapped onto upstream fields, so the manifest only ever contains
+		// fields which sdk.SDKFunction defines.
+		upstream := map[string]bool{}
+		typ := reflect.TypeOf(sdk.SDKFunction{})
+		for i := 0; i < typ.NumField(); i++ {
+			name, _, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+			upstream[name] = true
+		}
 
-	body := marshalRequest(t, r)
-	sig, _ := Sign(context.Background(), time.Now(), []byte(testKey), body)
+		fn := CreateFunction(
+			FunctionOpts{
+				ID:                 "charge-card",
+				Name:               "Charge card",
+				Aliases:            []string{"charge"},
+				DependsOn:          []string{"emails/send-receipt"},
+				EventDeduplication: &DeduplicationConfig{Strategy: DeduplicateSkip, Key: "event.data.orderId"},
+				EventBuffering:     &BufferingConfig{MaxSize: 10, Timeout: time.Minute, Key: "event.data.orderId"},
+				Retry:              WithBoundedExponentialBackoff(5, time.Second, time.Hour, WithFullJitter()),
+				RateLimit:          &RateLimit{Limit: 10, Period: time.Minute},
+				StepTimeout:        Ptr(time.Minute),
+				MaxParallelSteps:   IntPtr(5),
+				Timezone:           "America/New_York",
+				ResourceLimits:     &ResourceConfig{MaxMemoryMB: 512},
+				EventAck:           &AckConfig{Mode: AckModeManual, AckTimeout: time.Minute},
+				WarmPool:           IntPtr(2),
+				Region:             StrPtr("eu-west-1"),
+			},
+			EventTrigger("order/created", nil),
+			noop,
+		)
+		for field := range manifest(t, fn) {
+			require.True(t, upstream[field], "unsupported field %q synced", field)
+		}
+	})
 
-	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
-	require.NoError(t, err)
-	req.Header.Set("X-Inngest-Signature", sig)
+	t.Run("wildcard triggers", func(t *testing.T) {
+		t.Run("catch-all", func(t *testing.T) {
+			fn := CreateFunction(FunctionOpts{Name: "audit"}, EventTrigger(WildcardEvent, nil), noop)
+			require.Equal(t, []any{
+				map[string]any{"event": "*"},
+			}, manifest(t, fn)["triggers"])
+		})
 
-	resp, err := http.DefaultClient.Do(req)
-	require.NoError(t, err)
-	return resp
-}
+		t.Run("prefix with expression", func(t *testing.T) {
+			fn := CreateFunction(
+				FunctionOpts{Name: "payments"},
+				EventTrigger("payments/*", StrPtr("event.data.amount > 100")),
+				noop,
+			)
+			require.Equal(t, []any{
+				map[string]any{"event": "payments/*", "expression": "event.data.amount > 100"},
+			}, manifest(t, fn)["triggers"])
+		})
 
-func marshalRequest(t *testing.T, r *sdkrequest.Request) []byte {
-	t.Helper()
+		t.Run("invalid wildcards", func(t *testing.T) {
+			for _, name := range []string{"pay*ments", "payments*", "*/*", "payments/*/succeeded"} {
+				fn := CreateFunction(FunctionOpts{Name: "invalid"}, EventTrigger(name, nil), noop)
+				_, err := createFunctionConfigs("app", []ServableFunction{fn}, *appURL, false, "")
+				require.Error(t, err, name)
+			}
+		})
+	})
 
-	byt, err := json.Marshal(r)
-	require.NoError(t, err)
-	byt, err = jcs.Transform(byt)
-	require.NoError(t, err)
-	return byt
-}
+	t.Run("SLA", func(t *testing.T) {
+		fn := CreateFunction(
+			FunctionOpts{Name: "sla"},
+			EventTrigger("my-event", nil),
+			noop,
+			WithSLA(time.Hour, 10*time.Minute),
+		)
+		// SLAs aren't supported by the server, so they're never synced.
+		require.NotContains(t, manifest(t, fn), "sla")
+
+		t.Run("invalid", func(t *testing.T) {
+			fn := CreateFunction(
+				FunctionOpts{Name: "sla"},
+				EventTrigger("my-event", nil),
+				noop,
+				WithSLA(time.Minute, time.Hour),
+			)
+			_, err := createFunctionConfigs("app", []ServableFunction{fn}, *appURL, false, "")
+			require.Error(t, err)
+		})
+	})
 
-func toPtr[T any](v T) *T {
-	return &v
+	t.Run("DisableAutoRetry", func(t *testing.T) {
+		fn := CreateFunction(FunctionOpts{Name: "once", DisableAutoRetry: true}, EventTrigger("my-event", nil), noop)
+		steps := manifest(t, fn)["steps"].(map[string]any)
+		require.Equal(t, map[string]any{"attempts": float64(0)}, steps["step"].(map[string]any)["retries"])
+
+		t.Run("with retries", func(t *testing.T) {
+			opts := FunctionOpts{Name: "once", DisableAutoRetry: true, Retries: IntPtr(3)}
+			require.Error(t, opts.Validate())
+
+			fn := CreateFunction(opts, EventTrigger("my-event", nil), noop)
+			_, err := createFunctionConfigs("app", []ServableFunction{fn}, *appURL, false, "")
+			require.Error(t, err)
+		})
+	})
+
+	t.Run("aliases", func(t *testing.T) {
+		fn := CreateFunction(
+			FunctionOpts{ID: "charge-card", Name: "Charge card", Aliases: []string{"charge"}},
+			EventTrigger("my-event", nil),
+			noop,
+		)
+		// Aliases are only used to route requests, so they're never synced.
+		require.NotContains(t, manifest(t, fn), "aliases")
+
+		t.Run("conflicting with another function", func(t *testing.T) {
+			other := CreateFunction(FunctionOpts{ID: "charge", Name: "Charge"}, EventTrigger("my-event", nil), noop)
+			_, err := createFunctionConfigs("app", []ServableFunction{fn, other}, *appURL, false, "")
+			require.ErrorContains(t, err, "app-charge")
+		})
+
+		t.Run("reachable under each ID", func(t *testing.T) {
+			h := NewHandler("app", HandlerOpts{Dev: BoolPtr(true)})
+			h.Register(CreateFunction(
+				FunctionOpts{ID: "charge-card", Name: "Charge card", Aliases: []string{"charge"}},
+				EventTrigger("my-event", nil),
+				func(ctx context.Context, input Input[any]) (any, error) {
+					return "charged", nil
+				},
+			))
+			server := httptest.NewServer(h)
+			defer server.Close()
+
+			body, _ := json.Marshal(createRequest(t, map[string]any{"name": "my-event"}))
+			for _, id := range []string{"app-charge-card", "app-charge"} {
+				resp, err := http.Post(server.URL+"?fnId="+id, "application/json", bytes.NewReader(body))
+				require.NoError(t, err)
+				byt, _ := io.ReadAll(resp.Body)
+				_ = resp.Body.Close()
+				require.Equal(t, http.StatusOK, resp.StatusCode, id)
+				require.JSONEq(t, `"charged"`, string(byt))
+			}
+		})
+	})
+
+	t.Run("max parallel steps", func(t *testing.T) {
+		fn := CreateFunction(
+			FunctionOpts{Name: "fan-out", MaxParallelSteps: IntPtr(0)},
+			EventTrigger("my-event", nil),
+			noop,
+		)
+		_, err := createFunctionConfigs("app", []ServableFunction{fn}, *appURL, false, "")
+		require.ErrorContains(t, err, "MaxParallelSteps must be at least 1")
+	})
+
+	t.Run("resource limits", func(t *testing.T) {
+		fn := CreateFunction(
+			FunctionOpts{Name: "limited", ResourceLimits: &ResourceConfig{MaxMemoryMB: 512}},
+			EventTrigger("my-event", nil),
+			noop,
+		)
+		// Resource limits are enforced by the SDK, so they're never synced.
+		require.NotContains(t, manifest(t, fn), "resourceLimits")
+	})
+
+	t.Run("run and step timeouts", func(t *testing.T) {
+		start := time.Minute
+		fn := CreateFunction(
+			FunctionOpts{
+				Name:        "export",
+				Timeouts:    &Timeouts{Start: &start},
+				RunTimeout:  Ptr(time.Hour),
+				StepTimeout: Ptr(5 * time.Minute),
+			},
+			EventTrigger("my-event", nil),
+			noop,
+		)
+		out := manifest(t, fn)
+		require.Equal(t, map[string]any{"start": "1m0s", "finish": "1h0m0s"}, out["timeouts"])
+		// Step timeouts are enforced by the SDK, so they're never synced.
+		require.NotContains(t, out, "stepTimeout")
+
+		err := FunctionOpts{Name: "export", RunTimeout: Ptr(time.Hour), Timeouts: &Timeouts{Finish: &start}}.Validate()
+		require.EqualError(t, err, "RunTimeout can't be used with Timeouts.Finish")
+		err = FunctionOpts{Name: "export", StepTimeout: Ptr(time.Duration(0))}.Validate()
+		require.EqualError(t, err, "StepTimeout must be positive")
+	})
+
+	t.Run("resilience", func(t *testing.T) {
+		start := time.Minute
+		fn := CreateFunction(
+			FunctionOpts{
+				Name:     "charge",
+				Retries:  IntPtr(10),
+				Timeouts: &Timeouts{Start: &start},
+				Resilience: &ResilienceConfig{
+					MaxAttempts:             3,
+					Timeout:                 time.Hour,
+					BackoffPolicy:           BackoffLinear,
+					CircuitBreakerThreshold: Ptr(0.5),
+				},
+			},
+			EventTrigger("my-event", nil),
+			noop,
+		)
+		out := manifest(t, fn)
+		require.Equal(t, float64(2), out["steps"].(map[string]any)["step"].(map[string]any)["retries"].(map[string]any)["attempts"])
+		require.Equal(t, map[string]any{"start": "1m0s", "finish": "1h0m0s"}, out["timeouts"])
+		// Circuit breaking isn't supported by the server, so it's never synced.
+		require.NotContains(t, out, "resilience")
+		// Retry delays aren't supported by the server either, so the SDK
+		// computes them.
+		require.NotContains(t, out, "retry")
+		require.Equal(t, BackoffLinear, fn.Config().retryConfig().Backoff)
+
+		fn = CreateFunction(
+			FunctionOpts{Name: "charge", Retries: IntPtr(10), Resilience: &ResilienceConfig{BackoffPolicy: BackoffNoRetry}},
+			EventTrigger("my-event", nil),
+			noop,
+		)
+		out = manifest(t, fn)
+		require.Equal(t, float64(0), out["steps"].(map[string]any)["step"].(map[string]any)["retries"].(map[string]any)["attempts"])
+		require.Nil(t, fn.Config().retryConfig())
+
+		// MaxRetryDelay caps the resilience backoff.
+		fn = CreateFunction(
+			FunctionOpts{Name: "charge", MaxRetryDelay: Ptr(time.Minute), Resilience: &ResilienceConfig{MaxAttempts: 4, BackoffPolicy: BackoffExponential}},
+			EventTrigger("my-event", nil),
+			noop,
+		)
+		out = manifest(t, fn)
+		require.Equal(t, float64(3), out["steps"].(map[string]any)["step"].(map[string]any)["retries"].(map[string]any)["attempts"])
+		require.Equal(t, &RetryConfig{Backoff: BackoffExponential, MaxRetryDelay: Ptr(time.Minute)}, fn.Config().retryConfig())
+
+		invalid := []struct {
+			config ResilienceConfig
+			err    string
+		}{
+			{ResilienceConfig{MaxAttempts: 3, BackoffPolicy: BackoffNoRetry}, "MaxAttempts must be at most 1 with the no retry backoff policy"},
+			{ResilienceConfig{MaxAttempts: 1, BackoffPolicy: BackoffConstant}, "a backoff policy can't be used with a single attempt"},
+			{ResilienceConfig{BackoffPolicy: "random"}, "unknown backoff policy 'random'"},
+			{ResilienceConfig{CircuitBreakerThreshold: Ptr(1.5)}, "CircuitBreakerThreshold must be greater than 0.0 and at most 1.0"},
+			{ResilienceConfig{Timeout: -time.Second}, "Timeout must not be negative"},
+		}
+		for _, i := range invalid {
+			require.EqualError(t, i.config.Validate(), i.err)
+		}
+	})
+
+	t.Run("region", func(t *testing.T) {
+		// Regions aren't supported by the server, so they're never synced.
+		fn := CreateFunction(FunctionOpts{Name: "eu-only", Region: StrPtr("eu-west-1")}, EventTrigger("my-event", nil), noop)
+		require.NotContains(t, manifest(t, fn), "region")
+
+		err := FunctionOpts{Name: "mars", Region: StrPtr("mars-1")}.Validate()
+		require.EqualError(t, err, "region 'mars-1' must be one of: us-east-1, eu-west-1")
+	})
+
+	t.Run("locality", func(t *testing.T) {
+		// Localities aren't supported by the server, so they're never synced.
+		fn := CreateFunction(FunctionOpts{Name: "gdpr", Locality: StrPtr("eu")}, EventTrigger("my-event", nil), noop)
+		require.NotContains(t, manifest(t, fn), "locality")
+
+		err := FunctionOpts{Name: "mars", Locality: StrPtr("mars")}.Validate()
+		require.EqualError(t, err, "locality 'mars' must be one of: us, eu, apac")
+
+		t.Setenv("INNGEST_LOCALITY", "apac")
+		require.Equal(t, StrPtr("apac"), LocalityFromEnv())
+		t.Setenv("INNGEST_LOCALITY", "")
+		require.Nil(t, LocalityFromEnv())
+	})
+
+	t.Run("event ack", func(t *testing.T) {
+		// Event acknowledgement is enforced by the SDK, and isn't synced.
+		fn := CreateFunction(FunctionOpts{Name: "my-fn", EventAck: &AckConfig{Mode: AckModeManual, AckTimeout: time.Minute}}, EventTrigger("my-event", nil), noop)
+		require.NotContains(t, manifest(t, fn), "eventAck")
+	})
+
+	t.Run("side effect mode", func(t *testing.T) {
+		fn := CreateFunction(FunctionOpts{Name: "charge", SideEffectMode: SideEffectOnce}, EventTrigger("my-event", nil), noop)
+		out := manifest(t, fn)
+		require.Equal(t, "event.id", out["idempotency"])
+		require.NotContains(t, out, "deduplication")
+
+		fn = CreateFunction(FunctionOpts{Name: "charge", SideEffectMode: SideEffectOnce, Idempotency: StrPtr("event.data.orderId")}, EventTrigger("my-event", nil), noop)
+		require.Equal(t, "event.data.orderId", manifest(t, fn)["idempotency"])
+
+		err := FunctionOpts{Name: "charge", SideEffectMode: "twice"}.Validate()
+		require.EqualError(t, err, "unsupported side effect mode 'twice'")
+	})
+
+	t.Run("event deduplication", func(t *testing.T) {
+		fn := CreateFunction(
+			FunctionOpts{
+				Name:               "charge",
+				EventDeduplication: &DeduplicationConfig{Strategy: DeduplicateSkip, Key: "event.data.orderId"},
+			},
+			EventTrigger("order/created", nil),
+			noop,
+		)
+		// Skipping duplicates is synced as idempotency, as the server doesn't
+		// support deduplication strategies.
+		m := manifest(t, fn)
+		require.Equal(t, "event.data.orderId", m["idempotency"])
+		require.NotContains(t, m, "deduplication")
+
+		// Idempotency is shorthand for skipping duplicates.
+		fn = CreateFunction(FunctionOpts{Name: "charge", Idempotency: StrPtr("event.data.orderId")}, EventTrigger("order/created", nil), noop)
+		m = manifest(t, fn)
+		require.Equal(t, "event.data.orderId", m["idempotency"])
+
+		err := FunctionOpts{
+			Name:               "charge",
+			Idempotency:        StrPtr("event.data.orderId"),
+			EventDeduplication: &DeduplicationConfig{Strategy: DeduplicateSkip, Key: "event.data.orderId"},
+		}.Validate()
+		require.EqualError(t, err, "Idempotency and EventDeduplication cannot both be set")
+
+		err = FunctionOpts{Name: "charge", EventDeduplication: &DeduplicationConfig{Strategy: "drop", Key: "event.id"}}.Validate()
+		require.EqualError(t, err, "invalid EventDeduplication: unknown strategy 'drop'")
+		err = FunctionOpts{Name: "charge", EventDeduplication: &DeduplicationConfig{Strategy: "cancel", Key: "event.id"}}.Validate()
+		require.EqualError(t, err, "invalid EventDeduplication: unknown strategy 'cancel'")
+		err = FunctionOpts{Name: "charge", EventDeduplication: &DeduplicationConfig{Strategy: DeduplicateSkip}}.Validate()
+		require.EqualError(t, err, "invalid EventDeduplication: key must not be empty")
+	})
+
+	t.Run("retry delays", func(t *testing.T) {
+		fn := CreateFunction(
+			FunctionOpts{Name: "sync", Retry: WithBoundedExponentialBackoff(5, time.Second, time.Hour)},
+			EventTrigger("my-event", nil),
+			noop,
+		)
+		// Only the number of retries is synced, as the SDK computes the delays.
+		m := manifest(t, fn)
+		require.NotContains(t, m, "retry")
+		require.Equal(t, map[string]any{"attempts": float64(5)}, m["steps"].(map[string]any)["step"].(map[string]any)["retries"])
+
+		// MaxRetryDelay is shorthand for Retry.MaxRetryDelay.
+		fn = CreateFunction(
+			FunctionOpts{Name: "sync", Retries: IntPtr(10), MaxRetryDelay: Ptr(10 * time.Minute)},
+			EventTrigger("my-event", nil),
+			noop,
+		)
+		m = manifest(t, fn)
+		require.NotContains(t, m, "retry")
+		require.Equal(t, Ptr(10*time.Minute), fn.Config().retryConfig().MaxRetryDelay)
+		require.Equal(t, map[string]any{"attempts": float64(10)}, m["steps"].(map[string]any)["step"].(map[string]any)["retries"])
+
+		for opts, msg := range map[*FunctionOpts]string{
+			{Retry: WithBoundedExponentialBackoff(5, time.Hour, time.Second)}:                                "invalid retry config: MaxRetryDelay must be at least MinRetryDelay",
+			{Retry: &RetryConfig{MinRetryDelay: Ptr(time.Hour)}, MaxRetryDelay: Ptr(time.Second)}:            "invalid retry config: MaxRetryDelay must be at least MinRetryDelay",
+			{Retry: WithBoundedExponentialBackoff(5, time.Second, time.Hour), MaxRetryDelay: Ptr(time.Hour)}: "MaxRetryDelay and Retry.MaxRetryDelay cannot both be set",
+			{Retry: &RetryConfig{Attempts: 3}, Retries: IntPtr(3)}:                                           "Retry can't be used with Retries, DisableAutoRetry or Resilience",
+			{Retry: &RetryConfig{Backoff: BackoffNoRetry}}:                                                   "invalid retry config: unsupported backoff policy 'none'",
+		} {
+			opts.Name = "sync"
+			require.EqualError(t, opts.Validate(), msg)
+		}
+	})
+
+	t.Run("timezone", func(t *testing.T) {
+		cron := func(m map[string]any) any {
+			return m["triggers"].([]any)[0].(map[string]any)["cron"]
+		}
+
+		fn := CreateFunction(FunctionOpts{Name: "report", Timezone: "America/New_York"}, CronTrigger("0 9 * * *"), noop)
+		m := manifest(t, fn)
+		require.Equal(t, "TZ=America/New_York 0 9 * * *", cron(m))
+		require.NotContains(t, m, "timezone")
+
+		// Per-trigger timezones override the function's timezone.
+		fn = CreateFunction(FunctionOpts{Name: "report", Timezone: "America/New_York"}, CronTriggerInZone("0 9 * * *", "Europe/London"), noop)
+		require.Equal(t, "TZ=Europe/London 0 9 * * *", cron(manifest(t, fn)))
+
+		fn = CreateFunction(FunctionOpts{Name: "report"}, CronTrigger("0 9 * * *"), noop)
+		m = manifest(t, fn)
+		require.Equal(t, "0 9 * * *", cron(m))
+		require.NotContains(t, m, "timezone")
+
+		err := FunctionOpts{Name: "report", Timezone: "New York"}.Validate()
+		require.ErrorContains(t, err, "invalid Timezone 'New York'")
+
+		fn = CreateFunction(FunctionOpts{Name: "report"}, CronTriggerInZone("0 9 * * *", "Mars/Olympus"), noop)
+		_, err = createFunctionConfigs("app", []ServableFunction{fn}, *appURL, false, "")
+		require.ErrorContains(t, err, "invalid timezone for cron 'TZ=Mars/Olympus 0 9 * * *'")
+	})
+
+	t.Run("dlq", func(t *testing.T) {
+		type payment struct {
+			Amount int `json:"amount"`
+		}
+		var failed DLQInput[payment]
+		handler := CreateFunction(
+			FunctionOpts{ID: "payments-dlq"},
+			EventTrigger(DLQEventName, nil),
+			func(ctx context.Context, input Input[GenericEvent[DLQInput[payment], any]]) (any, error) {
+				failed = input.Event.Data
+				return nil, nil
+			},
+		)
+		fn := CreateFunction(
+			FunctionOpts{ID: "charge", DLQ: &DLQConfig{FunctionID: "payments-dlq", MaxAge: Ptr(24 * time.Hour)}},
+			EventTrigger("payment/requested", nil),
+			noop,
+		)
+
+		// DLQs aren't supported by the server, so they're never synced.
+		configs, err := createFunctionConfigs("app", []ServableFunction{fn, handler}, *appURL, false, "")
+		require.NoError(t, err)
+		byt, err := json.Marshal(configs[0])
+		require.NoError(t, err)
+		require.NotContains(t, string(byt), `"dlq"`)
+
+		_, err = createFunctionConfigs("app", []ServableFunction{fn}, *appURL, false, "")
+		require.EqualError(t, err, "invalid options for function 'app-charge': DLQ function 'payments-dlq' is not registered")
+
+		self := CreateFunction(FunctionOpts{ID: "charge", DLQ: &DLQConfig{FunctionID: "charge"}}, EventTrigger("payment/requested", nil), noop)
+		_, err = createFunctionConfigs("app", []ServableFunction{self}, *appURL, false, "")
+		require.ErrorContains(t, err, "function can't be its own DLQ handler")
+
+		require.EqualError(t, FunctionOpts{Name: "charge", DLQ: &DLQConfig{}}.Validate(), "invalid DLQ: FunctionID must be set")
+
+		// DLQ handlers receive the failed run's event.
+		_, _, err = invoke(context.Background(), handler, createRequest(t, map[string]any{
+			"name": DLQEventName,
+			"data": map[string]any{
+				"original_event": map[string]any{"name": "payment/requested", "data": map[string]any{"amount": 10}},
+				"error":          "card declined",
+				"attempts":       4,
+				"failed_at":      "2024-01-01T00:00:00Z",
+			},
+		}), nil, nil)
+		require.NoError(t, err)
+		require.Equal(t, 10, failed.OriginalEvent.Data.Amount)
+		require.Equal(t, "card declined", failed.Error)
+		require.Equal(t, 4, failed.Attempts)
+		require.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), failed.FailedAt)
+	})
+
+	t.Run("feature flags", func(t *testing.T) {
+		// Feature flags aren't supported by the server, so they're never synced.
+		fn := CreateFunction(FunctionOpts{Name: "pricing"}, EventTrigger("my-event", nil), noop, WithFeatureFlag("new-pricing"))
+		require.NotContains(t, manifest(t, fn), "featureFlags")
+	})
+
+	t.Run("cooldown", func(t *testing.T) {
+		// Cooldowns aren't supported by the server, so they're synced as a
+		// rate limit of one run per period.
+		fn := CreateFunction(FunctionOpts{Name: "alert"}, EventTrigger("alert/fired", nil), noop, WithCooldown(15*time.Minute))
+		out := manifest(t, fn)
+		require.NotContains(t, out, "cooldown")
+		require.Equal(t, map[string]any{"limit": float64(1), "period": "15m0s"}, out["rateLimit"])
+
+		fn = CreateFunction(FunctionOpts{Name: "alert"}, EventTrigger("alert/fired", nil), noop)
+		require.NotContains(t, manifest(t, fn), "rateLimit")
+
+		err := FunctionOpts{Name: "alert", Cooldown: Ptr(time.Duration(0))}.Validate()
+		require.EqualError(t, err, "Cooldown must be greater than 0")
+
+		err = FunctionOpts{Name: "alert", Cooldown: Ptr(time.Minute), RateLimit: &RateLimit{Limit: 5, Period: time.Minute}}.Validate()
+		require.EqualError(t, err, "Cooldown can't be used with RateLimit")
+	})
+
+	t.Run("concurrency key", func(t *testing.T) {
+		fn := CreateFunction(FunctionOpts{Name: "sync"}, EventTrigger("user/updated", nil), noop, WithPerEntityConcurrency("event.data.userId"))
+		equivalent := CreateFunction(
+			FunctionOpts{Name: "sync", Concurrency: []inngest.Concurrency{{Limit: 1, Key: StrPtr("event.data.userId")}}},
+			EventTrigger("user/updated", nil),
+			noop,
+		)
+		require.NotNil(t, manifest(t, fn)["concurrency"])
+		require.Equal(t, manifest(t, equivalent)["concurrency"], manifest(t, fn)["concurrency"])
+
+		err := FunctionOpts{
+			Name:           "sync",
+			ConcurrencyKey: StrPtr("event.data.userId"),
+			Concurrency:    []inngest.Concurrency{{Limit: 5}},
+		}.Validate()
+		require.EqualError(t, err, "ConcurrencyKey and Concurrency cannot both be set")
+
+		err = FunctionOpts{Name: "sync", ConcurrencyKey: StrPtr(" ")}.Validate()
+		require.EqualError(t, err, "ConcurrencyKey must not be empty")
+	})
+
+	t.Run("event buffering", func(t *testing.T) {
+		buffering := &BufferingConfig{MaxSize: 10, Timeout: 5 * time.Minute, Key: "event.data.orderId"}
+		fn := CreateFunction(FunctionOpts{Name: "fulfil", EventBuffering: buffering}, EventTrigger("order/item.added", nil), noop)
+		// Buffering is synced as a keyed batch, as the server doesn't support
+		// buffering directly.
+		m := manifest(t, fn)
+		require.Equal(t, map[string]any{
+			"maxSize": float64(10),
+			"timeout": "5m0s",
+			"key":     "event.data.orderId",
+		}, m["batchEvents"])
+		require.NotContains(t, m, "eventBuffer")
+		batch, err := inngest.NewEventBatchConfig(m["batchEvents"].(map[string]any))
+		require.NoError(t, err)
+		require.NoError(t, batch.IsValid(context.Background()))
+
+		err = FunctionOpts{Name: "fulfil", EventBuffering: &BufferingConfig{MaxSize: 10, Timeout: time.Minute}}.Validate()
+		require.EqualError(t, err, "invalid EventBuffering: key must not be empty")
+		err = FunctionOpts{Name: "fulfil", EventBuffering: &BufferingConfig{MaxSize: 1, Timeout: time.Minute, Key: "event.data.orderId"}}.Validate()
+		require.EqualError(t, err, "invalid EventBuffering: max size must be at least 2")
+		err = FunctionOpts{Name: "fulfil", EventBuffering: &BufferingConfig{MaxSize: 10, Timeout: time.Millisecond, Key: "event.data.orderId"}}.Validate()
+		require.EqualError(t, err, "invalid EventBuffering: timeout must be at least 1s")
+
+		err = FunctionOpts{
+			Name:           "fulfil",
+			EventBuffering: buffering,
+			BatchEvents:    &inngest.EventBatchConfig{MaxSize: 10, Timeout: "5s"},
+		}.Validate()
+		require.EqualError(t, err, "EventBuffering can't be used with BatchEvents")
+	})
+
+	t.Run("completion email", func(t *testing.T) {
+		fn := CreateFunction(
+			FunctionOpts{
+				Name: "export",
+				CompletionEmail: &EmailConfig{
+					To:              []string{"ops@example.com", "Data Team <data@example.com>"},
+					OnFailure:       true,
+					SubjectTemplate: "Export {function_id} {status} ({run_id})",
+				},
+			},
+			EventTrigger("export/requested", nil),
+			noop,
+		)
+		// Completion emails aren't supported by the server, so they're never
+		// synced.
+		require.NotContains(t, manifest(t, fn), "completionEmail")
+
+		for cfg, msg := range map[*EmailConfig]string{
+			{OnSuccess: true}:                      "at least one recipient is required",
+			{To: []string{"ops"}, OnSuccess: true}: "invalid email address 'ops'",
+			{To: []string{"ops@example.com"}}:      "at least one of OnSuccess or OnFailure must be set",
+			{To: []string{"ops@example.com"}, OnSuccess: true, SubjectTemplate: "{event}"}: "unknown subject template placeholder '{event}'",
+			{To: []string{"ops@example.com"}, OnSuccess: true, SubjectTemplate: "{run_id"}: "unmatched '{'",
+			{To: []string{"ops@example.com"}, OnSuccess: true, SubjectTemplate: "run_id}"}: "unmatched '}'",
+		} {
+			err := FunctionOpts{Name: "export", CompletionEmail: cfg}.Validate()
+			require.ErrorContains(t, err, msg)
+		}
+	})
+
+	t.Run("rate limit", func(t *testing.T) {
+		fn := CreateFunction(
+			FunctionOpts{Name: "notify", RateLimit: &RateLimit{Limit: 10, Period: time.Minute, Key: StrPtr("event.data.userId")}},
+			EventTrigger("my-event", nil),
+			noop,
+		)
+		require.Equal(t, map[string]any{
+			"limit":  float64(10),
+			"period": "1m0s",
+			"key":    "event.data.userId",
+		}, manifest(t, fn)["rateLimit"])
+	})
+
+	t.Run("auto cancel", func(t *testing.T) {
+		fn := CreateFunction(
+			FunctionOpts{
+				Name:       "user-report",
+				Cancel:     []inngest.Cancel{{Event: "user/deleted", If: StrPtr("event.data.userId == async.data.userId")}},
+				AutoCancel: AutoCancelOnSameEvent("event.data.userId"),
+			},
+			EventTrigger("report/requested", nil),
+			noop,
+		)
+		require.Equal(t, []any{
+			map[string]any{"event": "user/deleted", "if": "event.data.userId == async.data.userId"},
+			map[string]any{"event": "report/requested", "if": "event.data.userId == async.data.userId"},
+		}, manifest(t, fn)["cancel"])
+		require.Len(t, fn.Config().Cancel, 1)
+
+		fn = CreateFunction(
+			FunctionOpts{
+				Name:       "user-report",
+				AutoCancel: &AutoCancelConfig{Event: "report/regenerate", Key: "event.data.userId"},
+			},
+			EventTrigger("report/requested", nil),
+			noop,
+		)
+		require.Equal(t, []any{
+			map[string]any{"event": "report/regenerate", "if": "event.data.userId == async.data.userId"},
+		}, manifest(t, fn)["cancel"])
+
+		fn = CreateFunction(
+			FunctionOpts{Name: "user-report", AutoCancel: AutoCancelOnSameEvent("data.userId")},
+			EventTrigger("report/requested", nil),
+			noop,
+		)
+		_, err := createFunctionConfigs("app", []ServableFunction{fn}, *appURL, false, "")
+		require.ErrorContains(t, err, `key must start with "event."`)
+	})
+
+	t.Run("environment expressions", func(t *testing.T) {
+		env := map[string]string{"staging": "event.data.env == 'staging'"}
+		filtered := CreateFunction(
+			FunctionOpts{Name: "report", Environment: env},
+			EventTrigger("report/requested", StrPtr("event.data.ok")),
+			noop,
+		)
+		unfiltered := CreateFunction(
+			FunctionOpts{Name: "scheduled-report", Environment: env},
+			EventTrigger("report/scheduled", nil),
+			noop,
+		)
+
+		triggers := func(t *testing.T, env string) []any {
+			fns, err := createFunctionConfigs("app", []ServableFunction{filtered, unfiltered}, *appURL, false, env)
+			require.NoError(t, err)
+			out := []any{}
+			for _, fn := range fns {
+				byt, _ := json.Marshal(fn.Triggers[0])
+				var trigger any
+				require.NoError(t, json.Unmarshal(byt, &trigger))
+				out = append(out, trigger)
+			}
+			return out
+		}
+
+		require.Equal(t, []any{
+			map[string]any{"event": "report/requested", "expression": "(event.data.ok) && (event.data.env == 'staging')"},
+			map[string]any{"event": "report/scheduled", "expression": "event.data.env == 'staging'"},
+		}, triggers(t, "staging"))
+
+		require.Equal(t, []any{
+			map[string]any{"event": "report/requested", "expression": "event.data.ok"},
+			map[string]any{"event": "report/scheduled"},
+		}, triggers(t, "production"))
+
+		invalid := CreateFunction(
+			FunctionOpts{Name: "report", Environment: map[string]string{"staging": " "}},
+			EventTrigger("report/requested", nil),
+			noop,
+		)
+		_, err := createFunctionConfigs("app", []ServableFunction{invalid}, *appURL, false, "staging")
+		require.ErrorContains(t, err, "expression for environment 'staging' must not be empty")
+	})
+
+	t.Run("event namespace", func(t *testing.T) {
+		fn := CreateFunction(
+			FunctionOpts{
+				Name:           "tenant-report",
+				EventNamespace: StrPtr("acme"),
+				AutoCancel:     AutoCancelOnSameEvent("event.data.userId"),
+			},
+			EventTrigger("report/requested", StrPtr("event.data.ok")),
+			noop,
+		)
+		m := manifest(t, fn)
+		require.Equal(t, []any{
+			map[string]any{"event": "acme/report/requested", "expression": "event.data.ok"},
+		}, m["triggers"])
+		require.Equal(t, []any{
+			map[string]any{"event": "acme/report/requested", "if": "event.data.userId == async.data.userId"},
+		}, m["cancel"])
+
+		// Scoped triggers aren't prefixed twice.
+		fn = CreateFunction(
+			FunctionOpts{Name: "tenant-report", EventNamespace: StrPtr("acme")},
+			ScopedEventTrigger("acme", "report/requested", nil),
+			noop,
+		)
+		require.Equal(t, []any{
+			map[string]any{"event": "acme/report/requested"},
+		}, manifest(t, fn)["triggers"])
+
+		err := FunctionOpts{Name: "tenant-report", EventNamespace: StrPtr("acme/eu")}.Validate()
+		require.EqualError(t, err, "EventNamespace must be non-empty and must not contain '/' or '*'")
+	})
+}
+
+func createRequest(t *testing.T, evt any) *sdkrequest.Request {
+	t.Helper()
+
+	byt, err := json.Marshal(evt)
+	require.NoError(t, err)
+
+	return &sdkrequest.Request{
+		Event: byt,
+		CallCtx: sdkrequest.CallCtx{
+			FunctionID: "fn-id",
+			RunID:      "run-id",
+		},
+	}
+}
+
+func createBatchRequest(t *testing.T, evt any, num int) *sdkrequest.Request {
+	t.Helper()
+
+	events := make([]json.RawMessage, num)
+	for i := 0; i < num; i++ {
+		byt, err := json.Marshal(evt)
+		require.NoError(t, err)
+		events[i] = byt
+	}
+
+	return &sdkrequest.Request{
+		Event:  events[0],
+		Events: events,
+		CallCtx: sdkrequest.CallCtx{
+			FunctionID: "fn-id",
+			RunID:      "run-id",
+		},
+	}
+}
+
+func handlerPost(t *testing.T, url string, r *sdkrequest.Request) *http.Response {
+	t.Helper()
+
+	body := marshalRequest(t, r)
+	sig, _ := Sign(context.Background(), time.Now(), []byte(testKey), body)
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("X-Inngest-Signature", sig)
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	return resp
+}
+
+func marshalRequest(t *testing.T, r *sdkrequest.Request) []byte {
+	t.Helper()
+
+	byt, err := json.Marshal(r)
+	require.NoError(t, err)
+	byt, err = jcs.Transform(byt)
+	require.NoError(t, err)
+	return byt
+}
+
+func toPtr[T any](v T) *T {
+	return &v
+}
+
+func TestFunctionHooks(t *testing.T) {
+	starts := make(chan FunctionStartInfo, 10)
+	ends := make(chan FunctionEndInfo, 10)
+	h := NewHandler("test-hooks", HandlerOpts{
+		OnFunctionStart: func(ctx context.Context, info FunctionStartInfo) { starts <- info },
+		OnFunctionEnd:   func(ctx context.Context, info FunctionEndInfo) { ends <- info },
+	}).(*handler)
+
+	receive := func(t *testing.T, ch <-chan FunctionEndInfo) FunctionEndInfo {
+		select {
+		case info := <-ch:
+			return info
+		case <-time.After(time.Second):
+			require.FailNow(t, "hook not called")
+			return FunctionEndInfo{}
+		}
+	}
+
+	t.Run("successful run", func(t *testing.T) {
+		fn := CreateFunction(
+			FunctionOpts{Name: "my-fn"},
+			EventTrigger("my-event", nil),
+			func(ctx context.Context, input Input[any]) (any, error) {
+				return step.Run(ctx, "a", func(ctx context.Context) (string, error) {
+					return "ok", nil
+				})
+			},
+		)
+
+		req := createRequest(t, map[string]any{"name": "my-event"})
+		req.CallCtx.RunID = "run-1"
+		_, ops, err := h.invokeWithHooks(context.Background(), fn, req, nil)
+		require.NoError(t, err)
+		require.Len(t, ops, 1)
+
+		start := <-starts
+		require.Equal(t, "run-1", start.RunID)
+		require.JSONEq(t, `{"name":"my-event"}`, string(start.Event))
+
+		// Replaying the step finishes the run, without starting it again.
+		req.Steps = map[string]json.RawMessage{ops[0].ID: json.RawMessage(`{"data":"ok"}`)}
+		_, _, err = h.invokeWithHooks(context.Background(), fn, req, nil)
+		require.NoError(t, err)
+
+		end := receive(t, ends)
+		require.Equal(t, "run-1", end.RunID)
+		require.Equal(t, json.RawMessage(`"ok"`), end.Output)
+		require.NoError(t, end.Err)
+		require.Empty(t, starts)
+	})
+
+	t.Run("failed run", func(t *testing.T) {
+		fn := CreateFunction(
+			FunctionOpts{Name: "my-fn"},
+			EventTrigger("my-event", nil),
+			func(ctx context.Context, input Input[any]) (any, error) {
+				return nil, fmt.Errorf("oh no")
+			},
+		)
+
+		req := createRequest(t, map[string]any{"name": "my-event"})
+		_, _, err := h.invokeWithHooks(context.Background(), fn, req, nil)
+		require.Error(t, err)
+		require.Equal(t, 0, (<-starts).AttemptNumber)
+
+		// Retries neither start the run again nor end it until the final
+		// attempt.
+		req.CallCtx.Attempt = 1
+		_, _, err = h.invokeWithHooks(context.Background(), fn, req, nil)
+		require.Error(t, err)
+
+		req.CallCtx.Attempt = defaultRetries
+		_, _, err = h.invokeWithHooks(context.Background(), fn, req, nil)
+		require.Error(t, err)
+
+		end := receive(t, ends)
+		require.Equal(t, defaultRetries, end.AttemptNumber)
+		require.EqualError(t, end.Err, "oh no")
+		require.Nil(t, end.Output)
+		require.Empty(t, starts)
+		require.Empty(t, ends)
+	})
+}
+
+func TestStepIDHasher(t *testing.T) {
+	h := NewHandler("test-hasher", HandlerOpts{
+		StepIDHasher: func(op UnhashedOp) string {
+			return fmt.Sprintf("%s-%s", op.Op, op.ID)
+		},
+	}).(*handler)
+
+	fn := CreateFunction(
+		FunctionOpts{Name: "my-fn"},
+		EventTrigger("my-event", nil),
+		func(ctx context.Context, input Input[any]) (any, error) {
+			return step.Run(ctx, "a", func(ctx context.Context) (string, error) {
+				return "ok", nil
+			})
+		},
+	)
+
+	req := createRequest(t, map[string]any{"name": "my-event"})
+	_, ops, err := h.invokeWithHooks(context.Background(), fn, req, nil)
+	require.NoError(t, err)
+	require.Len(t, ops, 1)
+	require.Equal(t, "Step-a", ops[0].ID)
+
+	req.Steps = map[string]json.RawMessage{"Step-a": json.RawMessage(`{"data":"memoized"}`)}
+	actual, _, err := h.invokeWithHooks(context.Background(), fn, req, nil)
+	require.NoError(t, err)
+	require.Equal(t, "memoized", actual)
+}
+
+func TestStepHooks(t *testing.T) {
+	var events []string
+	fn := CreateFunction(
+		FunctionOpts{
+			Name: "my-fn",
+			Hooks: HookConfig{
+				OnStepStart: func(ctx context.Context, stepID, stepName string) {
+					events = append(events, "start "+stepName)
+				},
+				OnStepEnd: func(ctx context.Context, stepID, stepName string, result any, err error) {
+					events = append(events, fmt.Sprintf("end %s %v %v", stepName, result, err))
+				},
+			},
+		},
+		EventTrigger("my-event", nil),
+		func(ctx context.Context, input Input[any]) (any, error) {
+			a, _ := step.Run(ctx, "a", func(ctx context.Context) (string, error) {
+				return "ok", nil
+			})
+			_, err := step.Run(ctx, "b", func(ctx context.Context) (string, error) {
+				return "", fmt.Errorf("failed")
+			})
+			return a, err
+		},
+	)
+
+	req := createRequest(t, map[string]any{"name": "my-event"})
+	_, _, _ = invoke(context.Background(), fn, req, nil, nil)
+	require.Equal(t, []string{"start a", "end a ok <nil>"}, events)
+
+	// Hooks aren't called for memoized steps.
+	events = nil
+	op := sdkrequest.UnhashedOp{Op: enums.OpcodeStep, ID: "a"}
+	req.Steps = map[string]json.RawMessage{op.MustHash(): json.RawMessage(`{"data":"ok"}`)}
+	_, _, _ = invoke(context.Background(), fn, req, nil, nil)
+	require.Equal(t, []string{"start b", "end b  failed"}, events)
+}
+
+func TestOnSuccess(t *testing.T) {
+	h := NewHandler("test-on-success", HandlerOpts{}).(*handler)
+
+	var results []json.RawMessage
+	fn := CreateFunction(
+		FunctionOpts{
+			Name: "my-fn",
+			OnSuccess: func(ctx context.Context, result json.RawMessage) {
+				results = append(results, result)
+			},
+		},
+		EventTrigger("my-event", nil),
+		func(ctx context.Context, input Input[map[string]any]) (any, error) {
+			out, err := step.Run(ctx, "a", func(ctx context.Context) (string, error) {
+				return "ok", nil
+			})
+			if input.Event["fail"] == true {
+				return nil, fmt.Errorf("failed")
+			}
+			return map[string]string{"result": out}, err
+		},
+	)
+
+	// OnSuccess isn't called while steps are being run.
+	req := createRequest(t, map[string]any{"name": "my-event"})
+	_, ops, err := h.invokeWithHooks(context.Background(), fn, req, nil)
+	require.NoError(t, err)
+	require.Len(t, ops, 1)
+	require.Empty(t, results)
+
+	req.Steps = map[string]json.RawMessage{ops[0].ID: json.RawMessage(`{"data":"ok"}`)}
+	_, _, err = h.invokeWithHooks(context.Background(), fn, req, nil)
+	require.NoError(t, err)
+	require.Equal(t, []json.RawMessage{json.RawMessage(`{"result":"ok"}`)}, results)
+
+	// Nor for failed runs.
+	failed := createRequest(t, map[string]any{"name": "my-event", "fail": true})
+	failed.Steps = req.Steps
+	_, _, err = h.invokeWithHooks(context.Background(), fn, failed, nil)
+	require.Error(t, err)
+	require.Len(t, results, 1)
+
+	t.Run("panics are recovered", func(t *testing.T) {
+		fn := CreateFunction(
+			FunctionOpts{
+				Name:      "my-fn",
+				OnSuccess: func(ctx context.Context, result json.RawMessage) { panic("oh no") },
+			},
+			EventTrigger("my-event", nil),
+			func(ctx context.Context, input Input[any]) (any, error) {
+				return "ok", nil
+			},
+		)
+		resp, _, err := h.invokeWithHooks(context.Background(), fn, createRequest(t, map[string]any{"name": "my-event"}), nil)
+		require.NoError(t, err)
+		require.Equal(t, "ok", resp)
+	})
+}
+
+func TestOnRetry(t *testing.T) {
+	h := NewHandler("test-on-retry", HandlerOpts{}).(*handler)
+
+	type retry struct {
+		attempt int
+		err     string
+	}
+	var retries []retry
+	create := func(opts FunctionOpts, f func(ctx context.Context, input Input[any]) (any, error)) ServableFunction {
+		opts.Name = "my-fn"
+		opts.OnRetry = func(ctx context.Context, attempt int, lastErr error) {
+			retries = append(retries, retry{attempt, lastErr.Error()})
+		}
+		return CreateFunction(opts, EventTrigger("my-event", nil), f)
+	}
+	call := func(t *testing.T, fn ServableFunction, attempt int) {
+		req := createRequest(t, map[string]any{"name": "my-event"})
+		req.CallCtx.Attempt = attempt
+		_, _, _ = h.invokeWithHooks(context.Background(), fn, req, nil)
+	}
+
+	failing := create(FunctionOpts{Retries: IntPtr(2)}, func(ctx context.Context, input Input[any]) (any, error) {
+		return nil, fmt.Errorf("oh no")
+	})
+	for attempt := 0; attempt <= 2; attempt++ {
+		call(t, failing, attempt)
+	}
+	require.Equal(t, []retry{{1, "oh no"}, {2, "oh no"}}, retries)
+
+	t.Run("step errors", func(t *testing.T) {
+		retries = nil
+		fn := create(FunctionOpts{}, func(ctx context.Context, input Input[any]) (any, error) {
+			return step.Run(ctx, "a", func(ctx context.Context) (any, error) {
+				return nil, fmt.Errorf("step failed")
+			})
+		})
+		call(t, fn, 0)
+		require.Equal(t, []retry{{1, "step failed"}}, retries)
+	})
+
+	t.Run("not retried", func(t *testing.T) {
+		retries = nil
+		ok := create(FunctionOpts{}, func(ctx context.Context, input Input[any]) (any, error) {
+			return "ok", nil
+		})
+		noRetry := create(FunctionOpts{}, func(ctx context.Context, input Input[any]) (any, error) {
+			return nil, sdkerrors.NoRetryError(fmt.Errorf("fatal"))
+		})
+		disabled := create(FunctionOpts{DisableAutoRetry: true}, func(ctx context.Context, input Input[any]) (any, error) {
+			return nil, fmt.Errorf("oh no")
+		})
+		call(t, ok, 0)
+		call(t, noRetry, 0)
+		call(t, disabled, 0)
+		require.Empty(t, retries)
+	})
+
+	t.Run("panics are recovered", func(t *testing.T) {
+		fn := CreateFunction(
+			FunctionOpts{
+				Name:    "my-fn",
+				OnRetry: func(ctx context.Context, attempt int, lastErr error) { panic("oh no") },
+			},
+			EventTrigger("my-event", nil),
+			func(ctx context.Context, input Input[any]) (any, error) {
+				return nil, fmt.Errorf("failed")
+			},
+		)
+		_, _, err := h.invokeWithHooks(context.Background(), fn, createRequest(t, map[string]any{"name": "my-event"}), nil)
+		require.EqualError(t, err, "failed")
+	})
+}
+
+func TestDynamicRetry(t *testing.T) {
+	setEnvVars(t)
+
+	var attempts []int
+	fn := CreateFunction(
+		FunctionOpts{
+			Name:    "my-fn",
+			Retries: IntPtr(5),
+			DynamicRetry: &DynamicRetryConfig{
+				Policy: func(ctx context.Context, err error, attempt int) RetryPolicy {
+					attempts = append(attempts, attempt)
+					switch err.Error() {
+					case "rate limited":
+						return RetryPolicy{Retry: true, Wait: time.Hour}
+					case "invalid":
+						return RetryPolicy{Retry: false}
+					}
+					return RetryPolicy{Retry: true}
+				},
+			},
+		},
+		EventTrigger("my-event", nil),
+		func(ctx context.Context, input Input[map[string]any]) (any, error) {
+			msg := input.Event["data"].(map[string]any)["error"].(string)
+			if msg == "step" {
+				return step.Run(ctx, "a", func(ctx context.Context) (any, error) {
+					return nil, fmt.Errorf("invalid")
+				})
+			}
+			return nil, fmt.Errorf("%s", msg)
+		},
+	)
+	h := NewHandler("test-dynamic-retry", HandlerOpts{})
+	h.Register(fn)
+	server := httptest.NewServer(h)
+	defer server.Close()
+
+	call := func(t *testing.T, msg string, attempt int) *http.Response {
+		req := createRequest(t, map[string]any{"name": "my-event", "data": map[string]any{"error": msg}})
+		req.CallCtx.Attempt = attempt
+		resp := handlerPost(t, server.URL+"?fnId="+fn.Slug("test-dynamic-retry"), req)
+		_ = resp.Body.Close()
+		return resp
+	}
+
+	t.Run("retries after the policy's wait", func(t *testing.T) {
+		resp := call(t, "rate limited", 1)
+		require.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+		require.Empty(t, resp.Header.Get(HeaderKeyNoRetry))
+		at, err := time.Parse(time.RFC3339, resp.Header.Get(HeaderKeyRetryAfter))
+		require.NoError(t, err)
+		require.WithinDuration(t, time.Now().Add(time.Hour), at, time.Minute)
+	})
+
+	t.Run("uses the function's backoff", func(t *testing.T) {
+		resp := call(t, "timeout", 1)
+		require.Empty(t, resp.Header.Get(HeaderKeyNoRetry))
+		require.Empty(t, resp.Header.Get(HeaderKeyRetryAfter))
+	})
+
+	t.Run("stops retrying", func(t *testing.T) {
+		resp := call(t, "invalid", 0)
+		require.Equal(t, "true", resp.Header.Get(HeaderKeyNoRetry))
+
+		// Step errors are retried via the step's opcode.
+		resp = call(t, "step", 0)
+		require.Equal(t, http.StatusPartialContent, resp.StatusCode)
+		require.Equal(t, "true", resp.Header.Get(HeaderKeyNoRetry))
+	})
+
+	t.Run("isn't called for the final attempt", func(t *testing.T) {
+		attempts = nil
+		resp := call(t, "rate limited", 5)
+		require.Empty(t, resp.Header.Get(HeaderKeyRetryAfter))
+		require.Empty(t, attempts)
+	})
+
+	require.EqualError(t, FunctionOpts{Name: "fn", DynamicRetry: &DynamicRetryConfig{}}.Validate(), "DynamicRetry.Policy must be set")
+}
+
+func TestMustRun(t *testing.T) {
+	setEnvVars(t)
+
+	fn := CreateFunction(
+		FunctionOpts{Name: "my-fn"},
+		EventTrigger("my-event", nil),
+		func(ctx context.Context, input Input[any]) (any, error) {
+			return step.MustRun(ctx, "charge", func(ctx context.Context) (string, error) {
+				return "", fmt.Errorf("step should be memoized")
+			}), nil
+		},
+	)
+	h := NewHandler("test-must-run", HandlerOpts{})
+	h.Register(fn)
+	server := httptest.NewServer(h)
+	defer server.Close()
+
+	// The step has permanently failed.
+	op := sdkrequest.UnhashedOp{Op: enums.OpcodeStep, ID: "charge"}
+	req := createRequest(t, map[string]any{"name": "my-event"})
+	req.Steps = map[string]json.RawMessage{
+		op.MustHash(): json.RawMessage(`{"error":{"name":"Error","message":"card declined","data":null}}`),
+	}
+
+	resp := handlerPost(t, server.URL+"?fnId="+fn.Slug("test-must-run"), req)
+	defer resp.Body.Close()
+	byt, _ := io.ReadAll(resp.Body)
+	require.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	require.Contains(t, string(byt), "card declined")
+	require.NotContains(t, string(byt), "panicked")
+	require.Equal(t, "true", resp.Header.Get(HeaderKeyNoRetry))
+}
+
+func TestOutputSchema(t *testing.T) {
+	setEnvVars(t)
+
+	schema := jsonschema.MustParse(`{"type": "object", "required": ["id"], "properties": {"id": {"type": "integer"}}}`)
+	buf := &bytes.Buffer{}
+	h := NewHandler("test-output-schema", HandlerOpts{Logger: slog.New(slog.NewTextHandler(buf, nil))})
+	create := func(id string, strict bool) ServableFunction {
+		return CreateFunction(
+			FunctionOpts{ID: id, OutputSchema: schema, StrictOutputValidation: strict},
+			EventTrigger("my-event", nil),
+			func(ctx context.Context, input Input[map[string]any]) (any, error) {
+				return input.Event["data"], nil
+			},
+		)
+	}
+	lenient, strict := create("lenient", false), create("strict", true)
+	h.Register(lenient, strict)
+	server := httptest.NewServer(h)
+	defer server.Close()
+
+	call := func(t *testing.T, fn ServableFunction, data map[string]any) (*http.Response, string) {
+		resp := handlerPost(t, server.URL+"?fnId="+fn.Slug("test-output-schema"), createRequest(t, map[string]any{"name": "my-event", "data": data}))
+		defer resp.Body.Close()
+		byt, _ := io.ReadAll(resp.Body)
+		return resp, string(byt)
+	}
+
+	resp, body := call(t, strict, map[string]any{"id": 1})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.JSONEq(t, `{"id":1}`, body)
+
+	t.Run("invalid output is logged", func(t *testing.T) {
+		resp, body := call(t, lenient, map[string]any{"id": "1"})
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.JSONEq(t, `{"id":"1"}`, body)
+		require.Contains(t, buf.String(), "level=WARN msg=\"invalid output\"")
+		require.Contains(t, buf.String(), "expected integer, got string")
+	})
+
+	t.Run("strict validation fails the run", func(t *testing.T) {
+		resp, body := call(t, strict, map[string]any{})
+		require.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+		require.Contains(t, body, "invalid output: $: missing required property 'id'")
+	})
+
+	require.EqualError(t, FunctionOpts{Name: "fn", StrictOutputValidation: true}.Validate(), "StrictOutputValidation requires OutputSchema")
+}
+
+func TestTrackProgress(t *testing.T) {
+	setEnvVars(t)
+
+	create := func(id string, track bool) ServableFunction {
+		return CreateFunction(
+			FunctionOpts{ID: id, TrackProgress: track},
+			EventTrigger("my-event", nil),
+			func(ctx context.Context, input Input[any]) (any, error) {
+				return step.Run(ctx, "migrate", func(ctx context.Context) (int, error) {
+					return 1, step.Progress(ctx, 50, "halfway")
+				})
+			},
+		)
+	}
+	tracked, untracked := create("tracked", true), create("untracked", false)
+
+	var sent []map[string]any
+	events := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var evts []map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&evts))
+		sent = append(sent, evts...)
+		_, _ = w.Write([]byte(`{"ids":["evt-1"],"status":200}`))
+	}))
+	defer events.Close()
+	client := NewClient(ClientOpts{EventKey: StrPtr("key"), EventURL: StrPtr(events.URL)})
+
+	call := func(t *testing.T, opts HandlerOpts, fn ServableFunction) (*http.Response, string) {
+		sent = nil
+		opts.ProgressClient = client
+		h := NewHandler("test-progress", opts)
+		h.Register(tracked, untracked)
+		server := httptest.NewServer(h)
+		defer server.Close()
+		resp := handlerPost(t, server.URL+"?fnId="+fn.Slug("test-progress"), createRequest(t, map[string]any{"name": "my-event"}))
+		defer resp.Body.Close()
+		byt, _ := io.ReadAll(resp.Body)
+		return resp, strings.TrimSpace(string(byt))
+	}
+
+	t.Run("progress is sent as events", func(t *testing.T) {
+		// Tracking progress doesn't stream the response.
+		resp, _ := call(t, HandlerOpts{}, tracked)
+		require.Equal(t, 206, resp.StatusCode)
+
+		// The streamed body contains a single response.
+		resp, body := call(t, HandlerOpts{UseStreaming: true}, tracked)
+		require.Equal(t, 201, resp.StatusCode)
+		var sr StreamResponse
+		require.NoError(t, json.Unmarshal([]byte(body), &sr))
+		require.Equal(t, 206, sr.StatusCode)
+
+		require.Len(t, sent, 1)
+		require.Equal(t, ProgressEventName, sent[0]["name"])
+		require.Equal(t, map[string]any{
+			"id":          sdkrequest.UnhashedOp{Op: enums.OpcodeStep, ID: "migrate"}.MustHash(),
+			"name":        "migrate",
+			"percent":     float64(50),
+			"message":     "halfway",
+			"function_id": "fn-id",
+			"run_id":      "run-id",
+		}, sent[0]["data"])
+	})
+
+	t.Run("progress is discarded", func(t *testing.T) {
+		resp, _ := call(t, HandlerOpts{}, untracked)
+		require.Equal(t, 206, resp.StatusCode)
+		require.Empty(t, sent)
+	})
+}
+
+type memoryCheckpoints struct {
+	runs map[string]map[string]any
+	err  error
+}
+
+func (m *memoryCheckpoints) Save(ctx context.Context, runID string, data map[string]any) error {
+	if m.runs[runID] == nil {
+		m.runs[runID] = map[string]any{}
+	}
+	for id, val := range data {
+		m.runs[runID][id] = val
+	}
+	return m.err
+}
+
+func (m *memoryCheckpoints) Load(ctx context.Context, runID string) (map[string]any, error) {
+	return m.runs[runID], m.err
+}
+
+func TestCheckpointing(t *testing.T) {
+	h := NewHandler("test-checkpointing", HandlerOpts{}).(*handler)
+	backend := &memoryCheckpoints{runs: map[string]map[string]any{}}
+
+	var aCt, bCt int
+	fn := CreateFunction(
+		FunctionOpts{Name: "my-fn", Checkpointing: &CheckpointConfig{Backend: backend}},
+		EventTrigger("my-event", nil),
+		func(ctx context.Context, input Input[any]) (any, error) {
+			a, err := step.Run(ctx, "a", func(ctx context.Context) (int, error) {
+				aCt++
+				return 1, nil
+			})
+			if err != nil {
+				return nil, err
+			}
+			b, err := step.Run(ctx, "b", func(ctx context.Context) (int, error) {
+				bCt++
+				return 2, nil
+			})
+			return a + b, err
+		},
+	)
+
+	// steps holds the step state recorded by Inngest.
+	steps := map[string]any{}
+	call := func() (any, []state.GeneratorOpcode, error) {
+		req := createRequest(t, map[string]any{"name": "my-event"})
+		req.Steps = map[string]json.RawMessage{}
+		for id, data := range steps {
+			req.Steps[id], _ = json.Marshal(map[string]any{"data": data})
+		}
+		return h.invokeWithHooks(context.Background(), fn, req, nil)
+	}
+
+	// The process restarts after step "a" runs, before its result is sent to
+	// Inngest, so Inngest re-invokes the function without its state.
+	_, ops, err := call()
+	require.NoError(t, err)
+	require.Equal(t, "a", ops[0].Name)
+	require.Len(t, backend.runs["run-id"], 1)
+
+	// The checkpointed step is reported rather than re-executed.
+	_, ops, err = call()
+	require.NoError(t, err)
+	require.Len(t, ops, 1)
+	require.Equal(t, enums.OpcodeStepRun, ops[0].Op)
+	require.Equal(t, "a", ops[0].Name)
+	require.JSONEq(t, "1", string(ops[0].Data))
+	require.Equal(t, 1, aCt)
+	steps[ops[0].ID] = 1
+
+	// Step "b" runs, and the process restarts again.  Only "b" is restored,
+	// as "a" has state from Inngest.
+	_, ops, err = call()
+	require.NoError(t, err)
+	require.Equal(t, "b", ops[0].Name)
+	require.Len(t, backend.runs["run-id"], 2)
+
+	_, ops, err = call()
+	require.NoError(t, err)
+	require.Len(t, ops, 1)
+	require.Equal(t, "b", ops[0].Name)
+	require.JSONEq(t, "2", string(ops[0].Data))
+	require.Equal(t, 1, bCt)
+	steps[ops[0].ID] = 2
+
+	actual, ops, err := call()
+	require.NoError(t, err)
+	require.Empty(t, ops)
+	require.EqualValues(t, 3, actual)
+	require.Equal(t, 1, aCt)
+	require.Equal(t, 1, bCt)
+
+	t.Run("backend errors are ignored", func(t *testing.T) {
+		steps = map[string]any{}
+		backend.err = fmt.Errorf("unavailable")
+		_, ops, err := call()
+		require.NoError(t, err)
+		require.Equal(t, "a", ops[0].Name)
+		require.Equal(t, 2, aCt)
+	})
+
+	require.EqualError(t, FunctionOpts{Name: "fn", Checkpointing: &CheckpointConfig{}}.Validate(), "Checkpointing.Backend must be set")
+}
+
+func TestRunLogLevel(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	h := NewHandler("test-log-level", HandlerOpts{Logger: logger, Dev: BoolPtr(true)})
+
+	var levels []slog.Level
+	quiet := CreateFunction(
+		FunctionOpts{Name: "quiet", RunLogLevel: Ptr(slog.LevelWarn)},
+		EventTrigger("my-event", nil),
+		func(ctx context.Context, input Input[any]) (any, error) {
+			levels = append(levels, LogLevelFromContext(ctx))
+			return nil, nil
+		},
+	)
+	verbose := CreateFunction(
+		FunctionOpts{Name: "verbose"},
+		EventTrigger("my-event", nil),
+		func(ctx context.Context, input Input[any]) (any, error) {
+			levels = append(levels, LogLevelFromContext(ctx))
+			return nil, nil
+		},
+	)
+	h.Register(quiet, verbose)
+	server := httptest.NewServer(h)
+	defer server.Close()
+
+	call := func(t *testing.T, fn ServableFunction) string {
+		buf.Reset()
+		body, _ := json.Marshal(createRequest(t, map[string]any{"name": "my-event"}))
+		resp, err := http.Post(server.URL+"?fnId="+fn.Slug("test-log-level"), "application/json", bytes.NewReader(body))
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		return buf.String()
+	}
+
+	require.NotContains(t, call(t, quiet), "calling function")
+	require.Contains(t, call(t, verbose), "calling function")
+	require.Equal(t, []slog.Level{slog.LevelWarn, slog.LevelInfo}, levels)
+}
+
+func TestManagerLogger(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	h := NewHandler("test-manager-logger", HandlerOpts{Logger: logger, Dev: BoolPtr(true)}).(*handler)
+
+	fn := CreateFunction(
+		FunctionOpts{Name: "steps"},
+		EventTrigger("my-event", nil),
+		func(ctx context.Context, input Input[any]) (any, error) {
+			return step.Run(ctx, "a", func(ctx context.Context) (int, error) { return 1, nil })
+		},
+	)
+	_, ops, err := h.invokeWithHooks(context.Background(), fn, createRequest(t, map[string]any{"name": "my-event"}), nil)
+	require.NoError(t, err)
+	require.Len(t, ops, 1)
+
+	// Ops are logged using the handler's logger at DEBUG.
+	require.Contains(t, buf.String(), `level=DEBUG msg="appending op" run_id=run-id op=StepRun step=a`)
+}
+
+func TestSecretRedaction(t *testing.T) {
+	t.Setenv("TEST_API_KEY", "sk-global-secret")
+	t.Setenv("TEST_DB_PASSWORD", "hunter2")
+
+	buf := &bytes.Buffer{}
+	logger := slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	h := NewHandler("test-secrets", HandlerOpts{
+		Logger:              logger,
+		Dev:                 BoolPtr(true),
+		GlobalSecretEnvVars: []string{"TEST_API_KEY", "TEST_UNSET"},
+	})
+
+	fn := CreateFunction(
+		FunctionOpts{Name: "db", SecretEnv: []string{"TEST_DB_PASSWORD"}},
+		EventTrigger("my-event", nil),
+		func(ctx context.Context, input Input[any]) (any, error) {
+			return nil, fmt.Errorf("error connecting to postgres://admin:hunter2@db with key sk-global-secret")
+		},
+	)
+	h.Register(fn)
+	server := httptest.NewServer(h)
+	defer server.Close()
+
+	body, _ := json.Marshal(createRequest(t, map[string]any{"name": "my-event"}))
+	resp, err := http.Post(server.URL+"?fnId="+fn.Slug("test-secrets"), "application/json", bytes.NewReader(body))
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	logs := buf.String()
+	require.Contains(t, logs, "postgres://admin:[REDACTED]@db with key [REDACTED]")
+	require.NotContains(t, logs, "hunter2")
+	require.NotContains(t, logs, "sk-global-secret")
+
+	t.Run("attributes and groups", func(t *testing.T) {
+		buf.Reset()
+		l := loggerWithSecrets(logger, []string{"TEST_API_KEY"}).With("key", "sk-global-secret")
+		l.WithGroup("req").Info("using sk-global-secret",
+			slog.Group("auth", "token", "Bearer sk-global-secret"),
+			"count", 1,
+		)
+		require.Contains(t, buf.String(), `msg="using [REDACTED]" key=[REDACTED] req.auth.token="Bearer [REDACTED]" req.count=1`)
+	})
+
+	t.Run("set options", func(t *testing.T) {
+		buf.Reset()
+		h := NewHandler("test-secrets", HandlerOpts{}).(*handler)
+		h.SetOptions(HandlerOpts{Logger: logger, GlobalSecretEnvVars: []string{"TEST_API_KEY"}})
+		h.Logger.Info("using sk-global-secret")
+		require.Contains(t, buf.String(), `msg="using [REDACTED]"`)
+	})
+}
+
+func TestShutdown(t *testing.T) {
+	t.Run("waits for in-flight executions", func(t *testing.T) {
+		h := NewHandler("test-shutdown", HandlerOpts{ShutdownTimeout: time.Second}).(*handler)
+		fn := CreateFunction(
+			FunctionOpts{Name: "my-fn"},
+			EventTrigger("my-event", nil),
+			func(ctx context.Context, input Input[any]) (any, error) {
+				time.Sleep(50 * time.Millisecond)
+				return "ok", nil
+			},
+		)
+
+		errs := make(chan error, 1)
+		go func() {
+			_, _, err := h.invokeWithHooks(context.Background(), fn, createRequest(t, map[string]any{"name": "my-event"}), nil)
+			errs <- err
+		}()
+		time.Sleep(10 * time.Millisecond)
+
+		require.NoError(t, h.Shutdown(context.Background()))
+		require.NoError(t, <-errs)
+
+		// New executions are rejected after shutdown.
+		_, _, err := h.invokeWithHooks(context.Background(), fn, createRequest(t, map[string]any{"name": "my-event"}), nil)
+		require.ErrorIs(t, err, errShuttingDown)
+	})
+
+	t.Run("force-kills executions after the timeout", func(t *testing.T) {
+		h := NewHandler("test-shutdown", HandlerOpts{ShutdownTimeout: 50 * time.Millisecond}).(*handler)
+		fn := CreateFunction(
+			FunctionOpts{Name: "my-fn"},
+			EventTrigger("my-event", nil),
+			func(ctx context.Context, input Input[any]) (any, error) {
+				select {
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				case <-time.After(5 * time.Second):
+					return "ok", nil
+				}
+			},
+		)
+
+		errs := make(chan error, 1)
+		go func() {
+			req := createRequest(t, map[string]any{"name": "my-event"})
+			req.CallCtx.RunID = "run-sleeping"
+			_, _, err := h.invokeWithHooks(context.Background(), fn, req, nil)
+			errs <- err
+		}()
+		time.Sleep(10 * time.Millisecond)
+
+		start := time.Now()
+		err := h.Shutdown(context.Background())
+		require.Less(t, time.Since(start), time.Second)
+
+		serr := &ShutdownError{}
+		require.ErrorAs(t, err, &serr)
+		require.Equal(t, []string{"run-sleeping"}, serr.ForceKilledRunIDs)
+
+		select {
+		case err := <-errs:
+			require.ErrorIs(t, err, context.Canceled)
+		case <-time.After(time.Second):
+			require.FailNow(t, "execution not cancelled")
+		}
+	})
+
+	t.Run("doesn't cancel executions when requests are cancelled", func(t *testing.T) {
+		h := NewHandler("test-shutdown", HandlerOpts{}).(*handler)
+		fn := CreateFunction(
+			FunctionOpts{Name: "my-fn"},
+			EventTrigger("my-event", nil),
+			func(ctx context.Context, input Input[any]) (any, error) {
+				select {
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				case <-time.After(50 * time.Millisecond):
+					return "ok", nil
+				}
+			},
+		)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		resp, _, err := h.invokeWithHooks(ctx, fn, createRequest(t, map[string]any{"name": "my-event"}), nil)
+		require.NoError(t, err)
+		require.Equal(t, "ok", resp)
+	})
+}
+
+func TestFunctionHTTPConfig(t *testing.T) {
+	h := NewHandler("test-http-config", HandlerOpts{Dev: BoolPtr(true)})
+
+	sleep := func(ctx context.Context, input Input[any]) (any, error) {
+		time.Sleep(500 * time.Millisecond)
+		return "ok", nil
+	}
+	timeout := CreateFunction(
+		FunctionOpts{Name: "timeout", HTTP: &FunctionHTTPConfig{WriteTimeout: Ptr(100 * time.Millisecond)}},
+		EventTrigger("my-event", nil),
+		sleep,
+	)
+	slow := CreateFunction(
+		FunctionOpts{Name: "slow", HTTP: &FunctionHTTPConfig{WriteTimeout: Ptr(2 * time.Second)}},
+		EventTrigger("my-event", nil),
+		sleep,
+	)
+	small := CreateFunction(
+		FunctionOpts{Name: "small", HTTP: &FunctionHTTPConfig{MaxBodySize: Ptr(int64(10))}},
+		EventTrigger("my-event", nil),
+		func(ctx context.Context, input Input[any]) (any, error) {
+			return "ok", nil
+		},
+	)
+	h.Register(timeout, slow, small)
+	server := httptest.NewServer(h)
+	defer server.Close()
+
+	call := func(t *testing.T, fn ServableFunction) (*http.Response, error) {
+		body, _ := json.Marshal(createRequest(t, map[string]any{"name": "my-event"}))
+		return http.Post(server.URL+"?fnId="+fn.Slug("test-http-config"), "application/json", bytes.NewReader(body))
+	}
+
+	t.Run("write timeout exceeded", func(t *testing.T) {
+		resp, err := call(t, timeout)
+		if err == nil {
+			// The connection is closed while reading the response.
+			_, err = io.ReadAll(resp.Body)
+			_ = resp.Body.Close()
+		}
+		require.Error(t, err)
+	})
+
+	t.Run("within write timeout", func(t *testing.T) {
+		resp, err := call(t, slow)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("max body size", func(t *testing.T) {
+		resp, err := call(t, small)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("invalid config", func(t *testing.T) {
+		err := FunctionOpts{
+			Name: "invalid",
+			HTTP: &FunctionHTTPConfig{WriteTimeout: Ptr(time.Duration(0))},
+		}.Validate()
+		require.ErrorContains(t, err, "WriteTimeout must be positive")
+	})
+}
+
+func TestBodyParser(t *testing.T) {
+	setEnvVars(t)
+
+	fn := CreateFunction(
+		FunctionOpts{Name: "my-fn"},
+		EventTrigger("my-event", nil),
+		func(ctx context.Context, input Input[map[string]any]) (any, error) {
+			return input.Event["data"], nil
+		},
+	)
+
+	// call sends a signed invoke request, with the given body encoding.
+	call := func(t *testing.T, h Handler, encoding string, encode func([]byte) []byte) *http.Response {
+		server := httptest.NewServer(h)
+		defer server.Close()
+
+		body, _ := json.Marshal(createRequest(t, map[string]any{"name": "my-event", "data": map[string]any{"ok": true}}))
+		sig, _ := Sign(context.Background(), time.Now(), []byte(testKey), body)
+		req, err := http.NewRequest(http.MethodPost, server.URL+"?fnId="+fn.Slug("test-body"), bytes.NewReader(encode(body)))
+		require.NoError(t, err)
+		req.Header.Set(HeaderKeySignature, sig)
+		req.Header.Set("Content-Encoding", encoding)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		return resp
+	}
+	gzipped := func(byt []byte) []byte {
+		buf := &bytes.Buffer{}
+		gw := gzip.NewWriter(buf)
+		_, _ = gw.Write(byt)
+		_ = gw.Close()
+		return buf.Bytes()
+	}
+
+	t.Run("decodes gzip bodies by default", func(t *testing.T) {
+		h := NewHandler("test-body", HandlerOpts{})
+		h.Register(fn)
+
+		resp := call(t, h, "gzip", gzipped)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		out, _ := io.ReadAll(resp.Body)
+		require.JSONEq(t, `{"ok":true}`, string(out))
+
+		resp = call(t, h, "br", func(byt []byte) []byte { return byt })
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("custom parser", func(t *testing.T) {
+		h := NewHandler("test-body", HandlerOpts{
+			BodyParser: func(r *http.Request) (io.Reader, error) {
+				if r.Header.Get("Content-Encoding") != "reversed" {
+					return nil, fmt.Errorf("body must be reversed")
+				}
+				byt, err := io.ReadAll(r.Body)
+				slices.Reverse(byt)
+				return bytes.NewReader(byt), err
+			},
+		})
+		h.Register(fn)
+
+		reverse := func(byt []byte) []byte {
+			out := slices.Clone(byt)
+			slices.Reverse(out)
+			return out
+		}
+		resp := call(t, h, "reversed", reverse)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp = call(t, h, "gzip", gzipped)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
+
+func TestMaxConcurrentFunctions(t *testing.T) {
+	h := NewHandler("test-concurrency", HandlerOpts{
+		Dev:                    BoolPtr(true),
+		MaxConcurrentFunctions: Ptr(1),
+	})
+
+	started := make(chan struct{})
+	unblock := make(chan struct{})
+	fn := CreateFunction(
+		FunctionOpts{Name: "blocking"},
+		EventTrigger("my-event", nil),
+		func(ctx context.Context, input Input[any]) (any, error) {
+			started <- struct{}{}
+			<-unblock
+			return "ok", nil
+		},
+	)
+	h.Register(fn)
+	server := httptest.NewServer(h)
+	defer server.Close()
+
+	call := func(t *testing.T) (*http.Response, error) {
+		body, _ := json.Marshal(createRequest(t, map[string]any{"name": "my-event"}))
+		return http.Post(server.URL+"?fnId="+fn.Slug("test-concurrency"), "application/json", bytes.NewReader(body))
+	}
+
+	require.Equal(t, 0, h.CurrentConcurrency())
+
+	type result struct {
+		resp *http.Response
+		err  error
+	}
+	first := make(chan result, 1)
+	go func() {
+		resp, err := call(t)
+		first <- result{resp, err}
+	}()
+	<-started
+	require.Equal(t, 1, h.CurrentConcurrency())
+
+	t.Run("rejects functions over the limit", func(t *testing.T) {
+		resp, err := call(t)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		require.Equal(t, "1", resp.Header.Get("Retry-After"))
+	})
+
+	close(unblock)
+	r := <-first
+	require.NoError(t, r.err)
+	_ = r.resp.Body.Close()
+	require.Equal(t, http.StatusOK, r.resp.StatusCode)
+	require.Equal(t, 0, h.CurrentConcurrency())
+
+	t.Run("accepts functions once slots are released", func(t *testing.T) {
+		go func() { <-started }()
+		resp, err := call(t)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+}
+
+func TestOutboundRequestInterceptor(t *testing.T) {
+	setEnvVars(t)
+
+	var headers http.Header
+	mockCloud := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header.Clone()
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer mockCloud.Close()
+	registerURL := mockCloud.URL + "/fn/register"
+
+	h := NewHandler("test-interceptor", HandlerOpts{
+		RegisterURL: &registerURL,
+		Dev:         BoolPtr(false),
+		OutboundRequestInterceptor: func(r *http.Request) *http.Request {
+			r.Header.Set("Proxy-Authorization", "Basic dXNlcjpwYXNz")
+			return r
+		},
+	}).(*handler)
+	require.NoError(t, h.register(httptest.NewRecorder(), httptest.NewRequest(http.MethodPut, "/", nil)))
+	require.Equal(t, "Basic dXNlcjpwYXNz", headers.Get("Proxy-Authorization"))
+	// Headers set by the handler are retained.
+	require.NotEmpty(t, headers.Get(HeaderKeyAuthorization))
+
+	t.Run("nil requests are sent unmodified", func(t *testing.T) {
+		h := NewHandler("test-interceptor", HandlerOpts{
+			RegisterURL:                &registerURL,
+			Dev:                        BoolPtr(false),
+			OutboundRequestInterceptor: func(r *http.Request) *http.Request { return nil },
+		}).(*handler)
+		require.NoError(t, h.register(httptest.NewRecorder(), httptest.NewRequest(http.MethodPut, "/", nil)))
+		require.Empty(t, headers.Get("Proxy-Authorization"))
+	})
+
+	t.Run("the client is reused", func(t *testing.T) {
+		require.Same(t, h.httpClient(), h.httpClient())
+	})
+
+	t.Run("events", func(t *testing.T) {
+		c := NewClient(ClientOpts{
+			EventKey: StrPtr("key"),
+			EventURL: StrPtr(mockCloud.URL),
+			OutboundRequestInterceptor: func(r *http.Request) *http.Request {
+				r.Header.Set("Proxy-Authorization", "Basic dXNlcjpwYXNz")
+				return r
+			},
+		})
+		_, err := c.Send(context.Background(), Event{Name: "my-event", Data: map[string]any{"ok": true}})
+		require.NoError(t, err)
+		require.Equal(t, "Basic dXNlcjpwYXNz", headers.Get("Proxy-Authorization"))
+	})
+}
+
+func TestFunctionHealthCheck(t *testing.T) {
+	h := NewHandler("test-health", HandlerOpts{Dev: BoolPtr(true)})
+
+	var dbErr error
+	db := CreateFunction(
+		FunctionOpts{
+			Name: "db",
+			HealthCheck: func(ctx context.Context) error {
+				return dbErr
+			},
+		},
+		EventTrigger("my-event", nil),
+		func(ctx context.Context, input Input[any]) (any, error) {
+			return "ok", nil
+		},
+	)
+	other := CreateFunction(
+		FunctionOpts{Name: "other"},
+		EventTrigger("my-event", nil),
+		func(ctx context.Context, input Input[any]) (any, error) {
+			return "ok", nil
+		},
+	)
+	h.Register(db, other)
+	server := httptest.NewServer(h)
+	defer server.Close()
+
+	call := func(t *testing.T, fn ServableFunction) int {
+		body, _ := json.Marshal(createRequest(t, map[string]any{"name": "my-event"}))
+		resp, err := http.Post(server.URL+"?fnId="+fn.Slug("test-health"), "application/json", bytes.NewReader(body))
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	dbErr = fmt.Errorf("connection refused")
+	err := h.HealthCheck(context.Background())
+	require.ErrorContains(t, err, "function 'test-health-db' is unhealthy: connection refused")
+	require.ErrorIs(t, h.FunctionHealth(db.Slug("test-health")), dbErr)
+	require.NoError(t, h.FunctionHealth(other.Slug("test-health")))
+	require.Equal(t, http.StatusServiceUnavailable, call(t, db))
+	require.Equal(t, http.StatusOK, call(t, other))
+
+	dbErr = nil
+	require.NoError(t, h.HealthCheck(context.Background()))
+	require.NoError(t, h.FunctionHealth(db.Slug("test-health")))
+	require.Equal(t, http.StatusOK, call(t, db))
+
+	require.ErrorIs(t, h.FunctionHealth("missing"), errFunctionMissing)
+}
+
+func TestFunctionInit(t *testing.T) {
+	h := NewHandler("test-init", HandlerOpts{Dev: BoolPtr(true)}).(*handler)
+
+	var calls int32
+	warm := CreateFunction(
+		FunctionOpts{
+			Name:     "warm",
+			WarmPool: IntPtr(2),
+			InitFunc: func(ctx context.Context) error {
+				atomic.AddInt32(&calls, 1)
+				return nil
+			},
+		},
+		EventTrigger("my-event", nil),
+		func(ctx context.Context, input Input[any]) (any, error) {
+			return "ok", nil
+		},
+	)
+	failing := CreateFunction(
+		FunctionOpts{
+			Name: "failing",
+			InitFunc: func(ctx context.Context) error {
+				return fmt.Errorf("missing credentials")
+			},
+		},
+		EventTrigger("my-event", nil),
+		func(ctx context.Context, input Input[any]) (any, error) {
+			return "ok", nil
+		},
+	)
+	h.Register(warm, failing)
+	require.EqualValues(t, 1, atomic.LoadInt32(&calls))
+
+	require.NoError(t, h.FunctionHealth(warm.Slug("test-init")))
+	require.ErrorContains(t, h.FunctionHealth(failing.Slug("test-init")), "missing credentials")
+
+	// Warm pools aren't supported by the server, so they're never synced.
+	appURL, _ := url.Parse("http://test.local")
+	fns, err := createFunctionConfigs("test-init", []ServableFunction{warm}, *appURL, false, "")
+	require.NoError(t, err)
+	byt, err := json.Marshal(fns[0])
+	require.NoError(t, err)
+	require.NotContains(t, string(byt), "warmPool")
+
+	_, err = createFunctionConfigs("test-init", []ServableFunction{
+		CreateFunction(
+			FunctionOpts{Name: "invalid", WarmPool: IntPtr(0)},
+			EventTrigger("my-event", nil),
+			func(ctx context.Context, input Input[any]) (any, error) { return nil, nil },
+		),
+	}, *appURL, false, "")
+	require.ErrorContains(t, err, "WarmPool must be at least 1")
+}
+
+func TestEventAck(t *testing.T) {
+	setEnvVars(t)
+
+	h := NewHandler("test-event-ack", HandlerOpts{}).(*handler)
+	manual := &AckConfig{Mode: AckModeManual, AckTimeout: time.Minute}
+	ackOp := sdkrequest.UnhashedOp{Op: enums.OpcodeStepRun, ID: "inngest/ack"}
+
+	t.Run("acks the event", func(t *testing.T) {
+		fn := CreateFunction(
+			FunctionOpts{Name: "my-fn", EventAck: manual},
+			EventTrigger("my-event", nil),
+			func(ctx context.Context, input Input[any]) (any, error) {
+				if err := step.Ack(ctx); err != nil {
+					return nil, err
+				}
+				return "done", nil
+			},
+		)
+
+		_, ops, err := h.invokeWithHooks(context.Background(), fn, createRequest(t, map[string]any{"name": "my-event"}), nil)
+		require.NoError(t, err)
+		require.Len(t, ops, 1)
+		require.Equal(t, ackOp.MustHash(), ops[0].ID)
+		require.Nil(t, ops[0].Opts)
+		require.JSONEq(t, `null`, string(ops[0].Data))
+
+		req := createRequest(t, map[string]any{"name": "my-event"})
+		req.Steps = map[string]json.RawMessage{ackOp.MustHash(): json.RawMessage(`{"data":null}`)}
+		resp, ops, err := h.invokeWithHooks(context.Background(), fn, req, nil)
+		require.NoError(t, err)
+		require.Empty(t, ops)
+		require.Equal(t, "done", resp)
+	})
+
+	t.Run("nacks the event", func(t *testing.T) {
+		fn := CreateFunction(
+			FunctionOpts{Name: "my-fn", EventAck: manual},
+			EventTrigger("my-event", nil),
+			func(ctx context.Context, input Input[any]) (any, error) {
+				return nil, step.Nack(ctx, "malformed")
+			},
+		)
+
+		// Nacks aren't recorded:  the attempt fails with a retryable error so
+		// that the event is redelivered.
+		_, ops, err := h.invokeWithHooks(context.Background(), fn, createRequest(t, map[string]any{"name": "my-event"}), nil)
+		require.Empty(t, ops)
+		require.EqualError(t, err, "function rejected its event: malformed")
+		require.False(t, sdkerrors.IsNoRetryError(err))
+	})
+
+	t.Run("redelivers nacked events", func(t *testing.T) {
+		fn := CreateFunction(
+			FunctionOpts{Name: "my-fn", EventAck: &AckConfig{Mode: AckModeManual, AckTimeout: 50 * time.Millisecond}},
+			EventTrigger("my-event", nil),
+			func(ctx context.Context, input Input[any]) (any, error) {
+				if _, err := step.Run(ctx, "load", func(ctx context.Context) (string, error) {
+					return "loaded", nil
+				}); err != nil {
+					return nil, err
+				}
+				if input.InputCtx.Attempt == 0 {
+					return nil, step.Nack(ctx, "not ready")
+				}
+				if err := step.Ack(ctx); err != nil {
+					return nil, err
+				}
+				return "done", nil
+			},
+		)
+
+		// The event is older than the timeout, which applies to each attempt
+		// rather than to the event.
+		req := createRequest(t, map[string]any{"name": "my-event", "ts": time.Now().Add(-time.Hour).UnixMilli()})
+		loadOp := sdkrequest.UnhashedOp{Op: enums.OpcodeStepRun, ID: "load"}
+		req.Steps = map[string]json.RawMessage{loadOp.MustHash(): json.RawMessage(`{"data":"loaded"}`)}
+		_, ops, err := h.invokeWithHooks(context.Background(), fn, req, nil)
+		require.Empty(t, ops)
+		require.EqualError(t, err, "function rejected its event: not ready")
+		require.False(t, sdkerrors.IsNoRetryError(err))
+
+		// The retry runs with the first attempt's state, which doesn't
+		// include the nack, so the function can ack the event.
+		req.CallCtx.Attempt = 1
+		_, ops, err = h.invokeWithHooks(context.Background(), fn, req, nil)
+		require.NoError(t, err)
+		require.Len(t, ops, 1)
+		require.Equal(t, ackOp.MustHash(), ops[0].ID)
+
+		req.Steps[ackOp.MustHash()] = json.RawMessage(`{"data":null}`)
+		resp, ops, err := h.invokeWithHooks(context.Background(), fn, req, nil)
+		require.NoError(t, err)
+		require.Empty(t, ops)
+		require.Equal(t, "done", resp)
+	})
+
+	t.Run("errors if not acked", func(t *testing.T) {
+		fn := CreateFunction(
+			FunctionOpts{Name: "my-fn", EventAck: manual},
+			EventTrigger("my-event", nil),
+			func(ctx context.Context, input Input[any]) (any, error) {
+				return "done", nil
+			},
+		)
+
+		_, _, err := h.invokeWithHooks(context.Background(), fn, createRequest(t, map[string]any{"name": "my-event"}), nil)
+		require.EqualError(t, err, "function finished without acknowledging its event")
+	})
+
+	t.Run("times out", func(t *testing.T) {
+		fn := CreateFunction(
+			FunctionOpts{Name: "my-fn", EventAck: &AckConfig{Mode: AckModeManual, AckTimeout: 10 * time.Millisecond}},
+			EventTrigger("my-event", nil),
+			func(ctx context.Context, input Input[any]) (any, error) {
+				<-ctx.Done()
+				return nil, step.Ack(ctx)
+			},
+		)
+
+		_, ops, err := h.invokeWithHooks(context.Background(), fn, createRequest(t, map[string]any{"name": "my-event"}), nil)
+		require.Empty(t, ops)
+		require.EqualError(t, err, "function didn't acknowledge its event within 10ms")
+		require.False(t, sdkerrors.IsNoRetryError(err))
+	})
+
+	t.Run("ignores the timeout once acked", func(t *testing.T) {
+		fn := CreateFunction(
+			FunctionOpts{Name: "my-fn", EventAck: &AckConfig{Mode: AckModeManual, AckTimeout: time.Millisecond}},
+			EventTrigger("my-event", nil),
+			func(ctx context.Context, input Input[any]) (any, error) {
+				if err := step.Ack(ctx); err != nil {
+					return nil, err
+				}
+				time.Sleep(10 * time.Millisecond)
+				return "done", nil
+			},
+		)
+
+		// The function runs for longer than the timeout, but its ack is
+		// already recorded.
+		req := createRequest(t, map[string]any{"name": "my-event"})
+		req.Steps = map[string]json.RawMessage{ackOp.MustHash(): json.RawMessage(`{"data":null}`)}
+		resp, ops, err := h.invokeWithHooks(context.Background(), fn, req, nil)
+		require.NoError(t, err)
+		require.Empty(t, ops)
+		require.Equal(t, "done", resp)
+	})
+
+	t.Run("auto mode", func(t *testing.T) {
+		fn := CreateFunction(
+			FunctionOpts{Name: "my-fn", EventAck: &AckConfig{Mode: AckModeAuto}},
+			EventTrigger("my-event", nil),
+			func(ctx context.Context, input Input[any]) (any, error) {
+				return nil, step.Ack(ctx)
+			},
+		)
+
+		_, ops, err := h.invokeWithHooks(context.Background(), fn, createRequest(t, map[string]any{"name": "my-event"}), nil)
+		require.ErrorIs(t, err, step.ErrManualAckDisabled)
+		require.Empty(t, ops)
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		err := FunctionOpts{Name: "my-fn", EventAck: &AckConfig{Mode: AckModeManual}}.Validate()
+		require.EqualError(t, err, "invalid EventAck: AckTimeout must be greater than 0 in manual mode")
+
+		err = FunctionOpts{Name: "my-fn", EventAck: &AckConfig{Mode: "sometimes"}}.Validate()
+		require.EqualError(t, err, `invalid EventAck: Mode must be one of "auto", "manual"`)
+	})
+}
+
+func TestSideEffectOnce(t *testing.T) {
+	setEnvVars(t)
+
+	h := NewHandler("test-side-effect-once", HandlerOpts{}).(*handler)
+	var sent atomic.Int32
+	fn := CreateFunction(
+		FunctionOpts{Name: "my-fn", SideEffectMode: SideEffectOnce},
+		EventTrigger("my-event", nil),
+		func(ctx context.Context, input Input[any]) (any, error) {
+			user, err := step.Run(ctx, "load-user", func(ctx context.Context) (string, error) {
+				return "alice", nil
+			})
+			if err != nil {
+				return nil, err
+			}
+			sent.Add(1)
+			return "sent to " + user, nil
+		},
+	)
+	loadUser := sdkrequest.UnhashedOp{Op: enums.OpcodeStep, ID: "load-user"}
+	done := sdkrequest.UnhashedOp{Op: enums.OpcodeStepRun, ID: sideEffectOnceStepID}
+
+	// Steps run as usual.
+	_, ops, err := h.invokeWithHooks(context.Background(), fn, createRequest(t, map[string]any{"name": "my-event"}), nil)
+	require.NoError(t, err)
+	require.Len(t, ops, 1)
+	require.Equal(t, loadUser.MustHash(), ops[0].ID)
+	require.Zero(t, sent.Load())
+
+	// The function's output is saved as a step once it finishes.
+	req := createRequest(t, map[string]any{"name": "my-event"})
+	req.Steps = map[string]json.RawMessage{loadUser.MustHash(): json.RawMessage(`{"data":"alice"}`)}
+	resp, ops, err := h.invokeWithHooks(context.Background(), fn, req, nil)
+	require.NoError(t, err)
+	require.Nil(t, resp)
+	require.Len(t, ops, 1)
+	require.Equal(t, done.MustHash(), ops[0].ID)
+	require.JSONEq(t, `"sent to alice"`, string(ops[0].Data))
+	require.EqualValues(t, 1, sent.Load())
+
+	// Re-executing the run returns the saved output without calling the
+	// function again.
+	req.Steps[done.MustHash()] = json.RawMessage(`{"data":"sent to alice"}`)
+	resp, ops, err = h.invokeWithHooks(context.Background(), fn, req, nil)
+	require.NoError(t, err)
+	require.Empty(t, ops)
+	require.Equal(t, json.RawMessage(`"sent to alice"`), resp)
+	require.EqualValues(t, 1, sent.Load())
+}
+
+func TestRetryJitter(t *testing.T) {
+	setEnvVars(t)
+
+	prev := jitterRand
+	jitterRand = func() float64 { return 0.5 }
+	t.Cleanup(func() { jitterRand = prev })
+
+	t.Run("delays", func(t *testing.T) {
+		r := *WithBoundedExponentialBackoff(5, time.Second, time.Minute, WithFullJitter())
+		require.Equal(t, 1.0, r.JitterFactor)
+		require.Equal(t, 1500*time.Millisecond, r.retryDelay(0))
+		require.Equal(t, 6*time.Second, r.retryDelay(2))
+		require.Equal(t, time.Minute, r.retryDelay(10))
+
+		r = RetryConfig{Attempts: 5, Backoff: BackoffLinear, MinRetryDelay: Ptr(time.Second)}
+		WithEqualJitter()(&r)
+		require.Equal(t, 0.5, r.JitterFactor)
+		require.Equal(t, 3750*time.Millisecond, r.retryDelay(2))
+
+		r = RetryConfig{Attempts: 5, Backoff: BackoffConstant, JitterFactor: 0.5}
+		require.Equal(t, 12500*time.Millisecond, r.retryDelay(3))
+
+		// Large attempts don't overflow without a MaxRetryDelay.
+		r = RetryConfig{Attempts: 100, JitterFactor: 1}
+		require.Equal(t, retryMaxDelay, r.retryDelay(64))
+		require.Equal(t, retryMaxDelay, r.retryDelay(2000))
+	})
+
+	t.Run("retry after header", func(t *testing.T) {
+		fn := CreateFunction(
+			FunctionOpts{Name: "my-fn", Retry: WithBoundedExponentialBackoff(3, time.Minute, time.Hour, WithFullJitter())},
+			EventTrigger("my-event", nil),
+			func(ctx context.Context, input Input[any]) (any, error) {
+				return nil, fmt.Errorf("oh no")
+			},
+		)
+		h := NewHandler("test-retry-jitter", HandlerOpts{})
+		h.Register(fn)
+		server := httptest.NewServer(h)
+		defer server.Close()
+
+		req := createRequest(t, map[string]any{"name": "my-event"})
+		req.CallCtx.Attempt = 1
+		resp := handlerPost(t, server.URL+"?fnId="+fn.Slug("test-retry-jitter"), req)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+
+		at, err := time.Parse(time.RFC3339, resp.Header.Get(HeaderKeyRetryAfter))
+		require.NoError(t, err)
+		require.WithinDuration(t, time.Now().Add(3*time.Minute), at, 2*time.Second)
+
+		// Delays are computed by the SDK without jitter, too.
+		fn = CreateFunction(
+			FunctionOpts{Name: "my-linear-fn", Retry: &RetryConfig{Attempts: 3, Backoff: BackoffLinear, MinRetryDelay: Ptr(time.Minute)}},
+			EventTrigger("my-event", nil),
+			func(ctx context.Context, input Input[any]) (any, error) {
+				return nil, fmt.Errorf("oh no")
+			},
+		)
+		h.Register(fn)
+		resp = handlerPost(t, server.URL+"?fnId="+fn.Slug("test-retry-jitter"), req)
+		defer resp.Body.Close()
+		at, err = time.Parse(time.RFC3339, resp.Header.Get(HeaderKeyRetryAfter))
+		require.NoError(t, err)
+		require.WithinDuration(t, time.Now().Add(2*time.Minute), at, 2*time.Second)
+
+		// The final attempt isn't retried.
+		req.CallCtx.Attempt = 3
+		resp = handlerPost(t, server.URL+"?fnId="+fn.Slug("test-retry-jitter"), req)
+		defer resp.Body.Close()
+		require.Empty(t, resp.Header.Get(HeaderKeyRetryAfter))
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		err := FunctionOpts{Name: "my-fn", Retry: &RetryConfig{Attempts: 3, JitterFactor: 1.5}}.Validate()
+		require.EqualError(t, err, "invalid retry config: JitterFactor must be between 0.0 and 1.0")
+	})
 }
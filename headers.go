@@ -3,6 +3,7 @@ package inngestgo
 import (
 	"fmt"
 	"net/http"
+	"strings"
 )
 
 const (
@@ -34,3 +35,30 @@ func SetBasicResponseHeaders(w http.ResponseWriter) {
 	w.Header().Set(HeaderKeySDK, HeaderValueSDK)
 	w.Header().Set(HeaderKeyUserAgent, HeaderValueSDK)
 }
+
+// sdkHeaderValue returns the X-Inngest-SDK header value, suffixed with the app
+// version if set, eg. "go:v0.8.0/app:v1.2.3".
+func sdkHeaderValue(appVersion string) string {
+	if appVersion == "" {
+		return HeaderValueSDK
+	}
+	return fmt.Sprintf("%s/app:v%s", HeaderValueSDK, strings.TrimPrefix(appVersion, "v"))
+}
+
+// isProtectedHeader returns whether the given header is set by the SDK and must
+// not be overridden by HandlerOpts.CustomHeaders.
+func isProtectedHeader(key string) bool {
+	key = strings.ToLower(key)
+	return strings.HasPrefix(key, "x-inngest-") || key == strings.ToLower(HeaderKeyContentType)
+}
+
+// validateCustomHeaders returns an error if any custom header would override a
+// protected header.
+func validateCustomHeaders(headers map[string]string) error {
+	for k := range headers {
+		if isProtectedHeader(k) {
+			return fmt.Errorf("custom header %q overrides a protocol header", k)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package inngestgo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var errFunctionUnhealthy = fmt.Errorf("function is unhealthy")
+
+// functionHealth stores the results of functions' health checks, by slug.
+type functionHealth struct {
+	l       sync.RWMutex
+	results map[string]error
+}
+
+// HealthCheck calls the health check of every registered function, returning
+// the joined errors of any unhealthy functions.  Until a function's health
+// check passes again, invoke requests for the function are rejected with a
+// 503.
+func (h *handler) HealthCheck(ctx context.Context) error {
+	h.l.RLock()
+	funcs := make([]ServableFunction, len(h.funcs))
+	copy(funcs, h.funcs)
+	h.l.RUnlock()
+
+	results := map[string]error{}
+	var errs []error
+	for _, fn := range funcs {
+		check := fn.Config().HealthCheck
+		if check == nil {
+			continue
+		}
+		slug := fn.Slug(h.appName)
+		if err := check(ctx); err != nil {
+			results[slug] = err
+			errs = append(errs, fmt.Errorf("function '%s' is unhealthy: %w", slug, err))
+		}
+	}
+
+	h.health.l.Lock()
+	h.health.results = results
+	h.health.l.Unlock()
+
+	return errors.Join(errs...)
+}
+
+// FunctionHealth returns the error from the given function's InitFunc or last
+// health check, or nil if the function is healthy.
+func (h *handler) FunctionHealth(functionID string) error {
+	fn := h.invokableFunction(functionID)
+	if fn == nil {
+		return fmt.Errorf("%w: %s", errFunctionMissing, functionID)
+	}
+
+	slug := fn.Slug(h.appName)
+	if err := h.initErr(slug); err != nil {
+		return fmt.Errorf("error initializing function: %w", err)
+	}
+
+	h.health.l.RLock()
+	defer h.health.l.RUnlock()
+	return h.health.results[slug]
+}
@@ -0,0 +1,279 @@
+package inngestgo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/inngest/inngest/pkg/enums"
+	"github.com/inngest/inngest/pkg/execution/state"
+	sdkerrors "github.com/khulnasoft-lab/inngestgo/errors"
+	"github.com/khulnasoft-lab/inngestgo/internal/sdkrequest"
+)
+
+// FunctionStartInfo is passed to HandlerOpts.OnFunctionStart when a function run
+// starts.
+type FunctionStartInfo struct {
+	// FunctionID is the ID of the function.
+	FunctionID string
+	// RunID is the ID of the function run.
+	RunID string
+	// AttemptNumber is the zero-based attempt number of the run.
+	AttemptNumber int
+	// Event is the event which triggered the function.
+	Event json.RawMessage
+}
+
+// FunctionEndInfo is passed to HandlerOpts.OnFunctionEnd when a function run
+// finishes, whether it succeeds or fails.
+type FunctionEndInfo struct {
+	FunctionStartInfo
+
+	// Duration is how long the final execution of the function took.  Functions
+	// with steps are executed once per step, so this does not include the time
+	// spent in previous steps.
+	Duration time.Duration
+	// Output is the JSON-encoded output of the function, if it succeeded.
+	Output json.RawMessage
+	// Err is the error returned by the function, if it failed.
+	Err error
+}
+
+// HookConfig configures hooks which are called as steps within a function are
+// executed, eg. to create APM spans for each step.  The hooks are called
+// synchronously, only when a step's function is called:  they're not called for
+// steps which are replayed from state.
+type HookConfig struct {
+	// OnStepStart is called before a step's function is called, with the
+	// step's hashed ID and its name.
+	OnStepStart func(ctx context.Context, stepID, stepName string)
+	// OnStepEnd is called after a step's function returns, with the step's
+	// result and error.
+	OnStepEnd func(ctx context.Context, stepID, stepName string, result any, err error)
+}
+
+// invokeWithHooks invokes the given function using the handler's options,
+// enforcing the handler's concurrency limit, tracking the execution for
+// Shutdown, restoring and saving checkpointed step state, tracing the execution
+// and enforcing its memory limit.
+func (h *handler) invokeWithHooks(
+	ctx context.Context,
+	fn ServableFunction,
+	request *sdkrequest.Request,
+	stepID *string,
+) (any, []state.GeneratorOpcode, error) {
+	if h.concurrency != nil {
+		release, err := h.concurrency.acquire()
+		if err != nil {
+			return nil, nil, err
+		}
+		defer release()
+	}
+
+	ctx, done, err := h.executions.start(ctx, request.CallCtx.RunID)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer done()
+
+	if restorer := h.checkpointRestorer(ctx, fn, request); restorer != nil {
+		ctx = sdkrequest.WithStepRestorer(ctx, restorer)
+	}
+	ctx, end := startFunctionSpan(ctx, fn, request)
+	ctx, stop := monitorMemory(ctx, fn.Config().ResourceLimits)
+	resp, ops, err := h.invokeFunction(ctx, fn, request, stepID)
+	if merr := stop(); merr != nil {
+		resp, ops, err = nil, nil, merr
+	}
+	end(err)
+	h.saveCheckpoint(ctx, fn, request, ops)
+
+	if dr := fn.Config().DynamicRetry; dr != nil && willRetry(fn.Config(), request.CallCtx.Attempt, ops, err) {
+		err = applyRetryPolicy(ctx, dr, request.CallCtx.Attempt, err)
+	}
+	if r := fn.Config().retryConfig(); r != nil && willRetry(fn.Config(), request.CallCtx.Attempt, ops, err) {
+		err = applyRetryDelay(*r, request.CallCtx.Attempt, err)
+	}
+	if err == nil && len(ops) == 0 && fn.Config().OutputSchema != nil {
+		if verr := h.validateOutput(fn, request, resp); verr != nil {
+			resp, err = nil, verr
+		}
+	}
+
+	// The run has succeeded if there are no more steps to run.
+	if err == nil && len(ops) == 0 && fn.Config().OnSuccess != nil {
+		h.onSuccess(ctx, fn, resp)
+	}
+	if fn.Config().OnRetry != nil && willRetry(fn.Config(), request.CallCtx.Attempt, ops, err) {
+		h.onRetry(ctx, fn, request.CallCtx.Attempt+1, err)
+	}
+	return resp, ops, err
+}
+
+// validateOutput validates the function's output against its OutputSchema,
+// logging validation failures.  Failures are only returned when the function
+// uses StrictOutputValidation.
+func (h *handler) validateOutput(fn ServableFunction, request *sdkrequest.Request, resp any) error {
+	byt, err := json.Marshal(resp)
+	if err != nil {
+		return fmt.Errorf("error marshalling output: %w", err)
+	}
+	verr := fn.Config().OutputSchema.Validate(byt)
+	if verr == nil {
+		return nil
+	}
+	if fn.Config().StrictOutputValidation {
+		return fmt.Errorf("invalid output: %w", verr)
+	}
+	h.Logger.Warn("invalid output", "fn", fn.Slug(h.appName), "run_id", request.CallCtx.RunID, "error", verr)
+	return nil
+}
+
+// willRetry returns whether Inngest retries the function after the given
+// zero-based attempt returned the ops and error.
+func willRetry(c FunctionOpts, attempt int, ops []state.GeneratorOpcode, err error) bool {
+	if err == nil || sdkerrors.IsNoRetryError(err) {
+		return false
+	}
+	// Step errors are retried via an OpcodeStepError, whereas unhandled step
+	// errors returned from the function are never retried.
+	stepErr := len(ops) == 1 && ops[0].Op == enums.OpcodeStepError
+	if sdkerrors.IsStepError(err) && !stepErr {
+		return false
+	}
+
+	retries := defaultRetries
+	if n := c.retries(); n != nil {
+		retries = *n
+	}
+	return attempt < retries
+}
+
+// applyRetryPolicy wraps the error from a retryable attempt using the dynamic
+// retry policy's decision, so that the decision is sent to Inngest within the
+// response's No-Retry and Retry-After headers.  Errors which already specify
+// a retry time are unchanged.
+func applyRetryPolicy(ctx context.Context, dr *DynamicRetryConfig, attempt int, err error) error {
+	if sdkerrors.GetRetryAtTime(err) != nil {
+		return err
+	}
+	policy := dr.Policy(ctx, err, attempt)
+	switch {
+	case !policy.Retry:
+		return sdkerrors.NoRetryError(err)
+	case policy.Wait > 0:
+		return sdkerrors.RetryAtError(err, time.Now().Add(policy.Wait))
+	}
+	return err
+}
+
+// applyRetryDelay wraps the error from a retryable attempt with the retry time
+// computed using the retry config, as Inngest doesn't support configuring
+// retry delays.  Errors which already specify a retry time are unchanged.
+func applyRetryDelay(r RetryConfig, attempt int, err error) error {
+	if sdkerrors.GetRetryAtTime(err) != nil || sdkerrors.IsNoRetryError(err) {
+		return err
+	}
+	return sdkerrors.RetryAtError(err, time.Now().Add(r.retryDelay(attempt)))
+}
+
+// onRetry calls the function's OnRetry hook, logging panics within the hook.
+func (h *handler) onRetry(ctx context.Context, fn ServableFunction, attempt int, lastErr error) {
+	defer func() {
+		if r := recover(); r != nil {
+			h.Logger.Error("OnRetry panicked", "fn", fn.Slug(h.appName), "panic", r)
+		}
+	}()
+	fn.Config().OnRetry(ctx, attempt, lastErr)
+}
+
+// onSuccessTimeout is how long the handler waits for FunctionOpts.OnSuccess.
+const onSuccessTimeout = 5 * time.Second
+
+// onSuccess calls the function's OnSuccess hook with the run's output, waiting
+// at most onSuccessTimeout for it to return.  Panics within the hook are
+// logged.
+func (h *handler) onSuccess(ctx context.Context, fn ServableFunction, resp any) {
+	result, err := json.Marshal(resp)
+	if err != nil {
+		h.Logger.Error("error marshalling output for OnSuccess", "fn", fn.Slug(h.appName), "error", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), onSuccessTimeout)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer func() {
+			if r := recover(); r != nil {
+				h.Logger.Error("OnSuccess panicked", "fn", fn.Slug(h.appName), "panic", r)
+			}
+		}()
+		fn.Config().OnSuccess(ctx, result)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		h.Logger.Warn("OnSuccess timed out", "fn", fn.Slug(h.appName))
+	}
+}
+
+// invokeFunction invokes the given function, calling the OnFunctionStart and
+// OnFunctionEnd hooks in new goroutines so that they never block the function.
+func (h *handler) invokeFunction(
+	ctx context.Context,
+	fn ServableFunction,
+	request *sdkrequest.Request,
+	stepID *string,
+) (any, []state.GeneratorOpcode, error) {
+	if h.StepIDHasher != nil {
+		ctx = sdkrequest.WithStepIDHasher(ctx, h.StepIDHasher)
+	}
+	if h.CloudEventEmitter != nil {
+		ctx = withCloudEventEmitter(ctx, h.CloudEventEmitter)
+	}
+	ctx = sdkrequest.WithLogger(ctx, loggerWithSecrets(loggerWithLevel(h.Logger, fn.Config().RunLogLevel), fn.Config().SecretEnv))
+
+	if h.OnFunctionStart == nil && h.OnFunctionEnd == nil {
+		return invoke(ctx, fn, request, stepID, h.StepEncryption)
+	}
+
+	// Hooks may run after the request finishes, so they must not be cancelled
+	// with the request.
+	hookCtx := context.WithoutCancel(ctx)
+	info := FunctionStartInfo{
+		FunctionID:    request.CallCtx.FunctionID,
+		RunID:         request.CallCtx.RunID,
+		AttemptNumber: request.CallCtx.Attempt,
+		Event:         request.Event,
+	}
+
+	// Functions run from the beginning for each step, so the run has only
+	// started on the first attempt without step state.
+	if h.OnFunctionStart != nil && request.CallCtx.Attempt == 0 && len(request.Steps) == 0 {
+		go h.OnFunctionStart(hookCtx, info)
+	}
+
+	start := time.Now()
+	resp, ops, err := invoke(ctx, fn, request, stepID, h.StepEncryption)
+
+	// The run has finished if there are no more steps to run and the attempt
+	// won't be retried.
+	if h.OnFunctionEnd != nil && len(ops) == 0 && !willRetry(fn.Config(), request.CallCtx.Attempt, ops, err) {
+		end := FunctionEndInfo{
+			FunctionStartInfo: info,
+			Duration:          time.Since(start),
+			Err:               err,
+		}
+		if err == nil {
+			end.Output, _ = json.Marshal(resp)
+		}
+		go h.OnFunctionEnd(hookCtx, end)
+	}
+
+	return resp, ops, err
+}
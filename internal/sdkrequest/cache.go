@@ -0,0 +1,56 @@
+package sdkrequest
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// StepResultCache stores step results by step ID.  This allows step state to be
+// injected into a request without constructing the hashed Steps map, eg. within
+// unit tests.
+type StepResultCache interface {
+	// Set stores the result of the step with the given ID.  If the same step ID
+	// is used more than once, subsequent steps are identified by "<id>:<n>",
+	// where n is the zero-based index of the step with that ID.
+	Set(stepID string, result any) error
+	// Get returns the JSON-encoded result of the step with the given ID.
+	Get(stepID string) (json.RawMessage, bool)
+}
+
+// NewStepResultCache returns an empty, concurrency-safe StepResultCache.
+func NewStepResultCache() StepResultCache {
+	return &stepResultCache{results: map[string]json.RawMessage{}}
+}
+
+// NewRequestFromCache returns a new request which uses the given cache for step
+// state.  Steps within the request's Steps map take precedence.
+func NewRequestFromCache(cache StepResultCache) *Request {
+	return &Request{
+		Steps: map[string]json.RawMessage{},
+		cache: cache,
+	}
+}
+
+type stepResultCache struct {
+	l       sync.RWMutex
+	results map[string]json.RawMessage
+}
+
+func (c *stepResultCache) Set(stepID string, result any) error {
+	byt, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("error marshalling result for step '%s': %w", stepID, err)
+	}
+	c.l.Lock()
+	defer c.l.Unlock()
+	c.results[stepID] = byt
+	return nil
+}
+
+func (c *stepResultCache) Get(stepID string) (json.RawMessage, bool) {
+	c.l.RLock()
+	defer c.l.RUnlock()
+	val, ok := c.results[stepID]
+	return val, ok
+}
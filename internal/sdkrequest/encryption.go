@@ -0,0 +1,149 @@
+package sdkrequest
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"github.com/inngest/inngest/pkg/enums"
+	"github.com/inngest/inngest/pkg/execution/state"
+)
+
+// Encryption configures AES-GCM encryption of step results.
+type Encryption struct {
+	// Key is the AES-256 key used to encrypt and decrypt step results.
+	Key []byte
+	// KeyID identifies Key.  This is stored in plaintext alongside encrypted
+	// state so that state can be decrypted with the correct key after rotation.
+	KeyID string
+	// PreviousKeys are keys which were used before rotation, keyed by their
+	// key ID.  These are only used to decrypt state.
+	PreviousKeys map[string][]byte
+}
+
+// encryptedState is the envelope stored in place of an encrypted step result.
+type encryptedState struct {
+	KeyID string `json:"__inngest_key_id"`
+	Data  []byte `json:"__inngest_encrypted"`
+}
+
+// NewEncryptedManager returns an InvocationManager which encrypts step results
+// before they're added to generator opcodes, and decrypts step results within the
+// incoming request when steps are replayed.
+func NewEncryptedManager(cancel context.CancelFunc, request *Request, enc Encryption, logger *slog.Logger) (InvocationManager, error) {
+	aead, err := newAEAD(enc.Key)
+	if err != nil {
+		return nil, err
+	}
+	e := &encrypter{aead: aead, keyID: enc.KeyID, keys: map[string]cipher.AEAD{enc.KeyID: aead}}
+	for id, key := range enc.PreviousKeys {
+		if id == enc.KeyID {
+			return nil, fmt.Errorf("previous step encryption key ID %q matches the current key ID", id)
+		}
+		if e.keys[id], err = newAEAD(key); err != nil {
+			return nil, fmt.Errorf("invalid previous step encryption key %q: %w", id, err)
+		}
+	}
+
+	mgr := NewManagerWithLogger(cancel, request, logger).(*requestCtxManager)
+	mgr.enc = e
+	return mgr, nil
+}
+
+// newAEAD returns an AES-GCM cipher for the given AES-256 key.
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("step encryption key must be 32 bytes, got %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("error creating step encryption cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("error creating step encryption cipher: %w", err)
+	}
+	return aead, nil
+}
+
+type encrypter struct {
+	// aead encrypts state using the current key, identified by keyID.
+	aead  cipher.AEAD
+	keyID string
+	// keys decrypts state using the current or previous keys, by key ID.
+	keys map[string]cipher.AEAD
+}
+
+// encryptOp encrypts the data for step results.  The hashed step ID is used as
+// additional data, so that encrypted results can't be swapped between steps.
+func (e *encrypter) encryptOp(op state.GeneratorOpcode) (state.GeneratorOpcode, error) {
+	if op.Op != enums.OpcodeStepRun || len(op.Data) == 0 {
+		return op, nil
+	}
+
+	nonce := make([]byte, e.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return op, fmt.Errorf("error generating nonce: %w", err)
+	}
+	byt, err := json.Marshal(encryptedState{
+		KeyID: e.keyID,
+		Data:  e.aead.Seal(nonce, nonce, op.Data, []byte(op.ID)),
+	})
+	if err != nil {
+		return op, err
+	}
+	op.Data = byt
+	return op, nil
+}
+
+// decryptStep decrypts step state for the given hashed step ID.  State which
+// isn't encrypted, such as the results of sleeps or waits, is returned as-is.
+func (e *encrypter) decryptStep(hashedID string, val json.RawMessage) (json.RawMessage, error) {
+	// Step results are wrapped in a "data" object by the executor.
+	wrapped := map[string]json.RawMessage{}
+	if err := json.Unmarshal(val, &wrapped); err != nil {
+		return val, nil
+	}
+	if data, ok := wrapped["data"]; ok {
+		plaintext, err := e.decrypt(hashedID, data)
+		if err != nil || plaintext == nil {
+			return val, err
+		}
+		wrapped["data"] = plaintext
+		return json.Marshal(wrapped)
+	}
+
+	plaintext, err := e.decrypt(hashedID, val)
+	if err != nil || plaintext == nil {
+		return val, err
+	}
+	return plaintext, nil
+}
+
+// decrypt returns the plaintext for the given encrypted state, or nil if the
+// state isn't encrypted.
+func (e *encrypter) decrypt(hashedID string, val json.RawMessage) (json.RawMessage, error) {
+	es := encryptedState{}
+	if err := json.Unmarshal(val, &es); err != nil || es.Data == nil {
+		return nil, nil
+	}
+	aead, ok := e.keys[es.KeyID]
+	if !ok {
+		return nil, fmt.Errorf("step state was encrypted with key ID %q, which isn't configured", es.KeyID)
+	}
+
+	n := aead.NonceSize()
+	if len(es.Data) < n {
+		return nil, fmt.Errorf("invalid encrypted step state")
+	}
+	plaintext, err := aead.Open(nil, es.Data[:n], es.Data[n:], []byte(hashedID))
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting step state: %w", err)
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,27 @@
+package sdkrequest
+
+import "context"
+
+// StepHooks are called when steps within a function are executed.
+type StepHooks struct {
+	// OnStepStart is called before a step's function is called.
+	OnStepStart func(ctx context.Context, stepID, stepName string)
+	// OnStepEnd is called after a step's function returns.
+	OnStepEnd func(ctx context.Context, stepID, stepName string, result any, err error)
+}
+
+type stepHooksCtxKeyType struct{}
+
+var stepHooksCtxKey = stepHooksCtxKeyType{}
+
+// WithStepHooks returns a context which stores the given StepHooks.
+func WithStepHooks(ctx context.Context, h StepHooks) context.Context {
+	return context.WithValue(ctx, stepHooksCtxKey, h)
+}
+
+// StepHooksFromContext returns the StepHooks stored within the context, or empty
+// hooks if there's none.
+func StepHooksFromContext(ctx context.Context) StepHooks {
+	h, _ := ctx.Value(stepHooksCtxKey).(StepHooks)
+	return h
+}
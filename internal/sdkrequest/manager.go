@@ -1,11 +1,13 @@
 package sdkrequest
 
 import (
+	"bytes"
 	"context"
 	"crypto/sha1"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"sync"
 
 	"github.com/inngest/inngest/pkg/enums"
@@ -36,16 +38,121 @@ type InvocationManager interface {
 	// NewOp generates a new unhashed op for creating a state.GeneratorOpcode.  This
 	// is required for future execution of a step.
 	NewOp(op enums.Opcode, id string, opts map[string]any) UnhashedOp
+	// PeekOp returns the op that NewOp would next generate for the given ID,
+	// without reserving its position.
+	PeekOp(op enums.Opcode, id string) UnhashedOp
+	// SetCheckpoint saves intermediate data for the given ID within the step
+	// with the given hashed ID, without completing the step.
+	SetCheckpoint(stepID, id string, data json.RawMessage)
+	// Checkpoint returns the most recent data saved for the given ID within the
+	// step with the given hashed ID, either during this invocation or by a
+	// previous attempt of the step.
+	Checkpoint(stepID, id string) (json.RawMessage, bool)
+	// CheckpointOp returns an op which saves the checkpoints of the step with
+	// the given hashed ID as step state, if they changed during this
+	// invocation.
+	CheckpointOp(stepID, name string) (state.GeneratorOpcode, bool)
+	// SetStepIDHasher sets the function used to hash ops created via NewOp.
+	SetStepIDHasher(h StepIDHasher)
+	// SetOutputMasker sets the function used to mask step results before
+	// they're added to generator opcodes.
+	SetOutputMasker(m OutputMasker)
+	// SetStepRestorer sets the function used by RestoreStep.
+	SetStepRestorer(r StepRestorer)
+	// RestoreStep appends the given op with the step's saved result, returning
+	// false if the step has no saved result.  The result is sent as-is, as it
+	// was masked and encrypted when the step originally ran.
+	RestoreStep(op state.GeneratorOpcode) bool
 }
 
+// StepRestorer returns the saved result of the step with the given hashed ID,
+// for steps which ran before the process restarted but whose results weren't
+// sent to Inngest.
+type StepRestorer func(hashedID string) (json.RawMessage, bool)
+
+type stepRestorerCtxKeyType struct{}
+
+var stepRestorerCtxKey = stepRestorerCtxKeyType{}
+
+// WithStepRestorer returns a context which stores the given StepRestorer, so
+// that it can be used by the InvocationManager for the invocation.
+func WithStepRestorer(ctx context.Context, r StepRestorer) context.Context {
+	return context.WithValue(ctx, stepRestorerCtxKey, r)
+}
+
+// StepRestorerFromContext returns the StepRestorer stored within the context,
+// or nil if there's none.
+func StepRestorerFromContext(ctx context.Context) StepRestorer {
+	r, _ := ctx.Value(stepRestorerCtxKey).(StepRestorer)
+	return r
+}
+
+// OutputMasker returns the given step result with sensitive data masked.
+type OutputMasker func(data json.RawMessage) (json.RawMessage, error)
+
+// StepIDHasher returns the hashed step ID for the given op.
+type StepIDHasher func(op UnhashedOp) string
+
+type stepIDHasherCtxKeyType struct{}
+
+var stepIDHasherCtxKey = stepIDHasherCtxKeyType{}
+
+// WithStepIDHasher returns a context which stores the given StepIDHasher, so
+// that it can be used by the InvocationManager for the invocation.
+func WithStepIDHasher(ctx context.Context, h StepIDHasher) context.Context {
+	return context.WithValue(ctx, stepIDHasherCtxKey, h)
+}
+
+// StepIDHasherFromContext returns the StepIDHasher stored within the context, or
+// nil if there's none.
+func StepIDHasherFromContext(ctx context.Context) StepIDHasher {
+	h, _ := ctx.Value(stepIDHasherCtxKey).(StepIDHasher)
+	return h
+}
+
+type loggerCtxKeyType struct{}
+
+var loggerCtxKey = loggerCtxKeyType{}
+
+// WithLogger returns a context which stores the logger used by managers created
+// for the invocation.
+func WithLogger(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerCtxKey, l)
+}
+
+// LoggerFromContext returns the logger stored within the context, or nil if
+// there's none.
+func LoggerFromContext(ctx context.Context) *slog.Logger {
+	l, _ := ctx.Value(loggerCtxKey).(*slog.Logger)
+	return l
+}
+
+// CheckpointPrefix prefixes the unhashed IDs of the ops which save a step's
+// checkpoints, avoiding collisions with step IDs.
+const CheckpointPrefix = "checkpoint:"
+
 // NewManager returns an InvocationManager to manage the incoming executor request.  This
 // is required for step tooling to process.
 func NewManager(cancel context.CancelFunc, request *Request) InvocationManager {
+	return NewManagerWithLogger(cancel, request, slog.Default())
+}
+
+// NewManagerWithLogger returns an InvocationManager which logs using the given
+// logger.  New ops, replayed steps and cancellation are logged at DEBUG,
+// duplicate step IDs at WARN, and state (de)serialization failures at ERROR.
+func NewManagerWithLogger(cancel context.CancelFunc, request *Request, logger *slog.Logger) InvocationManager {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if request != nil {
+		logger = logger.With("run_id", request.CallCtx.RunID)
+	}
 	return &requestCtxManager{
 		cancel:  cancel,
 		request: request,
 		indexes: map[string]int{},
 		l:       &sync.RWMutex{},
+		logger:  logger,
 	}
 }
 
@@ -70,10 +177,25 @@ type requestCtxManager struct {
 	request *Request
 	// Indexes represents a map of indexes for each unhashed op.
 	indexes map[string]int
-	l       *sync.RWMutex
+	// checkpoints stores each step's checkpoints, by hashed step ID.
+	checkpoints map[string]*stepCheckpoints
+	// enc encrypts step results, if step encryption is enabled.
+	enc *encrypter
+	// masker masks step results before they're encrypted, if set.
+	masker OutputMasker
+	// hasher overrides the default hashing of ops, if set.
+	hasher StepIDHasher
+	// restorer returns the saved results of steps, if set.
+	restorer StepRestorer
+	// stateErr stores any error decrypting or encrypting step state.  This
+	// takes precedence over step errors, as steps can't handle invalid state.
+	stateErr error
+	logger   *slog.Logger
+	l        *sync.RWMutex
 }
 
 func (r *requestCtxManager) Cancel() {
+	r.logger.Debug("cancelling invocation context")
 	r.cancel()
 }
 
@@ -86,10 +208,17 @@ func (r *requestCtxManager) Request() *Request {
 }
 
 func (r *requestCtxManager) SetErr(err error) {
+	r.l.Lock()
+	defer r.l.Unlock()
 	r.err = err
 }
 
 func (r *requestCtxManager) Err() error {
+	r.l.RLock()
+	defer r.l.RUnlock()
+	if r.stateErr != nil {
+		return r.stateErr
+	}
 	return r.err
 }
 
@@ -97,6 +226,27 @@ func (r *requestCtxManager) AppendOp(op state.GeneratorOpcode) {
 	r.l.Lock()
 	defer r.l.Unlock()
 
+	if r.masker != nil && op.Op == enums.OpcodeStepRun && len(op.Data) > 0 {
+		data, err := r.masker(op.Data)
+		if err != nil {
+			r.stateErr = fmt.Errorf("error masking state for step '%s': %w", op.Name, err)
+			r.logger.Error("error masking step state", "step", op.Name, "error", err)
+			return
+		}
+		op.Data = data
+	}
+
+	if r.enc != nil {
+		var err error
+		if op, err = r.enc.encryptOp(op); err != nil {
+			r.stateErr = fmt.Errorf("error encrypting state for step '%s': %w", op.Name, err)
+			r.logger.Error("error encrypting step state", "step", op.Name, "error", err)
+			return
+		}
+	}
+
+	r.logger.Debug("appending op", "op", op.Op.String(), "step", op.Name, "id", op.ID)
+
 	if r.ops == nil {
 		r.ops = []state.GeneratorOpcode{op}
 		return
@@ -110,12 +260,173 @@ func (r *requestCtxManager) Ops() []state.GeneratorOpcode {
 }
 
 func (r *requestCtxManager) Step(op UnhashedOp) (json.RawMessage, bool) {
-	r.l.RLock()
-	defer r.l.RUnlock()
-	val, ok := r.request.Steps[op.MustHash()]
+	r.l.Lock()
+	defer r.l.Unlock()
+	return r.step(op)
+}
+
+// step returns the state for the given op.  This must be called with the lock
+// held.
+func (r *requestCtxManager) step(op UnhashedOp) (json.RawMessage, bool) {
+	hashedID := op.MustHash()
+	val, ok := r.request.Steps[hashedID]
+	if !ok && r.request.cache != nil {
+		val, ok = r.request.cache.Get(op.cacheKey())
+	}
+	if !ok {
+		return nil, false
+	}
+	r.logger.Debug("replaying step from state", "step", op.ID, "id", hashedID)
+	if r.enc == nil {
+		return val, true
+	}
+
+	plaintext, err := r.enc.decryptStep(hashedID, val)
+	if err != nil {
+		// Steps can't continue without valid state, so record the error and
+		// return no data.  The error is surfaced via Err().
+		r.stateErr = fmt.Errorf("error decrypting state for step '%s': %w", op.ID, err)
+		r.logger.Error("error decrypting step state", "step", op.ID, "error", err)
+		return nil, true
+	}
+	return plaintext, true
+}
+
+// stepCheckpoints stores the checkpoints saved within a single step.
+type stepCheckpoints struct {
+	data map[string]json.RawMessage
+	// pos is the position of the next op saving the checkpoints.  Each save
+	// is a separate op, as Inngest only stores the first result for a step.
+	pos uint
+	// changed records whether checkpoints were saved during this invocation.
+	changed bool
+}
+
+// stepCheckpoints returns the checkpoints for the given step, loading the most
+// recently saved checkpoints from step state.  This must be called with the
+// lock held.
+func (r *requestCtxManager) stepCheckpoints(stepID string) *stepCheckpoints {
+	if cp, ok := r.checkpoints[stepID]; ok {
+		return cp
+	}
+	if r.checkpoints == nil {
+		r.checkpoints = map[string]*stepCheckpoints{}
+	}
+
+	cp := &stepCheckpoints{data: map[string]json.RawMessage{}}
+	r.checkpoints[stepID] = cp
+
+	var latest json.RawMessage
+	for {
+		val, ok := r.step(r.checkpointOp(stepID, cp.pos))
+		if !ok {
+			break
+		}
+		latest = val
+		cp.pos++
+	}
+	if len(latest) == 0 {
+		return cp
+	}
+
+	// Step state may be wrapped within a "data" field.
+	wrapped := struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}{}
+	if err := json.Unmarshal(latest, &wrapped); err == nil && wrapped.Data != nil {
+		cp.data = wrapped.Data
+		return cp
+	}
+	if err := json.Unmarshal(latest, &cp.data); err != nil {
+		r.logger.Error("error unmarshalling checkpoints", "id", stepID, "error", err)
+		cp.data = map[string]json.RawMessage{}
+	}
+	return cp
+}
+
+func (r *requestCtxManager) checkpointOp(stepID string, pos uint) UnhashedOp {
+	return UnhashedOp{
+		ID:     CheckpointPrefix + stepID,
+		Op:     enums.OpcodeStep,
+		Pos:    pos,
+		hasher: r.hasher,
+	}
+}
+
+func (r *requestCtxManager) SetCheckpoint(stepID, id string, data json.RawMessage) {
+	r.l.Lock()
+	defer r.l.Unlock()
+
+	cp := r.stepCheckpoints(stepID)
+	if prev, ok := cp.data[id]; ok && bytes.Equal(prev, data) {
+		return
+	}
+	cp.data[id] = data
+	cp.changed = true
+}
+
+func (r *requestCtxManager) Checkpoint(stepID, id string) (json.RawMessage, bool) {
+	r.l.Lock()
+	defer r.l.Unlock()
+	val, ok := r.stepCheckpoints(stepID).data[id]
 	return val, ok
 }
 
+func (r *requestCtxManager) CheckpointOp(stepID, name string) (state.GeneratorOpcode, bool) {
+	r.l.Lock()
+	defer r.l.Unlock()
+
+	cp := r.stepCheckpoints(stepID)
+	if !cp.changed {
+		return state.GeneratorOpcode{}, false
+	}
+	byt, err := json.Marshal(cp.data)
+	if err != nil {
+		r.logger.Error("error marshalling checkpoints", "step", name, "error", err)
+		return state.GeneratorOpcode{}, false
+	}
+	return state.GeneratorOpcode{
+		ID:   r.checkpointOp(stepID, cp.pos).MustHash(),
+		Op:   enums.OpcodeStepRun,
+		Name: CheckpointPrefix + name,
+		Data: byt,
+	}, true
+}
+
+func (r *requestCtxManager) SetStepIDHasher(h StepIDHasher) {
+	r.l.Lock()
+	defer r.l.Unlock()
+	r.hasher = h
+}
+
+func (r *requestCtxManager) SetOutputMasker(m OutputMasker) {
+	r.l.Lock()
+	defer r.l.Unlock()
+	r.masker = m
+}
+
+func (r *requestCtxManager) SetStepRestorer(restorer StepRestorer) {
+	r.l.Lock()
+	defer r.l.Unlock()
+	r.restorer = restorer
+}
+
+func (r *requestCtxManager) RestoreStep(op state.GeneratorOpcode) bool {
+	r.l.Lock()
+	defer r.l.Unlock()
+	if r.restorer == nil {
+		return false
+	}
+	data, ok := r.restorer(op.ID)
+	if !ok {
+		return false
+	}
+	op.Data = data
+	r.logger.Debug("restoring step from checkpoint", "step", op.Name, "id", op.ID)
+	r.ops = append(r.ops, op)
+	return true
+}
+
 func (r *requestCtxManager) NewOp(op enums.Opcode, id string, opts map[string]any) UnhashedOp {
 	r.l.Lock()
 	defer r.l.Unlock()
@@ -125,16 +436,34 @@ func (r *requestCtxManager) NewOp(op enums.Opcode, id string, opts map[string]an
 		// We have an index already, so increase the counter as we're
 		// adding to this key.
 		n += 1
+		r.logger.Warn("duplicate step ID", "step", id, "position", n)
 	}
 
 	// Update indexes for each particualar key.
 	r.indexes[id] = n
 
 	return UnhashedOp{
-		ID:   id,
-		Op:   op,
-		Opts: opts,
-		Pos:  uint(n),
+		ID:     id,
+		Op:     op,
+		Opts:   opts,
+		Pos:    uint(n),
+		hasher: r.hasher,
+	}
+}
+
+func (r *requestCtxManager) PeekOp(op enums.Opcode, id string) UnhashedOp {
+	r.l.RLock()
+	defer r.l.RUnlock()
+
+	var pos uint
+	if n, ok := r.indexes[id]; ok {
+		pos = uint(n) + 1
+	}
+	return UnhashedOp{
+		ID:     id,
+		Op:     op,
+		Pos:    pos,
+		hasher: r.hasher,
 	}
 }
 
@@ -144,16 +473,27 @@ type UnhashedOp struct {
 	Name string         `json:"name"`
 	Opts map[string]any `json:"opts"`
 	Pos  uint           `json:"-"`
+
+	// hasher overrides the default sha1 hash of the op's ID, if set.
+	hasher StepIDHasher
 }
 
 func (u UnhashedOp) Hash() (string, error) {
-	input := u.ID
+	if u.hasher != nil {
+		return u.hasher(u), nil
+	}
+	sum := sha1.Sum([]byte(u.cacheKey()))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// cacheKey returns the unhashed ID of the op, including its position if there's
+// more than one op with the same ID.
+func (u UnhashedOp) cacheKey() string {
 	if u.Pos > 0 {
 		// We only suffix the counter if there's > 1 operation with the same ID.
-		input = fmt.Sprintf("%s:%d", u.ID, u.Pos)
+		return fmt.Sprintf("%s:%d", u.ID, u.Pos)
 	}
-	sum := sha1.Sum([]byte(input))
-	return hex.EncodeToString(sum[:]), nil
+	return u.ID
 }
 
 func (u UnhashedOp) MustHash() string {
@@ -0,0 +1,103 @@
+package sdkrequest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/inngest/inngest/pkg/enums"
+	"github.com/inngest/inngest/pkg/execution/state"
+	"github.com/stretchr/testify/require"
+)
+
+// logEntries decodes each JSON log line written to buf.
+func logEntries(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	entries := []map[string]any{}
+	dec := json.NewDecoder(buf)
+	for dec.More() {
+		entry := map[string]any{}
+		require.NoError(t, dec.Decode(&entry))
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestManagerLogging(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	replayed := UnhashedOp{Op: enums.OpcodeStep, ID: "replayed"}
+	req := &Request{
+		CallCtx: CallCtx{RunID: "run-1"},
+		Steps:   map[string]json.RawMessage{replayed.MustHash(): json.RawMessage(`{"data":1}`)},
+	}
+	_, cancel := context.WithCancel(context.Background())
+	mgr := NewManagerWithLogger(cancel, req, logger)
+
+	t.Run("replayed steps are logged at debug", func(t *testing.T) {
+		_, ok := mgr.Step(mgr.NewOp(enums.OpcodeStep, "replayed", nil))
+		require.True(t, ok)
+
+		entries := logEntries(t, buf)
+		require.Len(t, entries, 1)
+		require.Equal(t, "DEBUG", entries[0]["level"])
+		require.Equal(t, "replaying step from state", entries[0]["msg"])
+		require.Equal(t, "replayed", entries[0]["step"])
+		require.Equal(t, "run-1", entries[0]["run_id"])
+	})
+
+	t.Run("missing steps are not logged", func(t *testing.T) {
+		_, ok := mgr.Step(mgr.NewOp(enums.OpcodeStep, "new", nil))
+		require.False(t, ok)
+		require.Empty(t, logEntries(t, buf))
+	})
+
+	t.Run("new ops are logged at debug", func(t *testing.T) {
+		mgr.AppendOp(state.GeneratorOpcode{ID: "hashed", Op: enums.OpcodeStepRun, Name: "new"})
+
+		entries := logEntries(t, buf)
+		require.Len(t, entries, 1)
+		require.Equal(t, "DEBUG", entries[0]["level"])
+		require.Equal(t, "appending op", entries[0]["msg"])
+		require.Equal(t, "new", entries[0]["step"])
+		require.Equal(t, enums.OpcodeStepRun.String(), entries[0]["op"])
+	})
+
+	t.Run("duplicate step IDs are logged at warn", func(t *testing.T) {
+		op := mgr.NewOp(enums.OpcodeStep, "new", nil)
+		require.EqualValues(t, 1, op.Pos)
+
+		entries := logEntries(t, buf)
+		require.Len(t, entries, 1)
+		require.Equal(t, "WARN", entries[0]["level"])
+		require.Equal(t, "duplicate step ID", entries[0]["msg"])
+	})
+
+	t.Run("cancellation is logged at debug", func(t *testing.T) {
+		mgr.Cancel()
+
+		entries := logEntries(t, buf)
+		require.Len(t, entries, 1)
+		require.Equal(t, "DEBUG", entries[0]["level"])
+		require.Equal(t, "cancelling invocation context", entries[0]["msg"])
+	})
+
+	t.Run("state errors are logged at error", func(t *testing.T) {
+		enc, err := NewEncryptedManager(cancel, req, Encryption{Key: make([]byte, 32), KeyID: "a"}, nil)
+		require.NoError(t, err)
+		enc.(*requestCtxManager).logger = logger
+
+		// State encrypted with another key can't be decrypted.
+		op := UnhashedOp{Op: enums.OpcodeStep, ID: "encrypted"}
+		req.Steps[op.MustHash()] = json.RawMessage(`{"__inngest_key_id":"b","__inngest_encrypted":"AAAA"}`)
+		_, _ = enc.Step(enc.NewOp(enums.OpcodeStep, "encrypted", nil))
+		require.Error(t, enc.Err())
+
+		entries := logEntries(t, buf)
+		require.Equal(t, "ERROR", entries[len(entries)-1]["level"])
+		require.Equal(t, "error decrypting step state", entries[len(entries)-1]["msg"])
+	})
+}
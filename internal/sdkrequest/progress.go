@@ -0,0 +1,34 @@
+package sdkrequest
+
+import "context"
+
+// Progress is a progress update reported by a running step.
+type Progress struct {
+	// StepID is the hashed ID of the step.
+	StepID string `json:"id"`
+	// Name is the unhashed ID of the step.
+	Name string `json:"name"`
+	// Percent is the step's completion percentage, from 0 to 100.
+	Percent float64 `json:"percent"`
+	// Message is an optional description of the step's progress.
+	Message string `json:"message,omitempty"`
+}
+
+// ProgressReporter sends progress updates for running steps.
+type ProgressReporter func(p Progress) error
+
+type progressReporterCtxKeyType struct{}
+
+var progressReporterCtxKey = progressReporterCtxKeyType{}
+
+// WithProgressReporter returns a context which stores the given ProgressReporter.
+func WithProgressReporter(ctx context.Context, r ProgressReporter) context.Context {
+	return context.WithValue(ctx, progressReporterCtxKey, r)
+}
+
+// ProgressReporterFromContext returns the ProgressReporter stored within the
+// context, or nil if there's none.
+func ProgressReporterFromContext(ctx context.Context) ProgressReporter {
+	r, _ := ctx.Value(progressReporterCtxKey).(ProgressReporter)
+	return r
+}
@@ -9,6 +9,10 @@ type Request struct {
 	Steps   map[string]json.RawMessage `json:"steps"`
 	CallCtx CallCtx                    `json:"ctx"`
 	UseAPI  bool                       `json:"use_api"`
+
+	// cache stores step results by unhashed step ID.  This is only used when
+	// creating requests via NewRequestFromCache.
+	cache StepResultCache
 }
 
 // CallCtx represents context for individual function calls.  This logs the function ID, the
@@ -21,6 +25,9 @@ type CallCtx struct {
 	StepID                    string    `json:"step_id"`
 	Stack                     CallStack `json:"stack"`
 	Attempt                   int       `json:"attempt"`
+	// FeatureFlags represents the state of the function's feature flags for
+	// this run.  This isn't sent by the Inngest server yet.
+	FeatureFlags map[string]bool `json:"feature_flags,omitempty"`
 }
 
 type CallStack struct {
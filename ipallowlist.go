@@ -0,0 +1,101 @@
+package inngestgo
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// IPAllowList is a list of IP ranges which may make requests to the handler.
+type IPAllowList []*net.IPNet
+
+// ParseIPAllowList returns an IPAllowList of the given IPs or ranges in CIDR
+// notation, eg. "10.0.0.0/8", "2001:db8::/32" or "192.0.2.1".  IPs without a
+// prefix length only match themselves.
+func ParseIPAllowList(ranges ...string) (IPAllowList, error) {
+	list := make(IPAllowList, 0, len(ranges))
+	for _, r := range ranges {
+		r = strings.TrimSpace(r)
+		if !strings.Contains(r, "/") {
+			ip := net.ParseIP(r)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid IP '%s'", r)
+			}
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			list = append(list, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipnet, err := net.ParseCIDR(r)
+		if err != nil {
+			return nil, fmt.Errorf("invalid IP range '%s': %w", r, err)
+		}
+		list = append(list, ipnet)
+	}
+	return list, nil
+}
+
+// Contains returns whether the IP is within any of the list's ranges.
+func (l IPAllowList) Contains(ip net.IP) bool {
+	for _, ipnet := range l {
+		if ipnet != nil && ipnet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsRequest returns whether the request's remote address is within the
+// list.  Forwarding headers such as X-Forwarded-For are ignored, as they can
+// be set by any client.
+func (l IPAllowList) allowsRequest(r *http.Request) bool {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && l.Contains(ip)
+}
+
+var loadIPAllowListFromEnv = sync.OnceValues(func() (IPAllowList, error) {
+	return parseIPRanges(os.Getenv(envKeyIPAllowList))
+})
+
+// parseIPRanges parses the value of the INNGEST_IP_ALLOW_LIST environment
+// variable.
+func parseIPRanges(env string) (IPAllowList, error) {
+	ranges := strings.FieldsFunc(env, func(r rune) bool {
+		return r == ',' || r == ' '
+	})
+	if len(ranges) == 0 {
+		return nil, fmt.Errorf("%s is not set", envKeyIPAllowList)
+	}
+	list, err := ParseIPAllowList(ranges...)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", envKeyIPAllowList, err)
+	}
+	return list, nil
+}
+
+// IPAllowListFromEnv returns an IPAllowList for use within
+// HandlerOpts.IPAllowList, read once from the comma-separated IPs or CIDR
+// ranges within the INNGEST_IP_ALLOW_LIST environment variable.
+//
+// The variable isn't set by Inngest:  set it to the IP ranges Inngest sends
+// requests from, as published by Inngest, or to those of your own network
+// when requests are proxied.  An error is returned if it's unset or invalid,
+// as an empty IPAllowList allows requests from any IP:
+//
+//	ranges, err := inngestgo.IPAllowListFromEnv()
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	h := inngestgo.NewHandler("app", inngestgo.HandlerOpts{IPAllowList: ranges})
+func IPAllowListFromEnv() (IPAllowList, error) {
+	return loadIPAllowListFromEnv()
+}
@@ -0,0 +1,68 @@
+package inngestgo
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIPAllowList(t *testing.T) {
+	list, err := ParseIPAllowList("10.0.0.0/8", "192.0.2.1", "2001:db8::/32", "::1")
+	require.NoError(t, err)
+
+	t.Run("ipv4", func(t *testing.T) {
+		require.True(t, list.Contains(net.ParseIP("192.0.2.1")))
+		require.False(t, list.Contains(net.ParseIP("192.0.2.2")))
+	})
+
+	t.Run("ipv6", func(t *testing.T) {
+		require.True(t, list.Contains(net.ParseIP("::1")))
+		require.False(t, list.Contains(net.ParseIP("::2")))
+	})
+
+	t.Run("cidr", func(t *testing.T) {
+		require.True(t, list.Contains(net.ParseIP("10.1.2.3")))
+		require.False(t, list.Contains(net.ParseIP("11.1.2.3")))
+		require.True(t, list.Contains(net.ParseIP("2001:db8:1::1")))
+		require.False(t, list.Contains(net.ParseIP("2001:db9::1")))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := ParseIPAllowList("10.0.0.0/33")
+		require.ErrorContains(t, err, "invalid IP range '10.0.0.0/33'")
+		_, err = ParseIPAllowList("inngest.com")
+		require.EqualError(t, err, "invalid IP 'inngest.com'")
+	})
+
+	t.Run("handler", func(t *testing.T) {
+		h := NewHandler("test-ip-allow-list", HandlerOpts{IPAllowList: list})
+		serve := func(remoteAddr string) int {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = remoteAddr
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			return w.Code
+		}
+
+		require.Equal(t, http.StatusForbidden, serve("203.0.113.1:1234"))
+		require.Equal(t, http.StatusForbidden, serve("[2001:db9::1]:1234"))
+		require.Equal(t, http.StatusForbidden, serve("not-an-ip"))
+		require.NotEqual(t, http.StatusForbidden, serve("10.0.0.1:1234"))
+		require.NotEqual(t, http.StatusForbidden, serve("[2001:db8::1]:1234"))
+	})
+
+	t.Run("from env", func(t *testing.T) {
+		ranges, err := parseIPRanges("10.0.0.0/8, 192.0.2.1")
+		require.NoError(t, err)
+		require.Len(t, ranges, 2)
+
+		// Missing or invalid ranges fail rather than allowing every IP.
+		_, err = parseIPRanges("")
+		require.EqualError(t, err, "INNGEST_IP_ALLOW_LIST is not set")
+		_, err = parseIPRanges("10.0.0.0/8,inngest.com")
+		require.EqualError(t, err, "invalid INNGEST_IP_ALLOW_LIST: invalid IP 'inngest.com'")
+	})
+}
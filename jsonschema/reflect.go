@@ -0,0 +1,101 @@
+package jsonschema
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+var (
+	jsonUnmarshalerType = reflect.TypeFor[json.Unmarshaler]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+)
+
+// Reflect returns a schema describing the structure of the Go type when
+// encoded as JSON:  the properties of structs, named using their `json:`
+// tags, and the items of slices and arrays.  Types which unmarshal
+// themselves, such as time.Time, and maps accept any value.  Keywords other
+// than properties and items aren't generated.
+func Reflect(t reflect.Type) *Schema {
+	return reflectType(t, map[reflect.Type]*Schema{})
+}
+
+func reflectType(t reflect.Type, seen map[reflect.Type]*Schema) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Implements(jsonUnmarshalerType) || reflect.PointerTo(t).Implements(jsonUnmarshalerType) ||
+		t.Implements(textUnmarshalerType) || reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return &Schema{}
+	}
+	if s, ok := seen[t]; ok {
+		// Recursive types reuse the type's schema.
+		return s
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		s := &Schema{Properties: map[string]*Schema{}}
+		seen[t] = s
+		reflectFields(t, s, seen)
+		return s
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// Byte slices are encoded as strings.
+			return &Schema{}
+		}
+		return &Schema{Items: reflectType(t.Elem(), seen)}
+	default:
+		return &Schema{}
+	}
+}
+
+// reflectFields adds the properties of the struct's fields to the schema,
+// including the fields of embedded structs.
+func reflectFields(t reflect.Type, s *Schema, seen map[reflect.Type]*Schema) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		ft := f.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			reflectFields(ft, s, seen)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		s.Properties[name] = reflectType(f.Type, seen)
+	}
+}
+
+// DisallowAdditionalProperties sets AdditionalProperties to false for the
+// schema and every nested schema which lists Properties, so that objects
+// can't contain undeclared properties.
+func (s *Schema) DisallowAdditionalProperties() {
+	s.disallowAdditionalProperties(map[*Schema]bool{})
+}
+
+func (s *Schema) disallowAdditionalProperties(seen map[*Schema]bool) {
+	if s == nil || seen[s] {
+		return
+	}
+	seen[s] = true
+	if s.Properties != nil {
+		no := false
+		s.AdditionalProperties = &no
+	}
+	for _, prop := range s.Properties {
+		prop.disallowAdditionalProperties(seen)
+	}
+	s.Items.disallowAdditionalProperties(seen)
+}
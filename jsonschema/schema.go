@@ -0,0 +1,206 @@
+// Package jsonschema validates JSON documents against JSON Schemas.  Only a
+// subset of JSON Schema is supported:  the type, properties, required,
+// additionalProperties, items, enum, minimum, maximum, minLength, maxLength,
+// minItems and maxItems keywords.  Other keywords are ignored.
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// Schema is a JSON Schema.
+type Schema struct {
+	// Type is the JSON type of the value:  "object", "array", "string",
+	// "number", "integer", "boolean" or "null".  If empty, any type is valid.
+	Type string `json:"type,omitempty"`
+
+	// Properties are the schemas of an object's properties.
+	Properties map[string]*Schema `json:"properties,omitempty"`
+	// Required lists the properties which an object must contain.
+	Required []string `json:"required,omitempty"`
+	// AdditionalProperties is whether an object may contain properties which
+	// aren't listed within Properties.  If nil, additional properties are
+	// allowed.
+	AdditionalProperties *bool `json:"additionalProperties,omitempty"`
+
+	// Items is the schema of each item within an array.
+	Items *Schema `json:"items,omitempty"`
+	// MinItems is the minimum length of an array.
+	MinItems *int `json:"minItems,omitempty"`
+	// MaxItems is the maximum length of an array.
+	MaxItems *int `json:"maxItems,omitempty"`
+
+	// Enum lists the values which are valid.
+	Enum []any `json:"enum,omitempty"`
+	// Minimum is the minimum value of a number.
+	Minimum *float64 `json:"minimum,omitempty"`
+	// Maximum is the maximum value of a number.
+	Maximum *float64 `json:"maximum,omitempty"`
+	// MinLength is the minimum length of a string, in characters.
+	MinLength *int `json:"minLength,omitempty"`
+	// MaxLength is the maximum length of a string, in characters.
+	MaxLength *int `json:"maxLength,omitempty"`
+}
+
+// Parse parses the JSON-encoded schema.
+func Parse(byt []byte) (*Schema, error) {
+	s := &Schema{}
+	if err := json.Unmarshal(byt, s); err != nil {
+		return nil, fmt.Errorf("error parsing schema: %w", err)
+	}
+	return s, nil
+}
+
+// MustParse parses the JSON-encoded schema, panicking if it's invalid.
+func MustParse(schema string) *Schema {
+	s, err := Parse([]byte(schema))
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// ValidationError describes a value which doesn't match its schema.
+type ValidationError struct {
+	// Path is the path to the value, eg. "$.items[0].id".
+	Path string
+	// Message describes why the value is invalid.
+	Message string
+}
+
+func (v ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", v.Path, v.Message)
+}
+
+// ValidationErrors is returned by Schema.Validate when a document doesn't
+// match the schema.
+type ValidationErrors []ValidationError
+
+func (v ValidationErrors) Error() string {
+	msgs := make([]string, len(v))
+	for i, err := range v {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Validate validates the JSON document against the schema, returning
+// ValidationErrors if the document doesn't match.
+func (s *Schema) Validate(doc []byte) error {
+	var val any
+	if err := json.Unmarshal(doc, &val); err != nil {
+		return fmt.Errorf("error parsing document: %w", err)
+	}
+	errs := s.validate("$", val, nil)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (s *Schema) validate(path string, val any, errs ValidationErrors) ValidationErrors {
+	if s == nil {
+		return errs
+	}
+	invalid := func(format string, args ...any) {
+		errs = append(errs, ValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if s.Type != "" && typeOf(val) != s.Type && !(s.Type == "number" && typeOf(val) == "integer") {
+		invalid("expected %s, got %s", s.Type, typeOf(val))
+		return errs
+	}
+	if len(s.Enum) > 0 && !slices.ContainsFunc(s.Enum, func(e any) bool { return equal(e, val) }) {
+		invalid("value must be one of the enum values")
+	}
+
+	switch v := val.(type) {
+	case map[string]any:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				invalid("missing required property '%s'", name)
+			}
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		for _, k := range keys {
+			prop, ok := s.Properties[k]
+			if !ok {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					invalid("additional property '%s' is not allowed", k)
+				}
+				continue
+			}
+			errs = prop.validate(path+"."+k, v[k], errs)
+		}
+	case []any:
+		if s.MinItems != nil && len(v) < *s.MinItems {
+			invalid("must contain at least %d items", *s.MinItems)
+		}
+		if s.MaxItems != nil && len(v) > *s.MaxItems {
+			invalid("must contain at most %d items", *s.MaxItems)
+		}
+		for i, item := range v {
+			errs = s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item, errs)
+		}
+	case string:
+		n := utf8.RuneCountInString(v)
+		if s.MinLength != nil && n < *s.MinLength {
+			invalid("must be at least %d characters", *s.MinLength)
+		}
+		if s.MaxLength != nil && n > *s.MaxLength {
+			invalid("must be at most %d characters", *s.MaxLength)
+		}
+	case float64:
+		if s.Minimum != nil && v < *s.Minimum {
+			invalid("must be at least %v", *s.Minimum)
+		}
+		if s.Maximum != nil && v > *s.Maximum {
+			invalid("must be at most %v", *s.Maximum)
+		}
+	}
+	return errs
+}
+
+// typeOf returns the JSON Schema type of the decoded JSON value.
+func typeOf(val any) string {
+	switch v := val.(type) {
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		if v == float64(int64(v)) {
+			return "integer"
+		}
+		return "number"
+	case bool:
+		return "boolean"
+	default:
+		return "null"
+	}
+}
+
+// equal returns whether the enum value equals the decoded JSON value.  Enum
+// values are compared as JSON, as they may be set from Go types such as int.
+func equal(enum, val any) bool {
+	byt, err := json.Marshal(enum)
+	if err != nil {
+		return false
+	}
+	var decoded any
+	if err := json.Unmarshal(byt, &decoded); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(decoded, val)
+}
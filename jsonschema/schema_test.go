@@ -0,0 +1,78 @@
+package jsonschema
+
+import (
+	"maps"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	schema := MustParse(`{
+		"type": "object",
+		"required": ["id", "status"],
+		"additionalProperties": false,
+		"properties": {
+			"id": {"type": "integer", "minimum": 1},
+			"status": {"type": "string", "enum": ["ok", "failed"]},
+			"score": {"type": "number", "maximum": 1},
+			"tags": {"type": "array", "maxItems": 2, "items": {"type": "string", "minLength": 2}},
+			"meta": {}
+		}
+	}`)
+
+	require.NoError(t, schema.Validate([]byte(`{"id": 1, "status": "ok", "score": 0.5, "tags": ["ab"], "meta": null}`)))
+
+	err := schema.Validate([]byte(`{"id": 0, "status": "pending", "score": 1.5, "tags": ["a", "bc", "de"], "extra": true}`))
+	require.Equal(t, ValidationErrors{
+		{Path: "$", Message: "additional property 'extra' is not allowed"},
+		{Path: "$.id", Message: "must be at least 1"},
+		{Path: "$.score", Message: "must be at most 1"},
+		{Path: "$.status", Message: "value must be one of the enum values"},
+		{Path: "$.tags", Message: "must contain at most 2 items"},
+		{Path: "$.tags[0]", Message: "must be at least 2 characters"},
+	}, err)
+
+	err = schema.Validate([]byte(`{"id": 1.5}`))
+	require.EqualError(t, err, "$: missing required property 'status'; $.id: expected integer, got number")
+
+	require.EqualError(t, schema.Validate([]byte(`[]`)), "$: expected object, got array")
+	require.ErrorContains(t, schema.Validate([]byte(`{`)), "error parsing document")
+
+	_, err = Parse([]byte(`{"type": 1}`))
+	require.ErrorContains(t, err, "error parsing schema")
+}
+
+func TestReflect(t *testing.T) {
+	type Address struct {
+		City string `json:"city"`
+	}
+	type Base struct {
+		ID string `json:"id"`
+	}
+	type Data struct {
+		Base
+		Name      string            `json:"name,omitempty"`
+		Addresses []Address         `json:"addresses"`
+		Labels    map[string]string `json:"labels"`
+		CreatedAt time.Time         `json:"created_at"`
+		Ignored   string            `json:"-"`
+		Untagged  int
+		internal  bool
+	}
+
+	schema := Reflect(reflect.TypeFor[*Data]())
+	require.ElementsMatch(t, []string{"id", "name", "addresses", "labels", "created_at", "Untagged"}, slices.Collect(maps.Keys(schema.Properties)))
+
+	schema.DisallowAdditionalProperties()
+	require.NoError(t, schema.Validate([]byte(`{"id": "1", "addresses": [{"city": "Paris"}], "labels": {"any": "x"}, "created_at": "2024-01-01T00:00:00Z"}`)))
+
+	err := schema.Validate([]byte(`{"id": "1", "extra": true, "addresses": [{"city": "Paris", "zip": "75001"}]}`))
+	require.Equal(t, ValidationErrors{
+		{Path: "$.addresses[0]", Message: "additional property 'zip' is not allowed"},
+		{Path: "$", Message: "additional property 'extra' is not allowed"},
+	}, err)
+}
@@ -0,0 +1,156 @@
+package inngestgo
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultJWKTTL is the default for how long keys fetched by
+	// NewSigningKeyFromJWK are cached.
+	defaultJWKTTL = time.Hour
+	// defaultJWKTimeout is the timeout of the default client used to fetch
+	// JWK sets.
+	defaultJWKTimeout = 10 * time.Second
+)
+
+// SigningKeyProvider provides the signing key used to authenticate with
+// Inngest, allowing signing keys to be sourced from external key management.
+// See HandlerOpts.SigningKeyProvider.
+type SigningKeyProvider interface {
+	// SigningKey returns the current signing key.
+	SigningKey(ctx context.Context) (string, error)
+}
+
+// JWKOption configures the provider created by NewSigningKeyFromJWK.
+type JWKOption func(*jwkSigningKeyProvider)
+
+// WithJWKTTL sets how long the key is cached before it's refreshed.  Defaults
+// to one hour.
+func WithJWKTTL(ttl time.Duration) JWKOption {
+	return func(p *jwkSigningKeyProvider) {
+		p.ttl = ttl
+	}
+}
+
+// WithJWKHTTPClient sets the HTTP client used to fetch the JWK set.  Defaults
+// to a client with a 10 second timeout.
+func WithJWKHTTPClient(c *http.Client) JWKOption {
+	return func(p *jwkSigningKeyProvider) {
+		p.client = c
+	}
+}
+
+// NewSigningKeyFromJWK returns a SigningKeyProvider which derives the signing key
+// from the symmetric ("oct") key with the given key ID in the JWK set served
+// at jwkURL.  The key's "k" value is used as the HMAC-SHA256 signing key.
+//
+// The key is fetched immediately, returning an error if it can't be found.
+// Afterwards the key is cached, and is refreshed in the background once the
+// TTL elapses.  The cached key is used until a refresh succeeds.
+func NewSigningKeyFromJWK(jwkURL string, keyID string, opts ...JWKOption) (SigningKeyProvider, error) {
+	p := &jwkSigningKeyProvider{
+		url:    jwkURL,
+		keyID:  keyID,
+		ttl:    defaultJWKTTL,
+		client: &http.Client{Timeout: defaultJWKTimeout},
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	key, err := p.fetch(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	p.key = key
+	p.fetchedAt = time.Now()
+	return p, nil
+}
+
+type jwkSigningKeyProvider struct {
+	url    string
+	keyID  string
+	ttl    time.Duration
+	client *http.Client
+
+	l          sync.Mutex
+	key        string
+	fetchedAt  time.Time
+	refreshing bool
+}
+
+func (p *jwkSigningKeyProvider) SigningKey(ctx context.Context) (string, error) {
+	p.l.Lock()
+	defer p.l.Unlock()
+
+	if time.Since(p.fetchedAt) >= p.ttl && !p.refreshing {
+		p.refreshing = true
+		go p.refresh()
+	}
+	return p.key, nil
+}
+
+// refresh re-fetches the key, keeping the cached key if the fetch fails.
+func (p *jwkSigningKeyProvider) refresh() {
+	key, err := p.fetch(context.Background())
+
+	p.l.Lock()
+	defer p.l.Unlock()
+	p.refreshing = false
+	if err != nil {
+		return
+	}
+	p.key = key
+	p.fetchedAt = time.Now()
+}
+
+type jwk struct {
+	KeyID   string `json:"kid"`
+	KeyType string `json:"kty"`
+	K       string `json:"k"`
+}
+
+func (p *jwkSigningKeyProvider) fetch(ctx context.Context) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url, nil)
+	if err != nil {
+		return "", fmt.Errorf("error creating JWK request: %w", err)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("error fetching JWK set: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("error fetching JWK set: status %d", resp.StatusCode)
+	}
+
+	set := struct {
+		Keys []jwk `json:"keys"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return "", fmt.Errorf("error decoding JWK set: %w", err)
+	}
+
+	for _, k := range set.Keys {
+		if k.KeyID != p.keyID {
+			continue
+		}
+		if k.KeyType != "oct" {
+			return "", fmt.Errorf("JWK %q has key type %q; expected \"oct\"", p.keyID, k.KeyType)
+		}
+		byt, err := base64.RawURLEncoding.DecodeString(k.K)
+		if err != nil || len(byt) == 0 {
+			return "", fmt.Errorf("JWK %q has an invalid \"k\" value", p.keyID)
+		}
+		// Signing keys are hex encoded.
+		return hex.EncodeToString(byt), nil
+	}
+	return "", fmt.Errorf("JWK %q not found", p.keyID)
+}
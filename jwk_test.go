@@ -0,0 +1,123 @@
+package inngestgo
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSigningKeyFromJWK(t *testing.T) {
+	secret := []byte("super-secret-hmac-key")
+	var (
+		current  atomic.Value
+		requests atomic.Int32
+	)
+	current.Store(secret)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"keys": []map[string]any{
+				{"kid": "rsa", "kty": "RSA", "n": "abc", "e": "AQAB"},
+				{"kid": "inngest", "kty": "oct", "k": base64.RawURLEncoding.EncodeToString(current.Load().([]byte))},
+			},
+		})
+	}))
+	defer server.Close()
+
+	t.Run("derives the key", func(t *testing.T) {
+		p, err := NewSigningKeyFromJWK(server.URL, "inngest")
+		require.NoError(t, err)
+
+		key, err := p.SigningKey(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, hex.EncodeToString(secret), key)
+
+		h := NewHandler("test-jwk", HandlerOpts{SigningKeyProvider: p}).(*handler)
+		got, err := h.signingKey(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, key, got)
+	})
+
+	t.Run("refreshes in the background", func(t *testing.T) {
+		requests.Store(0)
+		p, err := NewSigningKeyFromJWK(server.URL, "inngest", WithJWKTTL(10*time.Millisecond))
+		require.NoError(t, err)
+
+		rotated := []byte("rotated-secret")
+		current.Store(rotated)
+		defer current.Store(secret)
+		time.Sleep(20 * time.Millisecond)
+
+		// The stale key is returned while refreshing.
+		key, err := p.SigningKey(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, hex.EncodeToString(secret), key)
+
+		require.Eventually(t, func() bool {
+			key, _ := p.SigningKey(context.Background())
+			return key == hex.EncodeToString(rotated)
+		}, time.Second, 5*time.Millisecond)
+		require.GreaterOrEqual(t, requests.Load(), int32(2))
+	})
+
+	t.Run("missing key", func(t *testing.T) {
+		_, err := NewSigningKeyFromJWK(server.URL, "missing")
+		require.EqualError(t, err, `JWK "missing" not found`)
+	})
+
+	t.Run("non-symmetric key", func(t *testing.T) {
+		_, err := NewSigningKeyFromJWK(server.URL, "rsa")
+		require.ErrorContains(t, err, `key type "RSA"`)
+	})
+}
+
+type testKeyProvider struct {
+	key string
+	err error
+	ctx context.Context
+}
+
+func (p *testKeyProvider) SigningKey(ctx context.Context) (string, error) {
+	p.ctx = ctx
+	return p.key, p.err
+}
+
+func TestHandlerSigningKeyProvider(t *testing.T) {
+	type ctxKey struct{}
+	ctx := context.WithValue(context.Background(), ctxKey{}, "request")
+
+	p := &testKeyProvider{err: fmt.Errorf("kms unavailable")}
+	h := NewHandler("test-provider", HandlerOpts{SigningKeyProvider: p}).(*handler)
+
+	// Errors are returned until the provider returns a key.
+	_, err := h.signingKey(ctx)
+	require.EqualError(t, err, "error getting signing key: kms unavailable")
+	require.Equal(t, "request", p.ctx.Value(ctxKey{}))
+
+	p.key, p.err = "signkey-test-12345678", nil
+	key, err := h.signingKey(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "signkey-test-12345678", key)
+
+	// The last key is used if the provider then fails.
+	p.key, p.err = "", fmt.Errorf("kms unavailable")
+	key, err = h.signingKey(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "signkey-test-12345678", key)
+
+	// SigningKey takes precedence over the provider.
+	h = NewHandler("test-provider", HandlerOpts{SigningKey: StrPtr("signkey-test-abc"), SigningKeyProvider: p}).(*handler)
+	key, err = h.signingKey(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "signkey-test-abc", key)
+}
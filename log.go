@@ -0,0 +1,134 @@
+package inngestgo
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+type logLevelCtxKeyType struct{}
+
+var logLevelCtxKey = logLevelCtxKeyType{}
+
+// LogLevelFromContext returns the log level set via FunctionOpts.RunLogLevel for
+// the function being executed.  This returns slog.LevelInfo, slog's default
+// level, if no level is set.
+func LogLevelFromContext(ctx context.Context) slog.Level {
+	if lvl, ok := ctx.Value(logLevelCtxKey).(slog.Level); ok {
+		return lvl
+	}
+	return slog.LevelInfo
+}
+
+func withLogLevel(ctx context.Context, lvl *slog.Level) context.Context {
+	if lvl == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, logLevelCtxKey, *lvl)
+}
+
+// loggerWithLevel returns a logger which discards messages below the given
+// level, if set.
+func loggerWithLevel(l *slog.Logger, lvl *slog.Level) *slog.Logger {
+	if lvl == nil {
+		return l
+	}
+	return slog.New(levelHandler{level: *lvl, Handler: l.Handler()})
+}
+
+// levelHandler wraps a slog.Handler, discarding messages below a minimum level.
+type levelHandler struct {
+	slog.Handler
+	level slog.Level
+}
+
+func (h levelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level && h.Handler.Enabled(ctx, level)
+}
+
+func (h levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return levelHandler{Handler: h.Handler.WithAttrs(attrs), level: h.level}
+}
+
+func (h levelHandler) WithGroup(name string) slog.Handler {
+	return levelHandler{Handler: h.Handler.WithGroup(name), level: h.level}
+}
+
+// redacted replaces secrets within redacted log messages.
+const redacted = "[REDACTED]"
+
+// loggerWithSecrets returns a logger which redacts the values of the given
+// environment variables from messages and attributes.  Empty or unset
+// variables are ignored.
+func loggerWithSecrets(l *slog.Logger, envVars []string) *slog.Logger {
+	secrets := []string{}
+	for _, name := range envVars {
+		if val := os.Getenv(name); val != "" {
+			secrets = append(secrets, val)
+		}
+	}
+	if len(secrets) == 0 {
+		return l
+	}
+	return slog.New(redactHandler{Handler: l.Handler(), secrets: secrets})
+}
+
+// redactHandler wraps a slog.Handler, redacting secrets from messages and
+// attribute values.
+type redactHandler struct {
+	slog.Handler
+	secrets []string
+}
+
+func (h redactHandler) Handle(ctx context.Context, r slog.Record) error {
+	out := slog.NewRecord(r.Time, r.Level, h.redact(r.Message), r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		out.AddAttrs(h.redactAttr(a))
+		return true
+	})
+	return h.Handler.Handle(ctx, out)
+}
+
+func (h redactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redacted[i] = h.redactAttr(a)
+	}
+	return redactHandler{Handler: h.Handler.WithAttrs(redacted), secrets: h.secrets}
+}
+
+func (h redactHandler) WithGroup(name string) slog.Handler {
+	return redactHandler{Handler: h.Handler.WithGroup(name), secrets: h.secrets}
+}
+
+func (h redactHandler) redactAttr(a slog.Attr) slog.Attr {
+	v := a.Value.Resolve()
+	switch v.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, h.redact(v.String()))
+	case slog.KindGroup:
+		group := v.Group()
+		attrs := make([]any, len(group))
+		for i, ga := range group {
+			attrs[i] = h.redactAttr(ga)
+		}
+		return slog.Group(a.Key, attrs...)
+	case slog.KindAny:
+		// Values such as errors and structs are logged via their string
+		// representation, so only redact those which contain a secret.
+		str := fmt.Sprintf("%+v", v.Any())
+		if redactedStr := h.redact(str); redactedStr != str {
+			return slog.String(a.Key, redactedStr)
+		}
+	}
+	return slog.Attr{Key: a.Key, Value: v}
+}
+
+func (h redactHandler) redact(s string) string {
+	for _, secret := range h.secrets {
+		s = strings.ReplaceAll(s, secret, redacted)
+	}
+	return s
+}
@@ -0,0 +1,131 @@
+// Package zap adapts zap loggers for use as the handler's logger.  It's a
+// separate module so that the inngestgo module doesn't depend on zap.
+package zap
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/khulnasoft-lab/inngestgo"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// WithZapLogger returns a HandlerOption which writes the handler's logs to the
+// given zap logger:
+//
+//	h := inngestgo.NewHandler("app", inngestgo.HandlerOpts{}, zap.WithZapLogger(l))
+func WithZapLogger(l *zap.Logger) inngestgo.HandlerOption {
+	return func(opts *inngestgo.HandlerOpts) {
+		opts.Logger = slog.New(NewHandler(l))
+	}
+}
+
+// NewHandler returns a slog.Handler which writes records to the given zap
+// logger.  Attributes within groups are written with dotted keys, eg.
+// "call_ctx.run_id".
+func NewHandler(l *zap.Logger) slog.Handler {
+	return &handler{l: l}
+}
+
+type handler struct {
+	l *zap.Logger
+	// prefix is the prefix for keys within the current group.
+	prefix string
+}
+
+func (h *handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.l.Core().Enabled(zapLevel(level))
+}
+
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	ce := h.l.Check(zapLevel(r.Level), r.Message)
+	if ce == nil {
+		return nil
+	}
+	fields := make([]zap.Field, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		fields = appendFields(fields, h.prefix, a)
+		return true
+	})
+	ce.Write(fields...)
+	return nil
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := make([]zap.Field, 0, len(attrs))
+	for _, a := range attrs {
+		fields = appendFields(fields, h.prefix, a)
+	}
+	c := *h
+	c.l = h.l.With(fields...)
+	return &c
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	c := *h
+	c.prefix = h.prefix + name + "."
+	return &c
+}
+
+// appendFields appends the given attribute as zap fields with their keys
+// prefixed, expanding groups into a field for each of their members.
+func appendFields(fields []zap.Field, prefix string, a slog.Attr) []zap.Field {
+	a.Value = a.Value.Resolve()
+	if a.Value.Kind() != slog.KindGroup {
+		if a.Key == "" {
+			return fields
+		}
+		return append(fields, field(prefix+a.Key, a.Value))
+	}
+
+	// Groups without keys are inlined.
+	if a.Key != "" {
+		prefix = prefix + a.Key + "."
+	}
+	for _, m := range a.Value.Group() {
+		fields = appendFields(fields, prefix, m)
+	}
+	return fields
+}
+
+// field returns the zap field for the value's kind.  Errors use NamedError so
+// that they're written as their message.
+func field(key string, v slog.Value) zap.Field {
+	switch v.Kind() {
+	case slog.KindString:
+		return zap.String(key, v.String())
+	case slog.KindInt64:
+		return zap.Int64(key, v.Int64())
+	case slog.KindUint64:
+		return zap.Uint64(key, v.Uint64())
+	case slog.KindFloat64:
+		return zap.Float64(key, v.Float64())
+	case slog.KindBool:
+		return zap.Bool(key, v.Bool())
+	case slog.KindDuration:
+		return zap.Duration(key, v.Duration())
+	case slog.KindTime:
+		return zap.Time(key, v.Time())
+	}
+	if err, ok := v.Any().(error); ok {
+		return zap.NamedError(key, err)
+	}
+	return zap.Any(key, v.Any())
+}
+
+func zapLevel(l slog.Level) zapcore.Level {
+	switch {
+	case l >= slog.LevelError:
+		return zapcore.ErrorLevel
+	case l >= slog.LevelWarn:
+		return zapcore.WarnLevel
+	case l >= slog.LevelInfo:
+		return zapcore.InfoLevel
+	default:
+		return zapcore.DebugLevel
+	}
+}
@@ -0,0 +1,56 @@
+package zap
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	"github.com/khulnasoft-lab/inngestgo"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func newLogger(buf *bytes.Buffer, level zapcore.Level) *zap.Logger {
+	enc := zapcore.NewJSONEncoder(zapcore.EncoderConfig{
+		MessageKey:  "msg",
+		LevelKey:    "level",
+		EncodeLevel: zapcore.LowercaseLevelEncoder,
+	})
+	return zap.New(zapcore.NewCore(enc, zapcore.AddSync(buf), level))
+}
+
+func TestHandler(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l := slog.New(NewHandler(newLogger(buf, zapcore.InfoLevel)))
+
+	l.Debug("ignored")
+	require.Empty(t, buf.String())
+
+	l.With("fn", "my-fn").WithGroup("call_ctx").Warn("calling function", "run_id", "run-1", "attempt", 2, slog.Group("step", "id", "a"))
+
+	out := map[string]any{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &out))
+	require.Equal(t, map[string]any{
+		"level":            "warn",
+		"msg":              "calling function",
+		"fn":               "my-fn",
+		"call_ctx.run_id":  "run-1",
+		"call_ctx.attempt": float64(2),
+		"call_ctx.step.id": "a",
+	}, out)
+}
+
+func TestWithZapLogger(t *testing.T) {
+	buf := &bytes.Buffer{}
+	opts := inngestgo.HandlerOpts{}
+	WithZapLogger(newLogger(buf, zapcore.DebugLevel))(&opts)
+
+	opts.Logger.Error("error registering functions", "error", fmt.Errorf("oh no"))
+	out := map[string]any{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &out))
+	require.Equal(t, "error", out["level"])
+	require.Equal(t, "oh no", out["error"])
+}
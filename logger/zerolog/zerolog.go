@@ -0,0 +1,134 @@
+// Package zerolog adapts zerolog loggers for use as the handler's logger.
+package zerolog
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/khulnasoft-lab/inngestgo"
+	"github.com/rs/zerolog"
+)
+
+// WithZerologLogger returns a HandlerOption which writes the handler's logs to
+// the given zerolog logger:
+//
+//	h := inngestgo.NewHandler("app", inngestgo.HandlerOpts{}, zerolog.WithZerologLogger(l))
+func WithZerologLogger(l zerolog.Logger) inngestgo.HandlerOption {
+	return func(opts *inngestgo.HandlerOpts) {
+		opts.Logger = slog.New(NewHandler(l))
+	}
+}
+
+// NewHandler returns a slog.Handler which writes records to the given zerolog
+// logger.  Attributes within groups are written with dotted keys, eg.
+// "call_ctx.run_id".
+func NewHandler(l zerolog.Logger) slog.Handler {
+	return &handler{l: l}
+}
+
+type handler struct {
+	l zerolog.Logger
+	// attrs are the attributes added via WithAttrs, with their keys already
+	// prefixed by their groups.
+	attrs []slog.Attr
+	// prefix is the prefix for keys within the current group.
+	prefix string
+}
+
+func (h *handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.l.GetLevel() <= zerologLevel(level) && zerolog.GlobalLevel() <= zerologLevel(level)
+}
+
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	e := h.l.WithLevel(zerologLevel(r.Level))
+	for _, a := range h.attrs {
+		e = addAttr(e, a)
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		for _, a := range flatten(h.prefix, a) {
+			e = addAttr(e, a)
+		}
+		return true
+	})
+	e.Msg(r.Message)
+	return nil
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	c := *h
+	c.attrs = append([]slog.Attr{}, h.attrs...)
+	for _, a := range attrs {
+		c.attrs = append(c.attrs, flatten(h.prefix, a)...)
+	}
+	return &c
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	c := *h
+	c.prefix = h.prefix + name + "."
+	return &c
+}
+
+// addAttr adds the attribute to the event using the field type for its kind.
+// Errors are added via AnErr, as they'd otherwise be marshalled as JSON and
+// usually written as "{}".
+func addAttr(e *zerolog.Event, a slog.Attr) *zerolog.Event {
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return e.Str(a.Key, a.Value.String())
+	case slog.KindInt64:
+		return e.Int64(a.Key, a.Value.Int64())
+	case slog.KindUint64:
+		return e.Uint64(a.Key, a.Value.Uint64())
+	case slog.KindFloat64:
+		return e.Float64(a.Key, a.Value.Float64())
+	case slog.KindBool:
+		return e.Bool(a.Key, a.Value.Bool())
+	case slog.KindDuration:
+		return e.Dur(a.Key, a.Value.Duration())
+	case slog.KindTime:
+		return e.Time(a.Key, a.Value.Time())
+	}
+	if err, ok := a.Value.Any().(error); ok {
+		return e.AnErr(a.Key, err)
+	}
+	return e.Interface(a.Key, a.Value.Any())
+}
+
+// flatten returns the given attribute with its key prefixed, expanding groups
+// into an attribute for each of their members.
+func flatten(prefix string, a slog.Attr) []slog.Attr {
+	a.Value = a.Value.Resolve()
+	if a.Value.Kind() != slog.KindGroup {
+		if a.Key == "" {
+			return nil
+		}
+		return []slog.Attr{{Key: prefix + a.Key, Value: a.Value}}
+	}
+
+	// Groups without keys are inlined.
+	if a.Key != "" {
+		prefix = prefix + a.Key + "."
+	}
+	out := []slog.Attr{}
+	for _, m := range a.Value.Group() {
+		out = append(out, flatten(prefix, m)...)
+	}
+	return out
+}
+
+func zerologLevel(l slog.Level) zerolog.Level {
+	switch {
+	case l >= slog.LevelError:
+		return zerolog.ErrorLevel
+	case l >= slog.LevelWarn:
+		return zerolog.WarnLevel
+	case l >= slog.LevelInfo:
+		return zerolog.InfoLevel
+	default:
+		return zerolog.DebugLevel
+	}
+}
@@ -0,0 +1,77 @@
+package zerolog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/khulnasoft-lab/inngestgo"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l := slog.New(NewHandler(zerolog.New(buf).Level(zerolog.InfoLevel)))
+
+	l.Debug("ignored")
+	require.Empty(t, buf.String())
+
+	l.With("fn", "my-fn").WithGroup("call_ctx").Warn("calling function", "run_id", "run-1", slog.Group("step", "id", "a"))
+
+	out := map[string]any{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &out))
+	require.Equal(t, map[string]any{
+		"level":            "warn",
+		"message":          "calling function",
+		"fn":               "my-fn",
+		"call_ctx.run_id":  "run-1",
+		"call_ctx.step.id": "a",
+	}, out)
+}
+
+func TestWithZerologLogger(t *testing.T) {
+	buf := &bytes.Buffer{}
+	opts := inngestgo.HandlerOpts{}
+	WithZerologLogger(zerolog.New(buf))(&opts)
+
+	opts.Logger.Error("error registering functions", "error", fmt.Errorf("oh no"))
+	out := map[string]any{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &out))
+	require.Equal(t, "error", out["level"])
+	require.Equal(t, "oh no", out["error"])
+}
+
+func TestAttrKinds(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l := slog.New(NewHandler(zerolog.New(buf)))
+
+	l.With("err", errors.New("with attrs")).Info("kinds",
+		"str", "a",
+		"int", -1,
+		"uint", uint64(2),
+		"float", 1.5,
+		"bool", true,
+		"dur", time.Second,
+		"map", map[string]int{"a": 1},
+	)
+
+	out := map[string]any{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &out))
+	require.Equal(t, map[string]any{
+		"level":   "info",
+		"message": "kinds",
+		"err":     "with attrs",
+		"str":     "a",
+		"int":     float64(-1),
+		"uint":    float64(2),
+		"float":   1.5,
+		"bool":    true,
+		"dur":     float64(1000),
+		"map":     map[string]any{"a": float64(1)},
+	}, out)
+}
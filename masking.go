@@ -0,0 +1,142 @@
+package inngestgo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// defaultMask replaces values masked by rules without a MaskFunc.
+const defaultMask = "***"
+
+// MaskRule masks a field within step results before they're stored in
+// Inngest's state store, eg. to prevent PII from being persisted.
+type MaskRule struct {
+	// Path is the dot-separated path to the field within the step's result,
+	// eg. "data.creditCard".  Arrays are traversed, so that "users.email"
+	// masks the email of every user within a users array, and "emails" masks
+	// every email within an emails array.
+	Path string
+	// MaskFunc returns the masked value.  Non-string values are passed as
+	// JSON, eg. "1234".  If nil, values are replaced with "***".
+	MaskFunc func(string) string
+}
+
+// Validate returns an error if the rule's path is invalid.
+func (m MaskRule) Validate() error {
+	if m.Path == "" {
+		return fmt.Errorf("path must be set")
+	}
+	for _, key := range strings.Split(m.Path, ".") {
+		if key == "" {
+			return fmt.Errorf("invalid path '%s'", m.Path)
+		}
+	}
+	return nil
+}
+
+func (m MaskRule) mask(val string) string {
+	if m.MaskFunc == nil {
+		return defaultMask
+	}
+	return m.MaskFunc(val)
+}
+
+// MaskingPresets provides MaskFuncs for common types of PII:
+//
+//	inngestgo.MaskRule{Path: "user.email", MaskFunc: inngestgo.MaskingPresets.MaskEmail()}
+var MaskingPresets = maskingPresets{}
+
+type maskingPresets struct{}
+
+// MaskEmail masks all but the first character of an email's local part, eg.
+// "jane@example.com" becomes "j***@example.com".
+func (maskingPresets) MaskEmail() func(string) string {
+	return func(s string) string {
+		local, domain, ok := strings.Cut(s, "@")
+		if !ok || local == "" {
+			return defaultMask
+		}
+		return local[:1] + defaultMask + "@" + domain
+	}
+}
+
+// MaskCreditCard masks all but the last four digits of a card number, eg.
+// "4242 4242 4242 4242" becomes "************4242".
+func (maskingPresets) MaskCreditCard() func(string) string {
+	return func(s string) string {
+		digits := onlyDigits(s)
+		if len(digits) <= 4 {
+			return defaultMask
+		}
+		return strings.Repeat("*", len(digits)-4) + digits[len(digits)-4:]
+	}
+}
+
+// MaskSSN masks all but the last four digits of a US social security
+// number, eg. "123-45-6789" becomes "***-**-6789".
+func (maskingPresets) MaskSSN() func(string) string {
+	return func(s string) string {
+		digits := onlyDigits(s)
+		if len(digits) != 9 {
+			return defaultMask
+		}
+		return "***-**-" + digits[5:]
+	}
+}
+
+func onlyDigits(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// maskJSON applies the mask rules to the JSON-encoded step result.  Fields
+// which don't exist are ignored.
+func maskJSON(rules []MaskRule, byt json.RawMessage) (json.RawMessage, error) {
+	// Decode numbers as json.Number so that they're re-encoded as-is.
+	dec := json.NewDecoder(bytes.NewReader(byt))
+	dec.UseNumber()
+	var val any
+	if err := dec.Decode(&val); err != nil {
+		return nil, err
+	}
+
+	for _, rule := range rules {
+		val = maskPath(val, strings.Split(rule.Path, "."), rule)
+	}
+	return json.Marshal(val)
+}
+
+// maskPath walks the decoded JSON value along the path, returning the value
+// with the field at the end of the path masked.
+func maskPath(val any, path []string, rule MaskRule) any {
+	switch v := val.(type) {
+	case []any:
+		for i, item := range v {
+			v[i] = maskPath(item, path, rule)
+		}
+		return v
+	case nil:
+		return nil
+	}
+
+	if len(path) == 0 {
+		if s, ok := val.(string); ok {
+			return rule.mask(s)
+		}
+		byt, _ := json.Marshal(val)
+		return rule.mask(string(byt))
+	}
+
+	if m, ok := val.(map[string]any); ok {
+		if field, ok := m[path[0]]; ok {
+			m[path[0]] = maskPath(field, path[1:], rule)
+		}
+	}
+	return val
+}
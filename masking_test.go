@@ -0,0 +1,48 @@
+package inngestgo
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaskJSON(t *testing.T) {
+	rules := []MaskRule{
+		{Path: "data.creditCard", MaskFunc: MaskingPresets.MaskCreditCard()},
+		{Path: "users.email", MaskFunc: MaskingPresets.MaskEmail()},
+		{Path: "ssns", MaskFunc: MaskingPresets.MaskSSN()},
+		{Path: "data.pin"},
+		{Path: "data.missing"},
+		{Path: "data.nothing"},
+	}
+
+	actual, err := maskJSON(rules, json.RawMessage(`{
+		"data": {"creditCard": "4242 4242 4242 4242", "pin": 1234, "nothing": null, "amount": 10.50},
+		"users": [{"email": "jane@example.com"}, {"email": "bob@example.com", "id": 1}],
+		"ssns": ["123-45-6789", "oops"]
+	}`))
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"data": {"creditCard": "************4242", "pin": "***", "nothing": null, "amount": 10.50},
+		"users": [{"email": "j***@example.com"}, {"email": "b***@example.com", "id": 1}],
+		"ssns": ["***-**-6789", "***"]
+	}`, string(actual))
+
+	// Masking non-object results is a no-op.
+	actual, err = maskJSON(rules, json.RawMessage(`"4242424242424242"`))
+	require.NoError(t, err)
+	require.Equal(t, `"4242424242424242"`, string(actual))
+
+	_, err = maskJSON(rules, json.RawMessage(`{`))
+	require.Error(t, err)
+}
+
+func TestMaskRuleValidate(t *testing.T) {
+	require.NoError(t, MaskRule{Path: "data.creditCard"}.Validate())
+	require.EqualError(t, MaskRule{}.Validate(), "path must be set")
+	require.EqualError(t, MaskRule{Path: "data..card"}.Validate(), "invalid path 'data..card'")
+
+	err := FunctionOpts{Name: "fn", DataMasking: []MaskRule{{Path: ".card"}}}.Validate()
+	require.EqualError(t, err, "invalid data masking rule: invalid path '.card'")
+}
@@ -0,0 +1,98 @@
+package inngestgo
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// RequestMetricsCollector records metrics for each request served by the
+// handler, eg. to report request rates and latencies to an APM.
+type RequestMetricsCollector interface {
+	// RecordRequest is called after every request with the request's HTTP
+	// method, its kind ("inspect", "sync", "probe" or "execute"), the
+	// response's status and the duration of the request.
+	RecordRequest(method, path string, status int, duration time.Duration)
+}
+
+// NoopMetricsCollector is a RequestMetricsCollector which discards metrics.
+type NoopMetricsCollector struct{}
+
+func (NoopMetricsCollector) RecordRequest(method, path string, status int, duration time.Duration) {}
+
+// LoggingMetricsCollector returns a RequestMetricsCollector which logs each
+// request's metrics at DEBUG using the given logger.
+func LoggingMetricsCollector(logger *slog.Logger) RequestMetricsCollector {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return loggingMetricsCollector{logger: logger}
+}
+
+type loggingMetricsCollector struct {
+	logger *slog.Logger
+}
+
+func (l loggingMetricsCollector) RecordRequest(method, path string, status int, duration time.Duration) {
+	l.logger.Debug("handled request", "method", method, "path", path, "status", status, "duration", duration)
+}
+
+// requestKind returns the kind of request served by the handler, as reported
+// to RequestMetricsCollector.
+func requestKind(r *http.Request) string {
+	switch r.Method {
+	case http.MethodGet:
+		return "inspect"
+	case http.MethodPut:
+		return "sync"
+	case http.MethodPost:
+		if r.URL.Query().Get("probe") != "" {
+			return "probe"
+		}
+		return "execute"
+	default:
+		return r.Method
+	}
+}
+
+// statusRecorder records the status of responses written to the underlying
+// http.ResponseWriter.
+type statusRecorder struct {
+	http.ResponseWriter
+
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	if s.status == 0 {
+		s.status = code
+	}
+	s.ResponseWriter.WriteHeader(code)
+}
+
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+	return s.ResponseWriter.Write(b)
+}
+
+func (s *statusRecorder) Flush() {
+	if f, ok := s.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap allows http.ResponseController to access the underlying writer.
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
+
+// Status returns the response's status, which defaults to 200 if no response
+// was written.
+func (s *statusRecorder) Status() int {
+	if s.status == 0 {
+		return http.StatusOK
+	}
+	return s.status
+}
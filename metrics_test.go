@@ -0,0 +1,91 @@
+package inngestgo
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type recordedRequest struct {
+	method, path string
+	status       int
+	duration     time.Duration
+}
+
+type memoryMetrics struct {
+	l        sync.Mutex
+	requests []recordedRequest
+}
+
+func (m *memoryMetrics) RecordRequest(method, path string, status int, duration time.Duration) {
+	m.l.Lock()
+	defer m.l.Unlock()
+	m.requests = append(m.requests, recordedRequest{method, path, status, duration})
+}
+
+func (m *memoryMetrics) last() recordedRequest {
+	m.l.Lock()
+	defer m.l.Unlock()
+	return m.requests[len(m.requests)-1]
+}
+
+func TestRequestMetrics(t *testing.T) {
+	setEnvVars(t)
+
+	metrics := &memoryMetrics{}
+	fn := CreateFunction(
+		FunctionOpts{Name: "my-fn"},
+		EventTrigger("my-event", nil),
+		func(ctx context.Context, input Input[any]) (any, error) {
+			time.Sleep(5 * time.Millisecond)
+			return "ok", nil
+		},
+	)
+	h := NewHandler("test-request-metrics", HandlerOpts{RequestMetrics: metrics, GzipResponse: true})
+	h.Register(fn)
+	server := httptest.NewServer(h)
+	defer server.Close()
+
+	t.Run("inspect", func(t *testing.T) {
+		resp, err := http.Get(server.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, "inspect", metrics.last().path)
+		require.Equal(t, http.MethodGet, metrics.last().method)
+		require.Equal(t, http.StatusOK, metrics.last().status)
+	})
+
+	t.Run("execute", func(t *testing.T) {
+		resp := handlerPost(t, server.URL+"?fnId="+fn.Slug("test-request-metrics"), createRequest(t, map[string]any{"name": "my-event"}))
+		resp.Body.Close()
+		require.Equal(t, "execute", metrics.last().path)
+		require.Equal(t, http.StatusOK, metrics.last().status)
+		require.GreaterOrEqual(t, metrics.last().duration, 5*time.Millisecond)
+
+		resp = handlerPost(t, server.URL+"?fnId=missing", createRequest(t, map[string]any{"name": "my-event"}))
+		resp.Body.Close()
+		require.Equal(t, http.StatusGone, metrics.last().status)
+	})
+
+	t.Run("probe", func(t *testing.T) {
+		resp, err := http.Post(server.URL+"?probe=trust", "application/json", nil)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, "probe", metrics.last().path)
+		require.Equal(t, resp.StatusCode, metrics.last().status)
+	})
+
+	t.Run("logging collector", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		c := LoggingMetricsCollector(slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+		c.RecordRequest(http.MethodPut, "sync", http.StatusOK, time.Second)
+		require.Contains(t, buf.String(), "method=PUT path=sync status=200 duration=1s")
+	})
+}
@@ -0,0 +1,94 @@
+package inngestgo
+
+import (
+	"context"
+	"reflect"
+	"strings"
+
+	"github.com/inngest/inngest/pkg/inngest"
+)
+
+type eventNamespaceCtxKeyType struct{}
+
+var eventNamespaceCtxKey = eventNamespaceCtxKeyType{}
+
+type unscopedCtxKeyType struct{}
+
+var unscopedCtxKey = unscopedCtxKeyType{}
+
+// ScopedEventTrigger returns a trigger which runs the function whenever the
+// named event is received within the given namespace, ie. for events named
+// "<namespace>/<event>".  The optional expression further filters which events
+// run the function.
+func ScopedEventTrigger(namespace, event string, expression *string) inngest.Trigger {
+	return EventTrigger(namespacedEvent(namespace, event), expression)
+}
+
+// EventNamespaceFromContext returns the FunctionOpts.EventNamespace of the
+// function being executed, or an empty string if there's none.
+func EventNamespaceFromContext(ctx context.Context) string {
+	ns, _ := ctx.Value(eventNamespaceCtxKey).(string)
+	return ns
+}
+
+// WithoutEventNamespace returns a context which sends events via Client.Send
+// and Client.SendMany without prefixing their names with the function's
+// FunctionOpts.EventNamespace, eg. to send events to other tenants or to
+// functions which aren't namespaced.
+func WithoutEventNamespace(ctx context.Context) context.Context {
+	return context.WithValue(ctx, unscopedCtxKey, true)
+}
+
+func isUnscoped(ctx context.Context) bool {
+	unscoped, _ := ctx.Value(unscopedCtxKey).(bool)
+	return unscoped
+}
+
+func withEventNamespace(ctx context.Context, ns *string) context.Context {
+	if ns == nil || *ns == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, eventNamespaceCtxKey, *ns)
+}
+
+// namespacedEvent prefixes the event name with the namespace, unless the name
+// is already within the namespace.
+func namespacedEvent(namespace, name string) string {
+	if namespace == "" || strings.HasPrefix(name, namespace+"/") {
+		return name
+	}
+	return namespace + "/" + name
+}
+
+// namespaceEvent returns a copy of the given event with its name prefixed by
+// the namespace.  Events are any struct with a string Name field, such as Event
+// or GenericEvent, or pointers to them.  Other values are returned as-is.
+func namespaceEvent(namespace string, e any) any {
+	if namespace == "" {
+		return e
+	}
+
+	v := reflect.ValueOf(e)
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return e
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return e
+	}
+
+	cp := reflect.New(v.Type())
+	cp.Elem().Set(v)
+	name := cp.Elem().FieldByName("Name")
+	if !name.IsValid() || name.Kind() != reflect.String || !name.CanSet() {
+		return e
+	}
+	name.SetString(namespacedEvent(namespace, name.String()))
+
+	if reflect.ValueOf(e).Kind() == reflect.Pointer {
+		return cp.Interface()
+	}
+	return cp.Elem().Interface()
+}
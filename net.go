@@ -5,7 +5,23 @@ import (
 	"net/http"
 )
 
+// interceptingTransport calls intercept with every request before it's sent.
+type interceptingTransport struct {
+	next      http.RoundTripper
+	intercept func(*http.Request) *http.Request
+}
+
+func (t interceptingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// RoundTrippers must not modify the request, so the interceptor is given a
+	// copy.
+	if r := t.intercept(req.Clone(req.Context())); r != nil {
+		req = r
+	}
+	return t.next.RoundTrip(req)
+}
+
 func fetchWithAuthFallback(
+	client *http.Client,
 	createRequest func() (*http.Request, error),
 	signingKey string,
 	signingKeyFallback string,
@@ -23,7 +39,7 @@ func fetchWithAuthFallback(
 		req.Header.Set(HeaderKeyAuthorization, fmt.Sprintf("Bearer %s", string(key)))
 	}
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("error making request: %w", err)
 	}
@@ -43,7 +59,7 @@ func fetchWithAuthFallback(
 		}
 		req.Header.Set(HeaderKeyAuthorization, fmt.Sprintf("Bearer %s", string(key)))
 
-		resp, err = http.DefaultClient.Do(req)
+		resp, err = client.Do(req)
 		if err != nil {
 			return nil, fmt.Errorf("error making request: %w", err)
 		}
@@ -0,0 +1,88 @@
+package inngestgo
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// NewReverseProxyHandler returns an http.Handler which routes incoming Inngest
+// execution requests to one of many backends, allowing functions to be split
+// across multiple services behind a single endpoint.
+//
+// Routes map function ID patterns to backend URLs.  Patterns match the full
+// function ID, including the app name, or may end in "*" to match any function ID
+// with the given prefix:
+//
+//	inngestgo.NewReverseProxyHandler(map[string]string{
+//		"billing-payments-*": "http://payments:8080/api/inngest",
+//		"billing-invoice":    "http://invoices:8080/api/inngest",
+//	})
+//
+// Exact patterns take precedence over wildcards, and longer wildcard prefixes
+// take precedence over shorter ones.  Requests are sent to the backend URL's
+// path along with the incoming query string.  The request body and all headers,
+// including the X-Inngest-* headers used for signing, are forwarded unchanged.
+// This panics if any backend URL is invalid.
+func NewReverseProxyHandler(routes map[string]string) http.Handler {
+	p := &reverseProxy{exact: map[string]*httputil.ReverseProxy{}}
+
+	for pattern, backend := range routes {
+		target, err := url.Parse(backend)
+		if err != nil || target.Scheme == "" || target.Host == "" {
+			panic(fmt.Sprintf("invalid backend URL for route '%s': %s", pattern, backend))
+		}
+		proxy := &httputil.ReverseProxy{
+			Rewrite: func(r *httputil.ProxyRequest) {
+				r.SetURL(target)
+				// Send requests to the backend's serve path, rather than
+				// joining it with the proxy's path.
+				r.Out.URL.Path, r.Out.URL.RawPath = target.Path, target.RawPath
+			},
+		}
+
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			p.prefixes = append(p.prefixes, proxyPrefix{prefix: prefix, proxy: proxy})
+			continue
+		}
+		p.exact[pattern] = proxy
+	}
+
+	sort.Slice(p.prefixes, func(i, j int) bool {
+		return len(p.prefixes[i].prefix) > len(p.prefixes[j].prefix)
+	})
+	return p
+}
+
+type reverseProxy struct {
+	exact    map[string]*httputil.ReverseProxy
+	prefixes []proxyPrefix
+}
+
+type proxyPrefix struct {
+	prefix string
+	proxy  *httputil.ReverseProxy
+}
+
+func (p *reverseProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	fnID := r.URL.Query().Get("fnId")
+	if fnID == "" {
+		http.Error(w, "missing fnId", http.StatusBadRequest)
+		return
+	}
+
+	if proxy, ok := p.exact[fnID]; ok {
+		proxy.ServeHTTP(w, r)
+		return
+	}
+	for _, route := range p.prefixes {
+		if strings.HasPrefix(fnID, route.prefix) {
+			route.proxy.ServeHTTP(w, r)
+			return
+		}
+	}
+	http.Error(w, fmt.Sprintf("function not found: %s", fnID), http.StatusGone)
+}
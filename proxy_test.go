@@ -0,0 +1,63 @@
+package inngestgo
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReverseProxyHandler(t *testing.T) {
+	backend := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			w.Header().Set("X-Backend", name)
+			w.Header().Set("X-Received-Signature", r.Header.Get(HeaderKeySignature))
+			w.Header().Set("X-Received-Path", r.URL.Path)
+			_, _ = w.Write(body)
+		}))
+	}
+	payments, invoices, fallback := backend("payments"), backend("invoices"), backend("fallback")
+	defer payments.Close()
+	defer invoices.Close()
+	defer fallback.Close()
+
+	proxy := httptest.NewServer(NewReverseProxyHandler(map[string]string{
+		"app-payments-*":       payments.URL + "/api/inngest",
+		"app-payments-invoice": invoices.URL + "/api/inngest",
+		"app-*":                fallback.URL + "/api/inngest",
+	}))
+	defer proxy.Close()
+
+	call := func(t *testing.T, fnID string) *http.Response {
+		req, err := http.NewRequest(http.MethodPost, proxy.URL+"?fnId="+fnID+"&stepId=step", strings.NewReader(`{"event":{}}`))
+		require.NoError(t, err)
+		req.Header.Set(HeaderKeySignature, "t=1&s=sig")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = resp.Body.Close() })
+		return resp
+	}
+
+	for fnID, expected := range map[string]string{
+		"app-payments-charge":  "payments",
+		"app-payments-invoice": "invoices",
+		"app-emails-send":      "fallback",
+	} {
+		resp := call(t, fnID)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, expected, resp.Header.Get("X-Backend"), fnID)
+		require.Equal(t, "t=1&s=sig", resp.Header.Get("X-Received-Signature"))
+		require.Equal(t, "/api/inngest", resp.Header.Get("X-Received-Path"))
+		body, _ := io.ReadAll(resp.Body)
+		require.Equal(t, `{"event":{}}`, string(body))
+	}
+
+	require.Equal(t, http.StatusGone, call(t, "other-fn").StatusCode)
+	require.Panics(t, func() {
+		NewReverseProxyHandler(map[string]string{"*": "not a url"})
+	})
+}
@@ -0,0 +1,75 @@
+package inngestgo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/metrics"
+	"time"
+)
+
+// ErrMemoryLimitExceeded is returned when a function's execution exceeds
+// ResourceConfig.MaxMemoryMB.
+var ErrMemoryLimitExceeded = fmt.Errorf("memory limit exceeded")
+
+const defaultMemorySampleInterval = 100 * time.Millisecond
+
+// heapObjectsMetric is the runtime metric for the process's allocated heap,
+// equivalent to runtime.MemStats.Alloc.  Unlike runtime.ReadMemStats, reading
+// this doesn't stop the world, so it's cheap to sample often.
+const heapObjectsMetric = "/memory/classes/heap/objects:bytes"
+
+// monitorMemory samples the process's heap allocation in the background until
+// the returned stop function is called.  If the limit is exceeded, the returned context is
+// cancelled and stop returns an error wrapping ErrMemoryLimitExceeded.
+func monitorMemory(ctx context.Context, cfg *ResourceConfig) (context.Context, func() error) {
+	if cfg == nil || cfg.MaxMemoryMB <= 0 {
+		return ctx, func() error { return nil }
+	}
+
+	interval := defaultMemorySampleInterval
+	if cfg.SampleIntervalMs > 0 {
+		interval = time.Duration(cfg.SampleIntervalMs) * time.Millisecond
+	}
+	limit := uint64(cfg.MaxMemoryMB) * 1024 * 1024
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	done := make(chan struct{})
+	exited := make(chan struct{})
+
+	go func() {
+		defer close(exited)
+		t := time.NewTicker(interval)
+		defer t.Stop()
+
+		sample := []metrics.Sample{{Name: heapObjectsMetric}}
+		for {
+			select {
+			case <-done:
+				return
+			case <-t.C:
+			}
+			metrics.Read(sample)
+			if alloc := sample[0].Value.Uint64(); alloc > limit {
+				cancel(fmt.Errorf(
+					"%w: allocated %dMB, limit is %dMB",
+					ErrMemoryLimitExceeded,
+					alloc/1024/1024,
+					cfg.MaxMemoryMB,
+				))
+				return
+			}
+		}
+	}()
+
+	return ctx, func() error {
+		close(done)
+		<-exited
+		err := context.Cause(ctx)
+		cancel(nil)
+		if errors.Is(err, ErrMemoryLimitExceeded) {
+			return err
+		}
+		return nil
+	}
+}
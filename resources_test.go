@@ -0,0 +1,50 @@
+package inngestgo
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryLimit(t *testing.T) {
+	h := NewHandler("test-resources", HandlerOpts{}).(*handler)
+
+	runtime.GC()
+	stats := &runtime.MemStats{}
+	runtime.ReadMemStats(stats)
+	limit := int(stats.Alloc/1024/1024) + 32
+
+	create := func(allocMB int) ServableFunction {
+		return CreateFunction(
+			FunctionOpts{
+				Name:           "my-fn",
+				ResourceLimits: &ResourceConfig{MaxMemoryMB: limit, SampleIntervalMs: 5},
+			},
+			EventTrigger("my-event", nil),
+			func(ctx context.Context, input Input[any]) (any, error) {
+				buf := make([]byte, allocMB*1024*1024)
+				select {
+				case <-ctx.Done():
+				case <-time.After(100 * time.Millisecond):
+				}
+				runtime.KeepAlive(buf)
+				return "ok", nil
+			},
+		)
+	}
+
+	t.Run("within the limit", func(t *testing.T) {
+		resp, _, err := h.invokeWithHooks(context.Background(), create(1), createRequest(t, map[string]any{"name": "my-event"}), nil)
+		require.NoError(t, err)
+		require.Equal(t, "ok", resp)
+	})
+
+	t.Run("exceeding the limit", func(t *testing.T) {
+		resp, _, err := h.invokeWithHooks(context.Background(), create(128), createRequest(t, map[string]any{"name": "my-event"}), nil)
+		require.ErrorIs(t, err, ErrMemoryLimitExceeded)
+		require.Nil(t, resp)
+	})
+}
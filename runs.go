@@ -0,0 +1,471 @@
+package inngestgo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvocationTimeout is returned by InvokeSync when the function run
+	// doesn't complete before the timeout.
+	ErrInvocationTimeout = fmt.Errorf("invocation timed out")
+	// ErrInvocationFailed is returned by InvokeSync when the function run fails
+	// or is cancelled.  The returned error wraps ErrInvocationFailed, and
+	// contains the function's error.
+	ErrInvocationFailed = fmt.Errorf("invocation failed")
+	// ErrFunctionNotFound is returned by TriggerFunction when the function
+	// doesn't exist.
+	ErrFunctionNotFound = fmt.Errorf("function not found")
+)
+
+// RunStatus represents the status of a function run.
+type RunStatus string
+
+const (
+	RunStatusRunning   RunStatus = "Running"
+	RunStatusCompleted RunStatus = "Completed"
+	RunStatusFailed    RunStatus = "Failed"
+	RunStatusCancelled RunStatus = "Cancelled"
+)
+
+// Run represents a function run, as returned by the REST API.
+type Run struct {
+	ID         string          `json:"run_id"`
+	FunctionID string          `json:"function_id"`
+	EventID    string          `json:"event_id"`
+	Status     RunStatus       `json:"status"`
+	Output     json.RawMessage `json:"output,omitempty"`
+	StartedAt  time.Time       `json:"run_started_at"`
+	EndedAt    *time.Time      `json:"ended_at,omitempty"`
+}
+
+// Ended returns whether the run has finished, successfully or not.
+func (r Run) Ended() bool {
+	return r.Status == RunStatusCompleted || r.Status == RunStatusFailed || r.Status == RunStatusCancelled
+}
+
+func (a apiClient) GetRun(ctx context.Context, runID string) (*Run, error) {
+	run := &Run{}
+	if err := a.fetch(ctx, "/v1/runs/"+url.PathEscape(runID), run); err != nil {
+		return nil, fmt.Errorf("error fetching run: %w", err)
+	}
+	return run, nil
+}
+
+func (a apiClient) GetEventRuns(ctx context.Context, eventID string) ([]Run, error) {
+	runs := []Run{}
+	if err := a.fetch(ctx, "/v1/events/"+url.PathEscape(eventID)+"/runs", &runs); err != nil {
+		return nil, fmt.Errorf("error fetching event runs: %w", err)
+	}
+	return runs, nil
+}
+
+// StepStatus represents the status of a step within a function run.
+type StepStatus string
+
+const (
+	StepStatusRunning   StepStatus = "Running"
+	StepStatusCompleted StepStatus = "Completed"
+	StepStatusFailed    StepStatus = "Failed"
+)
+
+// StepTrace represents a single step executed within a function run.
+type StepTrace struct {
+	ID        string          `json:"id"`
+	Name      string          `json:"name"`
+	StartedAt time.Time       `json:"started_at"`
+	EndedAt   *time.Time      `json:"ended_at,omitempty"`
+	Status    StepStatus      `json:"status"`
+	Output    json.RawMessage `json:"output,omitempty"`
+	Error     *string         `json:"error,omitempty"`
+}
+
+// FunctionRun represents a function run along with a trace of the steps it
+// executed, as shown within the Inngest dashboard.
+type FunctionRun struct {
+	Run
+
+	Steps []StepTrace `json:"steps"`
+}
+
+// Duration returns how long the run took.  For runs which haven't ended, this
+// is the time since the run started.
+func (r FunctionRun) Duration() time.Duration {
+	if r.EndedAt == nil {
+		return time.Since(r.StartedAt)
+	}
+	return r.EndedAt.Sub(r.StartedAt)
+}
+
+// SucceededSteps returns the run's successfully completed steps.
+func (r FunctionRun) SucceededSteps() []StepTrace {
+	steps := []StepTrace{}
+	for _, s := range r.Steps {
+		if s.Status == StepStatusCompleted {
+			steps = append(steps, s)
+		}
+	}
+	return steps
+}
+
+func (a apiClient) GetFunctionRun(ctx context.Context, runID string) (*FunctionRun, error) {
+	run := &FunctionRun{}
+	if err := a.fetch(ctx, "/v1/runs/"+url.PathEscape(runID)+"/trace", run); err != nil {
+		return nil, fmt.Errorf("error fetching run trace: %w", err)
+	}
+	return run, nil
+}
+
+// RunFilter filters the runs returned by ListRuns.
+type RunFilter struct {
+	// FunctionID only includes runs for the given function.
+	FunctionID *string
+	// Status only includes runs with the given status, eg. "Running".
+	Status *string
+	// Since only includes runs started at or after the given time.
+	Since *time.Time
+	// Until only includes runs started before the given time.
+	Until *time.Time
+	// Limit is the maximum number of runs to return.  If zero, the API's
+	// default limit is used.
+	Limit int
+	// Cursor is the ID of the last run in the previous page.  If empty, the
+	// first page is returned.
+	Cursor string
+}
+
+func (f RunFilter) query() url.Values {
+	q := url.Values{}
+	if f.FunctionID != nil {
+		q.Set("function_id", *f.FunctionID)
+	}
+	if f.Status != nil {
+		q.Set("status", *f.Status)
+	}
+	if f.Since != nil {
+		q.Set("since", f.Since.Format(time.RFC3339))
+	}
+	if f.Until != nil {
+		q.Set("until", f.Until.Format(time.RFC3339))
+	}
+	if f.Limit > 0 {
+		q.Set("limit", strconv.Itoa(f.Limit))
+	}
+	if f.Cursor != "" {
+		q.Set("cursor", f.Cursor)
+	}
+	return q
+}
+
+// RunSummary summarizes a function run, as returned by ListRuns.
+type RunSummary struct {
+	ID         string     `json:"run_id"`
+	FunctionID string     `json:"function_id"`
+	Status     RunStatus  `json:"status"`
+	StartedAt  time.Time  `json:"run_started_at"`
+	EndedAt    *time.Time `json:"ended_at,omitempty"`
+	EventID    string     `json:"event_id"`
+}
+
+func (a apiClient) ListRuns(ctx context.Context, filter RunFilter) ([]*RunSummary, error) {
+	path := "/v1/runs"
+	if q := filter.query(); len(q) > 0 {
+		path += "?" + q.Encode()
+	}
+	runs := []*RunSummary{}
+	if err := a.fetch(ctx, path, &runs); err != nil {
+		return nil, fmt.Errorf("error listing runs: %w", err)
+	}
+	return runs, nil
+}
+
+// RunSearchQuery searches the runs returned by SearchRuns.
+type RunSearchQuery struct {
+	// Expression is a CEL expression evaluated against each run's triggering
+	// event and output, eg. "event.data.userId == '123'".
+	Expression string
+	// FunctionID only includes runs for the given function.
+	FunctionID *string
+	// Since only includes runs started at or after the given time.
+	Since *time.Time
+}
+
+// WithEventField returns a copy of the query which only matches runs whose
+// triggering event's field equals the given value.  The field is relative to
+// the event's data unless it's prefixed with "event.", eg. "userId" and
+// "event.data.userId" are equivalent.
+func (q RunSearchQuery) WithEventField(field, value string) RunSearchQuery {
+	if !strings.HasPrefix(field, "event.") {
+		field = "event.data." + field
+	}
+	expr := fmt.Sprintf("%s == %s", field, strconv.Quote(value))
+	if q.Expression != "" {
+		expr = fmt.Sprintf("(%s) && %s", q.Expression, expr)
+	}
+	q.Expression = expr
+	return q
+}
+
+func (a apiClient) SearchRuns(ctx context.Context, query RunSearchQuery) ([]*RunSummary, error) {
+	body := struct {
+		Expression string     `json:"expression,omitempty"`
+		FunctionID *string    `json:"function_id,omitempty"`
+		Since      *time.Time `json:"since,omitempty"`
+	}{
+		Expression: query.Expression,
+		FunctionID: query.FunctionID,
+		Since:      query.Since,
+	}
+	byt, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling search query: %w", err)
+	}
+
+	runs := []*RunSummary{}
+	if err := a.do(ctx, http.MethodPost, "/v1/runs/search", byt, &runs); err != nil {
+		return nil, fmt.Errorf("error searching runs: %w", err)
+	}
+	return runs, nil
+}
+
+// PageIterator iterates through pages of results from a cursor-paginated API:
+//
+//	pages := inngestgo.RunPages(client, inngestgo.RunFilter{Limit: 100})
+//	for pages.Next(ctx) {
+//		for _, run := range pages.Page() {
+//			// ...
+//		}
+//	}
+//	if err := pages.Err(); err != nil {
+//		// handle error
+//	}
+type PageIterator[T any] struct {
+	fetch  func(ctx context.Context, cursor string) ([]T, string, error)
+	cursor string
+	page   []T
+	done   bool
+	err    error
+}
+
+// NewPageIterator returns a PageIterator which calls fetch with the cursor for
+// each page.  fetch returns the page plus the cursor for the next page, or an
+// empty cursor if there are no more pages.
+func NewPageIterator[T any](fetch func(ctx context.Context, cursor string) (page []T, next string, err error)) *PageIterator[T] {
+	return &PageIterator[T]{fetch: fetch}
+}
+
+// Next fetches the next page, returning false when there are no more pages or
+// an error occurs.
+func (p *PageIterator[T]) Next(ctx context.Context) bool {
+	if p.done {
+		return false
+	}
+	page, next, err := p.fetch(ctx, p.cursor)
+	if err != nil {
+		p.err, p.done, p.page = err, true, nil
+		return false
+	}
+	p.page, p.cursor = page, next
+	p.done = next == ""
+	return len(page) > 0
+}
+
+// Page returns the current page.
+func (p *PageIterator[T]) Page() []T {
+	return p.page
+}
+
+// Err returns the error which stopped iteration, if any.
+func (p *PageIterator[T]) Err() error {
+	return p.err
+}
+
+// RunPages returns a PageIterator which lists all runs matching the filter,
+// starting from filter.Cursor.  Each page's cursor is the ID of its last run.
+//
+// Experimental: like Client.ListRuns, this requires server support.
+func RunPages(c Client, filter RunFilter) *PageIterator[*RunSummary] {
+	p := NewPageIterator(func(ctx context.Context, cursor string) ([]*RunSummary, string, error) {
+		f := filter
+		f.Cursor = cursor
+		runs, err := c.ListRuns(ctx, f)
+		if err != nil || len(runs) == 0 || (f.Limit > 0 && len(runs) < f.Limit) {
+			return runs, "", err
+		}
+		return runs, runs[len(runs)-1].ID, nil
+	})
+	p.cursor = filter.Cursor
+	return p
+}
+
+func (a apiClient) TriggerFunction(ctx context.Context, functionID string, evt Event) (string, error) {
+	byt, err := json.Marshal(evt)
+	if err != nil {
+		return "", fmt.Errorf("error marshalling event: %w", err)
+	}
+
+	out := struct {
+		RunID string `json:"run_id"`
+	}{}
+	err = a.do(ctx, http.MethodPost, "/v1/functions/"+url.PathEscape(functionID)+"/invoke", byt, &out)
+	if serr := (statusError{}); errors.As(err, &serr) && serr.code == http.StatusNotFound {
+		return "", ErrFunctionNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("error triggering function: %w", err)
+	}
+	return out.RunID, nil
+}
+
+// statusError is returned by the REST API client for unsuccessful responses.
+type statusError struct {
+	code int
+	body []byte
+}
+
+func (e statusError) Error() string {
+	return fmt.Sprintf("unexpected status code %d: %s", e.code, e.body)
+}
+
+// fetch makes a GET request to the REST API, unmarshalling the response's data
+// into v.
+func (a apiClient) fetch(ctx context.Context, path string, v any) error {
+	return a.do(ctx, http.MethodGet, path, nil, v)
+}
+
+// do makes a request to the REST API, unmarshalling the response's data into v.
+func (a apiClient) do(ctx context.Context, method, path string, body []byte, v any) error {
+	resp, err := fetchWithAuthFallback(
+		a.HTTPClient,
+		func() (*http.Request, error) {
+			req, err := http.NewRequestWithContext(ctx, method, a.GetAPIBaseURL()+path, bytes.NewReader(body))
+			if err != nil {
+				return nil, err
+			}
+			SetBasicRequestHeaders(req)
+			if a.GetEnv() != "" {
+				req.Header.Set(HeaderKeyEnv, a.GetEnv())
+			}
+			return req, nil
+		},
+		a.GetSigningKey(),
+		a.GetSigningKeyFallback(),
+	)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode > 299 {
+		byt, _ := io.ReadAll(resp.Body)
+		return statusError{code: resp.StatusCode, body: byt}
+	}
+
+	out := struct {
+		Data json.RawMessage `json:"data"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return fmt.Errorf("error decoding response: %w", err)
+	}
+	return json.Unmarshal(out.Data, v)
+}
+
+// InvokeSync sends the given event then blocks until the function run for the
+// given function ID, triggered by the event, completes.  The function's output is
+// unmarshalled into T.
+//
+// This returns ErrInvocationTimeout if the run doesn't complete within timeout,
+// and an error wrapping ErrInvocationFailed if the run fails or is cancelled.
+// The run's status is polled every ClientOpts.PollInterval.
+func InvokeSync[T any](ctx context.Context, c Client, functionID string, evt Event, timeout time.Duration) (T, error) {
+	var output T
+
+	interval := defaultPollInterval
+	if p, ok := c.(interface{ GetPollInterval() time.Duration }); ok {
+		interval = p.GetPollInterval()
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	eventID, err := c.Send(ctx, evt)
+	if err != nil {
+		return output, fmt.Errorf("error sending event: %w", err)
+	}
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	var runID string
+	for {
+		run, err := pollRun(ctx, c, functionID, eventID, runID)
+		if err != nil {
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return output, ErrInvocationTimeout
+			}
+			return output, err
+		}
+		if run != nil {
+			runID = run.ID
+			if run.Ended() {
+				return runOutput[T](run)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return output, ErrInvocationTimeout
+			}
+			return output, ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// pollRun returns the current state of the run.  If the run ID isn't yet known,
+// this finds the run for the function within the event's runs, returning nil if
+// the run hasn't yet been created.
+func pollRun(ctx context.Context, c Client, functionID, eventID, runID string) (*Run, error) {
+	if runID != "" {
+		return c.GetRun(ctx, runID)
+	}
+
+	runs, err := c.GetEventRuns(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	for _, run := range runs {
+		if run.FunctionID == functionID {
+			return &run, nil
+		}
+	}
+	return nil, nil
+}
+
+func runOutput[T any](run *Run) (T, error) {
+	var output T
+	switch run.Status {
+	case RunStatusFailed:
+		return output, fmt.Errorf("%w: %s", ErrInvocationFailed, run.Output)
+	case RunStatusCancelled:
+		return output, fmt.Errorf("%w: run cancelled", ErrInvocationFailed)
+	}
+
+	if len(run.Output) == 0 {
+		return output, nil
+	}
+	if err := json.Unmarshal(run.Output, &output); err != nil {
+		return output, fmt.Errorf("error unmarshalling run output: %w", err)
+	}
+	return output, nil
+}
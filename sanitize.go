@@ -0,0 +1,90 @@
+package inngestgo
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+
+	"github.com/khulnasoft-lab/inngestgo/errors"
+)
+
+// InputSanitizer cleans a function's typed event before the function is called,
+// eg. truncating strings, removing disallowed characters or normalizing values.
+// Returning an error fails the run without retrying.
+type InputSanitizer[T any] func(ctx context.Context, input T) (T, error)
+
+// WithInputSanitizer wraps the function so that the sanitizer is called with
+// the function's event, and each event within batches, before the function is
+// called:
+//
+//	inngestgo.CreateFunction(
+//		inngestgo.FunctionOpts{ID: "signup"},
+//		inngestgo.EventTrigger("user/signup", nil),
+//		inngestgo.WithInputSanitizer(inngestgo.DefaultHTMLSanitizer[SignupEvent](), signup),
+//	)
+func WithInputSanitizer[T any](s InputSanitizer[T], f SDKFunction[T]) SDKFunction[T] {
+	return func(ctx context.Context, input Input[T]) (any, error) {
+		var err error
+		if input.Event, err = s(ctx, input.Event); err != nil {
+			return nil, errors.NoRetryError(fmt.Errorf("error sanitizing input: %w", err))
+		}
+		for i := range input.Events {
+			if input.Events[i], err = s(ctx, input.Events[i]); err != nil {
+				return nil, errors.NoRetryError(fmt.Errorf("error sanitizing input: %w", err))
+			}
+		}
+		return f(ctx, input)
+	}
+}
+
+var htmlTagRegexp = regexp.MustCompile(`<[^>]*>`)
+
+// DefaultHTMLSanitizer returns an InputSanitizer which strips HTML tags from
+// every string within the event, including strings within nested structs,
+// slices and maps.  Unexported struct fields are unchanged.
+func DefaultHTMLSanitizer[T any]() InputSanitizer[T] {
+	return func(ctx context.Context, input T) (T, error) {
+		stripHTML(reflect.ValueOf(&input).Elem())
+		return input, nil
+	}
+}
+
+// stripHTML strips HTML tags from strings within the settable value.
+func stripHTML(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(htmlTagRegexp.ReplaceAllString(v.String(), ""))
+	case reflect.Pointer:
+		if !v.IsNil() {
+			stripHTML(v.Elem())
+		}
+	case reflect.Interface:
+		if v.IsNil() {
+			return
+		}
+		// Values within interfaces aren't settable, so strip a copy.
+		elem := reflect.New(v.Elem().Type()).Elem()
+		elem.Set(v.Elem())
+		stripHTML(elem)
+		v.Set(elem)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				stripHTML(v.Field(i))
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			stripHTML(v.Index(i))
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			elem := reflect.New(iter.Value().Type()).Elem()
+			elem.Set(iter.Value())
+			stripHTML(elem)
+			v.SetMapIndex(iter.Key(), elem)
+		}
+	}
+}
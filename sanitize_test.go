@@ -0,0 +1,83 @@
+package inngestgo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	sdkerrors "github.com/khulnasoft-lab/inngestgo/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultHTMLSanitizer(t *testing.T) {
+	type profile struct {
+		Bio  *string
+		Tags []string
+	}
+	type event struct {
+		Name    string
+		Data    map[string]any
+		Profile profile
+		secret  string
+	}
+
+	bio := "<p>Hello</p>"
+	actual, err := DefaultHTMLSanitizer[event]()(context.Background(), event{
+		Name: `<script>alert("hi")</script>user/signup`,
+		Data: map[string]any{
+			"name":   "<b>Jane</b>",
+			"nested": map[string]any{"links": []any{`<a href="x">link</a>`, 1}},
+		},
+		Profile: profile{Bio: &bio, Tags: []string{"<i>new</i>"}},
+		secret:  "<b>kept</b>",
+	})
+	require.NoError(t, err)
+	require.Equal(t, event{
+		Name: `alert("hi")user/signup`,
+		Data: map[string]any{
+			"name":   "Jane",
+			"nested": map[string]any{"links": []any{"link", 1}},
+		},
+		Profile: profile{Bio: StrPtr("Hello"), Tags: []string{"new"}},
+		secret:  "<b>kept</b>",
+	}, actual)
+}
+
+func TestWithInputSanitizer(t *testing.T) {
+	type event = GenericEvent[map[string]string, any]
+
+	truncate := func(ctx context.Context, evt event) (event, error) {
+		if strings.Contains(evt.Data["name"], "\x00") {
+			return evt, fmt.Errorf("invalid name")
+		}
+		if len(evt.Data["name"]) > 4 {
+			evt.Data["name"] = evt.Data["name"][:4]
+		}
+		return evt, nil
+	}
+
+	var names []string
+	fn := CreateFunction(
+		FunctionOpts{Name: "my-fn"},
+		EventTrigger("my-event", nil),
+		WithInputSanitizer(truncate, func(ctx context.Context, input Input[event]) (any, error) {
+			names = append(names, input.Event.Data["name"])
+			for _, evt := range input.Events {
+				names = append(names, evt.Data["name"])
+			}
+			return nil, nil
+		}),
+	)
+
+	req := createBatchRequest(t, map[string]any{"name": "my-event", "data": map[string]any{"name": "Jonathan"}}, 2)
+	_, _, err := invoke(context.Background(), fn, req, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"Jona", "Jona", "Jona"}, names)
+
+	req = createRequest(t, map[string]any{"name": "my-event", "data": map[string]any{"name": "\x00"}})
+	_, _, err = invoke(context.Background(), fn, req, nil, nil)
+	require.EqualError(t, err, "error sanitizing input: invalid name")
+	require.True(t, sdkerrors.IsNoRetryError(err))
+	require.Len(t, names, 3)
+}
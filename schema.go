@@ -0,0 +1,279 @@
+package inngestgo
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/khulnasoft-lab/inngestgo/errors"
+	"github.com/khulnasoft-lab/inngestgo/jsonschema"
+)
+
+// SchemaConfig configures validation of a function's input.
+type SchemaConfig struct {
+	// ValidateBasicTags validates the function's typed event against its
+	// `validate:` struct tags before calling the function.  This isn't
+	// go-playground/validator:  tags use its syntax, but only the following
+	// validations are supported:  required, omitempty, min, max, len and
+	// oneof.  The length of a string is its number of characters.  Syncing
+	// the function returns an error if a tag is invalid or unsupported.  For
+	// example:
+	//
+	//	type SignupData struct {
+	//		Email string `json:"email" validate:"required"`
+	//		Plan  string `json:"plan" validate:"oneof=free pro"`
+	//	}
+	ValidateBasicTags bool
+	// StrictMode fails the run without retrying when validation fails.  By
+	// default, validation errors are retried.
+	StrictMode bool
+	// StrictAdditionalProperties fails the run without retrying when the
+	// event's data contains fields which aren't declared by the function's
+	// typed event, eg. to catch schema drift between event producers and
+	// consumers.  Fields are matched using their `json:` tags, and maps
+	// within the data accept any fields.
+	StrictAdditionalProperties bool
+}
+
+// InputValidationError is returned when a function's input fails validation.
+// See FunctionOpts.Schema.
+type InputValidationError struct {
+	Fields []FieldError
+}
+
+func (e InputValidationError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = f.Error()
+	}
+	return "invalid input: " + strings.Join(msgs, "; ")
+}
+
+// FieldError describes a single field which failed validation.
+type FieldError struct {
+	// Field is the path to the field, eg. "Data.Email".
+	Field string
+	// Tag is the validation which failed, eg. "required" or "min=3".
+	Tag string
+	// Value is the field's value.
+	Value any
+}
+
+func (f FieldError) Error() string {
+	return fmt.Sprintf("field '%s' failed '%s' validation", f.Field, f.Tag)
+}
+
+// validateInput validates the given events against their struct tags,
+// returning an InputValidationError if any fields are invalid.
+func validateInput(c SchemaConfig, events ...reflect.Value) error {
+	if !c.ValidateBasicTags {
+		return nil
+	}
+
+	verr := InputValidationError{}
+	for _, evt := range events {
+		fields, err := validateStruct(evt, "")
+		if err != nil {
+			return errors.NoRetryError(err)
+		}
+		verr.Fields = append(verr.Fields, fields...)
+	}
+	if len(verr.Fields) == 0 {
+		return nil
+	}
+	if c.StrictMode {
+		return errors.NoRetryError(verr)
+	}
+	return verr
+}
+
+// validateAdditionalProperties returns a non-retryable error if the data of
+// any of the events contains fields which aren't declared by the event type.
+// This validates the events against the event type's generated JSON Schema,
+// with additionalProperties disallowed within the event's data.
+func validateAdditionalProperties(eventType reflect.Type, events ...json.RawMessage) error {
+	schema := jsonschema.Reflect(eventType)
+	data, ok := schema.Properties["data"]
+	if !ok {
+		return nil
+	}
+	data.DisallowAdditionalProperties()
+	for _, evt := range events {
+		if err := schema.Validate(evt); err != nil {
+			return errors.NoRetryError(fmt.Errorf("invalid input: %w", err))
+		}
+	}
+	return nil
+}
+
+// validateTags returns an error if any `validate:` tag within t is invalid,
+// allowing invalid tags to be reported when the function is synced instead of
+// when it's first invoked.
+func validateTags(t reflect.Type) error {
+	if t == nil {
+		return nil
+	}
+	return checkTags(t, "", map[reflect.Type]bool{})
+}
+
+func checkTags(t reflect.Type, prefix string, seen map[reflect.Type]bool) error {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || seen[t] {
+		return nil
+	}
+	seen[t] = true
+
+	for i := 0; i < t.NumField(); i++ {
+		ft := t.Field(i)
+		if !ft.IsExported() {
+			continue
+		}
+		path := prefix + ft.Name
+
+		if tag := ft.Tag.Get("validate"); tag != "" && tag != "-" {
+			if err := checkTag(ft.Type, tag); err != nil {
+				return fmt.Errorf("invalid validate tag for field '%s': %w", path, err)
+			}
+		}
+		if err := checkTags(ft.Type, path+".", seen); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkTag returns an error if tag contains an unsupported validation, or a
+// validation which can't be used with fields of type t.
+func checkTag(t reflect.Type, tag string) error {
+	for _, rule := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "omitempty", "required", "oneof":
+		case "min", "max", "len":
+			if _, err := strconv.ParseFloat(param, 64); err != nil {
+				return fmt.Errorf("invalid parameter for '%s': %w", name, err)
+			}
+			if !sizedKind(t.Kind()) {
+				return fmt.Errorf("'%s' can't be used with %s fields", name, t.Kind())
+			}
+		default:
+			return fmt.Errorf("unsupported validation '%s'", name)
+		}
+	}
+	return nil
+}
+
+// validateStruct returns the fields within v which fail validation.  An error
+// is returned if a struct tag is invalid.
+func validateStruct(v reflect.Value, prefix string) ([]FieldError, error) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, nil
+	}
+
+	fields := []FieldError{}
+	for i := 0; i < v.NumField(); i++ {
+		ft := v.Type().Field(i)
+		if !ft.IsExported() {
+			continue
+		}
+		fv := v.Field(i)
+		path := prefix + ft.Name
+
+		if tag := ft.Tag.Get("validate"); tag != "" && tag != "-" {
+			failed, err := validateField(fv, tag)
+			if err != nil {
+				return nil, fmt.Errorf("invalid validate tag for field '%s': %w", path, err)
+			}
+			if failed != "" {
+				fields = append(fields, FieldError{Field: path, Tag: failed, Value: fv.Interface()})
+				continue
+			}
+		}
+
+		nested, err := validateStruct(fv, path+".")
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, nested...)
+	}
+	return fields, nil
+}
+
+// validateField returns the first validation within tag which v fails, or an
+// empty string if v is valid.
+func validateField(v reflect.Value, tag string) (string, error) {
+	for _, rule := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "omitempty":
+			if v.IsZero() {
+				return "", nil
+			}
+		case "required":
+			if v.IsZero() {
+				return rule, nil
+			}
+		case "min", "max", "len":
+			n, err := strconv.ParseFloat(param, 64)
+			if err != nil {
+				return "", fmt.Errorf("invalid parameter for '%s': %w", name, err)
+			}
+			size, ok := fieldSize(v)
+			if !ok {
+				return "", fmt.Errorf("'%s' can't be used with %s fields", name, v.Kind())
+			}
+			if (name == "min" && size < n) || (name == "max" && size > n) || (name == "len" && size != n) {
+				return rule, nil
+			}
+		case "oneof":
+			if !slices.Contains(strings.Fields(param), fmt.Sprintf("%v", v.Interface())) {
+				return rule, nil
+			}
+		default:
+			return "", fmt.Errorf("unsupported validation '%s'", name)
+		}
+	}
+	return "", nil
+}
+
+// fieldSize returns the number of characters in strings, the length of slices
+// and maps, or the value of numbers.
+func fieldSize(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(v.Len()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
+
+// sizedKind reports whether fieldSize supports values of kind k.
+func sizedKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
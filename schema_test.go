@@ -0,0 +1,125 @@
+package inngestgo
+
+import (
+	"context"
+	"net/url"
+	"reflect"
+	"testing"
+
+	sdkerrors "github.com/khulnasoft-lab/inngestgo/errors"
+	"github.com/stretchr/testify/require"
+)
+
+type signupEvent struct {
+	Name string     `json:"name"`
+	Data signupData `json:"data"`
+}
+
+type signupData struct {
+	Email    string   `json:"email" validate:"required"`
+	Plan     string   `json:"plan" validate:"oneof=free pro"`
+	Age      int      `json:"age" validate:"omitempty,min=18"`
+	Tags     []string `json:"tags" validate:"max=2"`
+	Referrer *string  `json:"referrer"`
+}
+
+func TestSchemaValidation(t *testing.T) {
+	create := func(schema *SchemaConfig) (ServableFunction, *bool) {
+		called := false
+		return CreateFunction(
+			FunctionOpts{Name: "signup", Schema: schema},
+			EventTrigger("user/signup", nil),
+			func(ctx context.Context, input Input[signupEvent]) (any, error) {
+				called = true
+				return nil, nil
+			},
+		), &called
+	}
+
+	valid := map[string]any{"name": "user/signup", "data": map[string]any{"email": "a@example.com", "plan": "pro"}}
+	invalid := map[string]any{"name": "user/signup", "data": map[string]any{"plan": "enterprise", "age": 12, "tags": []string{"a", "b", "c"}}}
+
+	t.Run("valid input", func(t *testing.T) {
+		fn, called := create(&SchemaConfig{ValidateBasicTags: true})
+		_, _, err := invoke(context.Background(), fn, createRequest(t, valid), nil, nil)
+		require.NoError(t, err)
+		require.True(t, *called)
+	})
+
+	t.Run("invalid input", func(t *testing.T) {
+		fn, called := create(&SchemaConfig{ValidateBasicTags: true})
+		_, _, err := invoke(context.Background(), fn, createRequest(t, invalid), nil, nil)
+		require.False(t, *called)
+		require.False(t, sdkerrors.IsNoRetryError(err))
+
+		verr := InputValidationError{}
+		require.ErrorAs(t, err, &verr)
+		require.Equal(t, []FieldError{
+			{Field: "Data.Email", Tag: "required", Value: ""},
+			{Field: "Data.Plan", Tag: "oneof=free pro", Value: "enterprise"},
+			{Field: "Data.Age", Tag: "min=18", Value: 12},
+			{Field: "Data.Tags", Tag: "max=2", Value: []string{"a", "b", "c"}},
+		}, verr.Fields)
+	})
+
+	t.Run("strict mode", func(t *testing.T) {
+		fn, _ := create(&SchemaConfig{ValidateBasicTags: true, StrictMode: true})
+		_, _, err := invoke(context.Background(), fn, createRequest(t, invalid), nil, nil)
+		require.True(t, sdkerrors.IsNoRetryError(err))
+		require.ErrorContains(t, err, "field 'Data.Email' failed 'required' validation")
+	})
+
+	t.Run("strict additional properties", func(t *testing.T) {
+		fn, called := create(&SchemaConfig{StrictAdditionalProperties: true})
+		_, _, err := invoke(context.Background(), fn, createRequest(t, valid), nil, nil)
+		require.NoError(t, err)
+		require.True(t, *called)
+
+		extra := map[string]any{"name": "user/signup", "data": map[string]any{"email": "a@example.com", "coupon": "SAVE10"}}
+		fn, called = create(&SchemaConfig{StrictAdditionalProperties: true})
+		_, _, err = invoke(context.Background(), fn, createRequest(t, extra), nil, nil)
+		require.False(t, *called)
+		require.True(t, sdkerrors.IsNoRetryError(err))
+		require.ErrorContains(t, err, "$.data: additional property 'coupon' is not allowed")
+	})
+
+	t.Run("string lengths count characters", func(t *testing.T) {
+		type data struct {
+			Name string `json:"name" validate:"min=2,max=3"`
+		}
+		vals := []reflect.Value{reflect.ValueOf(struct{ Data data }{data{Name: "日本語"}})}
+		require.NoError(t, validateInput(SchemaConfig{ValidateBasicTags: true}, vals...))
+
+		vals = []reflect.Value{reflect.ValueOf(struct{ Data data }{data{Name: "日本語です"}})}
+		verr := InputValidationError{}
+		require.ErrorAs(t, validateInput(SchemaConfig{ValidateBasicTags: true}, vals...), &verr)
+		require.Equal(t, "Data.Name", verr.Fields[0].Field)
+	})
+
+	t.Run("invalid tags fail to sync", func(t *testing.T) {
+		type badEvent struct {
+			Data struct {
+				Email string `json:"email" validate:"required,email"`
+			} `json:"data"`
+		}
+		fn := CreateFunction(
+			FunctionOpts{Name: "signup", Schema: &SchemaConfig{ValidateBasicTags: true}},
+			EventTrigger("user/signup", nil),
+			func(ctx context.Context, input Input[badEvent]) (any, error) { return nil, nil },
+		)
+		_, err := createFunctionConfigs("app", []ServableFunction{fn}, url.URL{Scheme: "http", Host: "localhost"}, false, "")
+		require.EqualError(t, err, "invalid schema for function 'app-signup': invalid validate tag for field 'Data.Email': unsupported validation 'email'")
+
+		require.ErrorContains(t, validateTags(reflect.TypeOf(struct {
+			Ref *string `validate:"min=1"`
+		}{})), "'min' can't be used with ptr fields")
+		require.NoError(t, validateTags(reflect.TypeOf(signupEvent{})))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		fn, called := create(&SchemaConfig{})
+		_, _, err := invoke(context.Background(), fn, createRequest(t, invalid), nil, nil)
+		require.NoError(t, err)
+		require.True(t, *called)
+	})
+}
@@ -0,0 +1,134 @@
+package inngestgo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+)
+
+// ResponseSerializer serializes the app's manifest within in-band sync
+// responses.
+type ResponseSerializer interface {
+	// Serialize returns the serialized manifest and its content type, which
+	// is used as the response's Content-Type.
+	Serialize(manifest AppManifest) (contentType string, body []byte, err error)
+}
+
+// JSONSerializer serializes manifests as JSON.  This is the default
+// ResponseSerializer.
+type JSONSerializer struct{}
+
+func (JSONSerializer) Serialize(manifest AppManifest) (string, []byte, error) {
+	byt, err := json.Marshal(manifest)
+	return "application/json", byt, err
+}
+
+// MessagePackSerializer serializes manifests as MessagePack, which is smaller
+// than JSON for apps with many functions.  The manifest is encoded using its
+// JSON field names, with map keys sorted.
+type MessagePackSerializer struct{}
+
+func (MessagePackSerializer) Serialize(manifest AppManifest) (string, []byte, error) {
+	byt, err := json.Marshal(manifest)
+	if err != nil {
+		return "", nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(byt))
+	dec.UseNumber()
+	var val any
+	if err := dec.Decode(&val); err != nil {
+		return "", nil, err
+	}
+
+	buf := &bytes.Buffer{}
+	if err := encodeMsgpack(buf, val); err != nil {
+		return "", nil, err
+	}
+	return "application/msgpack", buf.Bytes(), nil
+}
+
+// encodeMsgpack writes the decoded JSON value as MessagePack.
+func encodeMsgpack(buf *bytes.Buffer, val any) error {
+	switch v := val.(type) {
+	case nil:
+		buf.WriteByte(0xc0)
+	case bool:
+		if v {
+			buf.WriteByte(0xc3)
+		} else {
+			buf.WriteByte(0xc2)
+		}
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			encodeMsgpackInt(buf, i)
+			return nil
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return err
+		}
+		buf.WriteByte(0xcb)
+		_ = binary.Write(buf, binary.BigEndian, math.Float64bits(f))
+	case string:
+		writeMsgpackHeader(buf, len(v), 0xa0, 32, 0xd9, 0xda, 0xdb)
+		buf.WriteString(v)
+	case []any:
+		writeMsgpackHeader(buf, len(v), 0x90, 16, 0, 0xdc, 0xdd)
+		for _, item := range v {
+			if err := encodeMsgpack(buf, item); err != nil {
+				return err
+			}
+		}
+	case map[string]any:
+		writeMsgpackHeader(buf, len(v), 0x80, 16, 0, 0xde, 0xdf)
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		for _, k := range keys {
+			_ = encodeMsgpack(buf, k)
+			if err := encodeMsgpack(buf, v[k]); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported type %T", val)
+	}
+	return nil
+}
+
+func encodeMsgpackInt(buf *bytes.Buffer, i int64) {
+	switch {
+	case i >= 0 && i <= 127, i >= -32 && i < 0:
+		buf.WriteByte(byte(i))
+	case i >= math.MinInt32 && i <= math.MaxInt32:
+		buf.WriteByte(0xd2)
+		_ = binary.Write(buf, binary.BigEndian, int32(i))
+	default:
+		buf.WriteByte(0xd3)
+		_ = binary.Write(buf, binary.BigEndian, i)
+	}
+}
+
+// writeMsgpackHeader writes the header for a string, array or map of length n,
+// using the fixed format if n is below fixMax, or else the 8, 16 or 32-bit
+// format.  Arrays and maps have no 8-bit format.
+func writeMsgpackHeader(buf *bytes.Buffer, n int, fix byte, fixMax int, b8, b16, b32 byte) {
+	switch {
+	case n < fixMax:
+		buf.WriteByte(fix | byte(n))
+	case b8 != 0 && n <= math.MaxUint8:
+		buf.WriteByte(b8)
+		buf.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(b16)
+		_ = binary.Write(buf, binary.BigEndian, uint16(n))
+	default:
+		buf.WriteByte(b32)
+		_ = binary.Write(buf, binary.BigEndian, uint32(n))
+	}
+}
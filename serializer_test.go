@@ -0,0 +1,49 @@
+package inngestgo
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeMsgpack(t *testing.T) {
+	encode := func(doc string) []byte {
+		dec := json.NewDecoder(strings.NewReader(doc))
+		dec.UseNumber()
+		var val any
+		require.NoError(t, dec.Decode(&val))
+		buf := &bytes.Buffer{}
+		require.NoError(t, encodeMsgpack(buf, val))
+		return buf.Bytes()
+	}
+
+	require.Equal(t, []byte{
+		0x82,            // map of 2
+		0xa1, 'a', 0x93, // "a": array of 3
+		0x01, 0xe0, 0xd2, 0x00, 0x01, 0x00, 0x00, // 1, -32, 65536
+		0xa1, 'b', 0x94, // "b": array of 4
+		0xc3, 0xc0, 0xa1, 'x', // true, nil, "x"
+		0xcb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0, // 1.5
+	}, encode(`{"b": [true, null, "x", 1.5], "a": [1, -32, 65536]}`))
+
+	long := strings.Repeat("x", 300)
+	byt := encode(`["` + long + `"]`)
+	require.Equal(t, []byte{0x91, 0xda, 0x01, 0x2c}, byt[:4])
+	require.Equal(t, long, string(byt[4:]))
+
+	byt = encode(`[` + strings.TrimSuffix(strings.Repeat("0,", 20), ",") + `]`)
+	require.Equal(t, []byte{0xdc, 0x00, 0x14}, byt[:3])
+	require.Len(t, byt, 23)
+}
+
+func TestJSONSerializer(t *testing.T) {
+	contentType, byt, err := JSONSerializer{}.Serialize(AppManifest{AppID: "app"})
+	require.NoError(t, err)
+	require.Equal(t, "application/json", contentType)
+	m := map[string]any{}
+	require.NoError(t, json.Unmarshal(byt, &m))
+	require.Equal(t, "app", m["app_id"])
+}
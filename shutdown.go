@@ -0,0 +1,141 @@
+package inngestgo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultShutdownTimeout is the default for HandlerOpts.ShutdownTimeout.
+const defaultShutdownTimeout = 30 * time.Second
+
+// serverShutdownTimeout is how long Shutdown waits for the server started by
+// ListenAndServe to write outstanding responses once executions have stopped.
+const serverShutdownTimeout = 5 * time.Second
+
+// ShutdownError is returned by Handler.Shutdown when in-flight executions don't
+// finish before the shutdown timeout and are force-cancelled.
+type ShutdownError struct {
+	// ForceKilledRunIDs are the run IDs of the executions which were cancelled.
+	ForceKilledRunIDs []string
+}
+
+func (e *ShutdownError) Error() string {
+	return fmt.Sprintf(
+		"shutdown timed out; force-cancelled %d execution(s): %s",
+		len(e.ForceKilledRunIDs),
+		strings.Join(e.ForceKilledRunIDs, ", "),
+	)
+}
+
+// executions tracks in-flight executions.
+type executions struct {
+	l            sync.Mutex
+	wg           sync.WaitGroup
+	shuttingDown bool
+	nextID       int
+	running      map[int]execution
+}
+
+type execution struct {
+	runID  string
+	cancel context.CancelFunc
+}
+
+// start registers a new execution for the given run, returning a context which
+// is cancelled if the execution is force-cancelled on shutdown, and a function
+// which must be called when the execution finishes.
+func (e *executions) start(ctx context.Context, runID string) (context.Context, func(), error) {
+	e.l.Lock()
+	defer e.l.Unlock()
+
+	if e.shuttingDown {
+		return nil, nil, errShuttingDown
+	}
+	if e.running == nil {
+		e.running = map[int]execution{}
+	}
+
+	// Executions must not be cancelled when the request's context is
+	// cancelled, only when they're force-cancelled.  As functions have always
+	// run using a background context, a client disconnecting doesn't cancel
+	// the function:  Inngest retries the request if it never gets a response.
+	ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	id := e.nextID
+	e.nextID++
+	e.running[id] = execution{runID: runID, cancel: cancel}
+	e.wg.Add(1)
+
+	return ctx, func() {
+		e.l.Lock()
+		delete(e.running, id)
+		e.l.Unlock()
+		cancel()
+		e.wg.Done()
+	}, nil
+}
+
+// Shutdown stops the handler from executing new functions, then waits for
+// in-flight executions to finish.  Executions which are still running after
+// HandlerOpts.ShutdownTimeout, or once ctx is cancelled, are force-cancelled and
+// their run IDs are returned within a *ShutdownError.  Any server started by
+// ListenAndServe is also stopped, waiting at most 5 seconds for responses to be
+// written.
+func (h *handler) Shutdown(ctx context.Context) error {
+	h.executions.l.Lock()
+	h.executions.shuttingDown = true
+	h.executions.l.Unlock()
+
+	timeout := h.ShutdownTimeout
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		h.executions.wg.Wait()
+		close(done)
+	}()
+
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		err = h.forceCancel()
+	}
+
+	h.l.RLock()
+	srv := h.server
+	h.l.RUnlock()
+	if srv != nil {
+		// Executions have finished, so this only waits for responses to be
+		// written.  ctx may already be done, so this uses its own timeout.
+		sctx, scancel := context.WithTimeout(context.Background(), serverShutdownTimeout)
+		defer scancel()
+		if serr := srv.Shutdown(sctx); serr != nil && err == nil {
+			err = fmt.Errorf("error shutting down server: %w", serr)
+		}
+	}
+
+	return err
+}
+
+// forceCancel cancels all in-flight executions, returning a *ShutdownError
+// containing their run IDs.
+func (h *handler) forceCancel() error {
+	h.executions.l.Lock()
+	defer h.executions.l.Unlock()
+	if len(h.executions.running) == 0 {
+		return nil
+	}
+	err := &ShutdownError{}
+	for _, e := range h.executions.running {
+		e.cancel()
+		err.ForceKilledRunIDs = append(err.ForceKilledRunIDs, e.runID)
+	}
+	return err
+}
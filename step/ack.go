@@ -0,0 +1,138 @@
+package step
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/inngest/inngest/pkg/enums"
+	"github.com/inngest/inngest/pkg/execution/state"
+	"github.com/khulnasoft-lab/inngestgo/internal/sdkrequest"
+)
+
+const ackStepID = "inngest/ack"
+
+// ErrManualAckDisabled is returned by Ack and Nack when the function doesn't
+// use manual acknowledgement via FunctionOpts.EventAck.
+var ErrManualAckDisabled = fmt.Errorf("event acknowledgement requires EventAck mode \"manual\"")
+
+// ErrAckTimeout is returned by Ack and Nack once the function's AckTimeout
+// has passed.
+var ErrAckTimeout = fmt.Errorf("event acknowledgement timed out")
+
+type ackCtxKeyType struct{}
+
+var ackCtxKey = ackCtxKeyType{}
+
+// AckStatus is the acknowledgement status of a function's event.
+type AckStatus int32
+
+const (
+	// AckPending is the status of events which haven't been acknowledged or
+	// rejected.
+	AckPending AckStatus = iota
+	// AckAcked is the status of events acknowledged via Ack.
+	AckAcked
+	// AckNacked is the status of events rejected via Nack.
+	AckNacked
+	// AckTimedOut is the status of events which weren't acknowledged or
+	// rejected before the deadline given to WithManualAck.
+	AckTimedOut
+)
+
+// ackState records whether the function's event was acknowledged or rejected.
+type ackState struct {
+	status atomic.Int32
+	reason atomic.Value
+}
+
+// WithManualAck returns a context in which Ack and Nack acknowledge or reject
+// the function's event, a function which returns the event's status and the
+// reason given to Nack, and a function which stops the deadline's timer.
+//
+// If the event's acknowledgement isn't already recorded within the run's
+// state, the event times out at the deadline:  the function's context is
+// cancelled and the status becomes AckTimedOut.  The deadline applies to a
+// single attempt, so each retry gets a fresh deadline.
+func WithManualAck(ctx context.Context, deadline time.Time) (context.Context, func() (AckStatus, string), func()) {
+	s := &ackState{}
+	ctx = context.WithValue(ctx, ackCtxKey, s)
+	status := func() (AckStatus, string) {
+		reason, _ := s.reason.Load().(string)
+		return AckStatus(s.status.Load()), reason
+	}
+
+	mgr, ok := sdkrequest.Manager(ctx)
+	if !ok {
+		return ctx, status, func() {}
+	}
+	if _, ok := mgr.Step(mgr.PeekOp(enums.OpcodeStepRun, ackStepID)); ok {
+		return ctx, status, func() {}
+	}
+	t := time.AfterFunc(time.Until(deadline), func() {
+		if s.status.CompareAndSwap(int32(AckPending), int32(AckTimedOut)) {
+			mgr.Cancel()
+		}
+	})
+	return ctx, status, func() { t.Stop() }
+}
+
+// Ack acknowledges the function's event when the function uses manual
+// acknowledgement.  Runs which finish without calling Ack or Nack, or which
+// don't call either within the function's AckTimeout, fail and are retried:
+//
+//	if err := step.Ack(ctx); err != nil {
+//		return nil, err
+//	}
+func Ack(ctx context.Context) error {
+	return ack(ctx, ackStepID, AckAcked, "")
+}
+
+// Nack rejects the function's event when the function uses manual
+// acknowledgement.  Functions should return after calling Nack:  the attempt
+// then fails with a retryable error, so that the event is redelivered to the
+// next attempt.  Nacks aren't recorded within the run's state, so the next
+// attempt runs the function again and may call Ack.
+func Nack(ctx context.Context, reason string) error {
+	s, ok := ctx.Value(ackCtxKey).(*ackState)
+	if !ok {
+		return ErrManualAckDisabled
+	}
+	s.reason.Store(reason)
+	if !s.status.CompareAndSwap(int32(AckPending), int32(AckNacked)) {
+		if AckStatus(s.status.Load()) == AckTimedOut {
+			return ErrAckTimeout
+		}
+	}
+	return nil
+}
+
+func ack(ctx context.Context, id string, status AckStatus, reason string) error {
+	s, ok := ctx.Value(ackCtxKey).(*ackState)
+	if !ok {
+		return ErrManualAckDisabled
+	}
+	mgr := preflight(ctx)
+	op := mgr.NewOp(enums.OpcodeStepRun, id, nil)
+	if _, ok := mgr.Step(op); ok {
+		// The event has already been acknowledged.
+		s.status.Store(int32(status))
+		s.reason.Store(reason)
+		return nil
+	}
+	if AckStatus(s.status.Load()) == AckTimedOut {
+		return ErrAckTimeout
+	}
+
+	// Acks are recorded as regular steps, so that the acknowledgement is
+	// durable once the step's result is saved.
+	mgr.AppendOp(state.GeneratorOpcode{
+		ID:          op.MustHash(),
+		Op:          enums.OpcodeStepRun,
+		Name:        id,
+		DisplayName: groupedName(ctx, id),
+		Data:        []byte("null"),
+	})
+	panic(ControlHijack{})
+}
@@ -0,0 +1,107 @@
+package step
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrorCategory classifies a step error, so that the SDK can select how the
+// step is retried.
+type ErrorCategory string
+
+const (
+	// ErrorCategoryTransient errors are retried using the function's retry
+	// policy, as with uncategorized errors.
+	ErrorCategoryTransient ErrorCategory = "transient"
+	// ErrorCategoryPermanent errors aren't retried, failing the function.
+	ErrorCategoryPermanent ErrorCategory = "permanent"
+	// ErrorCategoryRateLimited errors are retried after StepError.RetryAfter,
+	// or after a delay which backs off exponentially with each attempt.
+	ErrorCategoryRateLimited ErrorCategory = "rate_limited"
+	// ErrorCategoryTimeout errors are retried using the function's retry
+	// policy.  Steps which exceed the function's StepTimeout use this
+	// category.
+	ErrorCategoryTimeout ErrorCategory = "timeout"
+)
+
+const (
+	// rateLimitBackoff is the delay before the first retry of rate limited
+	// steps, which doubles with each attempt.
+	rateLimitBackoff = 10 * time.Second
+	// maxRateLimitBackoff is the maximum delay before rate limited steps are
+	// retried.
+	maxRateLimitBackoff = 10 * time.Minute
+)
+
+// StepError categorizes an error returned from a step.Run function:
+//
+//	step.Run(ctx, "charge", func(ctx context.Context) (string, error) {
+//		id, err := stripe.Charge(ctx, amount)
+//		if errors.Is(err, stripe.ErrTooManyRequests) {
+//			return "", &step.StepError{Category: step.ErrorCategoryRateLimited, Cause: err}
+//		}
+//		return id, err
+//	})
+//
+// The category selects how the SDK retries the step;  it isn't sent to
+// Inngest.  Uncategorized errors are retried using the function's retry
+// policy.
+type StepError struct {
+	Category ErrorCategory
+	Cause    error
+	// RetryAfter is the delay before rate limited steps are retried, eg. from
+	// a Retry-After header.  If zero, the delay backs off exponentially.
+	RetryAfter time.Duration
+}
+
+func (e *StepError) Error() string {
+	if e.Cause == nil {
+		return string(e.Category) + " step error"
+	}
+	return e.Cause.Error()
+}
+
+func (e *StepError) Unwrap() error {
+	return e.Cause
+}
+
+// TransientError categorizes the error as transient, so that the step is
+// retried with exponential backoff.  This returns nil if err is nil.
+func TransientError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &StepError{Category: ErrorCategoryTransient, Cause: err}
+}
+
+// PermanentError categorizes the error as permanent, so that the step isn't
+// retried and the function fails.  This returns nil if err is nil.
+func PermanentError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &StepError{Category: ErrorCategoryPermanent, Cause: err}
+}
+
+// errorCategory returns the category of the step error, if any.
+func errorCategory(err error) (ErrorCategory, bool) {
+	var serr *StepError
+	if errors.As(err, &serr) {
+		return serr.Category, true
+	}
+	return "", false
+}
+
+// rateLimitDelay returns the delay before a rate limited step is retried, for
+// the given zero-indexed attempt.
+func rateLimitDelay(err error, attempt int) time.Duration {
+	var serr *StepError
+	if errors.As(err, &serr) && serr.RetryAfter > 0 {
+		return serr.RetryAfter
+	}
+	delay := rateLimitBackoff
+	for i := 0; i < attempt && delay < maxRateLimitBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, maxRateLimitBackoff)
+}
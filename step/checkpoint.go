@@ -0,0 +1,69 @@
+package step
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/khulnasoft-lab/inngestgo/internal/sdkrequest"
+)
+
+// Checkpoint saves intermediate progress within a long-running step without
+// completing the step.  This must be called within a step.Run callback.  If the
+// step then fails, the most recent data saved for each ID is available via
+// GetCheckpoint when the step runs again, allowing computation to resume where
+// it left off:
+//
+//	step.Run(ctx, "process", func(ctx context.Context) (int, error) {
+//		start, _ := step.GetCheckpoint[int](ctx, "offset")
+//		for i := start; i < len(rows); i++ {
+//			// ... process rows[i]
+//			_ = step.Checkpoint(ctx, "offset", i+1)
+//		}
+//		return len(rows), nil
+//	})
+//
+// Checkpoints are stored as step state under a "checkpoint:" prefixed step ID.
+// When a step fails after saving new checkpoints, the checkpoints are saved
+// instead of the error and the step runs again immediately, so that retries are
+// only used by attempts which make no progress.  Checkpoints aren't saved if the
+// process exits before the step returns, and are scoped to the step which saves
+// them.
+func Checkpoint(ctx context.Context, id string, data any) error {
+	mgr, ok := sdkrequest.Manager(ctx)
+	if !ok {
+		return ErrNotInFunction
+	}
+	s, ok := ctx.Value(runningStepCtxKey).(runningStep)
+	if !ok {
+		return ErrNotInStep
+	}
+	byt, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("error marshalling checkpoint '%s': %w", id, err)
+	}
+	mgr.SetCheckpoint(s.hashedID, id, byt)
+	return nil
+}
+
+// GetCheckpoint returns the most recent data saved via Checkpoint for the given
+// ID within the running step, and whether a checkpoint exists.
+func GetCheckpoint[T any](ctx context.Context, id string) (T, bool) {
+	var output T
+	mgr, ok := sdkrequest.Manager(ctx)
+	if !ok {
+		return output, false
+	}
+	s, ok := ctx.Value(runningStepCtxKey).(runningStep)
+	if !ok {
+		return output, false
+	}
+	val, ok := mgr.Checkpoint(s.hashedID, id)
+	if !ok {
+		return output, false
+	}
+	if err := json.Unmarshal(val, &output); err != nil {
+		return output, false
+	}
+	return output, true
+}
@@ -0,0 +1,88 @@
+package step
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/khulnasoft-lab/inngestgo/errors"
+	"github.com/khulnasoft-lab/inngestgo/internal/sdkrequest"
+)
+
+// Fallback provides fallback results for steps which fail, eg. cached data
+// when a dependency is down.
+type Fallback struct {
+	// Provider returns the fallback result for the step with the given ID.
+	Provider func(ctx context.Context, stepID string) (any, error)
+	// Errors lists the names of errors which trigger the fallback.  Each
+	// name is either the code of an error registered via
+	// errors.RegisterError or the Go type of an error, eg.
+	// "*net.OpError".  Errors wrapped by the step's error are matched.  If
+	// empty, every error triggers the fallback.
+	Errors []string
+}
+
+// WithFallback returns a context in which step.Run uses the fallback's result
+// instead of failing when a step errors with a matching error.  The step
+// completes with the fallback result and isn't retried, and a warning is
+// logged.  Inngest doesn't support marking steps as degraded, so the step is
+// otherwise reported as a regular completed step.  This is set from
+// FunctionOpts.GracefulDegradation when executing functions.
+func WithFallback(ctx context.Context, f Fallback) context.Context {
+	return context.WithValue(ctx, fallbackKey, f)
+}
+
+// fallback returns the fallback result for the failed step, and whether the
+// fallback was used.  Degraded steps and failing fallbacks are logged as
+// warnings.
+func fallback(ctx context.Context, id string, err error) (any, bool) {
+	f, ok := ctx.Value(fallbackKey).(Fallback)
+	if !ok || f.Provider == nil || !matchesError(err, f.Errors) {
+		return nil, false
+	}
+
+	logger := sdkrequest.LoggerFromContext(ctx)
+	if logger == nil {
+		logger = slog.Default()
+	}
+	result, ferr := f.Provider(ctx, id)
+	if ferr != nil {
+		logger.Warn("step fallback failed", "step", id, "error", err, "fallback_error", ferr)
+		return nil, false
+	}
+	logger.Warn("step degraded to fallback result", "step", id, "error", err)
+	return result, true
+}
+
+// matchesError returns whether the error, or any error it wraps, has one of
+// the given names.
+func matchesError(err error, names []string) bool {
+	if len(names) == 0 {
+		return true
+	}
+	for _, name := range names {
+		if registered, ok := errors.ErrRegistry.Lookup(name); ok && stderrors.Is(err, registered) {
+			return true
+		}
+	}
+
+	queue := []error{err}
+	for len(queue) > 0 {
+		err, queue = queue[0], queue[1:]
+		if err == nil {
+			continue
+		}
+		if slices.Contains(names, fmt.Sprintf("%T", err)) {
+			return true
+		}
+		switch e := err.(type) {
+		case interface{ Unwrap() []error }:
+			queue = append(queue, e.Unwrap()...)
+		default:
+			queue = append(queue, stderrors.Unwrap(err))
+		}
+	}
+	return false
+}
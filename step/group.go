@@ -0,0 +1,54 @@
+package step
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+)
+
+var groupKey = ctxKey("group")
+
+type group struct {
+	name   string
+	parent *group
+	ended  atomic.Bool
+}
+
+// BeginGroup starts a named group of steps, so that related steps are displayed
+// together within the Inngest UI.  Steps run using the returned context have
+// their display names prefixed with the group's name, eg. "billing/charge".
+// Groups can be nested by calling BeginGroup with a grouped context.
+//
+// The returned function ends the group, after which steps using the returned
+// context are no longer prefixed:
+//
+//	gctx, end := step.BeginGroup(ctx, "billing")
+//	step.Run(gctx, "charge", charge)
+//	step.Run(gctx, "send-receipt", sendReceipt)
+//	end()
+//
+// Groups only change how steps are displayed, and don't change step IDs.
+func BeginGroup(ctx context.Context, name string) (context.Context, func()) {
+	g := &group{name: name}
+	g.parent, _ = ctx.Value(groupKey).(*group)
+	return context.WithValue(ctx, groupKey, g), func() {
+		g.ended.Store(true)
+	}
+}
+
+// groupedName returns the display name for a step with the given name, prefixed
+// with the names of the context's active groups, or nil if the step isn't
+// within a group.
+func groupedName(ctx context.Context, name string) *string {
+	names := []string{}
+	for g, _ := ctx.Value(groupKey).(*group); g != nil; g = g.parent {
+		if !g.ended.Load() {
+			names = append([]string{g.name}, names...)
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	display := strings.Join(append(names, name), "/")
+	return &display
+}
@@ -0,0 +1,40 @@
+package step
+
+import (
+	"fmt"
+)
+
+// MaxStepIDLength is the maximum length of a step ID, in bytes.
+const MaxStepIDLength = 256
+
+// ErrInvalidStepID is returned by ValidateStepID and step.Run when a step ID is
+// invalid.
+var ErrInvalidStepID = fmt.Errorf("invalid step ID")
+
+// ValidateStepID returns an error wrapping ErrInvalidStepID if the given step ID
+// can't be used.  Step IDs must be at most MaxStepIDLength bytes and may only
+// contain the characters [a-zA-Z0-9_-. ].
+//
+// step.Run validates step IDs before running, but this can be used to validate
+// dynamically constructed step IDs up front.
+func ValidateStepID(id string) error {
+	if len(id) > MaxStepIDLength {
+		return fmt.Errorf("%w: %q is longer than %d bytes", ErrInvalidStepID, id, MaxStepIDLength)
+	}
+	for _, r := range id {
+		if !isStepIDChar(r) {
+			return fmt.Errorf("%w: %q contains disallowed character %q", ErrInvalidStepID, id, r)
+		}
+	}
+	return nil
+}
+
+func isStepIDChar(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return true
+	case r == '_', r == '-', r == '.', r == ' ':
+		return true
+	}
+	return false
+}
@@ -97,9 +97,10 @@ func Infer[InputT any, OutputT any](
 	}
 
 	mgr.AppendOp(state.GeneratorOpcode{
-		ID:   hashedID,
-		Op:   enums.OpcodeAIGateway,
-		Name: id,
+		ID:          hashedID,
+		Op:          enums.OpcodeAIGateway,
+		Name:        id,
+		DisplayName: groupedName(ctx, id),
 		Opts: inferOpcodeOpts{
 			URL:     in.Opts.URL,
 			Headers: in.Opts.Headers,
@@ -78,10 +78,11 @@ func Invoke[T any](ctx context.Context, id string, opts InvokeOpts) (T, error) {
 	}
 
 	mgr.AppendOp(state.GeneratorOpcode{
-		ID:   op.MustHash(),
-		Op:   op.Op,
-		Name: id,
-		Opts: op.Opts,
+		ID:          op.MustHash(),
+		Op:          op.Op,
+		Name:        id,
+		DisplayName: groupedName(ctx, id),
+		Opts:        op.Opts,
 	})
 	panic(ControlHijack{})
 }
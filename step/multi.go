@@ -0,0 +1,74 @@
+package step
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/khulnasoft-lab/inngestgo/errors"
+)
+
+// RunMulti runs a step like Run, for steps which return multiple distinct values.
+// Each value is returned within a named output port, and ports declares the
+// ports which the step must return:
+//
+//	out, err := step.RunMulti(ctx, "load-user", []string{"user", "permissions"},
+//		func(ctx context.Context) (map[string]any, error) {
+//			return map[string]any{"user": user, "permissions": perms}, nil
+//		},
+//	)
+//
+// If the returned map's keys don't match the declared ports, the step fails
+// without retrying.  Ports are only checked by the SDK:  Inngest doesn't support
+// them, so the step's output is stored as a single JSON object of its ports.
+func RunMulti[T any](
+	ctx context.Context,
+	id string,
+	ports []string,
+	fn func(ctx context.Context) (map[string]T, error),
+) (map[string]T, error) {
+	if len(ports) == 0 {
+		return nil, fmt.Errorf("step '%s' must declare at least one output port", id)
+	}
+	for i, p := range ports {
+		if slices.Contains(ports[:i], p) {
+			return nil, fmt.Errorf("step '%s' declares output port '%s' more than once", id, p)
+		}
+	}
+
+	return Run(ctx, id, func(ctx context.Context) (map[string]T, error) {
+		out, err := fn(ctx)
+		if err != nil {
+			return out, err
+		}
+		if err := validatePorts(ports, out); err != nil {
+			return nil, errors.NoRetryError(fmt.Errorf("step '%s' %w", id, err))
+		}
+		return out, nil
+	})
+}
+
+func validatePorts[T any](ports []string, out map[string]T) error {
+	missing := []string{}
+	for _, p := range ports {
+		if _, ok := out[p]; !ok {
+			missing = append(missing, p)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("is missing output ports: %s", strings.Join(missing, ", "))
+	}
+
+	undeclared := []string{}
+	for k := range out {
+		if !slices.Contains(ports, k) {
+			undeclared = append(undeclared, k)
+		}
+	}
+	if len(undeclared) > 0 {
+		slices.Sort(undeclared)
+		return fmt.Errorf("returned undeclared output ports: %s", strings.Join(undeclared, ", "))
+	}
+	return nil
+}
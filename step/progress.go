@@ -0,0 +1,65 @@
+package step
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/khulnasoft-lab/inngestgo/internal/sdkrequest"
+)
+
+var (
+	// ErrInvalidProgress is returned by Progress when the percentage is not
+	// within [0, 100].
+	ErrInvalidProgress = fmt.Errorf("progress must be between 0 and 100")
+	// ErrNotInStep is returned by Progress and Checkpoint when called outside
+	// of a step.Run callback.
+	ErrNotInStep = fmt.Errorf("called outside of step.Run")
+)
+
+type runningStepCtxKeyType struct{}
+
+var runningStepCtxKey = runningStepCtxKeyType{}
+
+// runningStep identifies the step whose callback is being executed.
+type runningStep struct {
+	hashedID string
+	id       string
+}
+
+// Progress reports the progress of a long-running step without completing the
+// step.  This must be called within a step.Run callback:
+//
+//	step.Run(ctx, "migrate", func(ctx context.Context) (int, error) {
+//		for i, batch := range batches {
+//			// ... migrate batch
+//			_ = step.Progress(ctx, float64(i+1)/float64(len(batches))*100, "migrating")
+//		}
+//		return len(batches), nil
+//	})
+//
+// Progress updates are sent as inngestgo.ProgressEventName events when the
+// function sets FunctionOpts.TrackProgress, and are otherwise discarded.
+func Progress(ctx context.Context, percent float64, message string) error {
+	if math.IsNaN(percent) || percent < 0 || percent > 100 {
+		return ErrInvalidProgress
+	}
+	if _, ok := sdkrequest.Manager(ctx); !ok {
+		return ErrNotInFunction
+	}
+	s, ok := ctx.Value(runningStepCtxKey).(runningStep)
+	if !ok {
+		return ErrNotInStep
+	}
+
+	report := sdkrequest.ProgressReporterFromContext(ctx)
+	if report == nil {
+		return nil
+	}
+	return report(sdkrequest.Progress{
+		StepID:  s.hashedID,
+		Name:    s.id,
+		Percent: percent,
+		Message: message,
+	})
+}
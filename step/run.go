@@ -1,14 +1,17 @@
 package step
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"time"
 
 	"github.com/inngest/inngest/pkg/enums"
 	"github.com/inngest/inngest/pkg/execution/state"
 	"github.com/khulnasoft-lab/inngestgo/errors"
+	"github.com/khulnasoft-lab/inngestgo/internal/sdkrequest"
 )
 
 type RunOpts struct {
@@ -25,11 +28,19 @@ type response struct {
 
 // StepRun runs any code reliably, with retries, returning the resulting data.  If this
 // fails the function stops.
+//
+// The step ID must be valid according to ValidateStepID, otherwise the step isn't
+// ran and an error wrapping ErrInvalidStepID is returned.
 func Run[T any](
 	ctx context.Context,
 	id string,
 	f func(ctx context.Context) (T, error),
 ) (T, error) {
+	if err := ValidateStepID(id); err != nil {
+		var zero T
+		return zero, err
+	}
+
 	targetID := getTargetStepID(ctx)
 	mgr := preflight(ctx)
 	op := mgr.NewOp(enums.OpcodeStep, id, nil)
@@ -57,6 +68,12 @@ func Run[T any](
 					panic(ControlHijack{})
 				}
 
+				// Restore registered errors so that they can be checked
+				// using errors.Is.
+				if cause, ok := errors.ErrRegistry.Lookup(err.Name); ok {
+					err.Cause = cause
+				}
+
 				val, _ := reflect.ValueOf(v).Elem().Interface().(T)
 				return val, err
 			}
@@ -84,11 +101,26 @@ func Run[T any](
 	planParallel := targetID == nil && isParallel(ctx)
 	planBeforeRun := targetID == nil && mgr.Request().CallCtx.DisableImmediateExecution
 	if planParallel || planBeforeRun {
-		mgr.AppendOp(state.GeneratorOpcode{
-			ID:   hashedID,
-			Op:   enums.OpcodeStepPlanned,
-			Name: id,
-		})
+		plan := state.GeneratorOpcode{
+			ID:          hashedID,
+			Op:          enums.OpcodeStepPlanned,
+			Name:        id,
+			DisplayName: groupedName(ctx, id),
+		}
+		mgr.AppendOp(plan)
+		panic(ControlHijack{})
+	}
+
+	// Report the saved result of steps which ran before the process restarted,
+	// instead of running them again.
+	restored := state.GeneratorOpcode{
+		ID:          hashedID,
+		Op:          enums.OpcodeStepRun,
+		Name:        id,
+		DisplayName: groupedName(ctx, id),
+	}
+	if mgr.RestoreStep(restored) {
+		mgr.Cancel()
 		panic(ControlHijack{})
 	}
 
@@ -96,7 +128,15 @@ func Run[T any](
 	// other tools run.
 	defer mgr.Cancel()
 
-	result, err := f(ctx)
+	stepCtx := context.WithValue(ctx, runningStepCtxKey, runningStep{hashedID: hashedID, id: id})
+	hooks := sdkrequest.StepHooksFromContext(ctx)
+	if hooks.OnStepStart != nil {
+		hooks.OnStepStart(stepCtx, hashedID, id)
+	}
+	result, err := callStep(stepCtx, f)
+	if hooks.OnStepEnd != nil {
+		hooks.OnStepEnd(stepCtx, hashedID, id, result, err)
+	}
 	if err != nil {
 		// If tihs is a StepFailure already, fail fast.
 		if errors.IsStepError(err) {
@@ -104,19 +144,64 @@ func Run[T any](
 			panic(ControlHijack{})
 		}
 
+		// Complete the step with the fallback result, if the error triggers
+		// the function's fallback.
+		if fallbackResult, ok := fallback(stepCtx, id, err); ok {
+			byt, err := json.Marshal(fallbackResult)
+			if err != nil {
+				mgr.SetErr(fmt.Errorf("unable to marshal fallback response for '%s': %w", id, err))
+			}
+			mgr.AppendOp(state.GeneratorOpcode{
+				ID:          hashedID,
+				Op:          enums.OpcodeStepRun,
+				Name:        id,
+				DisplayName: groupedName(ctx, id),
+				Data:        byt,
+			})
+			panic(ControlHijack{})
+		}
+
 		result, _ := json.Marshal(result)
 
+		category, _ := errorCategory(err)
+
+		// Save any new checkpoints instead of the error, so that the step
+		// runs again and resumes from them.
+		if category != ErrorCategoryPermanent && !errors.IsNoRetryError(err) {
+			if op, ok := mgr.CheckpointOp(hashedID, id); ok {
+				mgr.AppendOp(op)
+				panic(ControlHijack{})
+			}
+		}
+
+		// Registered errors are named using their code, so that they can
+		// be restored when the step is replayed.
+		name := "Step failed"
+		if code, ok := errors.ErrRegistry.Code(err); ok {
+			name = code
+		}
+
 		// Implement per-step errors.
 		mgr.AppendOp(state.GeneratorOpcode{
-			ID:   hashedID,
-			Op:   enums.OpcodeStepError,
-			Name: id,
+			ID:          hashedID,
+			Op:          enums.OpcodeStepError,
+			Name:        id,
+			DisplayName: groupedName(ctx, id),
 			Error: &state.UserError{
-				Name:    "Step failed",
+				Name:    name,
 				Message: err.Error(),
 				Data:    result,
 			},
 		})
+		switch category {
+		case ErrorCategoryPermanent:
+			err = errors.NoRetryError(err)
+		case ErrorCategoryRateLimited:
+			if errors.GetRetryAtTime(err) == nil {
+				delay := rateLimitDelay(err, mgr.Request().CallCtx.Attempt)
+				err = errors.RetryAtError(err, time.Now().Add(delay))
+			}
+		}
 		mgr.SetErr(err)
 		panic(ControlHijack{})
 	}
@@ -126,10 +211,159 @@ func Run[T any](
 		mgr.SetErr(fmt.Errorf("unable to marshal run respone for '%s': %w", id, err))
 	}
 	mgr.AppendOp(state.GeneratorOpcode{
-		ID:   hashedID,
-		Op:   enums.OpcodeStepRun,
-		Name: id,
-		Data: byt,
+		ID:          hashedID,
+		Op:          enums.OpcodeStepRun,
+		Name:        id,
+		DisplayName: groupedName(ctx, id),
+		Data:        byt,
 	})
 	panic(ControlHijack{})
 }
+
+// MustRunPanic is the value MustRun panics with when its step fails.  The
+// handler recovers this panic and fails the function with the step's error.
+type MustRunPanic struct {
+	Cause error
+}
+
+func (m MustRunPanic) Error() string {
+	return m.Cause.Error()
+}
+
+func (m MustRunPanic) Unwrap() error {
+	return m.Cause
+}
+
+// MustRun is Run, panicking with a MustRunPanic instead of returning the step's
+// error.  This removes error-checking boilerplate from functions which fail
+// whenever a step fails:
+//
+//	user := step.MustRun(ctx, "load-user", loadUser)
+//	step.MustRun(ctx, "send-email", func(ctx context.Context) (any, error) {
+//		return nil, sendEmail(ctx, user)
+//	})
+func MustRun[T any](
+	ctx context.Context,
+	id string,
+	f func(ctx context.Context) (T, error),
+) T {
+	result, err := Run(ctx, id, f)
+	if err != nil {
+		panic(MustRunPanic{Cause: err})
+	}
+	return result
+}
+
+// callStep calls the step's function, converting panics into errors if enabled
+// via WithRetryOnPanic.
+func callStep[T any](ctx context.Context, f func(ctx context.Context) (T, error)) (result T, err error) {
+	if retryOnPanic(ctx) {
+		defer func() {
+			r := recover()
+			if r == nil {
+				return
+			}
+			if _, ok := r.(ControlHijack); ok {
+				panic(r)
+			}
+			err = fmt.Errorf("step panicked: %v", r)
+		}()
+	}
+	if d, ok := getStepTimeout(ctx); ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, d, ErrStepTimeout)
+		defer cancel()
+		defer func() {
+			// Steps which exceed the timeout fail even if they return, as
+			// their result may be incomplete.
+			if context.Cause(ctx) == ErrStepTimeout {
+				err = &StepError{
+					Category: ErrorCategoryTimeout,
+					Cause:    fmt.Errorf("%w after %s", ErrStepTimeout, d),
+				}
+			}
+		}()
+	}
+	return f(ctx)
+}
+
+// RunWithMigration runs a step like Run, migrating memoized state when the step's
+// return type changes between deploys.  On replay, memoized state is decoded as
+// New.  If that fails, because the state has unknown fields or mismatched types,
+// the state is decoded as Old and converted to New via migrate:
+//
+//	// Amount was previously a string.
+//	step.RunWithMigration(ctx, "charge",
+//		func(old ChargeV1) (ChargeV2, error) {
+//			amount, err := strconv.Atoi(old.Amount)
+//			return ChargeV2{Amount: amount}, err
+//		},
+//		func(ctx context.Context) (ChargeV2, error) {
+//			return charge(ctx)
+//		},
+//	)
+//
+// This allows in-flight runs to continue after the return type changes.
+func RunWithMigration[Old, New any](
+	ctx context.Context,
+	id string,
+	migrate func(Old) (New, error),
+	f func(ctx context.Context) (New, error),
+) (New, error) {
+	// Run the step with raw JSON so that memoized state can be decoded as
+	// either type.
+	raw, runErr := Run(ctx, id, func(ctx context.Context) (json.RawMessage, error) {
+		result, err := f(ctx)
+		byt, merr := json.Marshal(result)
+		if merr != nil && err == nil {
+			err = fmt.Errorf("unable to marshal run response for '%s': %w", id, merr)
+		}
+		return byt, err
+	})
+
+	var output New
+	if len(raw) == 0 {
+		return output, runErr
+	}
+	if err := strictUnmarshal(raw, &output); err == nil {
+		return output, runErr
+	}
+
+	var old Old
+	if err := strictUnmarshal(raw, &old); err != nil {
+		mgr := preflight(ctx)
+		mgr.SetErr(fmt.Errorf("error unmarshalling state for step '%s': %w", id, err))
+		panic(ControlHijack{})
+	}
+	output, err := migrate(old)
+	if err != nil {
+		mgr := preflight(ctx)
+		mgr.SetErr(fmt.Errorf("error migrating state for step '%s': %w", id, err))
+		panic(ControlHijack{})
+	}
+	return output, runErr
+}
+
+// strictUnmarshal unmarshals JSON, returning an error for unknown fields.
+func strictUnmarshal(byt []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(byt))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+// SideEffect runs a non-idempotent side effect, such as sending an email or
+// charging a card, as a step.  The side effect's result is memoized, so that
+// the side effect executes once even when the function is re-executed:
+//
+//	receiptID, err := step.SideEffect(ctx, "send-receipt", func(ctx context.Context) (string, error) {
+//		return email.Send(ctx, receipt)
+//	})
+//
+// This is equivalent to Run, and documents that the step must not be repeated.
+func SideEffect[T any](
+	ctx context.Context,
+	id string,
+	f func(ctx context.Context) (T, error),
+) (T, error) {
+	return Run(ctx, id, f)
+}
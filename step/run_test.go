@@ -1,12 +1,19 @@
 package step
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/inngest/inngest/pkg/enums"
 	"github.com/inngest/inngest/pkg/execution/state"
+	sdkerrors "github.com/khulnasoft-lab/inngestgo/errors"
 	"github.com/khulnasoft-lab/inngestgo/internal/sdkrequest"
 	"github.com/stretchr/testify/require"
 )
@@ -221,3 +228,635 @@ func TestStep(t *testing.T) {
 		}()
 	})
 }
+
+func TestCheckpoint(t *testing.T) {
+	stepID := sdkrequest.UnhashedOp{ID: "process"}.MustHash()
+	checkpointID := func(pos uint) string {
+		return sdkrequest.UnhashedOp{ID: "checkpoint:" + stepID, Pos: pos}.MustHash()
+	}
+
+	// invoke runs the process step once using the given step state, saving
+	// the given offset as a checkpoint before failing.
+	invoke := func(t *testing.T, steps map[string]json.RawMessage, save *int) (int, bool, []state.GeneratorOpcode) {
+		ctx, cancel := context.WithCancel(context.Background())
+		mgr := sdkrequest.NewManager(cancel, &sdkrequest.Request{Steps: steps})
+		ctx = sdkrequest.SetManager(ctx, mgr)
+
+		var (
+			restored int
+			found    bool
+		)
+		func() {
+			defer func() {
+				require.Equal(t, ControlHijack{}, recover())
+			}()
+			_, _ = Run(ctx, "process", func(ctx context.Context) (int, error) {
+				restored, found = GetCheckpoint[int](ctx, "offset")
+				if save != nil {
+					require.NoError(t, Checkpoint(ctx, "offset", *save-1))
+					require.NoError(t, Checkpoint(ctx, "offset", *save))
+				}
+				return 0, fmt.Errorf("interrupted")
+			})
+		}()
+		return restored, found, mgr.Ops()
+	}
+
+	t.Run("saves new checkpoints as step state instead of the error", func(t *testing.T) {
+		_, found, ops := invoke(t, map[string]json.RawMessage{}, intPtr(42))
+		require.False(t, found)
+		require.Len(t, ops, 1)
+		require.Equal(t, enums.OpcodeStepRun, ops[0].Op)
+		require.Equal(t, checkpointID(0), ops[0].ID)
+		require.Equal(t, "checkpoint:process", ops[0].Name)
+		require.JSONEq(t, `{"offset": 42}`, string(ops[0].Data))
+	})
+
+	t.Run("restores checkpoints and fails without progress", func(t *testing.T) {
+		restored, found, ops := invoke(t, map[string]json.RawMessage{
+			checkpointID(0): json.RawMessage(`{"data": {"offset": 42}}`),
+		}, nil)
+		require.True(t, found)
+		require.Equal(t, 42, restored)
+		require.Len(t, ops, 1)
+		require.Equal(t, enums.OpcodeStepError, ops[0].Op)
+		require.Equal(t, stepID, ops[0].ID)
+	})
+
+	t.Run("saves later checkpoints as new ops", func(t *testing.T) {
+		restored, _, ops := invoke(t, map[string]json.RawMessage{
+			checkpointID(0): json.RawMessage(`{"data": {"offset": 42}}`),
+			checkpointID(1): json.RawMessage(`{"data": {"offset": 50}}`),
+		}, intPtr(60))
+		require.Equal(t, 50, restored)
+		require.Len(t, ops, 1)
+		require.Equal(t, checkpointID(2), ops[0].ID)
+		require.JSONEq(t, `{"offset": 60}`, string(ops[0].Data))
+	})
+
+	t.Run("requires a step", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		ctx = sdkrequest.SetManager(ctx, sdkrequest.NewManager(cancel, &sdkrequest.Request{}))
+		require.ErrorIs(t, Checkpoint(ctx, "offset", 1), ErrNotInStep)
+		_, ok := GetCheckpoint[int](ctx, "offset")
+		require.False(t, ok)
+		require.ErrorIs(t, Checkpoint(context.Background(), "offset", 1), ErrNotInFunction)
+	})
+}
+
+func intPtr(i int) *int { return &i }
+
+func TestStepResultCache(t *testing.T) {
+	cache := sdkrequest.NewStepResultCache()
+	require.NoError(t, cache.Set("first", 1))
+	require.NoError(t, cache.Set("first:1", 2))
+	require.NoError(t, cache.Set("event", map[string]any{"name": "my-event"}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	mgr := sdkrequest.NewManager(cancel, sdkrequest.NewRequestFromCache(cache))
+	ctx = sdkrequest.SetManager(ctx, mgr)
+
+	run := func(id string) int {
+		val, err := Run(ctx, id, func(ctx context.Context) (int, error) {
+			return 0, fmt.Errorf("step %s should be memoized", id)
+		})
+		require.NoError(t, err)
+		return val
+	}
+	require.Equal(t, 1, run("first"))
+	require.Equal(t, 2, run("first"))
+
+	evt, err := WaitForEvent[map[string]any](ctx, "event", WaitForEventOpts{Event: "my-event"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"name": "my-event"}, evt)
+	require.Empty(t, mgr.Ops())
+}
+
+func TestProgress(t *testing.T) {
+	var reported []sdkrequest.Progress
+	ctx, cancel := context.WithCancel(context.Background())
+	ctx = sdkrequest.WithProgressReporter(ctx, func(p sdkrequest.Progress) error {
+		reported = append(reported, p)
+		return nil
+	})
+	mgr := sdkrequest.NewManager(cancel, &sdkrequest.Request{})
+	ctx = sdkrequest.SetManager(ctx, mgr)
+
+	require.ErrorIs(t, Progress(context.Background(), 50, ""), ErrNotInFunction)
+	require.ErrorIs(t, Progress(ctx, 50, ""), ErrNotInStep)
+
+	func() {
+		defer func() {
+			require.Equal(t, ControlHijack{}, recover())
+		}()
+		_, _ = Run(ctx, "migrate", func(ctx context.Context) (int, error) {
+			require.ErrorIs(t, Progress(ctx, -1, ""), ErrInvalidProgress)
+			require.ErrorIs(t, Progress(ctx, 101, ""), ErrInvalidProgress)
+			require.NoError(t, Progress(ctx, 50, "halfway"))
+			require.NoError(t, Progress(ctx, 100, ""))
+			return 1, nil
+		})
+	}()
+
+	hashedID := sdkrequest.UnhashedOp{ID: "migrate"}.MustHash()
+	require.Equal(t, []sdkrequest.Progress{
+		{StepID: hashedID, Name: "migrate", Percent: 50, Message: "halfway"},
+		{StepID: hashedID, Name: "migrate", Percent: 100},
+	}, reported)
+	require.Len(t, mgr.Ops(), 1)
+	require.Equal(t, enums.OpcodeStepRun, mgr.Ops()[0].Op)
+}
+
+func TestRunWithMigration(t *testing.T) {
+	type chargeV1 struct {
+		Amount string `json:"amount"`
+	}
+	type chargeV2 struct {
+		Amount int `json:"amount"`
+	}
+
+	migrations := 0
+	migrate := func(old chargeV1) (chargeV2, error) {
+		migrations++
+		var amount int
+		_, err := fmt.Sscan(old.Amount, &amount)
+		return chargeV2{Amount: amount}, err
+	}
+
+	run := func(state string) (chargeV2, error) {
+		ctx, cancel := context.WithCancel(context.Background())
+		op := sdkrequest.UnhashedOp{Op: enums.OpcodeStep, ID: "charge"}
+		req := &sdkrequest.Request{Steps: map[string]json.RawMessage{
+			op.MustHash(): json.RawMessage(state),
+		}}
+		ctx = sdkrequest.SetManager(ctx, sdkrequest.NewManager(cancel, req))
+		return RunWithMigration(ctx, "charge", migrate, func(ctx context.Context) (chargeV2, error) {
+			return chargeV2{}, fmt.Errorf("step should be memoized")
+		})
+	}
+
+	t.Run("new state is not migrated", func(t *testing.T) {
+		val, err := run(`{"data":{"amount":100}}`)
+		require.NoError(t, err)
+		require.Equal(t, chargeV2{Amount: 100}, val)
+		require.Equal(t, 0, migrations)
+	})
+
+	t.Run("old state is migrated", func(t *testing.T) {
+		val, err := run(`{"data":{"amount":"100"}}`)
+		require.NoError(t, err)
+		require.Equal(t, chargeV2{Amount: 100}, val)
+		require.Equal(t, 1, migrations)
+	})
+
+	t.Run("executes with the new type", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		mgr := sdkrequest.NewManager(cancel, &sdkrequest.Request{})
+		ctx = sdkrequest.SetManager(ctx, mgr)
+
+		func() {
+			defer func() {
+				require.Equal(t, ControlHijack{}, recover())
+			}()
+			_, _ = RunWithMigration(ctx, "charge", migrate, func(ctx context.Context) (chargeV2, error) {
+				return chargeV2{Amount: 5}, nil
+			})
+		}()
+		require.Len(t, mgr.Ops(), 1)
+		require.JSONEq(t, `{"amount":5}`, string(mgr.Ops()[0].Data))
+	})
+}
+
+func TestRegisteredStepErrors(t *testing.T) {
+	errNotFound := fmt.Errorf("not found")
+	sdkerrors.RegisterError(errNotFound, "step_test.ErrNotFound")
+
+	// Run the step, failing with a wrapped registered error.
+	ctx, cancel := context.WithCancel(context.Background())
+	mgr := sdkrequest.NewManager(cancel, &sdkrequest.Request{})
+	ctx = sdkrequest.SetManager(ctx, mgr)
+	func() {
+		defer func() {
+			require.Equal(t, ControlHijack{}, recover())
+		}()
+		_, _ = Run(ctx, "load", func(ctx context.Context) (string, error) {
+			return "", fmt.Errorf("loading user: %w", errNotFound)
+		})
+	}()
+	require.Len(t, mgr.Ops(), 1)
+	op := mgr.Ops()[0]
+	require.Equal(t, enums.OpcodeStepError, op.Op)
+	require.Equal(t, "step_test.ErrNotFound", op.Error.Name)
+
+	replay := func(t *testing.T, userErr *state.UserError) error {
+		t.Helper()
+		byt, err := json.Marshal(map[string]any{"error": userErr})
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		req := &sdkrequest.Request{Steps: map[string]json.RawMessage{op.ID: byt}}
+		ctx = sdkrequest.SetManager(ctx, sdkrequest.NewManager(cancel, req))
+		_, err = Run(ctx, "load", func(ctx context.Context) (string, error) {
+			return "", fmt.Errorf("step should be memoized")
+		})
+		return err
+	}
+
+	t.Run("registered errors are restored", func(t *testing.T) {
+		err := replay(t, op.Error)
+		require.True(t, sdkerrors.IsStepError(err))
+		require.True(t, errors.Is(err, errNotFound))
+		require.Equal(t, "loading user: not found", err.Error())
+	})
+
+	t.Run("unregistered errors", func(t *testing.T) {
+		err := replay(t, &state.UserError{Name: "Step failed", Message: "oh no", Data: json.RawMessage(`""`)})
+		require.True(t, sdkerrors.IsStepError(err))
+		require.False(t, errors.Is(err, errNotFound))
+	})
+}
+
+func TestSetLocalConcurrency(t *testing.T) {
+	plan := func(t *testing.T, ctx context.Context) state.GeneratorOpcode {
+		t.Helper()
+		ctx, cancel := context.WithCancel(ctx)
+		mgr := sdkrequest.NewManager(cancel, &sdkrequest.Request{})
+		ctx = sdkrequest.SetManager(ctx, mgr)
+		ctx = context.WithValue(ctx, ParallelKey, true)
+
+		func() {
+			defer func() {
+				require.Equal(t, ControlHijack{}, recover())
+			}()
+			_, _ = Run(ctx, "a", func(ctx context.Context) (string, error) {
+				return "", nil
+			})
+		}()
+		require.Len(t, mgr.Ops(), 1)
+		require.Equal(t, enums.OpcodeStepPlanned, mgr.Ops()[0].Op)
+		return mgr.Ops()[0]
+	}
+
+	t.Run("without a local limit", func(t *testing.T) {
+		_, ok := LocalConcurrency(context.Background())
+		require.False(t, ok)
+	})
+
+	t.Run("with a local limit", func(t *testing.T) {
+		ctx := SetLocalConcurrency(context.Background(), 2)
+		n, ok := LocalConcurrency(ctx)
+		require.True(t, ok)
+		require.Equal(t, 2, n)
+		// The limit is applied by group.Parallel, so it isn't sent with
+		// planned steps.
+		require.Nil(t, plan(t, ctx).Opts)
+	})
+
+	t.Run("removing the local limit", func(t *testing.T) {
+		ctx := SetLocalConcurrency(SetLocalConcurrency(context.Background(), 2), 0)
+		_, ok := LocalConcurrency(ctx)
+		require.False(t, ok)
+	})
+}
+
+func TestValidateStepID(t *testing.T) {
+	valid := []string{"", "a", "load-user", "load_user", "v1.2 step", strings.Repeat("a", MaxStepIDLength)}
+	for _, id := range valid {
+		require.NoError(t, ValidateStepID(id), id)
+	}
+
+	invalid := []string{"a/b", "a\x00b", "a\tb", "a\nb", "emoji 🙂", strings.Repeat("a", MaxStepIDLength+1)}
+	for _, id := range invalid {
+		require.ErrorIs(t, ValidateStepID(id), ErrInvalidStepID, id)
+	}
+
+	t.Run("Run rejects invalid IDs", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		mgr := sdkrequest.NewManager(cancel, &sdkrequest.Request{})
+		ctx = sdkrequest.SetManager(ctx, mgr)
+
+		var called bool
+		val, err := Run(ctx, "users/1", func(ctx context.Context) (string, error) {
+			called = true
+			return "ok", nil
+		})
+		require.ErrorIs(t, err, ErrInvalidStepID)
+		require.ErrorContains(t, err, `"users/1" contains disallowed character '/'`)
+		require.Empty(t, val)
+		require.False(t, called)
+		require.Empty(t, mgr.Ops())
+	})
+}
+
+func TestRunMulti(t *testing.T) {
+	run := func(t *testing.T, out map[string]any) (state.GeneratorOpcode, error) {
+		t.Helper()
+		ctx, cancel := context.WithCancel(context.Background())
+		mgr := sdkrequest.NewManager(cancel, &sdkrequest.Request{})
+		ctx = sdkrequest.SetManager(ctx, mgr)
+
+		func() {
+			defer func() {
+				require.Equal(t, ControlHijack{}, recover())
+			}()
+			_, _ = RunMulti(ctx, "load-user", []string{"user", "permissions"}, func(ctx context.Context) (map[string]any, error) {
+				return out, nil
+			})
+		}()
+		require.Len(t, mgr.Ops(), 1)
+		return mgr.Ops()[0], mgr.Err()
+	}
+
+	t.Run("declared ports", func(t *testing.T) {
+		op, err := run(t, map[string]any{"user": "u1", "permissions": []string{"admin"}})
+		require.NoError(t, err)
+		require.Equal(t, enums.OpcodeStepRun, op.Op)
+		require.Nil(t, op.Opts)
+		require.JSONEq(t, `{"user":"u1","permissions":["admin"]}`, string(op.Data))
+	})
+
+	t.Run("missing ports", func(t *testing.T) {
+		op, err := run(t, map[string]any{"user": "u1"})
+		require.Equal(t, enums.OpcodeStepError, op.Op)
+		require.True(t, sdkerrors.IsNoRetryError(err))
+		require.ErrorContains(t, err, "step 'load-user' is missing output ports: permissions")
+	})
+
+	t.Run("undeclared ports", func(t *testing.T) {
+		_, err := run(t, map[string]any{"user": "u1", "permissions": nil, "roles": nil})
+		require.ErrorContains(t, err, "step 'load-user' returned undeclared output ports: roles")
+	})
+
+	t.Run("invalid ports", func(t *testing.T) {
+		_, err := RunMulti(context.Background(), "load-user", []string{"user", "user"}, func(ctx context.Context) (map[string]any, error) {
+			return nil, nil
+		})
+		require.EqualError(t, err, "step 'load-user' declares output port 'user' more than once")
+	})
+}
+
+func TestRetryOnPanic(t *testing.T) {
+	setup := func(ctx context.Context) (context.Context, sdkrequest.InvocationManager) {
+		ctx, cancel := context.WithCancel(ctx)
+		mgr := sdkrequest.NewManager(cancel, &sdkrequest.Request{})
+		return sdkrequest.SetManager(ctx, mgr), mgr
+	}
+	charge := func(ctx context.Context) (string, error) {
+		var m map[string]string
+		m["card"] = "4242"
+		return "ok", nil
+	}
+
+	t.Run("panics propagate by default", func(t *testing.T) {
+		ctx, _ := setup(context.Background())
+		require.Panics(t, func() {
+			_, _ = Run(ctx, "charge", charge)
+		})
+	})
+
+	t.Run("panics are converted into step errors", func(t *testing.T) {
+		ctx, mgr := setup(WithRetryOnPanic(context.Background()))
+		require.PanicsWithValue(t, ControlHijack{}, func() {
+			_, _ = Run(ctx, "charge", charge)
+		})
+
+		require.Len(t, mgr.Ops(), 1)
+		op := mgr.Ops()[0]
+		require.Equal(t, enums.OpcodeStepError, op.Op)
+		require.Equal(t, "step panicked: assignment to entry in nil map", op.Error.Message)
+		require.EqualError(t, mgr.Err(), "step panicked: assignment to entry in nil map")
+		require.False(t, sdkerrors.IsNoRetryError(mgr.Err()))
+	})
+}
+
+func TestStepTimeout(t *testing.T) {
+	setup := func(ctx context.Context) (context.Context, sdkrequest.InvocationManager) {
+		ctx, cancel := context.WithCancel(ctx)
+		mgr := sdkrequest.NewManager(cancel, &sdkrequest.Request{})
+		return sdkrequest.SetManager(ctx, mgr), mgr
+	}
+
+	t.Run("times out slow steps", func(t *testing.T) {
+		ctx, mgr := setup(WithStepTimeout(context.Background(), 10*time.Millisecond))
+		require.PanicsWithValue(t, ControlHijack{}, func() {
+			_, _ = Run(ctx, "export", func(ctx context.Context) (string, error) {
+				<-ctx.Done()
+				return "", ctx.Err()
+			})
+		})
+
+		require.Len(t, mgr.Ops(), 1)
+		require.Equal(t, enums.OpcodeStepError, mgr.Ops()[0].Op)
+		require.Nil(t, mgr.Ops()[0].Opts)
+		require.ErrorIs(t, mgr.Err(), ErrStepTimeout)
+		require.False(t, sdkerrors.IsNoRetryError(mgr.Err()))
+	})
+
+	t.Run("fast steps complete", func(t *testing.T) {
+		ctx, mgr := setup(WithStepTimeout(context.Background(), time.Minute))
+		require.PanicsWithValue(t, ControlHijack{}, func() {
+			_, _ = Run(ctx, "export", func(ctx context.Context) (string, error) {
+				return "ok", nil
+			})
+		})
+
+		require.Len(t, mgr.Ops(), 1)
+		require.Equal(t, enums.OpcodeStepRun, mgr.Ops()[0].Op)
+		require.NoError(t, mgr.Err())
+	})
+}
+
+func TestErrorCategory(t *testing.T) {
+	run := func(t *testing.T, stepErr error, attempt ...int) sdkrequest.InvocationManager {
+		ctx, cancel := context.WithCancel(context.Background())
+		req := &sdkrequest.Request{}
+		if len(attempt) > 0 {
+			req.CallCtx.Attempt = attempt[0]
+		}
+		mgr := sdkrequest.NewManager(cancel, req)
+		ctx = sdkrequest.SetManager(ctx, mgr)
+		require.PanicsWithValue(t, ControlHijack{}, func() {
+			_, _ = Run(ctx, "charge", func(ctx context.Context) (string, error) {
+				return "", stepErr
+			})
+		})
+		require.Len(t, mgr.Ops(), 1)
+		require.Equal(t, enums.OpcodeStepError, mgr.Ops()[0].Op)
+		return mgr
+	}
+	cause := fmt.Errorf("card declined")
+
+	t.Run("transient", func(t *testing.T) {
+		mgr := run(t, TransientError(cause))
+		require.Nil(t, mgr.Ops()[0].Opts)
+		require.Equal(t, "card declined", mgr.Ops()[0].Error.Message)
+		require.ErrorIs(t, mgr.Err(), cause)
+		require.False(t, sdkerrors.IsNoRetryError(mgr.Err()))
+		require.Nil(t, sdkerrors.GetRetryAtTime(mgr.Err()))
+	})
+
+	t.Run("permanent", func(t *testing.T) {
+		mgr := run(t, PermanentError(cause))
+		require.ErrorIs(t, mgr.Err(), cause)
+		require.True(t, sdkerrors.IsNoRetryError(mgr.Err()))
+	})
+
+	t.Run("rate limited", func(t *testing.T) {
+		retryAt := func(mgr sdkrequest.InvocationManager) time.Duration {
+			at := sdkerrors.GetRetryAtTime(mgr.Err())
+			require.NotNil(t, at)
+			return time.Until(*at).Round(time.Second)
+		}
+
+		mgr := run(t, fmt.Errorf("charging: %w", &StepError{Category: ErrorCategoryRateLimited, Cause: cause}))
+		require.Nil(t, mgr.Ops()[0].Opts)
+		require.False(t, sdkerrors.IsNoRetryError(mgr.Err()))
+		require.ErrorIs(t, mgr.Err(), cause)
+		require.Equal(t, 10*time.Second, retryAt(mgr))
+
+		// The delay backs off with each attempt, up to a maximum.
+		mgr = run(t, &StepError{Category: ErrorCategoryRateLimited, Cause: cause}, 2)
+		require.Equal(t, 40*time.Second, retryAt(mgr))
+		mgr = run(t, &StepError{Category: ErrorCategoryRateLimited, Cause: cause}, 20)
+		require.Equal(t, 10*time.Minute, retryAt(mgr))
+
+		mgr = run(t, &StepError{Category: ErrorCategoryRateLimited, Cause: cause, RetryAfter: time.Minute})
+		require.Equal(t, time.Minute, retryAt(mgr))
+	})
+
+	t.Run("uncategorized", func(t *testing.T) {
+		mgr := run(t, cause)
+		require.Nil(t, mgr.Ops()[0].Opts)
+	})
+
+	t.Run("nil causes", func(t *testing.T) {
+		require.NoError(t, TransientError(nil))
+		require.NoError(t, PermanentError(nil))
+		require.Equal(t, "rate_limited step error", (&StepError{Category: ErrorCategoryRateLimited}).Error())
+	})
+}
+
+func TestMustRun(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	req := &sdkrequest.Request{Steps: map[string]json.RawMessage{}}
+	ctx = sdkrequest.SetManager(ctx, sdkrequest.NewManager(cancel, req))
+
+	ok := sdkrequest.UnhashedOp{Op: enums.OpcodeStep, ID: "ok"}
+	req.Steps[ok.MustHash()] = json.RawMessage(`{"data":"done"}`)
+	failed := sdkrequest.UnhashedOp{Op: enums.OpcodeStep, ID: "failed"}
+	req.Steps[failed.MustHash()] = json.RawMessage(`{"error":{"name":"Error","message":"oh no","data":null}}`)
+
+	require.Equal(t, "done", MustRun(ctx, "ok", func(ctx context.Context) (string, error) {
+		return "", nil
+	}))
+
+	defer func() {
+		p, ok := recover().(MustRunPanic)
+		require.True(t, ok)
+		require.EqualError(t, p.Cause, "oh no")
+		require.True(t, sdkerrors.IsStepError(p))
+	}()
+	MustRun(ctx, "failed", func(ctx context.Context) (string, error) {
+		return "", nil
+	})
+	t.Fatal("MustRun didn't panic")
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string { return "timed out" }
+
+func TestFallback(t *testing.T) {
+	errUnavailable := fmt.Errorf("unavailable")
+	sdkerrors.RegisterError(errUnavailable, "step_test.ErrUnavailable")
+
+	run := func(fb Fallback, stepErr error) (sdkrequest.InvocationManager, string) {
+		logs := &bytes.Buffer{}
+		ctx, cancel := context.WithCancel(context.Background())
+		ctx = sdkrequest.WithLogger(ctx, slog.New(slog.NewTextHandler(logs, nil)))
+		mgr := sdkrequest.NewManager(cancel, &sdkrequest.Request{})
+		ctx = WithFallback(sdkrequest.SetManager(ctx, mgr), fb)
+		require.PanicsWithValue(t, ControlHijack{}, func() {
+			_, _ = Run(ctx, "prices", func(ctx context.Context) (map[string]int, error) {
+				return nil, stepErr
+			})
+		})
+		require.Len(t, mgr.Ops(), 1)
+		return mgr, logs.String()
+	}
+	cached := func(ctx context.Context, stepID string) (any, error) {
+		return map[string]int{stepID: 1}, nil
+	}
+
+	t.Run("fallback triggered", func(t *testing.T) {
+		for _, stepErr := range []error{
+			fmt.Errorf("fetching: %w", errUnavailable),
+			fmt.Errorf("fetching: %w", timeoutError{}),
+		} {
+			mgr, logs := run(Fallback{Provider: cached, Errors: []string{"step_test.ErrUnavailable", "step.timeoutError"}}, stepErr)
+			op := mgr.Ops()[0]
+			require.Equal(t, enums.OpcodeStepRun, op.Op)
+			require.JSONEq(t, `{"prices":1}`, string(op.Data))
+			// Inngest doesn't read step opts, so degraded steps are only
+			// logged.
+			require.Nil(t, op.Opts)
+			require.Contains(t, logs, "step degraded to fallback result")
+			require.NoError(t, mgr.Err())
+		}
+	})
+
+	t.Run("fallback also failed", func(t *testing.T) {
+		mgr, logs := run(Fallback{
+			Provider: func(ctx context.Context, stepID string) (any, error) {
+				return nil, fmt.Errorf("cache miss")
+			},
+		}, errUnavailable)
+		op := mgr.Ops()[0]
+		require.Equal(t, enums.OpcodeStepError, op.Op)
+		require.Equal(t, "unavailable", op.Error.Message)
+		require.Nil(t, op.Opts)
+		require.Contains(t, logs, "step fallback failed")
+		require.Contains(t, logs, "fallback_error=\"cache miss\"")
+		require.EqualError(t, mgr.Err(), "unavailable")
+	})
+
+	t.Run("other errors", func(t *testing.T) {
+		mgr, _ := run(Fallback{Provider: cached, Errors: []string{"step_test.ErrUnavailable"}}, fmt.Errorf("bad request"))
+		require.Equal(t, enums.OpcodeStepError, mgr.Ops()[0].Op)
+	})
+}
+
+func TestBeginGroup(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	mgr := sdkrequest.NewManager(cancel, &sdkrequest.Request{})
+	ctx = sdkrequest.SetManager(ctx, mgr)
+	ctx = context.WithValue(ctx, ParallelKey, true)
+
+	plan := func(ctx context.Context, id string) *string {
+		t.Helper()
+		require.PanicsWithValue(t, ControlHijack{}, func() {
+			_, _ = Run(ctx, id, func(ctx context.Context) (string, error) {
+				return "", nil
+			})
+		})
+		return mgr.Ops()[len(mgr.Ops())-1].DisplayName
+	}
+
+	require.Nil(t, plan(ctx, "a"))
+
+	billing, endBilling := BeginGroup(ctx, "billing")
+	require.Equal(t, "billing/charge", *plan(billing, "charge"))
+
+	receipts, endReceipts := BeginGroup(billing, "receipts")
+	require.Equal(t, "billing/receipts/send", *plan(receipts, "send"))
+
+	endReceipts()
+	require.Equal(t, "billing/send-again", *plan(receipts, "send-again"))
+
+	endBilling()
+	require.Nil(t, plan(billing, "b"))
+
+	// Grouping doesn't change step IDs.
+	op := sdkrequest.UnhashedOp{Op: enums.OpcodeStep, ID: "charge"}
+	require.Equal(t, op.MustHash(), mgr.Ops()[1].ID)
+}
@@ -23,9 +23,10 @@ func Sleep(ctx context.Context, id string, duration time.Duration) {
 		return
 	}
 	mgr.AppendOp(state.GeneratorOpcode{
-		ID:   op.MustHash(),
-		Op:   enums.OpcodeSleep,
-		Name: id,
+		ID:          op.MustHash(),
+		Op:          enums.OpcodeSleep,
+		Name:        id,
+		DisplayName: groupedName(ctx, id),
 		Opts: map[string]any{
 			"duration": str2duration.String(duration),
 		},
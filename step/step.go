@@ -2,6 +2,8 @@ package step
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/khulnasoft-lab/inngestgo/internal/sdkrequest"
 )
@@ -11,8 +13,13 @@ type ControlHijack struct{}
 type ctxKey string
 
 const (
-	targetStepIDKey = ctxKey("stepID")
-	ParallelKey     = ctxKey("parallelKey")
+	targetStepIDKey     = ctxKey("stepID")
+	ParallelKey         = ctxKey("parallelKey")
+	localConcurrencyKey = ctxKey("localConcurrency")
+	maxParallelStepsKey = ctxKey("maxParallelSteps")
+	retryOnPanicKey     = ctxKey("retryOnPanic")
+	fallbackKey         = ctxKey("fallback")
+	stepTimeoutKey      = ctxKey("stepTimeout")
 )
 
 var (
@@ -22,6 +29,10 @@ var (
 	// If this is thrown, you're likely executing an Inngest function manually instead
 	// of it being invoked by the scheduler.
 	ErrNotInFunction = &errNotInFunction{}
+
+	// ErrStepTimeout is returned by step.Run when a step's function runs for
+	// longer than the function's StepTimeout.  The step is retried as usual.
+	ErrStepTimeout = fmt.Errorf("step timed out")
 )
 
 type errNotInFunction struct{}
@@ -56,6 +67,74 @@ func isParallel(ctx context.Context) bool {
 	return false
 }
 
+// SetLocalConcurrency returns a context which limits how many of the parallel
+// steps planned using ctx execute concurrently, overriding
+// FunctionOpts.MaxParallelSteps for those steps.  Values of n less than 1 remove
+// the local limit.  For example, to execute at most two of four parallel steps
+// at a time:
+//
+//	ctx = step.SetLocalConcurrency(ctx, 2)
+//	group.Parallel(ctx, a, b, c, d)
+//
+// The Inngest executor doesn't support per-step limits, so the limit is applied
+// by group.Parallel, which plans at most n incomplete steps at once.
+func SetLocalConcurrency(ctx context.Context, n int) context.Context {
+	return context.WithValue(ctx, localConcurrencyKey, n)
+}
+
+// LocalConcurrency returns the limit set by SetLocalConcurrency within ctx, if
+// any.
+func LocalConcurrency(ctx context.Context) (int, bool) {
+	n, ok := ctx.Value(localConcurrencyKey).(int)
+	if !ok || n < 1 {
+		return 0, false
+	}
+	return n, true
+}
+
+// WithMaxParallelSteps returns a context which caps how many parallel steps
+// group.Parallel plans at once.  This is set from FunctionOpts.MaxParallelSteps
+// when executing functions.
+func WithMaxParallelSteps(ctx context.Context, n int) context.Context {
+	return context.WithValue(ctx, maxParallelStepsKey, n)
+}
+
+// MaxParallelSteps returns the cap on parallel steps stored within ctx, if any.
+func MaxParallelSteps(ctx context.Context) (int, bool) {
+	n, ok := ctx.Value(maxParallelStepsKey).(int)
+	if !ok || n < 1 {
+		return 0, false
+	}
+	return n, true
+}
+
+// WithStepTimeout returns a context in which each step.Run function's context
+// is cancelled after d, failing the step with ErrStepTimeout.  This is set from
+// FunctionOpts.StepTimeout when executing functions.
+func WithStepTimeout(ctx context.Context, d time.Duration) context.Context {
+	return context.WithValue(ctx, stepTimeoutKey, d)
+}
+
+func getStepTimeout(ctx context.Context) (time.Duration, bool) {
+	d, ok := ctx.Value(stepTimeoutKey).(time.Duration)
+	if !ok || d <= 0 {
+		return 0, false
+	}
+	return d, true
+}
+
+// WithRetryOnPanic returns a context in which panics within step.Run are
+// converted into retryable step errors.  This is set from
+// FunctionOpts.RetryOnPanic when executing functions.
+func WithRetryOnPanic(ctx context.Context) context.Context {
+	return context.WithValue(ctx, retryOnPanicKey, true)
+}
+
+func retryOnPanic(ctx context.Context) bool {
+	v, _ := ctx.Value(retryOnPanicKey).(bool)
+	return v
+}
+
 func preflight(ctx context.Context) sdkrequest.InvocationManager {
 	if ctx.Err() != nil {
 		// Another tool has already ran and the context is closed.  Return
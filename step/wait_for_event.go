@@ -67,10 +67,46 @@ func WaitForEvent[T any](ctx context.Context, stepID string, opts WaitForEventOp
 	}
 
 	mgr.AppendOp(state.GeneratorOpcode{
-		ID:   op.MustHash(),
-		Op:   op.Op,
-		Name: opts.Name,
-		Opts: op.Opts,
+		ID:          op.MustHash(),
+		Op:          op.Op,
+		Name:        opts.Name,
+		DisplayName: groupedName(ctx, opts.Name),
+		Opts:        op.Opts,
 	})
 	panic(ControlHijack{})
 }
+
+// WaitForEventResult is the value sent on the channel returned by EventChannel.
+type WaitForEventResult[T any] struct {
+	// Event is the received event, or the zero value if no event was received.
+	Event T
+	// Err is ErrEventNotReceived if the wait timed out.
+	Err error
+}
+
+// EventChannel is an alternative to WaitForEvent which returns a channel.  The
+// channel receives exactly one value:  the matching event, or a result with
+// ErrEventNotReceived if the wait timed out.  The channel is never closed.
+//
+// This uses the same opcodes as WaitForEvent, and follows the same replay model:
+// if the wait hasn't yet completed, the function pauses when EventChannel is
+// called, and the channel is only returned once the wait's result is known.  This
+// means that channels can be used with select:
+//
+//	opened := step.EventChannel[OpenedEvent](ctx, "wait-for-open", openOpts)
+//	clicked := step.EventChannel[ClickedEvent](ctx, "wait-for-click", clickOpts)
+//	select {
+//	case res := <-opened:
+//		// ...
+//	case res := <-clicked:
+//		// ...
+//	}
+//
+// Note that the waits are sequential, and that select chooses randomly between
+// channels which are both ready.  Check every channel if the order matters.
+func EventChannel[T any](ctx context.Context, stepID string, opts WaitForEventOpts) <-chan WaitForEventResult[T] {
+	evt, err := WaitForEvent[T](ctx, stepID, opts)
+	ch := make(chan WaitForEventResult[T], 1)
+	ch <- WaitForEventResult[T]{Event: evt, Err: err}
+	return ch
+}
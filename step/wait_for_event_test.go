@@ -0,0 +1,78 @@
+package step
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/inngest/inngest/pkg/enums"
+	"github.com/khulnasoft-lab/inngestgo/internal/sdkrequest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventChannel(t *testing.T) {
+	type evt struct {
+		Name string `json:"name"`
+	}
+
+	opts := WaitForEventOpts{Event: "email/opened", Timeout: time.Hour}
+	hash := func(id string) string {
+		return sdkrequest.UnhashedOp{ID: id, Op: enums.OpcodeWaitForEvent}.MustHash()
+	}
+
+	// run invokes a function which selects on two event channels, with the
+	// given step state.
+	run := func(steps map[string]json.RawMessage) (sdkrequest.InvocationManager, map[string]WaitForEventResult[evt]) {
+		ctx, cancel := context.WithCancel(context.Background())
+		mgr := sdkrequest.NewManager(cancel, &sdkrequest.Request{Steps: steps})
+		ctx = sdkrequest.SetManager(ctx, mgr)
+
+		results := map[string]WaitForEventResult[evt]{}
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					require.Equal(t, ControlHijack{}, r)
+				}
+			}()
+			a := EventChannel[evt](ctx, "a", opts)
+			b := EventChannel[evt](ctx, "b", opts)
+			for len(results) < 2 {
+				select {
+				case res := <-a:
+					results["a"], a = res, nil
+				case res := <-b:
+					results["b"], b = res, nil
+				}
+			}
+		}()
+		return mgr, results
+	}
+
+	t.Run("pauses on the first wait without state", func(t *testing.T) {
+		mgr, results := run(map[string]json.RawMessage{})
+		require.Empty(t, results)
+		require.Len(t, mgr.Ops(), 1)
+		require.Equal(t, hash("a"), mgr.Ops()[0].ID)
+		require.Equal(t, enums.OpcodeWaitForEvent, mgr.Ops()[0].Op)
+
+		mgr, results = run(map[string]json.RawMessage{
+			hash("a"): json.RawMessage(`{"name":"email/opened"}`),
+		})
+		require.Empty(t, results)
+		require.Len(t, mgr.Ops(), 1)
+		require.Equal(t, hash("b"), mgr.Ops()[0].ID)
+	})
+
+	t.Run("sends one result per channel when replayed", func(t *testing.T) {
+		mgr, results := run(map[string]json.RawMessage{
+			hash("a"): json.RawMessage(`{"name":"email/opened"}`),
+			hash("b"): json.RawMessage(`null`),
+		})
+		require.Empty(t, mgr.Ops())
+		require.Equal(t, map[string]WaitForEventResult[evt]{
+			"a": {Event: evt{Name: "email/opened"}},
+			"b": {Err: ErrEventNotReceived},
+		}, results)
+	})
+}
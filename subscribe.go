@@ -0,0 +1,159 @@
+package inngestgo
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sync"
+)
+
+// maxEventStreamLine is the maximum size of a single line within the event
+// stream.
+const maxEventStreamLine = 1024 * 1024
+
+// UnsubscribeFunc cancels a subscription created via Client.Subscribe, waiting
+// for the subscription to stop.  It returns the error which ended the event
+// stream before the subscription was cancelled, eg. if the connection failed or
+// a line within the stream was too long, or nil.
+type UnsubscribeFunc func() error
+
+// EventStreamFilter filters the events delivered to a subscription.
+type EventStreamFilter struct {
+	// Data only delivers events whose data contains each of the given
+	// top-level keys with an equal value.
+	Data map[string]any
+}
+
+// Match returns whether the event matches the filter.
+func (f EventStreamFilter) Match(evt Event) bool {
+	for k, v := range f.Data {
+		actual, ok := evt.Data[k]
+		if !ok || !reflect.DeepEqual(normalizeJSON(actual), normalizeJSON(v)) {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeJSON round-trips v through JSON, so that eg. ints in filters match
+// the float64s decoded from events.
+func normalizeJSON(v any) any {
+	byt, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var out any
+	if err := json.Unmarshal(byt, &out); err != nil {
+		return v
+	}
+	return out
+}
+
+func (a apiClient) Subscribe(ctx context.Context, eventName string, fn func(Event)) (UnsubscribeFunc, error) {
+	return a.SubscribeWithFilter(ctx, eventName, EventStreamFilter{}, fn)
+}
+
+func (a apiClient) SubscribeWithFilter(ctx context.Context, eventName string, filter EventStreamFilter, fn func(Event)) (UnsubscribeFunc, error) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	path := "/v1/events/stream?" + url.Values{"name": {eventName}}.Encode()
+	resp, err := fetchWithAuthFallback(
+		a.HTTPClient,
+		func() (*http.Request, error) {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.GetAPIBaseURL()+path, nil)
+			if err != nil {
+				return nil, err
+			}
+			SetBasicRequestHeaders(req)
+			req.Header.Set("Accept", "text/event-stream")
+			if a.GetEnv() != "" {
+				req.Header.Set(HeaderKeyEnv, a.GetEnv())
+			}
+			return req, nil
+		},
+		a.GetSigningKey(),
+		a.GetSigningKeyFallback(),
+	)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("error subscribing to events: %w", err)
+	}
+	if resp.StatusCode > 299 {
+		byt, _ := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		cancel()
+		return nil, fmt.Errorf("error subscribing to events: unexpected status code %d: %s", resp.StatusCode, byt)
+	}
+
+	var streamErr error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer resp.Body.Close()
+		err := readEventStream(resp.Body, func(data []byte) {
+			evt := Event{}
+			if err := json.Unmarshal(data, &evt); err != nil {
+				return
+			}
+			// Multiple subscriptions may share the stream, so check the name
+			// as well as the filter.
+			if evt.Name != eventName || !filter.Match(evt) {
+				return
+			}
+			if ctx.Err() == nil {
+				fn(evt)
+			}
+		})
+		// Reads fail once the subscription is cancelled, which isn't an
+		// error.
+		if err != nil && ctx.Err() == nil {
+			streamErr = fmt.Errorf("error reading event stream: %w", err)
+		}
+	}()
+
+	var once sync.Once
+	return func() error {
+		once.Do(cancel)
+		<-done
+		return streamErr
+	}, nil
+}
+
+// readEventStream reads server-sent events from r, calling fn with the data of
+// each event until r is closed.  It returns the error which stopped reading, or
+// nil if r was closed.
+func readEventStream(r io.Reader, fn func(data []byte)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxEventStreamLine)
+
+	var data []byte
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			// A blank line dispatches the event.
+			if len(data) > 0 {
+				fn(data)
+			}
+			data = nil
+			continue
+		}
+
+		field, value, _ := bytes.Cut(line, []byte(":"))
+		if string(field) != "data" {
+			// Ignore comments, eg. keepalives, and other fields.
+			continue
+		}
+		value = bytes.TrimPrefix(value, []byte(" "))
+		if data != nil {
+			data = append(data, '\n')
+		}
+		data = append(data, value...)
+	}
+	return scanner.Err()
+}
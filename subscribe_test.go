@@ -0,0 +1,117 @@
+package inngestgo
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscribe(t *testing.T) {
+	// The server broadcasts each event written to publish to every stream.
+	var (
+		l       sync.Mutex
+		streams []chan string
+	)
+	publish := func(data string) {
+		l.Lock()
+		defer l.Unlock()
+		for _, s := range streams {
+			s <- data
+		}
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/events/stream" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		ch := make(chan string, 10)
+		l.Lock()
+		streams = append(streams, ch)
+		l.Unlock()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte(": keepalive\n\n"))
+		w.(http.Flusher).Flush()
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case data := <-ch:
+				_, _ = fmt.Fprintf(w, "event: event\ndata: %s\n\n", data)
+				w.(http.Flusher).Flush()
+			}
+		}
+	}))
+	defer server.Close()
+
+	c := NewClient(ClientOpts{APIBaseURL: StrPtr(server.URL), SigningKey: StrPtr("")})
+	ctx := context.Background()
+
+	receive := func(t *testing.T, ch <-chan Event) Event {
+		t.Helper()
+		select {
+		case evt := <-ch:
+			return evt
+		case <-time.After(time.Second):
+			require.FailNow(t, "event not received")
+			return Event{}
+		}
+	}
+
+	all := make(chan Event, 10)
+	unsubAll, err := c.Subscribe(ctx, "user.created", func(evt Event) { all <- evt })
+	require.NoError(t, err)
+
+	filtered := make(chan Event, 10)
+	unsubFiltered, err := c.SubscribeWithFilter(ctx, "user.created", EventStreamFilter{
+		Data: map[string]any{"plan": "pro", "seats": 5},
+	}, func(evt Event) { filtered <- evt })
+	require.NoError(t, err)
+
+	publish(`{"name":"user.created","data":{"id":1,"plan":"free"}}`)
+	publish(`{"name":"user.deleted","data":{"id":2}}`)
+	publish(`{"name":"user.created","data":{"id":3,"plan":"pro","seats":5}}`)
+
+	require.EqualValues(t, 1, receive(t, all).Data["id"])
+	require.EqualValues(t, 3, receive(t, all).Data["id"])
+	require.EqualValues(t, 3, receive(t, filtered).Data["id"])
+
+	// Unsubscribing stops only that subscription.
+	require.NoError(t, unsubFiltered())
+	publish(`{"name":"user.created","data":{"id":4,"plan":"pro","seats":5}}`)
+	require.EqualValues(t, 4, receive(t, all).Data["id"])
+	select {
+	case <-filtered:
+		require.FailNow(t, "received event after unsubscribing")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	require.NoError(t, unsubAll())
+	require.NoError(t, unsubAll())
+
+	t.Run("stream errors", func(t *testing.T) {
+		// Lines longer than the maximum stop the stream with an error.
+		var events []string
+		err := readEventStream(strings.NewReader("data: a\n\ndata: "+strings.Repeat("b", maxEventStreamLine)+"\n\n"), func(data []byte) {
+			events = append(events, string(data))
+		})
+		require.ErrorIs(t, err, bufio.ErrTooLong)
+		require.Equal(t, []string{"a"}, events)
+
+		require.NoError(t, readEventStream(strings.NewReader("data: a\n\n"), func([]byte) {}))
+	})
+
+	t.Run("error status", func(t *testing.T) {
+		c := NewClient(ClientOpts{APIBaseURL: StrPtr(server.URL + "/missing"), SigningKey: StrPtr("")})
+		_, err := c.Subscribe(ctx, "user.created", func(Event) {})
+		require.ErrorContains(t, err, "unexpected status code 404")
+	})
+}
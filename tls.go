@@ -0,0 +1,97 @@
+package inngestgo
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// MutualTLSConfig returns a TLS config for mutual TLS using the given
+// PEM-encoded certificate, private key and CA certificate.  The certificate is
+// presented to peers, and peers' certificates are verified using the CA.  The
+// config can be used both for outbound requests and for serving, in which case
+// client certificates are required.
+func MutualTLSConfig(certPEM, keyPEM, caPEM []byte) (*tls.Config, error) {
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("error loading certificate: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("error loading CA certificate: no certificates found")
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      pool,
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// httpClient returns the client used for outbound requests to Inngest.
+func (h *handler) httpClient() *http.Client {
+	if h.outbound == nil {
+		return http.DefaultClient
+	}
+	return h.outbound
+}
+
+// newOutboundClient returns the client used for outbound requests to Inngest,
+// configured using the handler's TLSConfig and OutboundRequestInterceptor.
+// This is created once per handler so that connections are pooled.
+func newOutboundClient(opts HandlerOpts) *http.Client {
+	if opts.TLSConfig == nil && opts.OutboundRequestInterceptor == nil {
+		return http.DefaultClient
+	}
+
+	var rt http.RoundTripper = http.DefaultTransport
+	if opts.TLSConfig != nil {
+		rt = withTLSConfig(rt, opts.TLSConfig)
+	}
+	if opts.OutboundRequestInterceptor != nil {
+		rt = interceptingTransport{next: rt, intercept: opts.OutboundRequestInterceptor}
+	}
+	return &http.Client{Transport: rt}
+}
+
+// withTLSConfig returns a clone of the transport which uses the given TLS
+// config.  Transports other than *http.Transport are returned as-is, as their
+// TLS config can't be set.
+func withTLSConfig(rt http.RoundTripper, cfg *tls.Config) http.RoundTripper {
+	t, ok := rt.(*http.Transport)
+	if !ok {
+		return rt
+	}
+	t = t.Clone()
+	t.TLSClientConfig = cfg.Clone()
+	return t
+}
+
+func (h *handler) ListenAndServe(addr string) error {
+	srv := &http.Server{
+		Addr:      addr,
+		Handler:   h,
+		TLSConfig: h.TLSConfig,
+	}
+
+	h.l.Lock()
+	h.server = srv
+	h.l.Unlock()
+
+	var err error
+	if h.TLSConfig != nil {
+		// Certificates are loaded from the TLS config.
+		err = srv.ListenAndServeTLS("", "")
+	} else {
+		err = srv.ListenAndServe()
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,182 @@
+package inngestgo
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/khulnasoft-lab/inngestgo/internal/sdkrequest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMutualTLSConfig(t *testing.T) {
+	ca, caKey, caPEM := testCert(t, nil, nil)
+	certPEM, keyPEM := testLeafCert(t, ca, caKey)
+
+	t.Run("valid", func(t *testing.T) {
+		cfg, err := MutualTLSConfig(certPEM, keyPEM, caPEM)
+		require.NoError(t, err)
+		require.Len(t, cfg.Certificates, 1)
+		require.NotNil(t, cfg.RootCAs)
+		require.Equal(t, tls.RequireAndVerifyClientCert, cfg.ClientAuth)
+	})
+
+	t.Run("invalid key pair", func(t *testing.T) {
+		_, err := MutualTLSConfig(certPEM, []byte("nope"), caPEM)
+		require.ErrorContains(t, err, "error loading certificate")
+	})
+
+	t.Run("invalid CA", func(t *testing.T) {
+		_, err := MutualTLSConfig(certPEM, keyPEM, []byte("nope"))
+		require.ErrorContains(t, err, "error loading CA certificate")
+	})
+}
+
+func TestTLSConfig(t *testing.T) {
+	setEnvVars(t)
+
+	t.Run("syncs using the TLS config", func(t *testing.T) {
+		synced := false
+		mockCloud := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			synced = true
+			_, _ = w.Write([]byte(`{"ok":true}`))
+		}))
+		defer mockCloud.Close()
+
+		pool := x509.NewCertPool()
+		pool.AddCert(mockCloud.Certificate())
+		registerURL := mockCloud.URL + "/fn/register"
+
+		// Without the TLS config, the server's certificate isn't trusted.
+		h := NewHandler("test-tls", HandlerOpts{RegisterURL: &registerURL, Dev: BoolPtr(false)}).(*handler)
+		require.Error(t, h.register(httptest.NewRecorder(), httptest.NewRequest(http.MethodPut, "/", nil)))
+		require.False(t, synced)
+
+		h = NewHandler("test-tls", HandlerOpts{
+			RegisterURL: &registerURL,
+			Dev:         BoolPtr(false),
+			TLSConfig:   &tls.Config{RootCAs: pool},
+		}).(*handler)
+		require.NoError(t, h.register(httptest.NewRecorder(), httptest.NewRequest(http.MethodPut, "/", nil)))
+		require.True(t, synced)
+	})
+
+	t.Run("sends events using the TLS config", func(t *testing.T) {
+		var sent int
+		mockEvents := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			sent++
+			_, _ = w.Write([]byte(`{"ids":["id"],"status":200}`))
+		}))
+		defer mockEvents.Close()
+
+		pool := x509.NewCertPool()
+		pool.AddCert(mockEvents.Certificate())
+		evt := Event{Name: "my-event", Data: map[string]any{"ok": true}}
+
+		// Without the TLS config, the server's certificate isn't trusted.
+		c := NewClient(ClientOpts{EventKey: StrPtr("key"), EventURL: &mockEvents.URL})
+		_, err := c.Send(context.Background(), evt)
+		require.ErrorContains(t, err, "certificate")
+		require.Equal(t, 0, sent)
+
+		c = NewClient(ClientOpts{EventKey: StrPtr("key"), EventURL: &mockEvents.URL, TLSConfig: &tls.Config{RootCAs: pool}})
+		_, err = c.Send(context.Background(), evt)
+		require.NoError(t, err)
+		require.Equal(t, 1, sent)
+
+		// Progress events use the handler's TLS config.
+		h := NewHandler("test-tls", HandlerOpts{TLSConfig: &tls.Config{RootCAs: pool}}).(*handler)
+		t.Setenv("INNGEST_DEV", mockEvents.URL)
+		report := h.progressReporter(context.Background(), sdkrequest.CallCtx{})
+		require.NoError(t, report(sdkrequest.Progress{StepID: "step", Percent: 50}))
+		require.Equal(t, 2, sent)
+	})
+
+	t.Run("ListenAndServe terminates mutual TLS", func(t *testing.T) {
+		ca, caKey, caPEM := testCert(t, nil, nil)
+		certPEM, keyPEM := testLeafCert(t, ca, caKey)
+		cfg, err := MutualTLSConfig(certPEM, keyPEM, caPEM)
+		require.NoError(t, err)
+
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		addr := l.Addr().String()
+		require.NoError(t, l.Close())
+
+		h := NewHandler("test-tls", HandlerOpts{TLSConfig: cfg, Dev: BoolPtr(true)})
+		errs := make(chan error, 1)
+		go func() { errs <- h.ListenAndServe(addr) }()
+
+		do := func(cfg *tls.Config) (*http.Response, error) {
+			c := &http.Client{Transport: &http.Transport{TLSClientConfig: cfg}}
+			return c.Get("https://" + addr)
+		}
+
+		require.Eventually(t, func() bool {
+			resp, err := do(cfg)
+			if err != nil {
+				return false
+			}
+			_ = resp.Body.Close()
+			return resp.StatusCode == http.StatusOK
+		}, time.Second, 10*time.Millisecond)
+
+		// Clients without a certificate are rejected.
+		_, err = do(&tls.Config{RootCAs: cfg.RootCAs})
+		require.Error(t, err)
+
+		require.NoError(t, h.Shutdown(context.Background()))
+		require.NoError(t, <-errs)
+	})
+}
+
+// testCert creates a certificate for 127.0.0.1, signed by parent.  If parent is
+// nil, this creates a self-signed CA.
+func testCert(t *testing.T, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey, []byte) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "inngest-test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		parent, parentKey = tmpl, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert, key, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+// testLeafCert creates a PEM-encoded certificate and key signed by the given CA.
+func testLeafCert(t *testing.T, ca *x509.Certificate, caKey *ecdsa.PrivateKey) ([]byte, []byte) {
+	t.Helper()
+
+	_, key, certPEM := testCert(t, ca, caKey)
+	der, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return certPEM, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+}
@@ -0,0 +1,47 @@
+package inngestgo
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/khulnasoft-lab/inngestgo/tracing"
+)
+
+// TraceContextPropagator is the header format used to propagate trace context.
+type TraceContextPropagator string
+
+const (
+	// TraceContextPropagatorB3 uses Zipkin's B3 headers, eg. X-B3-TraceId.
+	TraceContextPropagatorB3 TraceContextPropagator = "b3"
+	// TraceContextPropagatorW3C uses the W3C traceparent header.
+	TraceContextPropagatorW3C TraceContextPropagator = "w3c"
+	// TraceContextPropagatorJaeger uses Jaeger's uber-trace-id header.
+	TraceContextPropagatorJaeger TraceContextPropagator = "jaeger"
+)
+
+// TracingConfig configures trace context propagation.  This is separate from
+// the OpenTelemetry integration.
+type TracingConfig struct {
+	// Propagator is the format used to extract trace context from incoming
+	// requests.
+	Propagator TraceContextPropagator
+}
+
+// extractTraceContext returns the request's context containing the span context
+// extracted using the handler's TracingConfig, if any.
+func (h *handler) extractTraceContext(r *http.Request) context.Context {
+	ctx := r.Context()
+	if h.Tracing == nil {
+		return ctx
+	}
+
+	p, err := tracing.NewPropagator(string(h.Tracing.Propagator))
+	if err != nil {
+		h.Logger.Error("error extracting trace context", "error", err)
+		return ctx
+	}
+	if sc, ok := p.Extract(r.Header); ok {
+		ctx = tracing.ContextWithSpanContext(ctx, sc)
+	}
+	return ctx
+}
@@ -0,0 +1,72 @@
+package inngestgo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/khulnasoft-lab/inngestgo/tracing"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTraceContextPropagation(t *testing.T) {
+	var (
+		sc tracing.SpanContext
+		ok bool
+	)
+	fn := CreateFunction(
+		FunctionOpts{Name: "traced"},
+		EventTrigger("my-event", nil),
+		func(ctx context.Context, input Input[any]) (any, error) {
+			sc, ok = tracing.SpanContextFromContext(ctx)
+			return nil, nil
+		},
+	)
+
+	call := func(t *testing.T, cfg *TracingConfig, headers map[string]string) {
+		t.Helper()
+		sc, ok = tracing.SpanContext{}, false
+
+		h := NewHandler("test-trace-context", HandlerOpts{Dev: BoolPtr(true), Tracing: cfg})
+		h.Register(fn)
+		server := httptest.NewServer(h)
+		defer server.Close()
+
+		body, _ := json.Marshal(createRequest(t, map[string]any{"name": "my-event"}))
+		req, err := http.NewRequest(http.MethodPost, server.URL+"?fnId="+fn.Slug("test-trace-context"), bytes.NewReader(body))
+		require.NoError(t, err)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+
+	b3 := map[string]string{
+		"X-B3-TraceId": "463ac35c9f6413ad48485a3953bb6124",
+		"X-B3-SpanId":  "a2fb4a1d1a96d312",
+		"X-B3-Sampled": "1",
+	}
+
+	t.Run("extracts using the configured propagator", func(t *testing.T) {
+		call(t, &TracingConfig{Propagator: TraceContextPropagatorB3}, b3)
+		require.True(t, ok)
+		require.Equal(t, "463ac35c9f6413ad48485a3953bb6124", sc.TraceID)
+		require.Equal(t, "a2fb4a1d1a96d312", sc.SpanID)
+	})
+
+	t.Run("ignores other formats", func(t *testing.T) {
+		call(t, &TracingConfig{Propagator: TraceContextPropagatorW3C}, b3)
+		require.False(t, ok)
+	})
+
+	t.Run("without tracing config", func(t *testing.T) {
+		call(t, nil, b3)
+		require.False(t, ok)
+	})
+}
@@ -0,0 +1,124 @@
+package inngestgo
+
+import (
+	"context"
+	"encoding/json"
+	"hash/fnv"
+	"math"
+	"strings"
+
+	"github.com/khulnasoft-lab/inngestgo/internal/sdkrequest"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/khulnasoft-lab/inngestgo"
+
+// startFunctionSpan starts a span for a function execution using the global
+// tracer provider, returning a function which ends the span with the
+// execution's error.  No span is created if the run isn't sampled using the
+// function's ObservabilityConfig.
+func startFunctionSpan(ctx context.Context, fn ServableFunction, request *sdkrequest.Request) (context.Context, func(err error)) {
+	cfg := fn.Config().Observability
+	if cfg != nil && cfg.SampleRate != nil && !sampleRun(request.CallCtx.RunID, *cfg.SampleRate) {
+		return ctx, func(error) {}
+	}
+
+	attrs := []attribute.KeyValue{
+		attribute.String("inngest.function.id", request.CallCtx.FunctionID),
+		attribute.String("inngest.run.id", request.CallCtx.RunID),
+		attribute.Int("inngest.attempt", request.CallCtx.Attempt),
+	}
+	if cfg != nil {
+		for k, v := range cfg.TraceAttributes {
+			attrs = append(attrs, attribute.String(k, v))
+		}
+	}
+	for k, v := range fn.Config().SpanAttributes {
+		attrs = append(attrs, attribute.String(k, v))
+	}
+	attrs = append(attrs, eventSpanAttributes(request.Event, fn.Config().SpanAttributesFromEvent)...)
+
+	ctx, span := otel.Tracer(tracerName).Start(
+		ctx,
+		fn.Name(),
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(attrs...),
+	)
+	return ctx, func(err error) {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+}
+
+// eventSpanAttributes returns span attributes for the fields at the given
+// paths within the event, eg. "data.userId" becomes "event.userId".
+func eventSpanAttributes(evt json.RawMessage, paths []string) []attribute.KeyValue {
+	if len(paths) == 0 {
+		return nil
+	}
+	var data map[string]any
+	if err := json.Unmarshal(evt, &data); err != nil {
+		return nil
+	}
+
+	attrs := []attribute.KeyValue{}
+	for _, path := range paths {
+		val, ok := eventField(data, path)
+		if !ok {
+			continue
+		}
+		key := "event." + strings.TrimPrefix(path, "data.")
+		switch v := val.(type) {
+		case string:
+			attrs = append(attrs, attribute.String(key, v))
+		case bool:
+			attrs = append(attrs, attribute.Bool(key, v))
+		case float64:
+			if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+				attrs = append(attrs, attribute.Int64(key, int64(v)))
+			} else {
+				attrs = append(attrs, attribute.Float64(key, v))
+			}
+		default:
+			byt, _ := json.Marshal(v)
+			attrs = append(attrs, attribute.String(key, string(byt)))
+		}
+	}
+	return attrs
+}
+
+// eventField returns the value at the dot-separated path within the event.
+func eventField(evt map[string]any, path string) (any, bool) {
+	var val any = evt
+	for _, key := range strings.Split(path, ".") {
+		m, ok := val.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if val, ok = m[key]; !ok {
+			return nil, false
+		}
+	}
+	return val, val != nil
+}
+
+// sampleRun returns whether the run should be traced given the sample rate.
+// The decision is derived from the run ID so that it's consistent across each
+// of the run's executions.
+func sampleRun(runID string, rate float64) bool {
+	if rate <= 0 {
+		return false
+	}
+	if rate >= 1 {
+		return true
+	}
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(runID))
+	return float64(h.Sum64())/math.MaxUint64 < rate
+}
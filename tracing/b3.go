@@ -0,0 +1,95 @@
+package tracing
+
+import (
+	"net/http"
+	"strings"
+)
+
+const (
+	headerB3Single       = "b3"
+	headerB3TraceID      = "X-B3-TraceId"
+	headerB3SpanID       = "X-B3-SpanId"
+	headerB3ParentSpanID = "X-B3-ParentSpanId"
+	headerB3Sampled      = "X-B3-Sampled"
+	headerB3Flags        = "X-B3-Flags"
+)
+
+// B3 propagates span contexts using Zipkin's B3 headers.  Both the multiple
+// header format, eg. X-B3-TraceId, and the single "b3" header are extracted.
+// Span contexts are injected using multiple headers.
+type B3 struct{}
+
+func (B3) Extract(h http.Header) (SpanContext, bool) {
+	if single := h.Get(headerB3Single); single != "" {
+		return extractB3Single(single)
+	}
+
+	sc := SpanContext{
+		TraceID:      strings.ToLower(h.Get(headerB3TraceID)),
+		SpanID:       strings.ToLower(h.Get(headerB3SpanID)),
+		ParentSpanID: strings.ToLower(h.Get(headerB3ParentSpanID)),
+	}
+	switch {
+	case h.Get(headerB3Flags) == "1":
+		// Debug implies an accept decision.
+		sc.Sampled = boolPtr(true)
+	case h.Get(headerB3Sampled) == "1" || strings.EqualFold(h.Get(headerB3Sampled), "true"):
+		sc.Sampled = boolPtr(true)
+	case h.Get(headerB3Sampled) == "0" || strings.EqualFold(h.Get(headerB3Sampled), "false"):
+		sc.Sampled = boolPtr(false)
+	}
+	return sc, validB3(sc)
+}
+
+// extractB3Single extracts the single header format:
+// {TraceId}-{SpanId}-{SamplingState}-{ParentSpanId}.
+func extractB3Single(v string) (SpanContext, bool) {
+	parts := strings.Split(strings.ToLower(v), "-")
+	if len(parts) < 2 || len(parts) > 4 {
+		return SpanContext{}, false
+	}
+	sc := SpanContext{TraceID: parts[0], SpanID: parts[1]}
+	if len(parts) > 2 {
+		switch parts[2] {
+		case "1", "d":
+			sc.Sampled = boolPtr(true)
+		case "0":
+			sc.Sampled = boolPtr(false)
+		default:
+			return SpanContext{}, false
+		}
+	}
+	if len(parts) > 3 {
+		sc.ParentSpanID = parts[3]
+	}
+	return sc, validB3(sc)
+}
+
+func validB3(sc SpanContext) bool {
+	// Trace IDs are either 64 or 128 bit.
+	if !isHex(sc.TraceID, 16, 16) && !isHex(sc.TraceID, 32, 32) {
+		return false
+	}
+	if !isHex(sc.SpanID, 16, 16) {
+		return false
+	}
+	return sc.ParentSpanID == "" || isHex(sc.ParentSpanID, 16, 16)
+}
+
+func (B3) Inject(sc SpanContext, h http.Header) {
+	if !sc.IsValid() {
+		return
+	}
+	h.Set(headerB3TraceID, sc.TraceID)
+	h.Set(headerB3SpanID, sc.SpanID)
+	if sc.ParentSpanID != "" {
+		h.Set(headerB3ParentSpanID, sc.ParentSpanID)
+	}
+	if sc.Sampled != nil {
+		if *sc.Sampled {
+			h.Set(headerB3Sampled, "1")
+		} else {
+			h.Set(headerB3Sampled, "0")
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package tracing
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const headerJaeger = "uber-trace-id"
+
+// Jaeger propagates span contexts using Jaeger's uber-trace-id header:
+// {trace-id}:{span-id}:{parent-span-id}:{flags}.
+type Jaeger struct{}
+
+func (Jaeger) Extract(h http.Header) (SpanContext, bool) {
+	v := h.Get(headerJaeger)
+	// The header may be URL-encoded.
+	if unescaped, err := url.QueryUnescape(v); err == nil {
+		v = unescaped
+	}
+
+	parts := strings.Split(strings.ToLower(v), ":")
+	if len(parts) != 4 {
+		return SpanContext{}, false
+	}
+	if !isHex(parts[0], 1, 32) || !isHex(parts[1], 1, 16) || !isHex(parts[2], 1, 16) {
+		return SpanContext{}, false
+	}
+	flags, err := strconv.ParseUint(parts[3], 16, 8)
+	if err != nil {
+		return SpanContext{}, false
+	}
+
+	sc := SpanContext{
+		TraceID: parts[0],
+		SpanID:  parts[1],
+		Sampled: boolPtr(flags&0x01 == 0x01),
+	}
+	// A parent ID of 0 means the span is a root span.
+	if strings.Trim(parts[2], "0") != "" {
+		sc.ParentSpanID = parts[2]
+	}
+	return sc, true
+}
+
+func (Jaeger) Inject(sc SpanContext, h http.Header) {
+	if !sc.IsValid() {
+		return
+	}
+	parent := sc.ParentSpanID
+	if parent == "" {
+		parent = "0"
+	}
+	flags := 0
+	if sc.Sampled != nil && *sc.Sampled {
+		flags = 1
+	}
+	h.Set(headerJaeger, fmt.Sprintf("%s:%s:%s:%x", sc.TraceID, sc.SpanID, parent, flags))
+}
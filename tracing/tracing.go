@@ -0,0 +1,83 @@
+// Package tracing propagates distributed trace context through Inngest
+// functions using the B3 (Zipkin), W3C Trace Context and Jaeger header
+// formats.  It is independent of OpenTelemetry.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// SpanContext identifies a span within a distributed trace.
+type SpanContext struct {
+	// TraceID is the hex-encoded trace ID.
+	TraceID string
+	// SpanID is the hex-encoded ID of the span.
+	SpanID string
+	// ParentSpanID is the hex-encoded ID of the span's parent, if known.
+	ParentSpanID string
+	// Sampled records the sampling decision, or nil if no decision was made.
+	Sampled *bool
+}
+
+// IsValid returns whether the span context has a trace ID and span ID.
+func (s SpanContext) IsValid() bool {
+	return s.TraceID != "" && s.SpanID != ""
+}
+
+// Propagator extracts and injects span contexts using HTTP headers.
+type Propagator interface {
+	// Extract returns the span context within the given headers, returning
+	// false if the headers contain no valid span context.
+	Extract(h http.Header) (SpanContext, bool)
+	// Inject adds the span context to the given headers.
+	Inject(sc SpanContext, h http.Header)
+}
+
+type ctxKey struct{}
+
+// ContextWithSpanContext returns a context containing the given span context.
+func ContextWithSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, ctxKey{}, sc)
+}
+
+// SpanContextFromContext returns the span context stored within ctx, if any.
+// Within Inngest functions, this is the span context extracted from the
+// incoming request.
+func SpanContextFromContext(ctx context.Context) (SpanContext, bool) {
+	sc, ok := ctx.Value(ctxKey{}).(SpanContext)
+	return sc, ok
+}
+
+// isHex returns whether s is a lowercase hex string with a length between min
+// and max.
+func isHex(s string, min, max int) bool {
+	if len(s) < min || len(s) > max {
+		return false
+	}
+	for _, c := range s {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return false
+		}
+	}
+	return true
+}
+
+func boolPtr(b bool) *bool { return &b }
+
+// NewPropagator returns the built-in propagator with the given name: "b3",
+// "w3c" or "jaeger".
+func NewPropagator(name string) (Propagator, error) {
+	switch name {
+	case "b3":
+		return B3{}, nil
+	case "w3c":
+		return W3C{}, nil
+	case "jaeger":
+		return Jaeger{}, nil
+	default:
+		return nil, fmt.Errorf("unknown trace context propagator: %q", name)
+	}
+}
@@ -0,0 +1,142 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	traceID  = "463ac35c9f6413ad48485a3953bb6124"
+	spanID   = "a2fb4a1d1a96d312"
+	parentID = "0020000000000001"
+)
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		name     string
+		p        Propagator
+		headers  map[string]string
+		expected *SpanContext
+	}{
+		{
+			name: "b3 multiple headers",
+			p:    B3{},
+			headers: map[string]string{
+				"X-B3-TraceId":      traceID,
+				"X-B3-SpanId":       spanID,
+				"X-B3-ParentSpanId": parentID,
+				"X-B3-Sampled":      "1",
+			},
+			expected: &SpanContext{TraceID: traceID, SpanID: spanID, ParentSpanID: parentID, Sampled: boolPtr(true)},
+		},
+		{
+			name:     "b3 64 bit trace ID without sampling",
+			p:        B3{},
+			headers:  map[string]string{"X-B3-TraceId": spanID, "X-B3-SpanId": spanID},
+			expected: &SpanContext{TraceID: spanID, SpanID: spanID},
+		},
+		{
+			name:     "b3 single header",
+			p:        B3{},
+			headers:  map[string]string{"b3": traceID + "-" + spanID + "-0-" + parentID},
+			expected: &SpanContext{TraceID: traceID, SpanID: spanID, ParentSpanID: parentID, Sampled: boolPtr(false)},
+		},
+		{
+			name:    "b3 invalid trace ID",
+			p:       B3{},
+			headers: map[string]string{"X-B3-TraceId": "nope", "X-B3-SpanId": spanID},
+		},
+		{
+			name:     "w3c",
+			p:        W3C{},
+			headers:  map[string]string{"traceparent": "00-" + traceID + "-" + spanID + "-01"},
+			expected: &SpanContext{TraceID: traceID, SpanID: spanID, Sampled: boolPtr(true)},
+		},
+		{
+			name:    "w3c all-zero trace ID",
+			p:       W3C{},
+			headers: map[string]string{"traceparent": "00-00000000000000000000000000000000-" + spanID + "-01"},
+		},
+		{
+			name:    "w3c missing header",
+			p:       W3C{},
+			headers: map[string]string{},
+		},
+		{
+			name:     "jaeger",
+			p:        Jaeger{},
+			headers:  map[string]string{"uber-trace-id": traceID + ":" + spanID + ":" + parentID + ":1"},
+			expected: &SpanContext{TraceID: traceID, SpanID: spanID, ParentSpanID: parentID, Sampled: boolPtr(true)},
+		},
+		{
+			name:     "jaeger url-encoded root span",
+			p:        Jaeger{},
+			headers:  map[string]string{"uber-trace-id": traceID + "%3A" + spanID + "%3A0%3A0"},
+			expected: &SpanContext{TraceID: traceID, SpanID: spanID, Sampled: boolPtr(false)},
+		},
+		{
+			name:    "jaeger invalid",
+			p:       Jaeger{},
+			headers: map[string]string{"uber-trace-id": traceID + ":" + spanID},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h := http.Header{}
+			for k, v := range test.headers {
+				h.Set(k, v)
+			}
+			sc, ok := test.p.Extract(h)
+			if test.expected == nil {
+				require.False(t, ok)
+				return
+			}
+			require.True(t, ok)
+			require.Equal(t, *test.expected, sc)
+		})
+	}
+}
+
+func TestInject(t *testing.T) {
+	sc := SpanContext{TraceID: traceID, SpanID: spanID, ParentSpanID: parentID, Sampled: boolPtr(true)}
+
+	for _, name := range []string{"b3", "w3c", "jaeger"} {
+		t.Run(name, func(t *testing.T) {
+			p, err := NewPropagator(name)
+			require.NoError(t, err)
+
+			h := http.Header{}
+			p.Inject(sc, h)
+			extracted, ok := p.Extract(h)
+			require.True(t, ok)
+			require.Equal(t, sc.TraceID, extracted.TraceID)
+			require.Equal(t, sc.SpanID, extracted.SpanID)
+			require.Equal(t, sc.Sampled, extracted.Sampled)
+		})
+	}
+
+	t.Run("w3c pads 64 bit trace IDs", func(t *testing.T) {
+		h := http.Header{}
+		W3C{}.Inject(SpanContext{TraceID: spanID, SpanID: spanID}, h)
+		require.Equal(t, "00-0000000000000000"+spanID+"-"+spanID+"-00", h.Get("traceparent"))
+	})
+
+	t.Run("unknown propagator", func(t *testing.T) {
+		_, err := NewPropagator("xray")
+		require.Error(t, err)
+	})
+}
+
+func TestSpanContextFromContext(t *testing.T) {
+	_, ok := SpanContextFromContext(context.Background())
+	require.False(t, ok)
+
+	sc := SpanContext{TraceID: traceID, SpanID: spanID}
+	actual, ok := SpanContextFromContext(ContextWithSpanContext(context.Background(), sc))
+	require.True(t, ok)
+	require.Equal(t, sc, actual)
+}
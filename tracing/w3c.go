@@ -0,0 +1,50 @@
+package tracing
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const headerTraceparent = "traceparent"
+
+// W3C propagates span contexts using the W3C Trace Context traceparent header:
+// {version}-{trace-id}-{parent-id}-{trace-flags}.
+type W3C struct{}
+
+func (W3C) Extract(h http.Header) (SpanContext, bool) {
+	parts := strings.Split(strings.ToLower(strings.TrimSpace(h.Get(headerTraceparent))), "-")
+	if len(parts) < 4 || parts[0] == "ff" || !isHex(parts[0], 2, 2) {
+		return SpanContext{}, false
+	}
+	// Version 00 has exactly four fields;  future versions may add more.
+	if parts[0] == "00" && len(parts) != 4 {
+		return SpanContext{}, false
+	}
+	if !isHex(parts[1], 32, 32) || !isHex(parts[2], 16, 16) || !isHex(parts[3], 2, 2) {
+		return SpanContext{}, false
+	}
+	if strings.Trim(parts[1], "0") == "" || strings.Trim(parts[2], "0") == "" {
+		// All-zero IDs are invalid.
+		return SpanContext{}, false
+	}
+
+	flags, _ := strconv.ParseUint(parts[3], 16, 8)
+	return SpanContext{
+		TraceID: parts[1],
+		SpanID:  parts[2],
+		Sampled: boolPtr(flags&0x01 == 0x01),
+	}, true
+}
+
+func (W3C) Inject(sc SpanContext, h http.Header) {
+	if !sc.IsValid() {
+		return
+	}
+	flags := 0
+	if sc.Sampled != nil && *sc.Sampled {
+		flags = 1
+	}
+	h.Set(headerTraceparent, fmt.Sprintf("00-%032s-%016s-%02x", sc.TraceID, sc.SpanID, flags))
+}
@@ -0,0 +1,130 @@
+package inngestgo
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestFunctionTracing(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(tp)
+	defer otel.SetTracerProvider(prev)
+
+	h := NewHandler("test-tracing", HandlerOpts{}).(*handler)
+	call := func(t *testing.T, opts FunctionOpts, runID string, fnErr error) tracetest.SpanStubs {
+		exporter.Reset()
+		fn := CreateFunction(
+			opts,
+			EventTrigger("my-event", nil),
+			func(ctx context.Context, input Input[any]) (any, error) {
+				return nil, fnErr
+			},
+		)
+		req := createRequest(t, map[string]any{"name": "my-event"})
+		req.CallCtx.RunID = runID
+		_, _, _ = h.invokeWithHooks(context.Background(), fn, req, nil)
+		return exporter.GetSpans()
+	}
+
+	t.Run("traces by default", func(t *testing.T) {
+		spans := call(t, FunctionOpts{Name: "my-fn"}, "run-1", nil)
+		require.Len(t, spans, 1)
+		require.Equal(t, "my-fn", spans[0].Name)
+		require.Contains(t, spans[0].Attributes, attribute.String("inngest.run.id", "run-1"))
+	})
+
+	t.Run("adds trace attributes", func(t *testing.T) {
+		spans := call(t, FunctionOpts{
+			Name: "my-fn",
+			Observability: &ObservabilityConfig{
+				SampleRate:      Ptr(1.0),
+				TraceAttributes: map[string]string{"team": "billing"},
+			},
+		}, "run-1", fmt.Errorf("oh no"))
+		require.Len(t, spans, 1)
+		require.Contains(t, spans[0].Attributes, attribute.String("team", "billing"))
+		require.Equal(t, codes.Error, spans[0].Status.Code)
+	})
+
+	t.Run("adds span attributes", func(t *testing.T) {
+		exporter.Reset()
+		fn := CreateFunction(
+			FunctionOpts{
+				Name:                    "my-fn",
+				SpanAttributes:          map[string]string{"tier": "gold"},
+				SpanAttributesFromEvent: []string{"data.userId", "data.order.total", "data.express", "data.missing", "name"},
+			},
+			EventTrigger("my-event", nil),
+			func(ctx context.Context, input Input[any]) (any, error) {
+				return nil, nil
+			},
+		)
+		req := createRequest(t, map[string]any{
+			"name": "my-event",
+			"data": map[string]any{"userId": "u-1", "order": map[string]any{"total": 42}, "express": true},
+		})
+		_, _, _ = h.invokeWithHooks(context.Background(), fn, req, nil)
+
+		spans := exporter.GetSpans()
+		require.Len(t, spans, 1)
+		attrs := spans[0].Attributes
+		require.Contains(t, attrs, attribute.String("tier", "gold"))
+		require.Contains(t, attrs, attribute.String("event.userId", "u-1"))
+		require.Contains(t, attrs, attribute.Int64("event.order.total", 42))
+		require.Contains(t, attrs, attribute.Bool("event.express", true))
+		require.Contains(t, attrs, attribute.String("event.name", "my-event"))
+		for _, attr := range attrs {
+			require.NotEqual(t, attribute.Key("event.missing"), attr.Key)
+		}
+	})
+
+	t.Run("zero sample rate creates no spans", func(t *testing.T) {
+		spans := call(t, FunctionOpts{
+			Name:          "my-fn",
+			Observability: &ObservabilityConfig{SampleRate: Ptr(0.0)},
+		}, "run-1", nil)
+		require.Empty(t, spans)
+	})
+
+	t.Run("samples by run", func(t *testing.T) {
+		opts := FunctionOpts{
+			Name:          "my-fn",
+			Observability: &ObservabilityConfig{SampleRate: Ptr(0.5)},
+		}
+		sampled := 0
+		for i := 0; i < 1000; i++ {
+			runID := fmt.Sprintf("run-%d", i)
+			n := len(call(t, opts, runID, nil))
+			// Every execution of a run has the same sampling decision.
+			require.Equal(t, n, len(call(t, opts, runID, nil)))
+			sampled += n
+		}
+		require.InDelta(t, 500, sampled, 100)
+	})
+
+	t.Run("nil sample rate traces every run", func(t *testing.T) {
+		spans := call(t, FunctionOpts{
+			Name:          "my-fn",
+			Observability: &ObservabilityConfig{TraceAttributes: map[string]string{"team": "billing"}},
+		}, "run-1", nil)
+		require.Len(t, spans, 1)
+	})
+
+	t.Run("invalid sample rate", func(t *testing.T) {
+		err := FunctionOpts{
+			Name:          "my-fn",
+			Observability: &ObservabilityConfig{SampleRate: Ptr(-0.1)},
+		}.Validate()
+		require.ErrorContains(t, err, "SampleRate must be between 0.0 and 1.0")
+	})
+}
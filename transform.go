@@ -0,0 +1,30 @@
+package inngestgo
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/khulnasoft-lab/inngestgo/internal/sdkrequest"
+)
+
+// transformInput returns a copy of the request with the event and each batched
+// event transformed by t.
+func transformInput(
+	ctx context.Context,
+	t func(ctx context.Context, raw json.RawMessage) (json.RawMessage, error),
+	input *sdkrequest.Request,
+) (*sdkrequest.Request, error) {
+	req := *input
+
+	var err error
+	if req.Event, err = t(ctx, input.Event); err != nil {
+		return nil, err
+	}
+	req.Events = make([]json.RawMessage, len(input.Events))
+	for i, evt := range input.Events {
+		if req.Events[i], err = t(ctx, evt); err != nil {
+			return nil, err
+		}
+	}
+	return &req, nil
+}
@@ -0,0 +1,155 @@
+package inngestgo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/inngest/inngest/pkg/enums"
+	sdkerrors "github.com/khulnasoft-lab/inngestgo/errors"
+	"github.com/khulnasoft-lab/inngestgo/internal/sdkrequest"
+	"github.com/khulnasoft-lab/inngestgo/step"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInputTransformer(t *testing.T) {
+	type signup struct {
+		Name string `json:"name"`
+		Data struct {
+			Email string `json:"email"`
+		} `json:"data"`
+	}
+
+	// flatten maps the legacy schema, which nests the email within a user
+	// object, to the current schema.
+	flatten := func(ctx context.Context, raw json.RawMessage) (json.RawMessage, error) {
+		legacy := struct {
+			Name string `json:"name"`
+			Data struct {
+				User struct {
+					Email string `json:"email"`
+				} `json:"user"`
+			} `json:"data"`
+		}{}
+		if err := json.Unmarshal(raw, &legacy); err != nil {
+			return nil, err
+		}
+		return json.Marshal(map[string]any{
+			"name": legacy.Name,
+			"data": map[string]any{"email": legacy.Data.User.Email},
+		})
+	}
+
+	legacy := map[string]any{
+		"name": "user/signup",
+		"data": map[string]any{"user": map[string]any{"email": "a@example.com"}},
+	}
+
+	t.Run("transforms the event and batched events", func(t *testing.T) {
+		fn := CreateFunction(
+			FunctionOpts{Name: "signup", InputTransformer: flatten},
+			EventTrigger("user/signup", nil),
+			func(ctx context.Context, input Input[signup]) (any, error) {
+				emails := []string{input.Event.Data.Email}
+				for _, evt := range input.Events {
+					emails = append(emails, evt.Data.Email)
+				}
+				return emails, nil
+			},
+		)
+
+		resp, _, err := invoke(context.Background(), fn, createBatchRequest(t, legacy, 2), nil, nil)
+		require.NoError(t, err)
+		require.Equal(t, []string{"a@example.com", "a@example.com", "a@example.com"}, resp)
+	})
+
+	t.Run("errors are not retried", func(t *testing.T) {
+		called := false
+		fn := CreateFunction(
+			FunctionOpts{
+				Name: "signup",
+				InputTransformer: func(ctx context.Context, raw json.RawMessage) (json.RawMessage, error) {
+					return nil, fmt.Errorf("unsupported schema")
+				},
+			},
+			EventTrigger("user/signup", nil),
+			func(ctx context.Context, input Input[signup]) (any, error) {
+				called = true
+				return nil, nil
+			},
+		)
+
+		_, _, err := invoke(context.Background(), fn, createRequest(t, legacy), nil, nil)
+		require.ErrorContains(t, err, "unsupported schema")
+		require.True(t, sdkerrors.IsNoRetryError(err))
+		require.False(t, called)
+	})
+}
+
+func TestOutputTransformer(t *testing.T) {
+	type account struct {
+		ID       string `json:"id"`
+		Password string `json:"password,omitempty"`
+	}
+
+	var calls int
+	redact := func(ctx context.Context, output any, err error) (any, error) {
+		calls++
+		if err != nil {
+			return nil, fmt.Errorf("account creation failed")
+		}
+		a := output.(account)
+		a.Password = ""
+		return a, nil
+	}
+
+	create := func(fnErr error) ServableFunction {
+		return CreateFunction(
+			FunctionOpts{Name: "create-account", OutputTransformer: redact},
+			EventTrigger("account/create", nil),
+			func(ctx context.Context, input Input[any]) (any, error) {
+				id, _ := step.Run(ctx, "create", func(ctx context.Context) (string, error) {
+					return "acct-1", nil
+				})
+				if fnErr != nil {
+					return nil, fnErr
+				}
+				return account{ID: id, Password: "hunter2"}, nil
+			},
+		)
+	}
+
+	evt := map[string]any{"name": "account/create", "data": map[string]any{"a": 1}}
+
+	t.Run("not called while steps are pending", func(t *testing.T) {
+		calls = 0
+		_, ops, err := invoke(context.Background(), create(nil), createRequest(t, evt), nil, nil)
+		require.NoError(t, err)
+		require.Len(t, ops, 1)
+		require.Equal(t, 0, calls)
+	})
+
+	// completed returns a request with the step completed.
+	completed := func(t *testing.T) *sdkrequest.Request {
+		req := createRequest(t, evt)
+		op := sdkrequest.UnhashedOp{Op: enums.OpcodeStep, ID: "create"}
+		req.Steps = map[string]json.RawMessage{op.MustHash(): json.RawMessage(`{"data":"acct-1"}`)}
+		return req
+	}
+
+	t.Run("transforms output", func(t *testing.T) {
+		calls = 0
+		resp, ops, err := invoke(context.Background(), create(nil), completed(t), nil, nil)
+		require.NoError(t, err)
+		require.Empty(t, ops)
+		require.Equal(t, account{ID: "acct-1"}, resp)
+		require.Equal(t, 1, calls)
+	})
+
+	t.Run("transforms errors", func(t *testing.T) {
+		resp, _, err := invoke(context.Background(), create(fmt.Errorf("duplicate key in accounts_pkey")), completed(t), nil, nil)
+		require.EqualError(t, err, "account creation failed")
+		require.Nil(t, resp)
+	})
+}
@@ -0,0 +1,32 @@
+package inngestgo
+
+import "net/http"
+
+// RequestValidator validates incoming invoke requests, eg. checking a custom
+// auth token in addition to the request signature.
+type RequestValidator interface {
+	// Validate returns an error if the request to invoke the function with the
+	// given ID must be rejected.
+	Validate(r *http.Request, functionID string) error
+}
+
+// RequestValidatorFunc is an adapter allowing functions to be used as
+// RequestValidators.
+type RequestValidatorFunc func(r *http.Request, functionID string) error
+
+func (f RequestValidatorFunc) Validate(r *http.Request, functionID string) error {
+	return f(r, functionID)
+}
+
+// ChainValidators returns a RequestValidator which runs each of the given
+// validators in order, returning the first error.
+func ChainValidators(validators ...RequestValidator) RequestValidator {
+	return RequestValidatorFunc(func(r *http.Request, functionID string) error {
+		for _, v := range validators {
+			if err := v.Validate(r, functionID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,75 @@
+package inngestgo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestValidator(t *testing.T) {
+	calls := 0
+	fn := CreateFunction(
+		FunctionOpts{Name: "validated"},
+		EventTrigger("my-event", nil),
+		func(ctx context.Context, input Input[any]) (any, error) {
+			calls++
+			return "ok", nil
+		},
+	)
+
+	requireToken := RequestValidatorFunc(func(r *http.Request, functionID string) error {
+		if r.Header.Get("X-Custom-Token") != "secret" {
+			return fmt.Errorf("invalid token")
+		}
+		return nil
+	})
+	var validatedIDs []string
+	recordID := RequestValidatorFunc(func(r *http.Request, functionID string) error {
+		validatedIDs = append(validatedIDs, functionID)
+		return nil
+	})
+
+	h := NewHandler("test-validator", HandlerOpts{
+		Dev:              BoolPtr(true),
+		RequestValidator: ChainValidators(recordID, requireToken),
+	})
+	h.Register(fn)
+	server := httptest.NewServer(h)
+	defer server.Close()
+
+	call := func(t *testing.T, token string) int {
+		t.Helper()
+		body, _ := json.Marshal(createRequest(t, map[string]any{"name": "my-event"}))
+		req, err := http.NewRequest(http.MethodPost, server.URL+"?fnId="+fn.Slug("test-validator"), bytes.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("X-Custom-Token", token)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		return resp.StatusCode
+	}
+
+	t.Run("passing validators", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, call(t, "secret"))
+		require.Equal(t, 1, calls)
+		require.Equal(t, []string{fn.Slug("test-validator")}, validatedIDs)
+	})
+
+	t.Run("failing validator", func(t *testing.T) {
+		require.Equal(t, http.StatusForbidden, call(t, "wrong"))
+		require.Equal(t, 1, calls)
+	})
+
+	t.Run("chain stops at the first error", func(t *testing.T) {
+		validatedIDs = nil
+		err := ChainValidators(requireToken, recordID).Validate(httptest.NewRequest(http.MethodPost, "/", nil), "fn")
+		require.ErrorContains(t, err, "invalid token")
+		require.Empty(t, validatedIDs)
+	})
+}
@@ -0,0 +1,21 @@
+package inngestgo
+
+import "runtime/debug"
+
+// AppVersionFromGitTag returns the main module's version from the binary's build
+// info, eg. "v1.2.3".  This is set when building from a tagged version of your
+// module, and can be used as HandlerOpts.AppVersion:
+//
+//	inngestgo.HandlerOpts{
+//		AppVersion: inngestgo.StrPtr(inngestgo.AppVersionFromGitTag()),
+//	}
+//
+// This returns an empty string if the version is unavailable, eg. for `go run`
+// or builds from an untagged commit.
+func AppVersionFromGitTag() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok || info.Main.Version == "(devel)" {
+		return ""
+	}
+	return info.Main.Version
+}
@@ -0,0 +1,47 @@
+package inngestgo
+
+import (
+	"context"
+	"sync"
+)
+
+// functionInits stores the errors from functions' InitFunc, by slug.
+type functionInits struct {
+	l    sync.RWMutex
+	errs map[string]error
+}
+
+// initFunctions calls the InitFunc of each of the given functions.  Functions
+// which fail to initialize are unhealthy.
+func (h *handler) initFunctions(funcs []ServableFunction) {
+	for _, fn := range funcs {
+		init := fn.Config().InitFunc
+		if init == nil {
+			continue
+		}
+
+		slug := fn.Slug(h.appName)
+		err := init(context.Background())
+		if err != nil {
+			h.Logger.Error("error initializing function", "fn", slug, "error", err)
+		}
+
+		h.inits.l.Lock()
+		if h.inits.errs == nil {
+			h.inits.errs = map[string]error{}
+		}
+		if err != nil {
+			h.inits.errs[slug] = err
+		} else {
+			delete(h.inits.errs, slug)
+		}
+		h.inits.l.Unlock()
+	}
+}
+
+// initErr returns the error from the given function's InitFunc, if any.
+func (h *handler) initErr(slug string) error {
+	h.inits.l.RLock()
+	defer h.inits.l.RUnlock()
+	return h.inits.errs[slug]
+}
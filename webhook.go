@@ -0,0 +1,279 @@
+package inngestgo
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/inngest/inngest/pkg/publicerr"
+)
+
+const (
+	// HeaderStripeSignature is the header used by Stripe to sign webhooks.
+	HeaderStripeSignature = "Stripe-Signature"
+	// HeaderGitHubSignature is the header used by GitHub to sign webhooks.
+	HeaderGitHubSignature = "X-Hub-Signature-256"
+
+	// DefaultMaxWebhookSize is the default maximum size of webhook bodies
+	// read by the router.
+	DefaultMaxWebhookSize = 1 << 20
+)
+
+// WebhookProvider is the provider which signs a route's webhooks.
+type WebhookProvider string
+
+const (
+	// WebhookProviderStripe verifies webhooks using the Stripe-Signature
+	// header and EventRouterOpts.StripeSigningSecret.
+	WebhookProviderStripe WebhookProvider = "stripe"
+	// WebhookProviderGitHub verifies webhooks using the X-Hub-Signature-256
+	// header and EventRouterOpts.GitHubWebhookSecret.
+	WebhookProviderGitHub WebhookProvider = "github"
+)
+
+var (
+	// ErrWebhookRouteNotFound is returned by EventRouter.Route when there's no
+	// route for the request's method and path.
+	ErrWebhookRouteNotFound = fmt.Errorf("webhook route not found")
+	// ErrMissingSignature is returned by EventRouter.Route when a webhook
+	// isn't signed by its route's provider, when the provider's secret isn't
+	// configured, or when the router requires signatures and the route has no
+	// provider.
+	ErrMissingSignature = fmt.Errorf("missing signature")
+	// ErrWebhookTooLarge is returned by EventRouter.Route when the webhook's
+	// body exceeds EventRouterOpts.MaxBodySize.  This is wrapped within an
+	// error with a 413 status.
+	ErrWebhookTooLarge = fmt.Errorf("webhook body too large")
+)
+
+// EventWriter emits events mapped from a webhook.
+type EventWriter interface {
+	// Write emits an event with the given name and data.
+	Write(name string, data any) error
+}
+
+// WebhookMapper parses a webhook and emits any number of events using the
+// writer.  body is the webhook's request body, which has already been read
+// from the request.
+type WebhookMapper func(r *http.Request, body []byte, w EventWriter) error
+
+// EventRouterOpts configures an EventRouter.
+type EventRouterOpts struct {
+	// StripeSigningSecret is the signing secret of the Stripe webhook
+	// endpoint, used to verify webhooks with a Stripe-Signature header.
+	StripeSigningSecret string
+	// GitHubWebhookSecret is the secret of the GitHub webhook, used to verify
+	// webhooks with an X-Hub-Signature-256 header.
+	GitHubWebhookSecret string
+	// RequireSignature rejects webhooks to routes without a provider.  This
+	// is implied when any provider's secret is configured, so webhooks are
+	// only routed without verification when there are no secrets.
+	RequireSignature bool
+	// MaxBodySize is the maximum size of webhook bodies, in bytes.  Larger
+	// webhooks are rejected with ErrWebhookTooLarge.  This defaults to
+	// DefaultMaxWebhookSize if zero.
+	MaxBodySize int64
+}
+
+// RouteOpt configures a route registered with an EventRouter.
+type RouteOpt func(*webhookRoute)
+
+// WithWebhookProvider sets the provider which signs the route's webhooks.  Only
+// the provider's signature is verified:  webhooks to the route which aren't
+// signed by the provider are rejected.
+func WithWebhookProvider(p WebhookProvider) RouteOpt {
+	return func(r *webhookRoute) {
+		r.provider = p
+	}
+}
+
+type webhookRoute struct {
+	mapper   WebhookMapper
+	provider WebhookProvider
+}
+
+// EventRouter maps raw HTTP webhooks, such as those sent by Stripe or GitHub,
+// to Inngest events:
+//
+//	router := inngestgo.NewEventRouter(inngestgo.EventRouterOpts{
+//		StripeSigningSecret: os.Getenv("STRIPE_WEBHOOK_SECRET"),
+//	})
+//	router.POST("/webhooks/stripe", func(r *http.Request, body []byte, w inngestgo.EventWriter) error {
+//		var evt stripe.Event
+//		if err := json.Unmarshal(body, &evt); err != nil {
+//			return err
+//		}
+//		return w.Write("stripe/"+evt.Type, evt.Data)
+//	}, inngestgo.WithWebhookProvider(inngestgo.WebhookProviderStripe))
+//
+// Webhooks to routes with a provider are verified using the provider's
+// signature before being passed to the route's mapper.
+type EventRouter struct {
+	opts EventRouterOpts
+
+	l      sync.RWMutex
+	routes map[string]webhookRoute
+}
+
+// NewEventRouter returns a new EventRouter.
+func NewEventRouter(opts EventRouterOpts) *EventRouter {
+	return &EventRouter{
+		opts:   opts,
+		routes: map[string]webhookRoute{},
+	}
+}
+
+// POST registers the mapper for POST requests to the given path.
+func (e *EventRouter) POST(path string, mapper WebhookMapper, opts ...RouteOpt) {
+	route := webhookRoute{mapper: mapper}
+	for _, opt := range opts {
+		opt(&route)
+	}
+
+	e.l.Lock()
+	defer e.l.Unlock()
+	e.routes[routeKey(http.MethodPost, path)] = route
+}
+
+// Route verifies the webhook's signature and calls the mapper registered for
+// the request's method and path, emitting events to the writer.  This returns
+// ErrWebhookRouteNotFound if there's no matching route, ErrWebhookTooLarge if
+// the body is too large, and ErrInvalidSignature, ErrExpiredSignature or
+// ErrMissingSignature if the webhook can't be verified.
+func (e *EventRouter) Route(r *http.Request, writer EventWriter) error {
+	e.l.RLock()
+	route, ok := e.routes[routeKey(r.Method, r.URL.Path)]
+	e.l.RUnlock()
+	if !ok {
+		return ErrWebhookRouteNotFound
+	}
+
+	max := e.opts.MaxBodySize
+	if max <= 0 {
+		max = DefaultMaxWebhookSize
+	}
+	// Read an extra byte so that bodies over the limit are rejected rather
+	// than truncated.
+	body, err := io.ReadAll(io.LimitReader(r.Body, max+1))
+	if err != nil {
+		return fmt.Errorf("error reading webhook body: %w", err)
+	}
+	if int64(len(body)) > max {
+		return publicerr.Error{
+			Err:     ErrWebhookTooLarge,
+			Message: fmt.Sprintf("webhook body exceeds %d bytes", max),
+			Status:  http.StatusRequestEntityTooLarge,
+		}
+	}
+	if err := e.verify(route.provider, r.Header, body); err != nil {
+		return err
+	}
+	return route.mapper(r, body, writer)
+}
+
+// verify verifies the webhook's signature using the route's provider.
+func (e *EventRouter) verify(provider WebhookProvider, h http.Header, body []byte) error {
+	switch provider {
+	case WebhookProviderStripe:
+		if e.opts.StripeSigningSecret == "" {
+			return fmt.Errorf("%w: no Stripe signing secret configured", ErrMissingSignature)
+		}
+		if h.Get(HeaderStripeSignature) == "" {
+			return ErrMissingSignature
+		}
+		return verifyStripeSignature(h.Get(HeaderStripeSignature), e.opts.StripeSigningSecret, body, time.Now())
+	case WebhookProviderGitHub:
+		if e.opts.GitHubWebhookSecret == "" {
+			return fmt.Errorf("%w: no GitHub webhook secret configured", ErrMissingSignature)
+		}
+		if h.Get(HeaderGitHubSignature) == "" {
+			return ErrMissingSignature
+		}
+		return verifyGitHubSignature(h.Get(HeaderGitHubSignature), e.opts.GitHubWebhookSecret, body)
+	case "":
+		if e.requireSignature() {
+			return fmt.Errorf("%w: route has no webhook provider", ErrMissingSignature)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown webhook provider %q", provider)
+	}
+}
+
+// requireSignature reports whether webhooks to routes without a provider are
+// rejected.  Configuring a provider's secret implies that webhooks must be
+// signed.
+func (e *EventRouter) requireSignature() bool {
+	return e.opts.RequireSignature || e.opts.StripeSigningSecret != "" || e.opts.GitHubWebhookSecret != ""
+}
+
+func routeKey(method, path string) string {
+	return method + " " + path
+}
+
+// verifyStripeSignature verifies a Stripe-Signature header in the format
+// "t=<timestamp>,v1=<signature>", where the signature is the hex-encoded HMAC
+// of "<timestamp>.<body>".
+func verifyStripeSignature(header, secret string, body []byte, now time.Time) error {
+	var (
+		ts   int64
+		sigs []string
+		err  error
+	)
+	for _, part := range strings.Split(header, ",") {
+		k, v, _ := strings.Cut(part, "=")
+		switch k {
+		case "t":
+			if ts, err = strconv.ParseInt(v, 10, 64); err != nil {
+				return ErrInvalidTimestamp
+			}
+		case "v1":
+			sigs = append(sigs, v)
+		}
+	}
+	if ts == 0 || len(sigs) == 0 {
+		return ErrInvalidSignature
+	}
+	if now.Sub(time.Unix(ts, 0)).Abs() > signatureTimeDeltaMax {
+		return ErrExpiredSignature
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = fmt.Fprintf(mac, "%d.", ts)
+	_, _ = mac.Write(body)
+	expected := mac.Sum(nil)
+	for _, sig := range sigs {
+		if actual, err := hex.DecodeString(sig); err == nil && hmac.Equal(actual, expected) {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
+
+// verifyGitHubSignature verifies an X-Hub-Signature-256 header in the format
+// "sha256=<signature>", where the signature is the hex-encoded HMAC of the
+// body.
+func verifyGitHubSignature(header, secret string, body []byte) error {
+	sig, ok := strings.CutPrefix(header, "sha256=")
+	if !ok {
+		return ErrInvalidSignature
+	}
+	actual, err := hex.DecodeString(sig)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write(body)
+	if !hmac.Equal(actual, mac.Sum(nil)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package inngestgo
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/inngest/inngest/pkg/publicerr"
+	"github.com/stretchr/testify/require"
+)
+
+type eventRecorder []Event
+
+func (e *eventRecorder) Write(name string, data any) error {
+	*e = append(*e, Event{Name: name, Data: map[string]any{"data": data}})
+	return nil
+}
+
+func hmacHex(secret, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestEventRouter(t *testing.T) {
+	router := NewEventRouter(EventRouterOpts{
+		StripeSigningSecret: "whsec_test",
+		GitHubWebhookSecret: "gh-secret",
+	})
+	router.POST("/webhooks/stripe", func(r *http.Request, body []byte, w EventWriter) error {
+		var evt struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(body, &evt); err != nil {
+			return err
+		}
+		return w.Write("stripe/"+evt.Type, evt.Type)
+	}, WithWebhookProvider(WebhookProviderStripe))
+	router.POST("/webhooks/github", func(r *http.Request, body []byte, w EventWriter) error {
+		// Emit an event per commit.
+		var push struct {
+			Commits []string `json:"commits"`
+		}
+		if err := json.Unmarshal(body, &push); err != nil {
+			return err
+		}
+		for _, c := range push.Commits {
+			if err := w.Write("github/"+r.Header.Get("X-GitHub-Event"), c); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, WithWebhookProvider(WebhookProviderGitHub))
+
+	request := func(path, body string, headers map[string]string) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		for k, v := range headers {
+			r.Header.Set(k, v)
+		}
+		return r
+	}
+
+	t.Run("stripe", func(t *testing.T) {
+		body := `{"type":"invoice.paid"}`
+		ts := time.Now().Unix()
+		sig := fmt.Sprintf("t=%d,v1=%s", ts, hmacHex("whsec_test", fmt.Sprintf("%d.%s", ts, body)))
+
+		evts := &eventRecorder{}
+		err := router.Route(request("/webhooks/stripe", body, map[string]string{HeaderStripeSignature: sig}), evts)
+		require.NoError(t, err)
+		require.Equal(t, "stripe/invoice.paid", (*evts)[0].Name)
+
+		sig = fmt.Sprintf("t=%d,v1=%s", ts, hmacHex("wrong", fmt.Sprintf("%d.%s", ts, body)))
+		err = router.Route(request("/webhooks/stripe", body, map[string]string{HeaderStripeSignature: sig}), evts)
+		require.ErrorIs(t, err, ErrInvalidSignature)
+
+		old := time.Now().Add(-time.Hour).Unix()
+		sig = fmt.Sprintf("t=%d,v1=%s", old, hmacHex("whsec_test", fmt.Sprintf("%d.%s", old, body)))
+		err = router.Route(request("/webhooks/stripe", body, map[string]string{HeaderStripeSignature: sig}), evts)
+		require.ErrorIs(t, err, ErrExpiredSignature)
+		require.Len(t, *evts, 1)
+	})
+
+	t.Run("github", func(t *testing.T) {
+		body := `{"commits":["a","b"]}`
+		headers := map[string]string{
+			HeaderGitHubSignature: "sha256=" + hmacHex("gh-secret", body),
+			"X-GitHub-Event":      "push",
+		}
+
+		evts := &eventRecorder{}
+		require.NoError(t, router.Route(request("/webhooks/github", body, headers), evts))
+		require.Len(t, *evts, 2)
+		require.Equal(t, "github/push", (*evts)[1].Name)
+
+		headers[HeaderGitHubSignature] = "sha256=" + hmacHex("gh-secret", "tampered")
+		require.ErrorIs(t, router.Route(request("/webhooks/github", body, headers), evts), ErrInvalidSignature)
+	})
+
+	t.Run("only the route's provider is verified", func(t *testing.T) {
+		// A valid GitHub signature doesn't authenticate Stripe webhooks.
+		body := `{"type":"invoice.paid"}`
+		headers := map[string]string{HeaderGitHubSignature: "sha256=" + hmacHex("gh-secret", body)}
+		evts := &eventRecorder{}
+		require.ErrorIs(t, router.Route(request("/webhooks/stripe", body, headers), evts), ErrMissingSignature)
+		require.Empty(t, *evts)
+	})
+
+	t.Run("too large", func(t *testing.T) {
+		small := NewEventRouter(EventRouterOpts{MaxBodySize: 8})
+		small.POST("/webhooks/any", func(r *http.Request, body []byte, w EventWriter) error { return nil })
+		require.NoError(t, small.Route(request("/webhooks/any", "12345678", nil), &eventRecorder{}))
+
+		err := small.Route(request("/webhooks/any", "123456789", nil), &eventRecorder{})
+		require.ErrorIs(t, err, ErrWebhookTooLarge)
+		var perr publicerr.Error
+		require.ErrorAs(t, err, &perr)
+		require.Equal(t, http.StatusRequestEntityTooLarge, perr.Status)
+	})
+
+	t.Run("unsigned", func(t *testing.T) {
+		body := `{"type":"invoice.paid"}`
+		open := NewEventRouter(EventRouterOpts{})
+		open.POST("/webhooks/stripe", func(r *http.Request, body []byte, w EventWriter) error { return nil })
+		require.NoError(t, open.Route(request("/webhooks/stripe", body, nil), &eventRecorder{}))
+
+		// Configured secrets require signatures, so omitting the header doesn't
+		// bypass verification.
+		evts := &eventRecorder{}
+		require.ErrorIs(t, router.Route(request("/webhooks/stripe", body, nil), evts), ErrMissingSignature)
+		require.ErrorIs(t, router.Route(request("/webhooks/github", `{"commits":["a"]}`, nil), evts), ErrMissingSignature)
+		require.Empty(t, *evts)
+
+		strict := NewEventRouter(EventRouterOpts{RequireSignature: true})
+		strict.POST("/webhooks/any", func(r *http.Request, body []byte, w EventWriter) error { return nil })
+		require.ErrorIs(t, strict.Route(request("/webhooks/any", body, nil), &eventRecorder{}), ErrMissingSignature)
+
+		// Signed webhooks are rejected if the provider's secret isn't configured.
+		strict.POST("/webhooks/stripe", func(r *http.Request, body []byte, w EventWriter) error { return nil }, WithWebhookProvider(WebhookProviderStripe))
+		signed := request("/webhooks/stripe", body, map[string]string{HeaderStripeSignature: "t=1,v1=abc"})
+		require.ErrorIs(t, strict.Route(signed, &eventRecorder{}), ErrMissingSignature)
+	})
+
+	t.Run("route not found", func(t *testing.T) {
+		require.ErrorIs(t, router.Route(request("/webhooks/twilio", "{}", nil), &eventRecorder{}), ErrWebhookRouteNotFound)
+
+		get := httptest.NewRequest(http.MethodGet, "/webhooks/stripe", nil)
+		require.ErrorIs(t, router.Route(get, &eventRecorder{}), ErrWebhookRouteNotFound)
+	})
+}